@@ -0,0 +1,97 @@
+package accounts
+
+import (
+	"context"
+	"fmt"
+	"lib/models"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// prestateAccount — баланс и nonce адреса в ответе prestateTracer.
+// В pre отсутствующее поле — ноль, в post — значение не изменилось.
+type prestateAccount struct {
+	Balance *hexutil.Big `json:"balance"`
+	Nonce   *uint64      `json:"nonce"`
+}
+
+// prestateDiff — изменения одной транзакции. Адрес, который есть в pre,
+// но отсутствует в post, удалён (selfdestruct).
+type prestateDiff struct {
+	Pre  map[common.Address]prestateAccount `json:"pre"`
+	Post map[common.Address]prestateAccount `json:"post"`
+}
+
+type txPrestate struct {
+	TxHash string        `json:"txHash"`
+	Result *prestateDiff `json:"result"`
+	Error  string        `json:"error"`
+}
+
+// fromPrestate собирает состояние изменившихся адресов после блока
+// из трейсов транзакций в порядке их выполнения
+func (t *Tracker) fromPrestate(ctx context.Context, block models.Block, txCount int) (map[common.Address]state, error) {
+	var traces []txPrestate
+	batch := []rpc.BatchElem{{
+		Method: "debug_traceBlockByNumber",
+		Args: []any{hexutil.Uint64(block.Number), map[string]any{
+			"tracer":       "prestateTracer",
+			"tracerConfig": map[string]any{"diffMode": true},
+		}},
+		Result: &traces,
+	}}
+	if err := t.caller.BatchCallContext(ctx, batch); err != nil {
+		return nil, fmt.Errorf("trace block %d: %w", block.Number, err)
+	}
+	if batch[0].Error != nil {
+		return nil, fmt.Errorf("trace block %d: %w", block.Number, batch[0].Error)
+	}
+	if len(traces) != txCount {
+		return nil, fmt.Errorf("trace block %d: %d traces for %d transactions", block.Number, len(traces), txCount)
+	}
+
+	states := make(map[common.Address]state)
+	for i, trace := range traces {
+		if trace.Error != "" {
+			return nil, fmt.Errorf("trace tx %d of block %d: %s", i, block.Number, trace.Error)
+		}
+		if trace.Result == nil {
+			continue
+		}
+		for address := range trace.Result.Pre {
+			if _, ok := trace.Result.Post[address]; !ok {
+				states[address] = state{balance: new(big.Int)}
+			}
+		}
+		for address, post := range trace.Result.Post {
+			if post.Balance == nil && post.Nonce == nil {
+				// Изменились только код или хранилище
+				continue
+			}
+			st := accountState(trace.Result.Pre[address])
+			if post.Balance != nil {
+				st.balance = post.Balance.ToInt()
+			}
+			if post.Nonce != nil {
+				st.nonce = *post.Nonce
+			}
+			states[address] = st
+		}
+	}
+	return states, nil
+}
+
+// accountState — состояние из pre; адреса нет в pre — новый адрес с нулями
+func accountState(pre prestateAccount) state {
+	st := state{balance: new(big.Int)}
+	if pre.Balance != nil {
+		st.balance = pre.Balance.ToInt()
+	}
+	if pre.Nonce != nil {
+		st.nonce = *pre.Nonce
+	}
+	return st
+}
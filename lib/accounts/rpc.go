@@ -0,0 +1,63 @@
+package accounts
+
+import (
+	"context"
+	"fmt"
+	"lib/models"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// rpcChunk — адресов в одном батче (по два запроса на адрес)
+const rpcChunk = 100
+
+// fromRPC запрашивает баланс и nonce адресов батчами eth_getBalance и
+// eth_getTransactionCount. Состояние читается по хешу блока (EIP-1898),
+// чтобы не получить состояние другого блока с тем же номером после реорга.
+func (t *Tracker) fromRPC(ctx context.Context, block models.Block, addresses []common.Address) (map[common.Address]state, error) {
+	var blockRef any = hexutil.Uint64(block.Number)
+	if block.Hash != "" {
+		blockRef = map[string]any{"blockHash": block.Hash}
+	}
+
+	states := make(map[common.Address]state, len(addresses))
+	for start := 0; start < len(addresses); start += rpcChunk {
+		chunk := addresses[start:min(start+rpcChunk, len(addresses))]
+
+		batch := make([]rpc.BatchElem, 0, 2*len(chunk))
+		for _, address := range chunk {
+			batch = append(batch,
+				rpc.BatchElem{
+					Method: "eth_getBalance",
+					Args:   []any{address, blockRef},
+					Result: new(hexutil.Big),
+				},
+				rpc.BatchElem{
+					Method: "eth_getTransactionCount",
+					Args:   []any{address, blockRef},
+					Result: new(hexutil.Uint64),
+				},
+			)
+		}
+		if err := t.caller.BatchCallContext(ctx, batch); err != nil {
+			return nil, fmt.Errorf("fetch account states at block %d: %w", block.Number, err)
+		}
+
+		for i, address := range chunk {
+			balance, nonce := batch[2*i], batch[2*i+1]
+			if balance.Error != nil {
+				return nil, fmt.Errorf("balance of %s at block %d: %w", address.Hex(), block.Number, balance.Error)
+			}
+			if nonce.Error != nil {
+				return nil, fmt.Errorf("nonce of %s at block %d: %w", address.Hex(), block.Number, nonce.Error)
+			}
+			states[address] = state{
+				balance: balance.Result.(*hexutil.Big).ToInt(),
+				nonce:   uint64(*nonce.Result.(*hexutil.Uint64)),
+			}
+		}
+	}
+	return states, nil
+}
@@ -0,0 +1,231 @@
+package accounts
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"lib/models"
+	"math/big"
+	"sort"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+var (
+	ErrInvalidAddress = errors.New("invalid address")
+	// ErrBlockOrder — блок не новее последнего зафиксированного (Commit)
+	ErrBlockOrder = errors.New("block is not above the last committed block")
+)
+
+// Caller — провайдер, у которого запрашивается состояние адресов (node.Provider)
+type Caller interface {
+	BatchCallContext(ctx context.Context, batch []rpc.BatchElem) error
+}
+
+// Options — какие адреса отслеживать и откуда брать их состояние
+type Options struct {
+	// Watched — адреса, которые проверяются в каждом блоке;
+	// записываются только изменения баланса или nonce
+	Watched []string
+	// Touched — все адреса, затронутые блоком: получатель комиссий,
+	// отправители и получатели транзакций, созданные контракты
+	Touched bool
+	// Prestate — изменения из трейсов блока (prestateTracer в diffMode)
+	// вместо eth_getBalance и eth_getTransactionCount. Трейсы видят и
+	// внутренние переводы, но не выводы (withdrawals) валидаторов.
+	Prestate bool
+}
+
+// state — баланс и nonce адреса
+type state struct {
+	balance *big.Int
+	nonce   uint64
+}
+
+func (s state) equal(other state) bool {
+	return s.nonce == other.nonce && s.balance.Cmp(other.balance) == 0
+}
+
+// Tracker записывает изменения балансов и nonce адресов по блокам.
+// Состояния отслеживаемых адресов сравниваются с последними записанными,
+// поэтому после успешной записи результата Track вызывается Commit.
+type Tracker struct {
+	caller  Caller
+	opts    Options
+	watched map[common.Address]bool
+
+	mu        sync.Mutex
+	last      map[common.Address]state // последнее записанное состояние отслеживаемых адресов
+	committed uint                     // номер последнего зафиксированного блока
+	hasCommit bool
+}
+
+func NewTracker(caller Caller, opts Options) (*Tracker, error) {
+	watched := make(map[common.Address]bool, len(opts.Watched))
+	for _, address := range opts.Watched {
+		if !common.IsHexAddress(address) {
+			return nil, fmt.Errorf("%w: watched %q", ErrInvalidAddress, address)
+		}
+		watched[common.HexToAddress(address)] = true
+	}
+	return &Tracker{
+		caller:  caller,
+		opts:    opts,
+		watched: watched,
+		last:    make(map[common.Address]state),
+	}, nil
+}
+
+// Track возвращает состояния адресов, изменившиеся в блоке, в порядке адресов.
+// txs — транзакции блока (metrics.Source.Txs); квитанции нужны только
+// для адресов созданных контрактов. Блоки передаются по возрастанию номеров:
+// блок не новее зафиксированного отклоняется с ErrBlockOrder. Track не меняет
+// состояние трекера — при ошибке записи результата блок можно повторить.
+func (t *Tracker) Track(ctx context.Context, block models.Block, txs []models.Tx) ([]models.AccountState, error) {
+	if err := t.checkOrder(block); err != nil {
+		return nil, err
+	}
+
+	var states map[common.Address]state
+	var touched map[common.Address]bool
+	var err error
+
+	if t.opts.Prestate {
+		// В diffMode трейсы содержат только изменившиеся адреса
+		if states, err = t.fromPrestate(ctx, block, len(txs)); err != nil {
+			return nil, err
+		}
+		for address := range states {
+			if !t.opts.Touched && !t.watched[address] {
+				delete(states, address)
+			}
+		}
+		touched = make(map[common.Address]bool, len(states))
+		for address := range states {
+			touched[address] = true
+		}
+	} else {
+		if t.opts.Touched {
+			touched = touchedAddresses(block, txs)
+		}
+		addresses := make([]common.Address, 0, len(t.watched)+len(touched))
+		for address := range t.watched {
+			addresses = append(addresses, address)
+		}
+		for address := range touched {
+			if !t.watched[address] {
+				addresses = append(addresses, address)
+			}
+		}
+		if states, err = t.fromRPC(ctx, block, addresses); err != nil {
+			return nil, err
+		}
+	}
+
+	return t.changes(block, states, touched), nil
+}
+
+// changes отбирает состояния для записи: затронутые блоком адреса
+// записываются всегда, отслеживаемые — если состояние изменилось
+func (t *Tracker) changes(block models.Block, states map[common.Address]state, touched map[common.Address]bool) []models.AccountState {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	addresses := make([]common.Address, 0, len(states))
+	for address := range states {
+		addresses = append(addresses, address)
+	}
+	sort.Slice(addresses, func(i, j int) bool { return bytes.Compare(addresses[i][:], addresses[j][:]) < 0 })
+
+	var result []models.AccountState
+	for _, address := range addresses {
+		st := states[address]
+		if t.watched[address] {
+			last, ok := t.last[address]
+			if ok && last.equal(st) && !touched[address] {
+				continue
+			}
+		}
+		result = append(result, models.AccountState{
+			Address:        address.Hex(),
+			Balance:        st.balance.String(),
+			Nonce:          uint(st.nonce),
+			BlockNumber:    block.Number,
+			BlockHash:      block.Hash,
+			BlockTimestamp: block.Timestamp,
+		})
+	}
+	return result
+}
+
+// Commit фиксирует записанные состояния блока: следующие блоки сравниваются
+// с ними. Вызывается после успешной записи результата Track этого блока.
+func (t *Tracker) Commit(block models.Block, states []models.AccountState) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if err := t.checkOrderLocked(block); err != nil {
+		return err
+	}
+
+	last := make(map[common.Address]state, len(states))
+	for _, st := range states {
+		address := common.HexToAddress(st.Address)
+		if !t.watched[address] {
+			continue
+		}
+		balance, ok := new(big.Int).SetString(st.Balance, 10)
+		if !ok {
+			return fmt.Errorf("commit block %d: invalid balance %q of %s", block.Number, st.Balance, st.Address)
+		}
+		last[address] = state{balance: balance, nonce: uint64(st.Nonce)}
+	}
+	for address, st := range last {
+		t.last[address] = st
+	}
+	t.committed, t.hasCommit = block.Number, true
+	return nil
+}
+
+func (t *Tracker) checkOrder(block models.Block) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.checkOrderLocked(block)
+}
+
+func (t *Tracker) checkOrderLocked(block models.Block) error {
+	if t.hasCommit && block.Number <= t.committed {
+		return fmt.Errorf("%w: block %d, committed %d", ErrBlockOrder, block.Number, t.committed)
+	}
+	return nil
+}
+
+// touchedAddresses — адреса, баланс или nonce которых мог изменить блок.
+// Внутренние переводы видны только в трейсах (Options.Prestate).
+func touchedAddresses(block models.Block, txs []models.Tx) map[common.Address]bool {
+	touched := make(map[common.Address]bool)
+	add := func(address string) {
+		if common.IsHexAddress(address) {
+			touched[common.HexToAddress(address)] = true
+		}
+	}
+
+	add(block.Miner)
+	for _, tx := range txs {
+		add(tx.From)
+		if tx.To != nil {
+			add(*tx.To)
+		}
+		if r := tx.Receipt; r != nil && r.ContractAddress != nil && r.Status == 1 {
+			add(*r.ContractAddress)
+		}
+	}
+	return touched
+}
+
+// Checksum приводит адрес к checksum-виду, в котором он хранится в таблицах
+func Checksum(address string) string {
+	return common.HexToAddress(address).Hex()
+}
@@ -0,0 +1,87 @@
+package accounts
+
+import (
+	"context"
+	"errors"
+	"lib/models"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// stateCaller отвечает на eth_getBalance и eth_getTransactionCount
+// одинаковым состоянием для любого адреса
+type stateCaller struct {
+	balance int64
+	nonce   uint64
+}
+
+func (c *stateCaller) BatchCallContext(_ context.Context, batch []rpc.BatchElem) error {
+	for i := range batch {
+		switch batch[i].Method {
+		case "eth_getBalance":
+			*batch[i].Result.(*hexutil.Big) = hexutil.Big(*big.NewInt(c.balance))
+		case "eth_getTransactionCount":
+			*batch[i].Result.(*hexutil.Uint64) = hexutil.Uint64(c.nonce)
+		default:
+			batch[i].Error = rpc.ErrNoResult
+		}
+	}
+	return nil
+}
+
+func TestTrackerCommit(t *testing.T) {
+	ctx := context.Background()
+	watched := common.HexToAddress("0x00000000000000000000000000000000000000a1").Hex()
+	caller := &stateCaller{balance: 5, nonce: 1}
+	tracker, err := NewTracker(caller, Options{Watched: []string{watched}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	block := func(number uint) models.Block {
+		return models.Block{Number: number, Hash: common.BigToHash(big.NewInt(int64(number))).Hex()}
+	}
+
+	// Без Commit блок можно обработать повторно с тем же результатом
+	for range 2 {
+		states, err := tracker.Track(ctx, block(10), nil)
+		if err != nil || len(states) != 1 || states[0].Address != watched || states[0].Balance != "5" {
+			t.Fatalf("track block 10 = %+v, %v; want state of %s", states, err, watched)
+		}
+	}
+	states, _ := tracker.Track(ctx, block(10), nil)
+	if err := tracker.Commit(block(10), states); err != nil {
+		t.Fatalf("commit block 10: %v", err)
+	}
+
+	// Состояние не изменилось — записывать нечего
+	if states, err := tracker.Track(ctx, block(11), nil); err != nil || len(states) != 0 {
+		t.Errorf("track unchanged block 11 = %+v, %v; want no states", states, err)
+	}
+	caller.balance = 7
+	if states, err := tracker.Track(ctx, block(11), nil); err != nil || len(states) != 1 || states[0].Balance != "7" {
+		t.Errorf("track changed block 11 = %+v, %v; want balance 7", states, err)
+	}
+
+	// Зафиксированный и более старые блоки отклоняются
+	for _, number := range []uint{10, 9} {
+		if _, err := tracker.Track(ctx, block(number), nil); !errors.Is(err, ErrBlockOrder) {
+			t.Errorf("track block %d = %v, want ErrBlockOrder", number, err)
+		}
+		if err := tracker.Commit(block(number), nil); !errors.Is(err, ErrBlockOrder) {
+			t.Errorf("commit block %d = %v, want ErrBlockOrder", number, err)
+		}
+	}
+
+	// Неверный баланс не фиксируется частично
+	invalid := []models.AccountState{{Address: watched, Balance: "0x7", BlockNumber: 11}}
+	if err := tracker.Commit(block(11), invalid); err == nil {
+		t.Fatal("commit with invalid balance succeeded")
+	}
+	if _, err := tracker.Track(ctx, block(11), nil); err != nil {
+		t.Errorf("track block 11 after failed commit: %v", err)
+	}
+}
@@ -1,107 +1,292 @@
 package collector
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"lib/blocks/metrics"
-	"lib/models"
+	"lib/clients/node"
+	appMetrics "lib/utils/metrics"
+	"lib/utils/tracing"
 	"math/big"
+	"time"
 
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/rpc"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
-// PostBatch отправляет батч запросов на Alchemy и возвращает массив models.Block.
-func (bc *BlockCollector) PostBatch(ctx context.Context, blocks []uint64) ([]models.Block, error) {
+// PostBatch загружает блоки с квитанциями батч-запросами к провайдеру.
+//
+// Номера делятся на батчи адаптивного размера (см. BatchConfig); батч,
+// который провайдер отверг из-за размера, делится пополам, а отказ по размеру
+// для одного блока считается неустранимым. Блоки с временными
+// ошибками запрашиваются повторно с нарастающей паузой, остальные сразу
+// получают статус StatusPermanent. Результат содержит запись для каждого
+// номера в порядке запроса; ошибка (ErrPartialBatch) возвращается, если
+// хотя бы один блок загрузить не удалось.
+func (bc *BlockCollector) PostBatch(ctx context.Context, blocks []uint64) (_ BatchResult, err error) {
 	if len(blocks) == 0 {
-		return nil, fmt.Errorf("no block numbers provided")
+		return BatchResult{}, fmt.Errorf("no block numbers provided")
 	}
 
-	var batch []rpc.BatchElem
-	for _, n := range blocks {
-		numHex := "0x" + big.NewInt(int64(n)).Text(16)
+	ctx, span := tracing.Start(ctx, "collector.PostBatch", trace.WithAttributes(
+		tracing.BlockNumber(blocks[0]),
+		attribute.Int("block.count", len(blocks)),
+	))
+	defer func() { tracing.End(span, err) }()
 
-		batch = append(batch,
-			rpc.BatchElem{
-				Method: "eth_getBlockByNumber",
-				Args:   []interface{}{numHex, true},
-				Result: new(json.RawMessage),
-			},
-			rpc.BatchElem{
+	cfg := bc.batch.cfg
+	result := BatchResult{Results: make([]BlockResult, len(blocks))}
+	pending := make([]int, len(blocks))
+	for i, n := range blocks {
+		result.Results[i] = BlockResult{Number: n, Status: StatusRetryable}
+		pending[i] = i
+	}
+
+	bc.logger.Infof("sending batch request for %d blocks", len(blocks))
+	startTime := time.Now()
+
+retries:
+	for attempt := 1; len(pending) > 0 && attempt <= cfg.MaxAttempts; attempt++ {
+		if attempt > 1 {
+			delay := cfg.backoff(attempt)
+			bc.logger.Warnf("retrying %d failed blocks in %v (attempt %d/%d)", len(pending), delay, attempt, cfg.MaxAttempts)
+			select {
+			case <-ctx.Done():
+				break retries
+			case <-time.After(delay):
+			}
+		}
+
+		for _, chunk := range bc.batch.chunks(pending) {
+			bc.fetchChunk(ctx, chunk, &result, attempt)
+		}
+
+		pending = pending[:0]
+		for i, res := range result.Results {
+			if res.Status == StatusRetryable {
+				pending = append(pending, i)
+			}
+		}
+	}
+
+	blocksOK := len(result.Results) - len(result.Failed())
+	appMetrics.BlocksCollected.WithLabelValues(modeBatch).Add(float64(blocksOK))
+	appMetrics.BlockCollectDuration.WithLabelValues(modeBatch).Observe(appMetrics.Since(startTime))
+
+	// Итог
+	if err := result.Err(); err != nil {
+		bc.logger.Warnf("batch for %d blocks completed with %d failed blocks", len(blocks), len(blocks)-blocksOK)
+		return result, err
+	}
+
+	bc.logger.Infof("batch for %d blocks completed successfully", len(blocks))
+	return result, nil
+}
+
+// fetchChunk отправляет один батч для блоков result.Results[idx] и записывает
+// их результаты. Батч, отвергнутый провайдером из-за размера, делится пополам.
+func (bc *BlockCollector) fetchChunk(ctx context.Context, idx []int, result *BatchResult, attempt int) {
+	// Без eth_getBlockReceipts батч содержит только блоки, квитанции
+	// догружаются после него через eth_getTransactionReceipt
+	withReceipts := bc.receipts.blockReceipts(bc.client)
+	stride := 1
+	if withReceipts {
+		stride = 2
+	}
+
+	batch := make([]rpc.BatchElem, 0, stride*len(idx))
+	for _, i := range idx {
+		numHex := "0x" + big.NewInt(int64(result.Results[i].Number)).Text(16)
+
+		batch = append(batch, rpc.BatchElem{
+			Method: "eth_getBlockByNumber",
+			Args:   []interface{}{numHex, true},
+			Result: new(json.RawMessage),
+		})
+		if withReceipts {
+			batch = append(batch, rpc.BatchElem{
 				Method: "eth_getBlockReceipts",
 				Args:   []interface{}{map[string]string{"blockNumber": numHex}},
 				Result: new(json.RawMessage),
-			},
-		)
+			})
+		}
 	}
 
-	bc.logger.Infof("sending batch request for %d blocks", len(blocks))
+	start := time.Now()
+	err := bc.client.BatchCallContext(ctx, batch)
+	latency := time.Since(start)
 
-	// Отправляем батч запрос
-	if err := bc.client.BatchCallContext(ctx, batch); err != nil {
-		bc.logger.Errorf("batch request failed: %v", err)
-		return nil, fmt.Errorf("batch call failed: %w", err)
+	var receiptElems []rpc.BatchElem
+	var uncles []uncleHeaders
+	if err == nil {
+		receiptElems = bc.chunkReceipts(ctx, bc.client, batch, stride)
+		uncles = chunkUncles(ctx, bc.client, batch, stride)
 	}
 
-	var result []models.Block
-	var hasErrors bool
+	if err != nil && isTooLarge(err) && len(idx) > 1 {
+		bc.batch.tooLarge(len(idx))
+		half := len(idx) / 2
+		bc.logger.Warnf("provider rejected batch of %d blocks as too large, splitting: %v", len(idx), err)
+		bc.fetchChunk(ctx, idx[:half], result, attempt)
+		bc.fetchChunk(ctx, idx[half:], result, attempt)
+		return
+	}
 
-	for i := 0; i < len(batch); i += 2 {
-		blockIndex := i / 2
-		blockNumber := blocks[blockIndex]
+	failed := 0
+	for k, i := range idx {
+		res := &result.Results[i]
+		res.Attempts = attempt
 
-		// Проверка ошибок конкретных RPC-элементов
-		if batch[i].Error != nil {
-			bc.logger.WithFields(map[string]interface{}{
-				"block":  blockNumber,
-				"method": batch[i].Method,
-			}).Errorf("block fetch failed: %v", batch[i].Error)
-			hasErrors = true
-			continue
+		if err != nil {
+			res.Status, res.Err = classifyError(err), fmt.Errorf("batch call failed: %w", err)
+		} else {
+			*res = bc.parseElement(ctx, res.Number, batch[stride*k], receiptElems[k], uncles[k])
+			res.Attempts = attempt
 		}
-		if batch[i+1].Error != nil {
-			bc.logger.WithFields(map[string]interface{}{
-				"block":  blockNumber,
-				"method": batch[i+1].Method,
-			}).Errorf("receipts fetch failed: %v", batch[i+1].Error)
-			hasErrors = true
-			continue
+
+		if res.Status != StatusOK {
+			failed++
+			appMetrics.BatchBlockFailures.WithLabelValues(string(res.Status)).Inc()
+			bc.logger.WithBlock(res.Number).Errorf("block fetch failed (%s, attempt %d): %v", res.Status, attempt, res.Err)
 		}
+	}
+	bc.batch.observe(len(idx), failed, latency)
+}
+
+// chunkReceipts возвращает для каждого блока батча элемент с его квитанциями.
+// Если провайдер не поддерживает eth_getBlockReceipts (или только что
+// ответил отказом), квитанции запрашиваются через eth_getTransactionReceipt
+// и собираются в массив в порядке транзакций.
+func (bc *BlockCollector) chunkReceipts(ctx context.Context, provider node.Provider, batch []rpc.BatchElem, stride int) []rpc.BatchElem {
+	n := len(batch) / stride
+	elems := make([]rpc.BatchElem, n)
 
-		rawBlock, ok1 := batch[i].Result.(*json.RawMessage)
-		rawReceipts, ok2 := batch[i+1].Result.(*json.RawMessage)
+	var reqs []txReceiptsRequest
+	var owners []int
+	for k := range n {
+		blockElem := batch[stride*k]
+		if stride == 2 {
+			elems[k] = batch[2*k+1]
+			if !isUnsupportedMethod(elems[k].Error) {
+				continue
+			}
+			bc.noteReceiptsUnsupported(provider, elems[k].Error)
+		}
 
-		if !ok1 || !ok2 || rawBlock == nil || rawReceipts == nil {
-			bc.logger.WithFields(map[string]interface{}{
-				"block": blockNumber,
-			}).Warn("invalid batch element — nil or unexpected type")
-			hasErrors = true
+		elems[k] = rpc.BatchElem{Method: "eth_getTransactionReceipt", Result: new(json.RawMessage)}
+		raw, ok := blockElem.Result.(*json.RawMessage)
+		if blockElem.Error != nil || !ok || raw == nil || isEmptyResult(*raw) {
+			// Ошибку блока разберёт parseElement
+			continue
+		}
+		req, err := blockTxHashes(*raw)
+		if err != nil {
+			elems[k].Error = err
 			continue
 		}
+		reqs = append(reqs, req)
+		owners = append(owners, k)
+	}
 
-		if len(*rawBlock) == 0 {
-			bc.logger.WithFields(map[string]interface{}{
-				"block": blockNumber,
-			}).Warn("empty block response")
-			hasErrors = true
+	if len(reqs) == 0 {
+		return elems
+	}
+	raws, errs := bc.txReceipts(ctx, provider, reqs)
+	for j, k := range owners {
+		if errs[j] != nil {
+			elems[k].Error = errs[j]
 			continue
 		}
+		*elems[k].Result.(*json.RawMessage) = raws[j]
+	}
+	return elems
+}
 
-		block := metrics.NewBlockFromJSON(*rawBlock, *rawReceipts)
-		result = append(result, block)
+// parseElement разбирает ответы на запросы блока, квитанций и дядей одного номера
+func (bc *BlockCollector) parseElement(ctx context.Context, blockNumber uint64, blockElem, receiptsElem rpc.BatchElem, uncles uncleHeaders) BlockResult {
+	res := BlockResult{Number: blockNumber}
+	fail := func(err error) BlockResult {
+		res.Status, res.Err = classifyError(err), err
+		return res
+	}
 
-		bc.logger.WithFields(map[string]interface{}{
-			"block": block.Number,
-			"txs":   len(block.Transactions),
-		}).Debug("block parsed successfully")
+	// Проверка ошибок конкретных RPC-элементов
+	if blockElem.Error != nil {
+		return fail(fmt.Errorf("%s: %w", blockElem.Method, blockElem.Error))
+	}
+	if receiptsElem.Error != nil {
+		return fail(fmt.Errorf("%s: %w", receiptsElem.Method, receiptsElem.Error))
+	}
+	if uncles.err != nil {
+		return fail(uncles.err)
 	}
 
-	// Итог
-	if hasErrors {
-		bc.logger.Warnf("batch for %d blocks completed with partial errors", len(blocks))
-		return result, fmt.Errorf("some batch elements failed, see logs for details")
+	rawBlock, ok1 := blockElem.Result.(*json.RawMessage)
+	rawReceipts, ok2 := receiptsElem.Result.(*json.RawMessage)
+	if !ok1 || !ok2 || rawBlock == nil || rawReceipts == nil {
+		return fail(fmt.Errorf("%w: nil or unexpected type", ErrInvalidBatchReply))
 	}
 
-	bc.logger.Infof("batch for %d blocks completed successfully", len(blocks))
-	return result, nil
+	// null — узел ещё не знает блок (отстаёт от головы сети)
+	if isEmptyResult(*rawBlock) || isEmptyResult(*rawReceipts) {
+		return fail(ErrBlockNotFound)
+	}
+
+	src, err := metrics.SourceFromJSON(*rawBlock, *rawReceipts)
+	if err == nil && len(src.Uncles) > 0 {
+		err = src.SetUncleHeaders(uncles.raws)
+	}
+	if err != nil {
+		return fail(fmt.Errorf("%w: %w", ErrInvalidBatchReply, err))
+	}
+
+	// Проверка данных по корням заголовка; в режиме strict блок
+	// с расхождениями загружается заново отдельным запросом
+	if bc.verifying() {
+		uncles := src.UncleHeaders
+		if uncles == nil {
+			uncles = []*types.Header{}
+		}
+		if err := verifyJSONBlock(*rawBlock, *rawReceipts, uncles); err != nil {
+			observeVerification(bc.client, verifyMismatch)
+			bc.logger.WithBlock(blockNumber).Errorf("block data from %s does not match its header: %v", bc.client.Name(), err)
+
+			if bc.verification.Mode == VerifyStrict {
+				refetched, receipts, err := bc.refetchVerified(ctx, blockNumber)
+				if err != nil {
+					return fail(err)
+				}
+				block, err := metrics.NewBlockMetrics(refetched, receipts)
+				if err != nil {
+					return fail(fmt.Errorf("%w: %w", ErrInvalidBatchReply, err))
+				}
+				res.Block, res.Status = &block, StatusOK
+				return res
+			}
+		} else {
+			observeVerification(bc.client, verifyOK)
+		}
+	}
+
+	block, err := src.Block()
+	if err != nil {
+		return fail(fmt.Errorf("%w: %w", ErrInvalidBatchReply, err))
+	}
+	res.Block, res.Status = &block, StatusOK
+
+	bc.logger.WithFields(map[string]interface{}{
+		"block": block.Number,
+		"txs":   len(block.Transactions),
+	}).Debug("block parsed successfully")
+	return res
+}
+
+// isEmptyResult сообщает, что элемент батча вернул пустой ответ или null
+func isEmptyResult(raw json.RawMessage) bool {
+	raw = bytes.TrimSpace(raw)
+	return len(raw) == 0 || bytes.Equal(raw, []byte("null"))
 }
@@ -0,0 +1,158 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"lib/utils/logging"
+
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// rpcError — ошибка JSON-RPC с кодом
+type rpcError struct {
+	code int
+	msg  string
+}
+
+func (e rpcError) Error() string  { return e.msg }
+func (e rpcError) ErrorCode() int { return e.code }
+
+// blockRequests считает блоки в батче
+func blockRequests(batch []rpc.BatchElem) int {
+	n := 0
+	for _, elem := range batch {
+		if elem.Method == "eth_getBlockByNumber" {
+			n++
+		}
+	}
+	return n
+}
+
+func newBatchCollector(t *testing.T, cfg BatchConfig, from, n uint64) (*BlockCollector, *fakeProvider, []uint64) {
+	t.Helper()
+	var blocks []testBlock
+	var numbers []uint64
+	for i := range n {
+		blocks = append(blocks, newTestBlock(t, from+i))
+		numbers = append(numbers, from+i)
+	}
+	p := newFakeProvider(t, blocks...)
+	bc := NewBlockCollector(p, logging.GetLogger())
+	bc.SetBatchConfig(cfg)
+	return bc, p, numbers
+}
+
+func TestPostBatchSplitsTooLargeBatch(t *testing.T) {
+	bc, p, numbers := newBatchCollector(t, BatchConfig{InitialSize: 8, MaxSize: 8, InitialBackoff: time.Millisecond}, 100, 8)
+
+	// Провайдер принимает не больше трёх блоков в батче
+	p.batch = func(batch []rpc.BatchElem) error {
+		if blockRequests(batch) > 3 {
+			return rpc.HTTPError{StatusCode: http.StatusRequestEntityTooLarge, Status: "413 Request Entity Too Large"}
+		}
+		return nil
+	}
+
+	result, err := bc.PostBatch(context.Background(), numbers)
+	if err != nil {
+		t.Fatalf("post batch: %v", err)
+	}
+	for i, res := range result.Results {
+		if res.Status != StatusOK || res.Attempts != 1 || uint64(res.Block.Number) != numbers[i] {
+			t.Errorf("result %d = %+v, want block %d loaded on the first attempt", i, res, numbers[i])
+		}
+	}
+	// 8 → 4 + 4 → (2 + 2) + (2 + 2)
+	if p.calls != 7 {
+		t.Errorf("%d batch calls, want 7", p.calls)
+	}
+	if size := bc.batch.current(); size > 3 {
+		t.Errorf("batch size after split = %d, want at most 3", size)
+	}
+}
+
+func TestPostBatchSingleBlockTooLargeIsPermanent(t *testing.T) {
+	bc, p, numbers := newBatchCollector(t, BatchConfig{MaxAttempts: 3, InitialBackoff: time.Millisecond}, 100, 1)
+
+	p.batch = func([]rpc.BatchElem) error {
+		return rpcError{code: rpcCodeResponseTooLarge, msg: "response size exceeded"}
+	}
+
+	result, err := bc.PostBatch(context.Background(), numbers)
+	if !errors.Is(err, ErrPartialBatch) {
+		t.Fatalf("post batch = %v, want ErrPartialBatch", err)
+	}
+	if res := result.Results[0]; res.Status != StatusPermanent || res.Attempts != 1 {
+		t.Errorf("result = %s after %d attempts, want permanent without retries", res.Status, res.Attempts)
+	}
+	if p.calls != 1 {
+		t.Errorf("%d batch calls, want 1", p.calls)
+	}
+}
+
+func TestPostBatchRetriesTransientErrors(t *testing.T) {
+	bc, p, numbers := newBatchCollector(t, BatchConfig{InitialSize: 4, MaxAttempts: 3, InitialBackoff: time.Millisecond}, 100, 4)
+
+	// Первый батч упирается в лимит запросов
+	p.batch = func([]rpc.BatchElem) error {
+		if p.calls == 1 {
+			return rpc.HTTPError{StatusCode: http.StatusTooManyRequests, Status: "429 Too Many Requests"}
+		}
+		return nil
+	}
+	// Блок 103 узел ещё не видит
+	delete(p.blocks, "0x67")
+
+	result, err := bc.PostBatch(context.Background(), numbers)
+	if !errors.Is(err, ErrPartialBatch) {
+		t.Fatalf("post batch = %v, want ErrPartialBatch", err)
+	}
+	for i, res := range result.Results[:3] {
+		if res.Status != StatusOK || res.Attempts != 2 {
+			t.Errorf("result %d = %s after %d attempts, want ok after 2", i, res.Status, res.Attempts)
+		}
+	}
+	missing := result.Results[3]
+	if missing.Status != StatusRetryable || missing.Attempts != 3 || !errors.Is(missing.Err, ErrBlockNotFound) {
+		t.Errorf("missing block = %s after %d attempts (%v), want retryable ErrBlockNotFound after 3", missing.Status, missing.Attempts, missing.Err)
+	}
+	if failed := result.Failed(); len(failed) != 1 || failed[0].Number != 103 {
+		t.Errorf("failed = %+v, want only block 103", failed)
+	}
+	if blocks := result.Blocks(); len(blocks) != 3 {
+		t.Errorf("%d blocks loaded, want 3", len(blocks))
+	}
+}
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want BlockStatus
+	}{
+		{"nil", nil, StatusOK},
+		{"verification", fmt.Errorf("block 1: %w", ErrVerification), StatusPermanent},
+		{"invalid reply", ErrInvalidBatchReply, StatusPermanent},
+		{"not found", ErrBlockNotFound, StatusRetryable},
+		{"deadline", context.DeadlineExceeded, StatusRetryable},
+		{"http 413", rpc.HTTPError{StatusCode: http.StatusRequestEntityTooLarge}, StatusPermanent},
+		{"rpc response too large", rpcError{code: rpcCodeResponseTooLarge, msg: "response size exceeded"}, StatusPermanent},
+		{"rpc batch too large", rpcError{code: rpcCodeInvalidRequest, msg: "batch too large"}, StatusPermanent},
+		{"http 429", rpc.HTTPError{StatusCode: http.StatusTooManyRequests}, StatusRetryable},
+		{"http 502", rpc.HTTPError{StatusCode: http.StatusBadGateway}, StatusRetryable},
+		{"http 401", rpc.HTTPError{StatusCode: http.StatusUnauthorized}, StatusPermanent},
+		{"rpc limit exceeded", rpcError{code: rpcCodeLimitExceeded, msg: "rate limited"}, StatusRetryable},
+		{"rpc invalid params", rpcError{code: rpcCodeInvalidParams, msg: "invalid argument"}, StatusPermanent},
+		{"rpc header not found", rpcError{code: -32000, msg: "header not found"}, StatusRetryable},
+	}
+	for _, tt := range tests {
+		if got := classifyError(tt.err); got != tt.want {
+			t.Errorf("%s: classifyError(%v) = %s, want %s", tt.name, tt.err, got, tt.want)
+		}
+	}
+}
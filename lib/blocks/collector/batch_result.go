@@ -0,0 +1,154 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"lib/models"
+	"net/http"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// BlockStatus — итог загрузки одного блока в батче
+type BlockStatus string
+
+const (
+	// StatusOK — блок загружен
+	StatusOK BlockStatus = "ok"
+	// StatusRetryable — временная ошибка (лимиты, таймаут, блок ещё не виден узлу);
+	// блок имеет смысл запросить позже
+	StatusRetryable BlockStatus = "retryable"
+	// StatusPermanent — повтор не поможет (неверные параметры, битые данные,
+	// ответ не проходит проверку)
+	StatusPermanent BlockStatus = "permanent"
+)
+
+// Ошибки загрузки отдельных блоков
+var (
+	ErrBlockNotFound     = errors.New("block not available from provider")
+	ErrResponseTooLarge  = errors.New("batch exceeds provider size limit")
+	ErrPartialBatch      = errors.New("some blocks in batch failed")
+	ErrInvalidBatchReply = errors.New("invalid batch element")
+)
+
+// BlockResult — результат загрузки одного блока.
+// Block заполнен только при Status == StatusOK.
+type BlockResult struct {
+	Number   uint64
+	Block    *models.Block
+	Status   BlockStatus
+	Err      error
+	Attempts int
+}
+
+// BatchResult — результаты PostBatch в порядке запрошенных номеров
+type BatchResult struct {
+	Results []BlockResult
+}
+
+// Blocks возвращает загруженные блоки в порядке запроса
+func (r BatchResult) Blocks() []models.Block {
+	blocks := make([]models.Block, 0, len(r.Results))
+	for _, res := range r.Results {
+		if res.Status == StatusOK {
+			blocks = append(blocks, *res.Block)
+		}
+	}
+	return blocks
+}
+
+// Failed возвращает результаты блоков, которые не удалось загрузить
+func (r BatchResult) Failed() []BlockResult {
+	var failed []BlockResult
+	for _, res := range r.Results {
+		if res.Status != StatusOK {
+			failed = append(failed, res)
+		}
+	}
+	return failed
+}
+
+// Err возвращает nil, если загружены все блоки, иначе ErrPartialBatch
+// вместе с ошибками отдельных блоков
+func (r BatchResult) Err() error {
+	failed := r.Failed()
+	if len(failed) == 0 {
+		return nil
+	}
+	errs := []error{fmt.Errorf("%w: %d of %d", ErrPartialBatch, len(failed), len(r.Results))}
+	for _, res := range failed {
+		errs = append(errs, fmt.Errorf("block %d (%s): %w", res.Number, res.Status, res.Err))
+	}
+	return errors.Join(errs...)
+}
+
+// Коды ошибок JSON-RPC, по которым определяется, стоит ли повторять запрос
+const (
+	rpcCodeInvalidRequest   = -32600
+	rpcCodeMethodNotFound   = -32601
+	rpcCodeInvalidParams    = -32602
+	rpcCodeLimitExceeded    = -32005
+	rpcCodeResponseTooLarge = -32003
+)
+
+// isTooLarge сообщает, что провайдер отказал из-за размера запроса или ответа;
+// такой батч нужно разделить
+func isTooLarge(err error) bool {
+	if errors.Is(err, ErrResponseTooLarge) {
+		return true
+	}
+	var httpErr rpc.HTTPError
+	if errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusRequestEntityTooLarge {
+		return true
+	}
+	var rpcErr rpc.Error
+	if errors.As(err, &rpcErr) && rpcErr.ErrorCode() == rpcCodeResponseTooLarge {
+		return true
+	}
+
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "too large") || strings.Contains(msg, "size exceeded") ||
+		strings.Contains(msg, "batch size")
+}
+
+// classifyError определяет, можно ли повторить запрос, завершившийся ошибкой err
+func classifyError(err error) BlockStatus {
+	switch {
+	case err == nil:
+		return StatusOK
+	case errors.Is(err, ErrVerification), errors.Is(err, ErrInvalidBatchReply):
+		return StatusPermanent
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded),
+		errors.Is(err, ErrBlockNotFound):
+		return StatusRetryable
+	case isTooLarge(err):
+		// Отказ по размеру (HTTP 413, -32003, "batch too large") доходит сюда
+		// только для батча из одного блока, который уже не разделить:
+		// тот же запрос провайдер отвергнет снова
+		return StatusPermanent
+	}
+
+	var httpErr rpc.HTTPError
+	if errors.As(err, &httpErr) {
+		if httpErr.StatusCode == http.StatusTooManyRequests || httpErr.StatusCode >= http.StatusInternalServerError {
+			return StatusRetryable
+		}
+		return StatusPermanent
+	}
+
+	var rpcErr rpc.Error
+	if errors.As(err, &rpcErr) {
+		switch rpcErr.ErrorCode() {
+		case rpcCodeMethodNotFound, rpcCodeInvalidParams, rpcCodeInvalidRequest:
+			return StatusPermanent
+		case rpcCodeLimitExceeded:
+			return StatusRetryable
+		}
+	}
+
+	// Сетевые ошибки и прочие ответы узла (-32000 "header not found" и т. п.)
+	// обычно проходят при повторе
+	return StatusRetryable
+}
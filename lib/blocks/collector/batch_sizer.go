@@ -0,0 +1,136 @@
+package collector
+
+import (
+	appMetrics "lib/utils/metrics"
+	"sync"
+	"time"
+)
+
+// Значения по умолчанию для BatchConfig
+const (
+	defaultBatchInitialSize    = 20
+	defaultBatchMinSize        = 1
+	defaultBatchMaxSize        = 100
+	defaultBatchTargetLatency  = 2 * time.Second
+	defaultBatchMaxAttempts    = 3
+	defaultBatchInitialBackoff = 500 * time.Millisecond
+	defaultBatchMaxBackoff     = 10 * time.Second
+)
+
+// BatchConfig — настройки PostBatch. Размеры — число блоков в одном запросе
+// (каждый блок — два элемента батча: блок и квитанции). Нулевые поля
+// заменяются значениями по умолчанию.
+type BatchConfig struct {
+	InitialSize int
+	MinSize     int
+	MaxSize     int
+	// TargetLatency — время ответа, к которому подстраивается размер батча
+	TargetLatency time.Duration
+
+	// MaxAttempts — сколько раз запрашивается блок с временной ошибкой
+	MaxAttempts int
+	// InitialBackoff — пауза перед первым повтором, далее удваивается до MaxBackoff
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+func (c BatchConfig) withDefaults() BatchConfig {
+	if c.MinSize <= 0 {
+		c.MinSize = defaultBatchMinSize
+	}
+	if c.MaxSize <= 0 {
+		c.MaxSize = defaultBatchMaxSize
+	}
+	c.MaxSize = max(c.MaxSize, c.MinSize)
+	if c.InitialSize <= 0 {
+		c.InitialSize = defaultBatchInitialSize
+	}
+	c.InitialSize = min(max(c.InitialSize, c.MinSize), c.MaxSize)
+	if c.TargetLatency <= 0 {
+		c.TargetLatency = defaultBatchTargetLatency
+	}
+	if c.MaxAttempts <= 0 {
+		c.MaxAttempts = defaultBatchMaxAttempts
+	}
+	if c.InitialBackoff <= 0 {
+		c.InitialBackoff = defaultBatchInitialBackoff
+	}
+	if c.MaxBackoff <= 0 {
+		c.MaxBackoff = defaultBatchMaxBackoff
+	}
+	return c
+}
+
+// backoff возвращает паузу перед попыткой attempt (начиная со второй)
+func (c BatchConfig) backoff(attempt int) time.Duration {
+	delay := c.InitialBackoff
+	for i := 2; i < attempt && delay < c.MaxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, c.MaxBackoff)
+}
+
+// batchSizer подбирает размер батча: увеличивает его на один блок после
+// быстрых успешных запросов и уменьшает вдвое при ошибках, медленных ответах
+// и отказах из-за размера. Потолок, на котором провайдер отказал по размеру,
+// больше не превышается.
+type batchSizer struct {
+	mu      sync.Mutex
+	cfg     BatchConfig
+	size    int
+	ceiling int
+}
+
+func newBatchSizer(cfg BatchConfig) *batchSizer {
+	cfg = cfg.withDefaults()
+	s := &batchSizer{cfg: cfg, size: cfg.InitialSize, ceiling: cfg.MaxSize}
+	appMetrics.BatchSize.Set(float64(s.size))
+	return s
+}
+
+// current возвращает текущий размер батча
+func (s *batchSizer) current() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.size
+}
+
+// chunks делит номера (индексы) на батчи текущего размера
+func (s *batchSizer) chunks(items []int) [][]int {
+	size := s.current()
+	var out [][]int
+	for len(items) > size {
+		out = append(out, items[:size])
+		items = items[size:]
+	}
+	if len(items) > 0 {
+		out = append(out, items)
+	}
+	return out
+}
+
+// observe учитывает ответ на батч из n блоков, из которых failed завершились ошибкой
+func (s *batchSizer) observe(n, failed int, latency time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch {
+	case failed*10 > n, latency > s.cfg.TargetLatency:
+		// Больше 10% ошибок или медленный ответ — уменьшаем вдвое
+		s.size = max(s.size/2, s.cfg.MinSize)
+	case failed == 0 && n >= s.size && latency < s.cfg.TargetLatency/2:
+		// Полный батч обработан быстро и без ошибок — понемногу растём
+		s.size = min(s.size+1, s.ceiling)
+	}
+	appMetrics.BatchSize.Set(float64(s.size))
+}
+
+// tooLarge учитывает отказ провайдера по размеру для батча из n блоков
+func (s *batchSizer) tooLarge(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.ceiling = max(n/2, s.cfg.MinSize)
+	s.size = min(s.size, s.ceiling)
+	appMetrics.BatchSize.Set(float64(s.size))
+}
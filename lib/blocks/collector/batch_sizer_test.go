@@ -0,0 +1,63 @@
+package collector
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestBatchSizerAdapts(t *testing.T) {
+	s := newBatchSizer(BatchConfig{InitialSize: 4, MinSize: 2, MaxSize: 6, TargetLatency: time.Second})
+	fast, slow := 100*time.Millisecond, 2*time.Second
+
+	steps := []struct {
+		name    string
+		observe func()
+		want    int
+	}{
+		{"fast full batch grows", func() { s.observe(4, 0, fast) }, 5},
+		{"partial batch keeps size", func() { s.observe(3, 0, fast) }, 5},
+		{"latency near target keeps size", func() { s.observe(5, 0, 600*time.Millisecond) }, 5},
+		{"grows up to max", func() { s.observe(5, 0, fast); s.observe(6, 0, fast) }, 6},
+		{"slow batch halves", func() { s.observe(6, 0, slow) }, 3},
+		{"more than 10% failures halves to min", func() { s.observe(3, 1, fast) }, 2},
+		{"one failure in a large batch is tolerated", func() { s.observe(10, 1, fast) }, 2},
+		{"too large lowers ceiling", func() { s.observe(2, 0, fast); s.observe(3, 0, fast); s.tooLarge(4) }, 2},
+		{"growth stops at ceiling", func() { s.observe(2, 0, fast); s.observe(2, 0, fast) }, 2},
+	}
+	for _, step := range steps {
+		step.observe()
+		if got := s.current(); got != step.want {
+			t.Fatalf("%s: size = %d, want %d", step.name, got, step.want)
+		}
+	}
+}
+
+func TestBatchSizerChunks(t *testing.T) {
+	s := newBatchSizer(BatchConfig{InitialSize: 3})
+	got := s.chunks([]int{0, 1, 2, 3, 4, 5, 6})
+	want := [][]int{{0, 1, 2}, {3, 4, 5}, {6}}
+	if !slices.EqualFunc(got, want, slices.Equal[[]int]) {
+		t.Errorf("chunks = %v, want %v", got, want)
+	}
+	if got := s.chunks(nil); len(got) != 0 {
+		t.Errorf("chunks of nothing = %v", got)
+	}
+}
+
+func TestBatchConfigDefaultsAndBackoff(t *testing.T) {
+	cfg := BatchConfig{InitialSize: 500, MinSize: 10, MaxSize: 5}.withDefaults()
+	if cfg.MaxSize != 10 || cfg.InitialSize != 10 {
+		t.Errorf("sizes = initial %d, max %d, want both clamped to min 10", cfg.InitialSize, cfg.MaxSize)
+	}
+	if cfg.MaxAttempts != defaultBatchMaxAttempts || cfg.TargetLatency != defaultBatchTargetLatency {
+		t.Errorf("defaults not applied: %+v", cfg)
+	}
+
+	cfg = BatchConfig{InitialBackoff: time.Second, MaxBackoff: 5 * time.Second}.withDefaults()
+	for attempt, want := range map[int]time.Duration{2: time.Second, 3: 2 * time.Second, 4: 4 * time.Second, 5: 5 * time.Second, 10: 5 * time.Second} {
+		if got := cfg.backoff(attempt); got != want {
+			t.Errorf("backoff(%d) = %v, want %v", attempt, got, want)
+		}
+	}
+}
@@ -5,19 +5,39 @@ import (
 	"lib/utils/logging"
 )
 
+// Значения метки mode в метриках сбора
+const (
+	modeSingle = "single"
+	modeBatch  = "batch"
+)
+
 type BlockCollector struct {
 	client node.Provider
 	logger *logging.Logger
+
+	// verification — проверка блоков по заголовку, см. SetVerification
+	verification Verification
+	// batch — адаптивный размер батча PostBatch
+	batch *batchSizer
+	// receipts — провайдеры, у которых нет eth_getBlockReceipts
+	receipts receiptsSupport
 }
 
 func NewBlockCollector(client node.Provider, logger *logging.Logger) *BlockCollector {
 	blk := &BlockCollector{
 		client: client,
 		logger: logger,
+		batch:  newBatchSizer(BatchConfig{}),
 	}
 	return blk
 }
 
+// SetBatchConfig задаёт размеры батча и политику повторов PostBatch.
+// Вызывается до начала сбора.
+func (bc *BlockCollector) SetBatchConfig(cfg BatchConfig) {
+	bc.batch = newBatchSizer(cfg)
+}
+
 func (bc *BlockCollector) Client() node.Provider {
 	return bc.client
 }
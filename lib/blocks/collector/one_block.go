@@ -2,25 +2,66 @@ package collector
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"lib/blocks/metrics"
+	"lib/clients/node"
 	"lib/models"
-	"math/big"
+	appMetrics "lib/utils/metrics"
+	"lib/utils/tracing"
 	"time"
 
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/rpc"
+	"go.opentelemetry.io/otel/trace"
 )
 
-func (bc *BlockCollector) CollectBlockByNumber(ctx context.Context, blockNumber uint64) (*models.Block, error) {
+func (bc *BlockCollector) CollectBlockByNumber(ctx context.Context, blockNumber uint64) (_ *models.Block, err error) {
+	ctx, span := tracing.Start(ctx, "collector.CollectBlockByNumber", trace.WithAttributes(tracing.BlockNumber(blockNumber)))
+	defer func() { tracing.End(span, err) }()
+
 	bc.logger.Debugf("Starting collection for block #%d", blockNumber)
 	startTime := time.Now()
 
+	block, reportedHash, receipts, err := bc.fetchBlock(ctx, bc.client, blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	block, receipts, err = bc.verifyFetched(ctx, blockNumber, block, reportedHash, receipts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Calculate metrics
+	metricsCalcStart := time.Now()
+	blk, err := metrics.NewBlockMetrics(block, receipts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert block %d: %w", blockNumber, err)
+	}
+	metricsCalcTime := time.Since(metricsCalcStart)
+
+	totalTime := time.Since(startTime)
+	appMetrics.BlocksCollected.WithLabelValues(modeSingle).Inc()
+	appMetrics.BlockCollectDuration.WithLabelValues(modeSingle).Observe(totalTime.Seconds())
+
+	bc.logger.Infof("Block %d collection completed in %v (metrics: %v) - %d tx, %d gas, miner: %s",
+		blockNumber, totalTime, metricsCalcTime,
+		len(block.Transactions()), block.GasUsed(), block.Coinbase().Hex())
+
+	return &blk, nil
+}
+
+// fetchBlock загружает блок и его квитанции у провайдера provider.
+// Вместе с блоком возвращается хеш, который сообщил провайдер.
+func (bc *BlockCollector) fetchBlock(ctx context.Context, provider node.Provider, blockNumber uint64) (*types.Block, common.Hash, []*types.Receipt, error) {
 	// Fetch block data
 	blockFetchStart := time.Now()
-	block, err := bc.client.BlockByNumber(ctx, big.NewInt(int64(blockNumber)))
+	block, reportedHash, err := rpcBlock(ctx, provider, blockNumber)
 	if err != nil {
 		bc.logger.Errorf("Failed to fetch block %d from network: %v", blockNumber, err)
-		return nil, fmt.Errorf("failed to fetch block %d: %w", blockNumber, err)
+		return nil, common.Hash{}, nil, fmt.Errorf("failed to fetch block %d: %w", blockNumber, err)
 	}
 	blockFetchTime := time.Since(blockFetchStart)
 
@@ -29,28 +70,111 @@ func (bc *BlockCollector) CollectBlockByNumber(ctx context.Context, blockNumber
 
 	// Fetch receipts
 	receiptsFetchStart := time.Now()
-	blockNrOrHash := rpc.BlockNumberOrHashWithHash(block.Hash(), false)
-	receipts, err := bc.client.BlockReceipts(ctx, blockNrOrHash)
+	receipts, err := bc.fetchReceipts(ctx, provider, block)
 	if err != nil {
 		bc.logger.Errorf("Failed to fetch receipts for block %d (hash: %s): %v",
 			blockNumber, block.Hash().Hex(), err)
-		return nil, fmt.Errorf("failed to fetch receipts for block %d: %w", blockNumber, err)
+		return nil, common.Hash{}, nil, fmt.Errorf("failed to fetch receipts for block %d: %w", blockNumber, err)
 	}
 	receiptsFetchTime := time.Since(receiptsFetchStart)
 
 	bc.logger.Debugf("Receipts for block %d fetched successfully in %v: %d receipts",
 		blockNumber, receiptsFetchTime, len(receipts))
 
-	// Calculate metrics
-	metricsCalcStart := time.Now()
-	metrics := metrics.NewBlockMetrics(block, receipts)
-	metricsCalcTime := time.Since(metricsCalcStart)
+	return block, reportedHash, receipts, nil
+}
 
-	totalTime := time.Since(startTime)
+// rpcBlock загружает блок через eth_getBlockByNumber. ethclient собирает блок
+// из заголовка и отбрасывает поле hash ответа, поэтому ответ разбирается здесь:
+// без хеша провайдера проверка хеша заголовка ничего не проверяет.
+func rpcBlock(ctx context.Context, provider node.Provider, blockNumber uint64) (*types.Block, common.Hash, error) {
+	batch := []rpc.BatchElem{{
+		Method: "eth_getBlockByNumber",
+		Args:   []interface{}{hexutil.Uint64(blockNumber), true},
+		Result: new(json.RawMessage),
+	}}
+	if err := provider.BatchCallContext(ctx, batch); err != nil {
+		return nil, common.Hash{}, err
+	}
+	elem := batch[0]
+	if elem.Error != nil {
+		return nil, common.Hash{}, fmt.Errorf("%s: %w", elem.Method, elem.Error)
+	}
+	raw := *elem.Result.(*json.RawMessage)
+	if isEmptyResult(raw) {
+		return nil, common.Hash{}, ErrBlockNotFound
+	}
 
-	bc.logger.Infof("Block %d collection completed in %v (block: %v, receipts: %v, metrics: %v) - %d tx, %d gas, miner: %s",
-		blockNumber, totalTime, blockFetchTime, receiptsFetchTime, metricsCalcTime,
-		len(block.Transactions()), block.GasUsed(), block.Coinbase().Hex())
+	var header types.Header
+	if err := json.Unmarshal(raw, &header); err != nil {
+		return nil, common.Hash{}, fmt.Errorf("%w: decode header: %v", ErrInvalidBatchReply, err)
+	}
+	var body struct {
+		Hash         common.Hash          `json:"hash"`
+		Transactions []*types.Transaction `json:"transactions"`
+		Withdrawals  []*types.Withdrawal  `json:"withdrawals"`
+	}
+	if err := json.Unmarshal(raw, &body); err != nil {
+		return nil, common.Hash{}, fmt.Errorf("%w: decode block body: %v", ErrInvalidBatchReply, err)
+	}
+
+	uncles := chunkUncles(ctx, provider, batch, 1)[0]
+	if uncles.err != nil {
+		return nil, common.Hash{}, uncles.err
+	}
+	headers := make([]*types.Header, len(uncles.raws))
+	for i, raw := range uncles.raws {
+		headers[i] = new(types.Header)
+		if err := json.Unmarshal(raw, headers[i]); err != nil {
+			return nil, common.Hash{}, fmt.Errorf("%w: decode uncle %d: %v", ErrInvalidBatchReply, i, err)
+		}
+	}
+
+	block := types.NewBlockWithHeader(&header).WithBody(types.Body{
+		Transactions: body.Transactions,
+		Uncles:       headers,
+		Withdrawals:  body.Withdrawals,
+	})
+	return block, body.Hash, nil
+}
+
+// verifyFetched проверяет загруженный блок, если проверка включена.
+// В режиме strict блок с расхождениями загружается заново и возвращаются новые
+// данные; ошибка возвращается, только если они тоже не прошли проверку.
+func (bc *BlockCollector) verifyFetched(ctx context.Context, blockNumber uint64, block *types.Block, reportedHash common.Hash, receipts []*types.Receipt) (*types.Block, []*types.Receipt, error) {
+	if !bc.verifying() {
+		return block, receipts, nil
+	}
+
+	err := verifyRPCBlock(block, reportedHash, receipts)
+	if err == nil {
+		observeVerification(bc.client, verifyOK)
+		return block, receipts, nil
+	}
+	observeVerification(bc.client, verifyMismatch)
+	bc.logger.WithBlock(blockNumber).Errorf("Block data from %s does not match its header: %v", bc.client.Name(), err)
+
+	if bc.verification.Mode != VerifyStrict {
+		return block, receipts, nil
+	}
+
+	return bc.refetchVerified(ctx, blockNumber)
+}
+
+// refetchVerified заново загружает блок, не прошедший проверку, с резервного
+// провайдера (или с основного, если резервного нет) и проверяет его ещё раз
+func (bc *BlockCollector) refetchVerified(ctx context.Context, blockNumber uint64) (*types.Block, []*types.Receipt, error) {
+	provider := bc.refetchProvider()
+	block, reportedHash, receipts, err := bc.fetchBlock(ctx, provider, blockNumber)
+	if err != nil {
+		return nil, nil, fmt.Errorf("refetch block %d after failed verification: %w", blockNumber, err)
+	}
+	if err := verifyRPCBlock(block, reportedHash, receipts); err != nil {
+		observeVerification(provider, verifyMismatch)
+		return nil, nil, err
+	}
+	observeVerification(provider, verifyRefetch)
+	bc.logger.WithBlock(blockNumber).Warnf("Block refetched from %s passed verification", provider.Name())
 
-	return &metrics, nil
+	return block, receipts, nil
 }
@@ -0,0 +1,216 @@
+package collector
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"lib/clients/node"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Сколько eth_getTransactionReceipt отправляется в одном батче
+const txReceiptsBatchSize = 200
+
+// ErrMethodUnsupported — провайдер не поддерживает вызванный метод
+var ErrMethodUnsupported = errors.New("method not supported by provider")
+
+// receiptsSupport запоминает провайдеров без eth_getBlockReceipts, чтобы
+// не проверять метод при каждом блоке: после первого отказа квитанции
+// таких провайдеров запрашиваются через eth_getTransactionReceipt
+type receiptsSupport struct {
+	unsupported sync.Map // node.Provider → struct{}
+}
+
+// blockReceipts сообщает, можно ли запрашивать у provider eth_getBlockReceipts
+func (s *receiptsSupport) blockReceipts(provider node.Provider) bool {
+	_, unsupported := s.unsupported.Load(provider)
+	return !unsupported
+}
+
+// markUnsupported запоминает отказ provider; возвращает true при первом отказе
+func (s *receiptsSupport) markUnsupported(provider node.Provider) bool {
+	_, loaded := s.unsupported.LoadOrStore(provider, struct{}{})
+	return !loaded
+}
+
+// isUnsupportedMethod сообщает, что узел не знает вызванный метод
+func isUnsupportedMethod(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, ErrMethodUnsupported) {
+		return true
+	}
+	var rpcErr rpc.Error
+	if errors.As(err, &rpcErr) && rpcErr.ErrorCode() == rpcCodeMethodNotFound {
+		return true
+	}
+
+	// Тексты разных узлов: "the method ... does not exist/is not available",
+	// "Unsupported method", "method not found"
+	msg := strings.ToLower(err.Error())
+	if !strings.Contains(msg, "method") {
+		return false
+	}
+	for _, s := range []string{"not found", "does not exist", "not supported", "not available", "unsupported"} {
+		if strings.Contains(msg, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// noteReceiptsUnsupported переключает provider на eth_getTransactionReceipt
+func (bc *BlockCollector) noteReceiptsUnsupported(provider node.Provider, err error) {
+	if bc.receipts.markUnsupported(provider) {
+		bc.logger.Warnf("%s does not support eth_getBlockReceipts (%v), falling back to eth_getTransactionReceipt", provider.Name(), err)
+	}
+}
+
+// fetchReceipts возвращает квитанции блока: через eth_getBlockReceipts, а если
+// провайдер его не поддерживает — через eth_getTransactionReceipt по каждой транзакции
+func (bc *BlockCollector) fetchReceipts(ctx context.Context, provider node.Provider, block *types.Block) ([]*types.Receipt, error) {
+	if bc.receipts.blockReceipts(provider) {
+		receipts, err := provider.BlockReceipts(ctx, rpc.BlockNumberOrHashWithHash(block.Hash(), false))
+		if !isUnsupportedMethod(err) {
+			return receipts, err
+		}
+		bc.noteReceiptsUnsupported(provider, err)
+	}
+
+	hashes := make([]common.Hash, len(block.Transactions()))
+	for i, tx := range block.Transactions() {
+		hashes[i] = tx.Hash()
+	}
+	raws, errs := bc.txReceipts(ctx, provider, []txReceiptsRequest{{blockHash: block.Hash(), txs: hashes}})
+	if errs[0] != nil {
+		return nil, errs[0]
+	}
+
+	var receipts []*types.Receipt
+	if err := json.Unmarshal(raws[0], &receipts); err != nil {
+		return nil, fmt.Errorf("decode transaction receipts: %w", err)
+	}
+	return receipts, nil
+}
+
+// txReceiptsRequest — транзакции одного блока, квитанции которых нужно получить
+type txReceiptsRequest struct {
+	blockHash common.Hash // нулевой — принадлежность блоку не проверяется
+	txs       []common.Hash
+}
+
+// txReceipts запрашивает квитанции транзакций нескольких блоков батчами
+// eth_getTransactionReceipt. Для каждого блока возвращает JSON-массив квитанций
+// в порядке транзакций (тот же формат, что у eth_getBlockReceipts) или ошибку.
+func (bc *BlockCollector) txReceipts(ctx context.Context, provider node.Provider, reqs []txReceiptsRequest) ([]json.RawMessage, []error) {
+	raws := make([]json.RawMessage, len(reqs))
+	errs := make([]error, len(reqs))
+
+	// Все транзакции в одном списке; owner[i] — индекс блока для элемента i
+	var batch []rpc.BatchElem
+	var owner []int
+	for r, req := range reqs {
+		for _, hash := range req.txs {
+			batch = append(batch, rpc.BatchElem{
+				Method: "eth_getTransactionReceipt",
+				Args:   []interface{}{hash},
+				Result: new(json.RawMessage),
+			})
+			owner = append(owner, r)
+		}
+	}
+
+	for start := 0; start < len(batch); start += txReceiptsBatchSize {
+		end := min(start+txReceiptsBatchSize, len(batch))
+		if err := provider.BatchCallContext(ctx, batch[start:end]); err != nil {
+			for i := start; i < end; i++ {
+				batch[i].Error = err
+			}
+		}
+	}
+
+	receipts := make([][]json.RawMessage, len(reqs))
+	for i, elem := range batch {
+		r := owner[i]
+		if errs[r] != nil {
+			continue
+		}
+		tx := reqs[r].txs[len(receipts[r])]
+		raw, err := checkTxReceipt(elem, tx, reqs[r].blockHash)
+		if err != nil {
+			errs[r] = err
+			continue
+		}
+		receipts[r] = append(receipts[r], raw)
+	}
+
+	for r := range reqs {
+		if errs[r] != nil {
+			continue
+		}
+		if receipts[r] == nil {
+			receipts[r] = []json.RawMessage{}
+		}
+		raw, err := json.Marshal(receipts[r])
+		if err != nil {
+			errs[r] = err
+			continue
+		}
+		raws[r] = raw
+	}
+	return raws, errs
+}
+
+// checkTxReceipt проверяет, что квитанция относится к транзакции tx блока blockHash
+func checkTxReceipt(elem rpc.BatchElem, tx, blockHash common.Hash) (json.RawMessage, error) {
+	if elem.Error != nil {
+		return nil, fmt.Errorf("eth_getTransactionReceipt %s: %w", tx.Hex(), elem.Error)
+	}
+	raw, ok := elem.Result.(*json.RawMessage)
+	if !ok || raw == nil || isEmptyResult(*raw) {
+		return nil, fmt.Errorf("receipt for %s: %w", tx.Hex(), ErrBlockNotFound)
+	}
+
+	var ids struct {
+		TxHash    common.Hash `json:"transactionHash"`
+		BlockHash common.Hash `json:"blockHash"`
+	}
+	if err := json.Unmarshal(*raw, &ids); err != nil {
+		return nil, fmt.Errorf("%w: receipt for %s: %v", ErrInvalidBatchReply, tx.Hex(), err)
+	}
+	if ids.TxHash != tx {
+		return nil, fmt.Errorf("%w: receipt for %s has transaction hash %s", ErrInvalidBatchReply, tx.Hex(), ids.TxHash.Hex())
+	}
+	// Квитанция из другого блока — транзакция попала в него после реорга
+	if blockHash != (common.Hash{}) && ids.BlockHash != blockHash {
+		return nil, fmt.Errorf("receipt for %s belongs to block %s, expected %s: %w",
+			tx.Hex(), ids.BlockHash.Hex(), blockHash.Hex(), ErrBlockNotFound)
+	}
+	return *raw, nil
+}
+
+// blockTxHashes извлекает хеш блока и хеши его транзакций из ответа eth_getBlockByNumber
+func blockTxHashes(rawBlock json.RawMessage) (txReceiptsRequest, error) {
+	var body struct {
+		Hash         common.Hash `json:"hash"`
+		Transactions []struct {
+			Hash common.Hash `json:"hash"`
+		} `json:"transactions"`
+	}
+	if err := json.Unmarshal(rawBlock, &body); err != nil {
+		return txReceiptsRequest{}, fmt.Errorf("%w: decode block: %v", ErrInvalidBatchReply, err)
+	}
+
+	req := txReceiptsRequest{blockHash: body.Hash, txs: make([]common.Hash, len(body.Transactions))}
+	for i, tx := range body.Transactions {
+		req.txs[i] = tx.Hash
+	}
+	return req, nil
+}
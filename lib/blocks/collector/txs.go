@@ -0,0 +1,95 @@
+package collector
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"lib/blocks/metrics"
+	"lib/clients/node"
+	"lib/models"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// CollectTxs загружает транзакции блока hash с квитанциями. Блок запрашивается
+// по хешу, чтобы после реорга не получить транзакции другого блока с тем же
+// номером. Квитанции загружаются так же, как в PostBatch: через
+// eth_getBlockReceipts, а у провайдеров без него — через eth_getTransactionReceipt.
+// При включённой проверке ответ сверяется с заголовком (кроме хеша дядей);
+// в режиме strict блок с расхождениями загружается заново.
+func (bc *BlockCollector) CollectTxs(ctx context.Context, hash common.Hash) ([]models.Tx, error) {
+	rawBlock, rawReceipts, err := bc.fetchByHash(ctx, bc.client, hash)
+	if err != nil {
+		return nil, err
+	}
+
+	if bc.verifying() {
+		if err := verifyJSONBlock(rawBlock, rawReceipts, nil); err == nil {
+			observeVerification(bc.client, verifyOK)
+		} else {
+			observeVerification(bc.client, verifyMismatch)
+			bc.logger.Errorf("Block %s data from %s does not match its header: %v", hash.Hex(), bc.client.Name(), err)
+
+			if bc.verification.Mode == VerifyStrict {
+				provider := bc.refetchProvider()
+				rawBlock, rawReceipts, err = bc.fetchByHash(ctx, provider, hash)
+				if err != nil {
+					return nil, fmt.Errorf("refetch block %s after failed verification: %w", hash.Hex(), err)
+				}
+				if err := verifyJSONBlock(rawBlock, rawReceipts, nil); err != nil {
+					observeVerification(provider, verifyMismatch)
+					return nil, err
+				}
+				observeVerification(provider, verifyRefetch)
+				bc.logger.Warnf("Block %s refetched from %s passed verification", hash.Hex(), provider.Name())
+			}
+		}
+	}
+
+	src, err := metrics.SourceFromJSON(rawBlock, rawReceipts)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidBatchReply, err)
+	}
+	return src.Txs()
+}
+
+// fetchByHash загружает у provider сырые ответы eth_getBlockByHash и квитанции блока
+func (bc *BlockCollector) fetchByHash(ctx context.Context, provider node.Provider, hash common.Hash) (json.RawMessage, json.RawMessage, error) {
+	batch := []rpc.BatchElem{{
+		Method: "eth_getBlockByHash",
+		Args:   []interface{}{hash, true},
+		Result: new(json.RawMessage),
+	}}
+	if bc.receipts.blockReceipts(provider) {
+		batch = append(batch, rpc.BatchElem{
+			Method: "eth_getBlockReceipts",
+			Args:   []interface{}{hash},
+			Result: new(json.RawMessage),
+		})
+	}
+	if err := provider.BatchCallContext(ctx, batch); err != nil {
+		return nil, nil, fmt.Errorf("fetch block %s: %w", hash.Hex(), err)
+	}
+	receiptsElem := bc.chunkReceipts(ctx, provider, batch, len(batch))[0]
+
+	for _, elem := range []rpc.BatchElem{batch[0], receiptsElem} {
+		if elem.Error != nil {
+			return nil, nil, fmt.Errorf("%s of block %s: %w", elem.Method, hash.Hex(), elem.Error)
+		}
+	}
+	rawBlock := *batch[0].Result.(*json.RawMessage)
+	rawReceipts := *receiptsElem.Result.(*json.RawMessage)
+	if isEmptyResult(rawBlock) || isEmptyResult(rawReceipts) {
+		return nil, nil, fmt.Errorf("block %s: %w", hash.Hex(), ErrBlockNotFound)
+	}
+
+	req, err := blockTxHashes(rawBlock)
+	if err != nil {
+		return nil, nil, err
+	}
+	if req.blockHash != hash {
+		return nil, nil, fmt.Errorf("%w: requested block %s, got %s", ErrInvalidBatchReply, hash.Hex(), req.blockHash.Hex())
+	}
+	return rawBlock, rawReceipts, nil
+}
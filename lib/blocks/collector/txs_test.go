@@ -0,0 +1,68 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"lib/utils/logging"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestCollectTxs(t *testing.T) {
+	tb := newTestBlock(t, 100)
+	forged := copyReceipts(tb.receipts)
+	forged[1].Status = 0
+
+	tests := []struct {
+		name            string
+		tampered        bool // провайдер отдаёт испорченные квитанции
+		noBlockReceipts bool
+		mode            string
+		wantErr         error
+	}{
+		{name: "eth_getBlockReceipts", mode: VerifyStrict},
+		{name: "eth_getTransactionReceipt fallback", noBlockReceipts: true, mode: VerifyStrict},
+		{name: "tampered receipts flagged", tampered: true, noBlockReceipts: true, mode: VerifyFlag},
+		{name: "tampered receipts rejected", tampered: true, mode: VerifyStrict, wantErr: ErrVerification},
+		{name: "tampered fallback receipts rejected", tampered: true, noBlockReceipts: true, mode: VerifyStrict, wantErr: ErrVerification},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newFakeProvider(t)
+			receipts := tb.receipts
+			if tt.tampered {
+				receipts = forged
+			}
+			p.add(t, tb, tb.block.Hash(), receipts)
+			p.noBlockReceipts = tt.noBlockReceipts
+
+			bc := NewBlockCollector(p, logging.GetLogger())
+			bc.SetVerification(Verification{Mode: tt.mode})
+
+			txs, err := bc.CollectTxs(context.Background(), tb.block.Hash())
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("collect txs = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if len(txs) != 3 {
+				t.Fatalf("%d txs, want 3", len(txs))
+			}
+			for i, tx := range txs {
+				if tx.Hash != tb.block.Transactions()[i].Hash().Hex() || tx.Receipt == nil || uint64(tx.Receipt.Status) != receipts[i].Status {
+					t.Errorf("tx %d = %s with receipt %+v", i, tx.Hash, tx.Receipt)
+				}
+			}
+		})
+	}
+}
+
+func TestCollectTxsUnknownBlock(t *testing.T) {
+	bc := NewBlockCollector(newFakeProvider(t), logging.GetLogger())
+	if _, err := bc.CollectTxs(context.Background(), common.HexToHash("0x01")); !errors.Is(err, ErrBlockNotFound) {
+		t.Fatalf("collect txs of unknown block = %v, want ErrBlockNotFound", err)
+	}
+}
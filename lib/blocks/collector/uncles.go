@@ -0,0 +1,80 @@
+package collector
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"lib/clients/node"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// uncleHeaders — сырые ответы eth_getUncleByBlockNumberAndIndex для одного блока
+// в порядке индексов; raws == nil — у блока нет дядей или блок не загружен
+type uncleHeaders struct {
+	raws []json.RawMessage
+	err  error
+}
+
+// chunkUncles запрашивает заголовки дядей блоков батча одним батчем
+// eth_getUncleByBlockNumberAndIndex. Ответ eth_getBlockByNumber содержит только
+// хеши дядей, а майнер и номер дяди нужны для расчёта наград.
+func chunkUncles(ctx context.Context, provider node.Provider, batch []rpc.BatchElem, stride int) []uncleHeaders {
+	n := len(batch) / stride
+	out := make([]uncleHeaders, n)
+
+	var elems []rpc.BatchElem
+	var owners []int
+	for k := range n {
+		raw, ok := batch[stride*k].Result.(*json.RawMessage)
+		if batch[stride*k].Error != nil || !ok || raw == nil || isEmptyResult(*raw) {
+			continue
+		}
+
+		var body struct {
+			Number hexutil.Uint64    `json:"number"`
+			Uncles []json.RawMessage `json:"uncles"`
+		}
+		if err := json.Unmarshal(*raw, &body); err != nil || len(body.Uncles) == 0 {
+			// Ошибку разбора блока вернёт parseElement
+			continue
+		}
+
+		for i := range body.Uncles {
+			elems = append(elems, rpc.BatchElem{
+				Method: "eth_getUncleByBlockNumberAndIndex",
+				Args:   []interface{}{body.Number, hexutil.Uint(i)},
+				Result: new(json.RawMessage),
+			})
+			owners = append(owners, k)
+		}
+	}
+	if len(elems) == 0 {
+		return out
+	}
+
+	if err := provider.BatchCallContext(ctx, elems); err != nil {
+		for _, k := range owners {
+			out[k].err = fmt.Errorf("eth_getUncleByBlockNumberAndIndex: %w", err)
+		}
+		return out
+	}
+
+	for i, elem := range elems {
+		k := owners[i]
+		if out[k].err != nil {
+			continue
+		}
+		raw := elem.Result.(*json.RawMessage)
+		switch {
+		case elem.Error != nil:
+			out[k].err = fmt.Errorf("%s: %w", elem.Method, elem.Error)
+		case isEmptyResult(*raw):
+			out[k].err = fmt.Errorf("uncle %d: %w", len(out[k].raws), ErrBlockNotFound)
+		default:
+			out[k].raws = append(out[k].raws, *raw)
+		}
+	}
+	return out
+}
@@ -0,0 +1,186 @@
+package collector
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"lib/clients/node"
+	appMetrics "lib/utils/metrics"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// Режимы проверки полученных от провайдера блоков
+const (
+	// VerifyOff — блоки не проверяются
+	VerifyOff = "off"
+	// VerifyFlag — расхождения пишутся в лог и метрики, блок сохраняется как есть
+	VerifyFlag = "flag"
+	// VerifyStrict — блок с расхождениями загружается заново (с резервного
+	// провайдера, если он задан); если расхождения остаются, возвращается ошибка
+	VerifyStrict = "strict"
+)
+
+// Значения метки result в метрике проверок
+const (
+	verifyOK       = "ok"
+	verifyMismatch = "mismatch"
+	verifyRefetch  = "refetched"
+)
+
+// ErrVerification — данные блока не согласуются с его заголовком
+var ErrVerification = errors.New("block verification failed")
+
+// Verification — настройки проверки блоков.
+// Fallback — провайдер для повторной загрузки в режиме strict; nil — тот же провайдер.
+type Verification struct {
+	Mode     string
+	Fallback node.Provider
+}
+
+// VerificationError перечисляет расхождения между данными блока и его заголовком
+type VerificationError struct {
+	Block      uint64
+	Hash       common.Hash
+	Mismatches []string
+}
+
+func (e *VerificationError) Error() string {
+	return fmt.Sprintf("block %d (%s): %s", e.Block, e.Hash.Hex(), strings.Join(e.Mismatches, "; "))
+}
+
+func (e *VerificationError) Unwrap() error {
+	return ErrVerification
+}
+
+// SetVerification включает проверку блоков. Вызывается до начала сбора.
+func (bc *BlockCollector) SetVerification(v Verification) {
+	if v.Mode == "" {
+		v.Mode = VerifyOff
+	}
+	bc.verification = v
+}
+
+// verifying сообщает, включена ли проверка блоков
+func (bc *BlockCollector) verifying() bool {
+	return bc.verification.Mode != VerifyOff && bc.verification.Mode != ""
+}
+
+// refetchProvider возвращает провайдер для повторной загрузки блока
+func (bc *BlockCollector) refetchProvider() node.Provider {
+	if bc.verification.Fallback != nil {
+		return bc.verification.Fallback
+	}
+	return bc.client
+}
+
+// observeVerification учитывает результат проверки в метриках
+func observeVerification(provider node.Provider, result string) {
+	appMetrics.BlockVerifications.WithLabelValues(provider.Name(), result).Inc()
+}
+
+// VerifyBlock пересчитывает по данным блока корни дерева транзакций, квитанций
+// и withdrawals, logs bloom и хеш заголовка и сравнивает их с заголовком.
+// reportedHash — хеш, который вернул провайдер (нулевой — не проверяется).
+// uncles == nil — хеш дядей не проверяется.
+func VerifyBlock(header *types.Header, reportedHash common.Hash, txs types.Transactions,
+	receipts types.Receipts, withdrawals types.Withdrawals, uncles []*types.Header) error {
+	hash := header.Hash()
+	var mismatches []string
+	mismatch := func(format string, args ...any) {
+		mismatches = append(mismatches, fmt.Sprintf(format, args...))
+	}
+
+	if reportedHash != (common.Hash{}) && reportedHash != hash {
+		mismatch("block hash %s, header hashes to %s", reportedHash.Hex(), hash.Hex())
+	}
+
+	if root := types.DeriveSha(txs, trie.NewStackTrie(nil)); root != header.TxHash {
+		mismatch("transactions root %s, header %s", root.Hex(), header.TxHash.Hex())
+	}
+
+	if len(receipts) != len(txs) {
+		mismatch("%d receipts for %d transactions", len(receipts), len(txs))
+	} else {
+		for i, receipt := range receipts {
+			if receipt.TxHash != txs[i].Hash() {
+				mismatch("receipt %d is for tx %s, expected %s", i, receipt.TxHash.Hex(), txs[i].Hash().Hex())
+				break
+			}
+			if receipt.BlockHash != (common.Hash{}) && receipt.BlockHash != hash {
+				mismatch("receipt %d belongs to block %s", i, receipt.BlockHash.Hex())
+				break
+			}
+		}
+	}
+
+	if root := types.DeriveSha(receipts, trie.NewStackTrie(nil)); root != header.ReceiptHash {
+		mismatch("receipts root %s, header %s", root.Hex(), header.ReceiptHash.Hex())
+	}
+
+	// Bloom пересчитывается из логов, а не берётся из квитанций
+	var bloom types.Bloom
+	for i, receipt := range receipts {
+		receiptBloom := types.CreateBloom(receipt)
+		if receiptBloom != receipt.Bloom {
+			mismatch("logs bloom of receipt %d does not match its logs", i)
+		}
+		for j := range bloom {
+			bloom[j] |= receiptBloom[j]
+		}
+	}
+	if bloom != header.Bloom {
+		mismatch("logs bloom does not match header")
+	}
+
+	if header.WithdrawalsHash != nil {
+		if root := types.DeriveSha(withdrawals, trie.NewStackTrie(nil)); root != *header.WithdrawalsHash {
+			mismatch("withdrawals root %s, header %s", root.Hex(), header.WithdrawalsHash.Hex())
+		}
+	}
+
+	if uncles != nil {
+		if uncleHash := types.CalcUncleHash(uncles); uncleHash != header.UncleHash {
+			mismatch("uncles hash %s, header %s", uncleHash.Hex(), header.UncleHash.Hex())
+		}
+	}
+
+	if len(mismatches) > 0 {
+		return &VerificationError{Block: header.Number.Uint64(), Hash: hash, Mismatches: mismatches}
+	}
+	return nil
+}
+
+// verifyRPCBlock проверяет блок, загруженный rpcBlock; reportedHash — хеш из ответа провайдера
+func verifyRPCBlock(block *types.Block, reportedHash common.Hash, receipts []*types.Receipt) error {
+	return VerifyBlock(block.Header(), reportedHash, block.Transactions(), receipts, block.Withdrawals(), block.Uncles())
+}
+
+// verifyJSONBlock проверяет сырые ответы eth_getBlockByNumber и eth_getBlockReceipts
+// вместе с заголовками дядей, полученными отдельным батчем (пусто — дядей нет,
+// nil — хеш дядей не проверяется)
+func verifyJSONBlock(rawBlock, rawReceipts json.RawMessage, uncles []*types.Header) error {
+	var header types.Header
+	if err := json.Unmarshal(rawBlock, &header); err != nil {
+		return fmt.Errorf("%w: decode header: %v", ErrVerification, err)
+	}
+
+	var body struct {
+		Hash         common.Hash          `json:"hash"`
+		Transactions []*types.Transaction `json:"transactions"`
+		Withdrawals  []*types.Withdrawal  `json:"withdrawals"`
+	}
+	if err := json.Unmarshal(rawBlock, &body); err != nil {
+		return fmt.Errorf("%w: decode block body: %v", ErrVerification, err)
+	}
+
+	var receipts []*types.Receipt
+	if err := json.Unmarshal(rawReceipts, &receipts); err != nil {
+		return fmt.Errorf("%w: decode receipts: %v", ErrVerification, err)
+	}
+
+	return VerifyBlock(&header, body.Hash, body.Transactions, receipts, body.Withdrawals, uncles)
+}
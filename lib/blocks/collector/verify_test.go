@@ -0,0 +1,370 @@
+package collector
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+
+	"lib/clients/node"
+	"lib/utils/logging"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+var transferTopic = crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+
+// testBlock — блок с подписанными транзакциями и квитанциями с логами,
+// корни и bloom заголовка посчитаны по ним
+type testBlock struct {
+	block    *types.Block
+	receipts types.Receipts
+}
+
+func newTestBlock(t *testing.T, number uint64) testBlock {
+	t.Helper()
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	signer := types.LatestSignerForChainID(big.NewInt(1))
+	to := common.HexToAddress("0xdAC17F958D2ee523a2206206994597C13D831ec7")
+
+	txs := types.Transactions{
+		types.MustSignNewTx(key, signer, &types.LegacyTx{Nonce: 0, GasPrice: big.NewInt(2e9), Gas: 60000, To: &to, Value: big.NewInt(1)}),
+		types.MustSignNewTx(key, signer, &types.DynamicFeeTx{ChainID: big.NewInt(1), Nonce: 1, GasTipCap: big.NewInt(1e9), GasFeeCap: big.NewInt(3e9), Gas: 60000, To: &to}),
+		types.MustSignNewTx(key, signer, &types.AccessListTx{ChainID: big.NewInt(1), Nonce: 2, GasPrice: big.NewInt(2e9), Gas: 60000, To: &to}),
+	}
+
+	receipts := make(types.Receipts, len(txs))
+	for i, tx := range txs {
+		receipt := &types.Receipt{
+			Type:              tx.Type(),
+			Status:            types.ReceiptStatusSuccessful,
+			CumulativeGasUsed: uint64(50000 * (i + 1)),
+			GasUsed:           50000,
+			TxHash:            tx.Hash(),
+			TransactionIndex:  uint(i),
+			Logs: []*types.Log{{
+				Address: to,
+				Topics:  []common.Hash{transferTopic, common.BigToHash(big.NewInt(int64(i)))},
+				Data:    common.LeftPadBytes(big.NewInt(int64(1000*i)).Bytes(), 32),
+				TxHash:  tx.Hash(),
+				TxIndex: uint(i),
+				Index:   uint(i),
+			}},
+		}
+		receipt.Bloom = types.CreateBloom(receipt)
+		receipts[i] = receipt
+	}
+
+	header := &types.Header{
+		Number:     new(big.Int).SetUint64(number),
+		GasLimit:   30_000_000,
+		GasUsed:    150000,
+		Time:       1_700_000_000 + number*12,
+		Difficulty: new(big.Int),
+		BaseFee:    big.NewInt(1e9),
+		Coinbase:   common.HexToAddress("0x95222290DD7278Aa3Ddd389Cc1E1d165CC4BAfe5"),
+	}
+	block := types.NewBlock(header, &types.Body{Transactions: txs}, receipts, trie.NewStackTrie(nil))
+
+	for _, receipt := range receipts {
+		receipt.BlockHash = block.Hash()
+		receipt.BlockNumber = block.Number()
+		for _, log := range receipt.Logs {
+			log.BlockHash = block.Hash()
+			log.BlockNumber = number
+		}
+	}
+	return testBlock{block: block, receipts: receipts}
+}
+
+// rawBlock — ответ eth_getBlockByNumber для блока; hash — поле hash ответа
+func (tb testBlock) rawBlock(t *testing.T, hash common.Hash) json.RawMessage {
+	t.Helper()
+	raw, err := json.Marshal(tb.block.Header())
+	if err != nil {
+		t.Fatalf("marshal header: %v", err)
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		t.Fatalf("unmarshal header: %v", err)
+	}
+	fields["hash"] = hash
+	fields["transactions"] = tb.block.Transactions()
+	fields["uncles"] = []common.Hash{}
+
+	raw, err = json.Marshal(fields)
+	if err != nil {
+		t.Fatalf("marshal block: %v", err)
+	}
+	return raw
+}
+
+func (tb testBlock) rawReceipts(t *testing.T) json.RawMessage {
+	t.Helper()
+	raw, err := json.Marshal(tb.receipts)
+	if err != nil {
+		t.Fatalf("marshal receipts: %v", err)
+	}
+	return raw
+}
+
+// copyReceipts копирует квитанции, чтобы их можно было испортить
+func copyReceipts(receipts types.Receipts) types.Receipts {
+	out := make(types.Receipts, len(receipts))
+	for i, receipt := range receipts {
+		cp := *receipt
+		cp.Logs = make([]*types.Log, len(receipt.Logs))
+		for j, log := range receipt.Logs {
+			logCopy := *log
+			cp.Logs[j] = &logCopy
+		}
+		out[i] = &cp
+	}
+	return out
+}
+
+func TestVerifyBlock(t *testing.T) {
+	tb := newTestBlock(t, 100)
+	otherKey, _ := crypto.GenerateKey()
+
+	tests := []struct {
+		name   string
+		tamper func(hash *common.Hash, txs types.Transactions, receipts types.Receipts) (types.Transactions, types.Receipts)
+		want   []string
+	}{
+		{
+			name: "valid block",
+			tamper: func(_ *common.Hash, txs types.Transactions, receipts types.Receipts) (types.Transactions, types.Receipts) {
+				return txs, receipts
+			},
+		},
+		{
+			name: "reported hash differs from header",
+			tamper: func(hash *common.Hash, txs types.Transactions, receipts types.Receipts) (types.Transactions, types.Receipts) {
+				*hash = common.HexToHash("0x01")
+				return txs, receipts
+			},
+			want: []string{"block hash 0x0000000000000000000000000000000000000000000000000000000000000001"},
+		},
+		{
+			name: "tampered transaction",
+			tamper: func(_ *common.Hash, txs types.Transactions, receipts types.Receipts) (types.Transactions, types.Receipts) {
+				to := common.HexToAddress("0x01")
+				forged := types.MustSignNewTx(otherKey, types.LatestSignerForChainID(big.NewInt(1)),
+					&types.LegacyTx{Nonce: 0, GasPrice: big.NewInt(2e9), Gas: 60000, To: &to, Value: big.NewInt(1e18)})
+				txs = append(types.Transactions{}, txs...)
+				txs[1] = forged
+				return txs, receipts
+			},
+			want: []string{"transactions root", "receipt 1 is for tx"},
+		},
+		{
+			name: "tampered receipt status",
+			tamper: func(_ *common.Hash, txs types.Transactions, receipts types.Receipts) (types.Transactions, types.Receipts) {
+				receipts = copyReceipts(receipts)
+				receipts[2].Status = types.ReceiptStatusFailed
+				return txs, receipts
+			},
+			want: []string{"receipts root"},
+		},
+		{
+			name: "tampered log keeps receipt bloom",
+			tamper: func(_ *common.Hash, txs types.Transactions, receipts types.Receipts) (types.Transactions, types.Receipts) {
+				receipts = copyReceipts(receipts)
+				receipts[0].Logs[0].Address = common.HexToAddress("0x02")
+				return txs, receipts
+			},
+			want: []string{"receipts root", "logs bloom of receipt 0 does not match its logs", "logs bloom does not match header"},
+		},
+		{
+			name: "receipt from another block",
+			tamper: func(_ *common.Hash, txs types.Transactions, receipts types.Receipts) (types.Transactions, types.Receipts) {
+				receipts = copyReceipts(receipts)
+				receipts[1].BlockHash = common.HexToHash("0x03")
+				return txs, receipts
+			},
+			want: []string{"receipt 1 belongs to block"},
+		},
+		{
+			name: "missing receipt",
+			tamper: func(_ *common.Hash, txs types.Transactions, receipts types.Receipts) (types.Transactions, types.Receipts) {
+				return txs, receipts[:2]
+			},
+			want: []string{"2 receipts for 3 transactions", "receipts root", "logs bloom does not match header"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hash := tb.block.Hash()
+			txs, receipts := tt.tamper(&hash, tb.block.Transactions(), tb.receipts)
+
+			err := VerifyBlock(tb.block.Header(), hash, txs, receipts, nil, []*types.Header{})
+			if len(tt.want) == 0 {
+				if err != nil {
+					t.Fatalf("verify = %v, want nil", err)
+				}
+				return
+			}
+
+			var verr *VerificationError
+			if !errors.As(err, &verr) || !errors.Is(err, ErrVerification) {
+				t.Fatalf("verify = %v, want VerificationError", err)
+			}
+			if len(verr.Mismatches) != len(tt.want) {
+				t.Errorf("mismatches = %q, want %d", verr.Mismatches, len(tt.want))
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("verify = %v, want mismatch %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestVerifyJSONBlockChecksReportedHash(t *testing.T) {
+	tb := newTestBlock(t, 100)
+
+	if err := verifyJSONBlock(tb.rawBlock(t, tb.block.Hash()), tb.rawReceipts(t), nil); err != nil {
+		t.Fatalf("verify intact block = %v", err)
+	}
+
+	forged := common.HexToHash("0xbad")
+	err := verifyJSONBlock(tb.rawBlock(t, forged), tb.rawReceipts(t), nil)
+	if !errors.Is(err, ErrVerification) || !strings.Contains(err.Error(), "block hash "+forged.Hex()) {
+		t.Fatalf("verify block with forged hash = %v, want hash mismatch", err)
+	}
+}
+
+// fakeProvider отвечает на запросы блоков и квитанций заранее заданными блоками
+type fakeProvider struct {
+	node.Provider
+
+	blocks   map[string]json.RawMessage // номер или хеш блока → ответ eth_getBlockByNumber
+	receipts map[string]json.RawMessage // номер или хеш блока → ответ eth_getBlockReceipts
+	byHash   map[common.Hash]types.Receipts
+	txs      map[common.Hash]json.RawMessage // хеш транзакции → ответ eth_getTransactionReceipt
+
+	// noBlockReceipts — провайдер не знает eth_getBlockReceipts
+	noBlockReceipts bool
+	// batch, если задан, вызывается перед ответом и может отвергнуть весь батч
+	batch func(batch []rpc.BatchElem) error
+	calls int
+}
+
+func newFakeProvider(t *testing.T, blocks ...testBlock) *fakeProvider {
+	p := &fakeProvider{
+		blocks:   make(map[string]json.RawMessage),
+		receipts: make(map[string]json.RawMessage),
+		byHash:   make(map[common.Hash]types.Receipts),
+		txs:      make(map[common.Hash]json.RawMessage),
+	}
+	for _, tb := range blocks {
+		p.add(t, tb, tb.block.Hash(), tb.receipts)
+	}
+	return p
+}
+
+// add отдаёт блок tb с полем hash = reportedHash и квитанциями receipts
+func (p *fakeProvider) add(t *testing.T, tb testBlock, reportedHash common.Hash, receipts types.Receipts) {
+	num := fmt.Sprintf("0x%x", tb.block.NumberU64())
+	hash := tb.block.Hash().Hex()
+	tb.receipts = receipts
+	p.blocks[num] = tb.rawBlock(t, reportedHash)
+	p.blocks[hash] = p.blocks[num]
+	p.receipts[num] = tb.rawReceipts(t)
+	p.receipts[hash] = p.receipts[num]
+	p.byHash[tb.block.Hash()] = receipts
+	for _, receipt := range receipts {
+		raw, err := json.Marshal(receipt)
+		if err != nil {
+			t.Fatalf("marshal receipt: %v", err)
+		}
+		p.txs[receipt.TxHash] = raw
+	}
+}
+
+func (p *fakeProvider) Name() string { return "fake" }
+
+func (p *fakeProvider) BlockReceipts(_ context.Context, blockNrOrHash rpc.BlockNumberOrHash) ([]*types.Receipt, error) {
+	if p.noBlockReceipts {
+		return nil, rpcError{code: rpcCodeMethodNotFound, msg: "the method eth_getBlockReceipts does not exist"}
+	}
+	hash, _ := blockNrOrHash.Hash()
+	receipts, ok := p.byHash[hash]
+	if !ok {
+		return nil, fmt.Errorf("block %s not found", hash.Hex())
+	}
+	return receipts, nil
+}
+
+func (p *fakeProvider) BatchCallContext(_ context.Context, batch []rpc.BatchElem) error {
+	p.calls++
+	if p.batch != nil {
+		if err := p.batch(batch); err != nil {
+			return err
+		}
+	}
+	for i := range batch {
+		elem := &batch[i]
+		var raw json.RawMessage
+		switch elem.Method {
+		case "eth_getBlockByNumber", "eth_getBlockByHash":
+			raw = p.blocks[fmt.Sprint(elem.Args[0])]
+		case "eth_getBlockReceipts":
+			if p.noBlockReceipts {
+				elem.Error = rpcError{code: rpcCodeMethodNotFound, msg: "the method eth_getBlockReceipts does not exist"}
+				continue
+			}
+			switch arg := elem.Args[0].(type) {
+			case map[string]string:
+				raw = p.receipts[arg["blockNumber"]]
+			default:
+				raw = p.receipts[fmt.Sprint(arg)]
+			}
+		case "eth_getTransactionReceipt":
+			raw = p.txs[elem.Args[0].(common.Hash)]
+		default:
+			elem.Error = fmt.Errorf("the method %s does not exist", elem.Method)
+			continue
+		}
+		if raw == nil {
+			raw = json.RawMessage("null")
+		}
+		*elem.Result.(*json.RawMessage) = raw
+	}
+	return nil
+}
+
+func TestCollectBlockByNumberVerifiesReportedHash(t *testing.T) {
+	tb := newTestBlock(t, 100)
+
+	p := newFakeProvider(t, tb)
+	bc := NewBlockCollector(p, logging.GetLogger())
+	bc.SetVerification(Verification{Mode: VerifyStrict})
+
+	block, err := bc.CollectBlockByNumber(context.Background(), 100)
+	if err != nil {
+		t.Fatalf("collect intact block: %v", err)
+	}
+	if block.Hash != tb.block.Hash().Hex() || len(block.Transactions) != 3 {
+		t.Errorf("collected block %s with %d txs, want %s with 3", block.Hash, len(block.Transactions), tb.block.Hash().Hex())
+	}
+
+	// Провайдер отдаёт согласованное тело, но чужой хеш блока
+	p.add(t, tb, common.HexToHash("0xbad"), tb.receipts)
+	if _, err := bc.CollectBlockByNumber(context.Background(), 100); !errors.Is(err, ErrVerification) {
+		t.Fatalf("collect block with forged hash = %v, want ErrVerification", err)
+	}
+}
@@ -1,144 +1,311 @@
 package metrics
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"lib/models"
-	"log"
+	"math/big"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
 )
 
+// ErrConvert — данные блока нельзя преобразовать в модели
+var ErrConvert = errors.New("block conversion failed")
+
+// Source — данные блока в типах go-ethereum, из которых строятся модели.
+// Оба пути получения блока (ethclient и сырой JSON батча) сводятся к Source,
+// поэтому дают одинаковые models.Block и models.Tx.
+//
+// Формат полей моделей:
+//   - суммы и сложность (Value, Difficulty) — десятичные строки (UInt256 в ClickHouse);
+//   - подпись V/R/S — hex с префиксом 0x;
+//   - MaxFeePerGas/MaxPriorityFeePerGas — только у транзакций с динамической комиссией;
+//   - AccessList — JSON списка доступа, пустая строка, если список пуст;
+//   - TotalDifficulty не заполняется: поле убрано из ответов узлов после The Merge.
+type Source struct {
+	Header       *types.Header
+	Hash         common.Hash // хеш из ответа провайдера; нулевой — вычисляется по заголовку
+	Transactions types.Transactions
+	Receipts     []*types.Receipt // nil — квитанции не запрашивались
+	Uncles       []common.Hash
+	UncleHeaders []*types.Header // nil — заголовки дядей не запрашивались
+	Size         uint64
+}
+
+// SourceFromBlock собирает Source из блока и квитанций, полученных через ethclient
+func SourceFromBlock(block *types.Block, receipts []*types.Receipt) Source {
+	uncles := make([]common.Hash, len(block.Uncles()))
+	for i, u := range block.Uncles() {
+		uncles[i] = u.Hash()
+	}
+	return Source{
+		Header:       block.Header(),
+		Hash:         block.Hash(),
+		Transactions: block.Transactions(),
+		Receipts:     receipts,
+		Uncles:       uncles,
+		UncleHeaders: block.Uncles(),
+		Size:         block.Size(),
+	}
+}
+
 // NewBlockMetrics преобразует блок и квитанции из go-ethereum в models.Block (JSON-safe)
-func NewBlockMetrics(block *types.Block, receipts []*types.Receipt) models.Block {
+func NewBlockMetrics(block *types.Block, receipts []*types.Receipt) (models.Block, error) {
 	if block == nil {
-		return models.Block{}
+		return models.Block{}, fmt.Errorf("%w: nil block", ErrConvert)
 	}
+	return SourceFromBlock(block, receipts).Block()
+}
 
-	uncles := make([]string, len(block.Uncles()))
-	for i, u := range block.Uncles() {
-		uncles[i] = u.Hash().Hex()
+// hash возвращает хеш блока
+func (s Source) hash() common.Hash {
+	if s.Hash != (common.Hash{}) {
+		return s.Hash
+	}
+	return s.Header.Hash()
+}
+
+// timestamp возвращает время блока в UTC
+func (s Source) timestamp() time.Time {
+	return time.Unix(int64(s.Header.Time), 0).UTC()
+}
+
+// validate проверяет, что квитанции и заголовки дядей соответствуют блоку
+func (s Source) validate() error {
+	if s.Header == nil {
+		return fmt.Errorf("%w: missing header", ErrConvert)
+	}
+	if s.UncleHeaders != nil && len(s.UncleHeaders) != len(s.Uncles) {
+		return fmt.Errorf("%w: block %d lists %d uncles but %d uncle headers",
+			ErrConvert, s.Header.Number.Uint64(), len(s.Uncles), len(s.UncleHeaders))
+	}
+	if s.Receipts != nil && len(s.Receipts) != len(s.Transactions) {
+		return fmt.Errorf("%w: block %d has %d transactions but %d receipts",
+			ErrConvert, s.Header.Number.Uint64(), len(s.Transactions), len(s.Receipts))
+	}
+	return nil
+}
+
+// Block строит models.Block
+func (s Source) Block() (models.Block, error) {
+	if err := s.validate(); err != nil {
+		return models.Block{}, err
+	}
+	h := s.Header
+
+	uncles := make([]string, len(s.Uncles))
+	for i, u := range s.Uncles {
+		uncles[i] = u.Hex()
 	}
 
 	var baseFee *uint
-	if block.BaseFee() != nil {
-		val := uint(block.BaseFee().Uint64())
+	if h.BaseFee != nil {
+		val := uint(h.BaseFee.Uint64())
 		baseFee = &val
 	}
 
-	timestamp := time.Unix(int64(block.Time()), 0).UTC()
-
 	blk := models.Block{
-		Hash:             block.Hash().Hex(),
-		Number:           uint(block.NumberU64()),
-		ParentHash:       block.ParentHash().Hex(),
-		Nonce:            uint(block.Nonce()),
-		Sha3Uncles:       block.UncleHash().Hex(),
-		LogsBloom:        "0x" + common.Bytes2Hex(block.Bloom().Bytes()),
-		TransactionsRoot: block.TxHash().Hex(),
-		StateRoot:        block.Root().Hex(),
-		ReceiptsRoot:     block.ReceiptHash().Hex(),
-		Miner:            block.Coinbase().Hex(),
-		Difficulty:       block.Difficulty().String(),
-		TotalDifficulty:  "", // если ты её не считаешь — можно позже добавить
-		Size:             uint(block.Size()),
-		ExtraData:        "0x" + common.Bytes2Hex(block.Extra()),
-		GasLimit:         uint(block.GasLimit()),
-		GasUsed:          uint(block.GasUsed()),
+		Hash:             s.hash().Hex(),
+		Number:           uint(h.Number.Uint64()),
+		ParentHash:       h.ParentHash.Hex(),
+		Nonce:            uint(h.Nonce.Uint64()),
+		Sha3Uncles:       h.UncleHash.Hex(),
+		LogsBloom:        hexutil.Encode(h.Bloom.Bytes()),
+		TransactionsRoot: h.TxHash.Hex(),
+		StateRoot:        h.Root.Hex(),
+		ReceiptsRoot:     h.ReceiptHash.Hex(),
+		Miner:            h.Coinbase.Hex(),
+		Difficulty:       h.Difficulty.String(),
+		Size:             uint(s.Size),
+		ExtraData:        hexutil.Encode(h.Extra),
+		GasLimit:         uint(h.GasLimit),
+		GasUsed:          uint(h.GasUsed),
 		BaseFeePerGas:    baseFee,
-		Timestamp:        timestamp,
-		MixHash:          block.MixDigest().Hex(),
-		Transactions:     make([]string, 0, len(block.Transactions())),
+		Timestamp:        s.timestamp(),
+		MixHash:          h.MixDigest.Hex(),
+		Transactions:     make([]string, len(s.Transactions)),
 		Uncles:           uncles,
 	}
 
-	// Транзакции
-	for i, tx := range block.Transactions() {
-		var receipt *types.Receipt
-		if i < len(receipts) {
-			receipt = receipts[i]
-		}
+	for i, tx := range s.Transactions {
+		blk.Transactions[i] = tx.Hash().Hex()
+	}
 
-		txModel := NewTx(tx, receipt, block, timestamp, i)
-		blk.Transactions = append(blk.Transactions, txModel.Hash)
+	if len(s.UncleHeaders) > 0 {
+		blk.UncleHeaders = s.uncles(blk.Hash)
+	}
+
+	// Без квитанций неизвестны комиссии, без заголовков дядей — награды их майнерам
+	if s.Receipts != nil && (len(s.Uncles) == 0 || s.UncleHeaders != nil) {
+		txs, err := s.Txs()
+		if err != nil {
+			return models.Block{}, err
+		}
+		rewards, err := BlockRewards(blk, txs)
+		if err != nil {
+			return models.Block{}, err
+		}
+		blk.Rewards = &rewards
 	}
+	return blk, nil
+}
 
-	return blk
+// uncles строит модели дядей с наградой их майнерам
+func (s Source) uncles(blockHash string) []models.Uncle {
+	number := s.Header.Number.Uint64()
+	uncles := make([]models.Uncle, len(s.UncleHeaders))
+	for i, u := range s.UncleHeaders {
+		uncles[i] = models.Uncle{
+			Hash:           u.Hash().Hex(),
+			Number:         uint(u.Number.Uint64()),
+			ParentHash:     u.ParentHash.Hex(),
+			Miner:          u.Coinbase.Hex(),
+			Difficulty:     u.Difficulty.String(),
+			GasLimit:       uint(u.GasLimit),
+			GasUsed:        uint(u.GasUsed),
+			Timestamp:      time.Unix(int64(u.Time), 0).UTC(),
+			BlockNumber:    uint(number),
+			BlockHash:      blockHash,
+			UncleIndex:     uint(i),
+			Reward:         UncleReward(u.Number.Uint64(), number).String(),
+			BlockTimestamp: s.timestamp(),
+		}
+	}
+	return uncles
 }
 
-// NewTx преобразует транзакцию из go-ethereum в models.Tx
-func NewTx(tx *types.Transaction, receipt *types.Receipt, block *types.Block, ts time.Time, index int) models.Tx {
-	v, r, s := tx.RawSignatureValues()
-	from := getTxSender(tx)
+// Txs строит модели транзакций блока с квитанциями
+func (s Source) Txs() ([]models.Tx, error) {
+	if err := s.validate(); err != nil {
+		return nil, err
+	}
 
-	var (
-		maxFeePerGas, maxPriorityFeePerGas *uint
-	)
+	txs := make([]models.Tx, len(s.Transactions))
+	for i, tx := range s.Transactions {
+		var receipt *types.Receipt
+		if s.Receipts != nil {
+			receipt = s.Receipts[i]
+		}
 
-	if tx.GasFeeCap() != nil {
-		val := uint(tx.GasFeeCap().Uint64())
-		maxFeePerGas = &val
+		txModel, err := s.newTx(tx, receipt, i)
+		if err != nil {
+			return nil, err
+		}
+		txs[i] = txModel
 	}
-	if tx.GasTipCap() != nil {
-		val := uint(tx.GasTipCap().Uint64())
-		maxPriorityFeePerGas = &val
+	return txs, nil
+}
+
+// newTx преобразует транзакцию из go-ethereum в models.Tx
+func (s Source) newTx(tx *types.Transaction, receipt *types.Receipt, index int) (models.Tx, error) {
+	from, err := txSender(tx)
+	if err != nil {
+		return models.Tx{}, err
+	}
+	v, r, sig := tx.RawSignatureValues()
+
+	var maxFeePerGas, maxPriorityFeePerGas *uint
+	if tx.Type() >= types.DynamicFeeTxType {
+		feeCap, tipCap := uint(tx.GasFeeCap().Uint64()), uint(tx.GasTipCap().Uint64())
+		maxFeePerGas, maxPriorityFeePerGas = &feeCap, &tipCap
+	}
+
+	var accessList string
+	if al := tx.AccessList(); len(al) > 0 {
+		data, err := json.Marshal(al)
+		if err != nil {
+			return models.Tx{}, fmt.Errorf("%w: access list of tx %s: %v", ErrConvert, tx.Hash().Hex(), err)
+		}
+		accessList = string(data)
 	}
 
+	ts := s.timestamp()
 	txModel := models.Tx{
 		Hash:                 tx.Hash().Hex(),
-		BlockHash:            block.Hash().Hex(),
-		BlockNumber:          uint(block.NumberU64()),
+		BlockHash:            s.hash().Hex(),
+		BlockNumber:          uint(s.Header.Number.Uint64()),
 		TransactionIndex:     uint(index),
 		From:                 from.Hex(),
 		To:                   addressToOptionalString(tx.To()),
-		Value:                tx.Value().String(), // big.Int в string
+		Value:                tx.Value().String(),
 		Gas:                  uint(tx.Gas()),
-		GasPrice:             uint(tx.GasPrice().Uint64()),
-		Input:                "0x" + common.Bytes2Hex(tx.Data()),
+		GasPrice:             uint(s.gasPrice(tx, receipt).Uint64()),
+		Input:                hexutil.Encode(tx.Data()),
 		Nonce:                uint(tx.Nonce()),
 		Type:                 uint(tx.Type()),
 		MaxFeePerGas:         maxFeePerGas,
 		MaxPriorityFeePerGas: maxPriorityFeePerGas,
 		ChainID:              uint(tx.ChainId().Uint64()),
-		V:                    v.String(),
-		R:                    r.String(),
-		S:                    s.String(),
-		AccessList:           "", // если нужно сериализовать — потом добавим
+		V:                    hexutil.EncodeBig(v),
+		R:                    hexutil.EncodeBig(r),
+		S:                    hexutil.EncodeBig(sig),
+		AccessList:           accessList,
 		BlockTimestamp:       ts,
 	}
 
 	if receipt != nil {
-		txModel.Receipt = NewReceipt(receipt, tx, block, ts, index)
+		txModel.Receipt = s.newReceipt(receipt, tx, from, index)
 	}
+	return txModel, nil
+}
 
-	return txModel
+// gasPrice возвращает фактическую цену газа транзакции, как поле gasPrice
+// ответа узла: у транзакций EIP-1559 tx.GasPrice() — это max fee, а платится
+// base fee + min(tip, max fee - base fee). Цена берётся из квитанции, без неё
+// считается по base fee блока.
+func (s Source) gasPrice(tx *types.Transaction, receipt *types.Receipt) *big.Int {
+	if receipt != nil && receipt.EffectiveGasPrice != nil && receipt.EffectiveGasPrice.Sign() > 0 {
+		return receipt.EffectiveGasPrice
+	}
+	baseFee := s.Header.BaseFee
+	if baseFee == nil {
+		return tx.GasPrice()
+	}
+	tip, err := tx.EffectiveGasTip(baseFee)
+	if err != nil {
+		// max fee ниже base fee: такая транзакция не могла попасть в блок
+		return tx.GasPrice()
+	}
+	return tip.Add(tip, baseFee)
 }
 
-// NewReceipt преобразует go-ethereum Receipt в models.Receipt
-func NewReceipt(receipt *types.Receipt, tx *types.Transaction, block *types.Block, ts time.Time, index int) *models.Receipt {
-	if receipt == nil {
-		return nil
+// newReceipt преобразует go-ethereum Receipt в models.Receipt
+func (s Source) newReceipt(receipt *types.Receipt, tx *types.Transaction, from common.Address, index int) *models.Receipt {
+	var contractAddress *common.Address
+	if receipt.ContractAddress != (common.Address{}) {
+		contractAddress = &receipt.ContractAddress
+	}
+
+	// Старые узлы не отдают effectiveGasPrice для блоков до London
+	effectiveGasPrice := uint(s.gasPrice(tx, receipt).Uint64())
+	var blobGasPrice uint
+	if receipt.BlobGasPrice != nil {
+		blobGasPrice = uint(receipt.BlobGasPrice.Uint64())
 	}
 
-	rec := &models.Receipt{
+	ts := s.timestamp()
+	return &models.Receipt{
 		TransactionHash:   tx.Hash().Hex(),
 		TransactionIndex:  uint(index),
-		BlockHash:         block.Hash().Hex(),
-		BlockNumber:       uint(block.NumberU64()),
-		From:              getTxSender(tx).Hex(),
+		BlockHash:         s.hash().Hex(),
+		BlockNumber:       uint(s.Header.Number.Uint64()),
+		From:              from.Hex(),
 		To:                addressToOptionalString(tx.To()),
-		ContractAddress:   addressToOptionalString(&receipt.ContractAddress),
+		ContractAddress:   addressToOptionalString(contractAddress),
 		CumulativeGasUsed: uint(receipt.CumulativeGasUsed),
 		GasUsed:           uint(receipt.GasUsed),
-		EffectiveGasPrice: uint(receipt.EffectiveGasPrice.Uint64()),
+		EffectiveGasPrice: effectiveGasPrice,
+		BlobGasUsed:       uint(receipt.BlobGasUsed),
+		BlobGasPrice:      blobGasPrice,
 		Status:            uint(receipt.Status),
-		LogsBloom:         "0x" + common.Bytes2Hex(receipt.Bloom.Bytes()),
+		LogsBloom:         hexutil.Encode(receipt.Bloom.Bytes()),
 		BlockTimestamp:    ts,
+		Logs:              NewLogs(receipt.Logs, ts),
 	}
-
-	// Логи
-	rec.Logs = NewLogs(receipt.Logs, ts)
-	return rec
 }
 
 // NewLogs преобразует []*types.Log → []models.Log
@@ -148,6 +315,12 @@ func NewLogs(logs []*types.Log, ts time.Time) []models.Log {
 	}
 	result := make([]models.Log, len(logs))
 	for i, l := range logs {
+		topics := topicsToStrings(l.Topics)
+		var topic0 string
+		if len(topics) > 0 {
+			topic0 = topics[0]
+		}
+
 		result[i] = models.Log{
 			BlockNumber:      uint(l.BlockNumber),
 			BlockHash:        l.BlockHash.Hex(),
@@ -155,10 +328,10 @@ func NewLogs(logs []*types.Log, ts time.Time) []models.Log {
 			TransactionIndex: uint(l.TxIndex),
 			LogIndex:         uint(l.Index),
 			Address:          l.Address.Hex(),
-			Data:             "0x" + common.Bytes2Hex(l.Data),
-			Topics:           topicsToStrings(l.Topics),
+			Data:             hexutil.Encode(l.Data),
+			Topics:           topics,
 			BlockTimestamp:   ts,
-			Topic0:           firstTopic(l.Topics),
+			Topic0:           topic0,
 		}
 	}
 	return result
@@ -180,21 +353,16 @@ func topicsToStrings(topics []common.Hash) []string {
 	return res
 }
 
-func getTxSender(tx *types.Transaction) common.Address {
-	var from common.Address
+// txSender восстанавливает отправителя по подписи
+func txSender(tx *types.Transaction) (common.Address, error) {
+	var signer types.Signer = types.HomesteadSigner{}
 	if chainID := tx.ChainId(); chainID != nil && chainID.Sign() > 0 {
-		signer := types.LatestSignerForChainID(chainID)
-		if sender, err := types.Sender(signer, tx); err == nil {
-			from = sender
-		} else {
-			log.Printf("Failed to get sender for tx %s: %v", tx.Hash().Hex(), err)
-		}
-	} else {
-		if sender, err := types.Sender(types.HomesteadSigner{}, tx); err == nil {
-			from = sender
-		} else {
-			log.Printf("Failed to get sender for legacy tx %s: %v", tx.Hash().Hex(), err)
-		}
+		signer = types.LatestSignerForChainID(chainID)
+	}
+
+	from, err := types.Sender(signer, tx)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("%w: sender of tx %s: %v", ErrConvert, tx.Hash().Hex(), err)
 	}
-	return from
+	return from, nil
 }
@@ -1,234 +1,78 @@
 package metrics
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"lib/models"
-	"math/big"
-	"strconv"
-	"strings"
-	"time"
-)
 
-// NewBlockFromJSON парсит сырые JSON блока и квитанций в models.Block
-func NewBlockFromJSON(blockRaw json.RawMessage, receiptsRaw json.RawMessage) models.Block {
-	var rb map[string]interface{}
-	if err := json.Unmarshal(blockRaw, &rb); err != nil {
-		fmt.Printf("failed to unmarshal block JSON: %v\n", err)
-		return models.Block{}
-	}
-
-	var rcs []map[string]interface{}
-	if err := json.Unmarshal(receiptsRaw, &rcs); err != nil {
-		fmt.Printf("failed to unmarshal receipts JSON: %v\n", err)
-		rcs = []map[string]interface{}{}
-	}
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
 
-	blk := models.Block{
-		Hash:             parseString(rb["hash"]),
-		Number:           parseUint(rb["number"]),
-		ParentHash:       parseString(rb["parentHash"]),
-		Nonce:            parseUint(rb["nonce"]),
-		Sha3Uncles:       parseString(rb["sha3Uncles"]),
-		LogsBloom:        parseString(rb["logsBloom"]),
-		TransactionsRoot: parseString(rb["transactionsRoot"]),
-		StateRoot:        parseString(rb["stateRoot"]),
-		ReceiptsRoot:     parseString(rb["receiptsRoot"]),
-		Miner:            parseString(rb["miner"]),
-		Difficulty:       parseString(rb["difficulty"]),
-		TotalDifficulty:  parseString(rb["totalDifficulty"]),
-		Size:             parseUint(rb["size"]),
-		ExtraData:        parseString(rb["extraData"]),
-		GasLimit:         parseUint(rb["gasLimit"]),
-		GasUsed:          parseUint(rb["gasUsed"]),
-		BaseFeePerGas:    parseOptionalUint(rb["baseFeePerGas"]),
-		Timestamp:        parseTime(rb["timestamp"]),
-		MixHash:          parseString(rb["mixHash"]),
+// SourceFromJSON разбирает сырые ответы eth_getBlockByNumber (с полными
+// транзакциями) и eth_getBlockReceipts. Пустой receiptsRaw — квитанций нет.
+func SourceFromJSON(blockRaw json.RawMessage, receiptsRaw json.RawMessage) (Source, error) {
+	var header types.Header
+	if err := json.Unmarshal(blockRaw, &header); err != nil {
+		return Source{}, fmt.Errorf("%w: block header: %v", ErrConvert, err)
 	}
 
-	// Uncles
-	if uncles, ok := rb["uncles"].([]interface{}); ok {
-		for _, u := range uncles {
-			blk.Uncles = append(blk.Uncles, parseString(u))
-		}
+	var body struct {
+		Hash         common.Hash          `json:"hash"`
+		Size         hexutil.Uint64       `json:"size"`
+		Transactions []*types.Transaction `json:"transactions"`
+		Uncles       []common.Hash        `json:"uncles"`
 	}
-
-	// Transactions
-	if txs, ok := rb["transactions"].([]interface{}); ok {
-		for i, txRaw := range txs {
-			txMap, ok := txRaw.(map[string]interface{})
-			if !ok {
-				continue
-			}
-
-			tx := models.Tx{
-				Hash:                 parseString(txMap["hash"]),
-				BlockHash:            parseString(txMap["blockHash"]),
-				BlockNumber:          parseUint(txMap["blockNumber"]),
-				TransactionIndex:     parseUint(txMap["transactionIndex"]),
-				From:                 parseString(txMap["from"]),
-				To:                   parseOptionalString(txMap["to"]),
-				Value:                parseString(txMap["value"]),
-				Gas:                  parseUint(txMap["gas"]),
-				GasPrice:             parseUint(txMap["gasPrice"]),
-				Input:                parseString(txMap["input"]),
-				Nonce:                parseUint(txMap["nonce"]),
-				Type:                 parseUint(txMap["type"]),
-				MaxFeePerGas:         parseOptionalUint(txMap["maxFeePerGas"]),
-				MaxPriorityFeePerGas: parseOptionalUint(txMap["maxPriorityFeePerGas"]),
-				ChainID:              parseUint(txMap["chainId"]),
-				V:                    parseString(txMap["v"]),
-				R:                    parseString(txMap["r"]),
-				S:                    parseString(txMap["s"]),
-				AccessList:           parseString(txMap["accessList"]),
-				BlockTimestamp:       blk.Timestamp,
-			}
-
-			// Привязываем квитанцию
-			if i < len(rcs) {
-				tx.Receipt = convertReceiptJSON(rcs[i], blk.Timestamp)
-			}
-
-			blk.Transactions = append(blk.Transactions, tx.Hash)
-		}
+	if err := json.Unmarshal(blockRaw, &body); err != nil {
+		return Source{}, fmt.Errorf("%w: block body of %d: %v", ErrConvert, header.Number.Uint64(), err)
 	}
 
-	return blk
-}
-
-// convertReceiptJSON преобразует JSON-квитанцию в models.Receipt
-func convertReceiptJSON(r map[string]interface{}, ts time.Time) *models.Receipt {
-	rec := &models.Receipt{
-		TransactionHash:   parseString(r["transactionHash"]),
-		TransactionIndex:  parseUint(r["transactionIndex"]),
-		BlockHash:         parseString(r["blockHash"]),
-		BlockNumber:       parseUint(r["blockNumber"]),
-		From:              parseString(r["from"]),
-		To:                parseOptionalString(r["to"]),
-		ContractAddress:   parseOptionalString(r["contractAddress"]),
-		CumulativeGasUsed: parseUint(r["cumulativeGasUsed"]),
-		GasUsed:           parseUint(r["gasUsed"]),
-		EffectiveGasPrice: parseUint(r["effectiveGasPrice"]),
-		Status:            parseUint(r["status"]),
-		LogsBloom:         parseString(r["logsBloom"]),
-		BlockTimestamp:    ts,
+	src := Source{
+		Header:       &header,
+		Hash:         body.Hash,
+		Transactions: body.Transactions,
+		Uncles:       body.Uncles,
+		Size:         uint64(body.Size),
 	}
 
-	// Логи
-	if logs, ok := r["logs"].([]interface{}); ok {
-		for _, l := range logs {
-			lMap, ok := l.(map[string]interface{})
-			if !ok {
-				continue
-			}
-			rec.Logs = append(rec.Logs, models.Log{
-				BlockNumber:      parseUint(lMap["blockNumber"]),
-				BlockHash:        parseString(lMap["blockHash"]),
-				TransactionHash:  parseString(lMap["transactionHash"]),
-				TransactionIndex: parseUint(lMap["transactionIndex"]),
-				LogIndex:         parseUint(lMap["logIndex"]),
-				Address:          parseString(lMap["address"]),
-				Data:             parseString(lMap["data"]),
-				Topics:           parseStringSlice(lMap["topics"]),
-				BlockTimestamp:   ts,
-				Topic0:           firstTopic(lMap["topics"]),
-			})
+	// null — квитанции не запрашивались
+	if raw := bytes.TrimSpace(receiptsRaw); len(raw) > 0 && !bytes.Equal(raw, []byte("null")) {
+		if err := json.Unmarshal(raw, &src.Receipts); err != nil {
+			return Source{}, fmt.Errorf("%w: receipts of block %d: %v", ErrConvert, header.Number.Uint64(), err)
 		}
 	}
-
-	return rec
-}
-
-func parseString(v interface{}) string {
-	if v == nil {
-		return ""
-	}
-	return fmt.Sprintf("%v", v)
+	return src, nil
 }
 
-func parseOptionalString(v interface{}) *string {
-	if v == nil {
-		return nil
-	}
-	s := parseString(v)
-	if s == "" {
-		return nil
-	}
-	return &s
-}
-
-func parseUint(v interface{}) uint {
-	if v == nil {
-		return 0
-	}
-	switch val := v.(type) {
-	case float64:
-		return uint(val)
-	case json.Number:
-		n, _ := val.Int64()
-		return uint(n)
-	case string:
-		// поддерживаем 0x-формат
-		s := strings.TrimPrefix(val, "0x")
-		if s == "" {
-			return 0
-		}
-		n := new(big.Int)
-		n.SetString(s, 16)
-		return uint(n.Uint64())
-	default:
-		return 0
+// NewBlockFromJSON парсит сырые JSON блока и квитанций в models.Block
+func NewBlockFromJSON(blockRaw json.RawMessage, receiptsRaw json.RawMessage) (models.Block, error) {
+	src, err := SourceFromJSON(blockRaw, receiptsRaw)
+	if err != nil {
+		return models.Block{}, err
 	}
+	return src.Block()
 }
 
-func parseOptionalUint(v interface{}) *uint {
-	u := parseUint(v)
-	if u == 0 {
-		return nil
+// SetUncleHeaders разбирает ответы eth_getUncleByBlockNumberAndIndex
+// (в порядке индексов) и проверяет, что их хеши совпадают с хешами дядей блока
+func (s *Source) SetUncleHeaders(raws []json.RawMessage) error {
+	if len(raws) != len(s.Uncles) {
+		return fmt.Errorf("%w: %d uncle headers for %d uncles", ErrConvert, len(raws), len(s.Uncles))
 	}
-	return &u
-}
 
-func parseTime(v interface{}) time.Time {
-	switch val := v.(type) {
-	case float64:
-		return time.Unix(int64(val), 0).UTC()
-	case string:
-		// timestamp может быть в hex, decimal или RFC3339
-		if strings.HasPrefix(val, "0x") {
-			n := new(big.Int)
-			n.SetString(strings.TrimPrefix(val, "0x"), 16)
-			return time.Unix(n.Int64(), 0).UTC()
-		}
-		if t, err := strconv.ParseInt(val, 10, 64); err == nil {
-			return time.Unix(t, 0).UTC()
+	headers := make([]*types.Header, len(raws))
+	for i, raw := range raws {
+		var header types.Header
+		if err := json.Unmarshal(raw, &header); err != nil {
+			return fmt.Errorf("%w: uncle %d: %v", ErrConvert, i, err)
 		}
-		if t, err := time.Parse(time.RFC3339, val); err == nil {
-			return t.UTC()
+		if hash := header.Hash(); hash != s.Uncles[i] {
+			return fmt.Errorf("%w: uncle %d hashes to %s, block lists %s", ErrConvert, i, hash.Hex(), s.Uncles[i].Hex())
 		}
+		headers[i] = &header
 	}
-	return time.Time{}
-}
-
-func parseStringSlice(v interface{}) []string {
-	if v == nil {
-		return nil
-	}
-	arr, ok := v.([]interface{})
-	if !ok {
-		return nil
-	}
-	out := make([]string, 0, len(arr))
-	for _, a := range arr {
-		out = append(out, parseString(a))
-	}
-	return out
-}
-
-func firstTopic(v interface{}) string {
-	topics := parseStringSlice(v)
-	if len(topics) > 0 {
-		return topics[0]
-	}
-	return ""
+	s.UncleHeaders = headers
+	return nil
 }
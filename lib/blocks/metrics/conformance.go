@@ -0,0 +1,146 @@
+package metrics
+
+import (
+	"encoding/json"
+	"fmt"
+	"lib/models"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// Converted — модели, построенные из одного Source
+type Converted struct {
+	Block models.Block `json:"block"`
+	Txs   []models.Tx  `json:"txs"`
+}
+
+// Convert строит модели блока и его транзакций
+func (s Source) Convert() (Converted, error) {
+	block, err := s.Block()
+	if err != nil {
+		return Converted{}, err
+	}
+	txs, err := s.Txs()
+	if err != nil {
+		return Converted{}, err
+	}
+	return Converted{Block: block, Txs: txs}, nil
+}
+
+// Fixture — golden-файл одного блока: сырые ответы провайдера (включая
+// заголовки дядей), блок в RLP (так его видит ethclient) и ожидаемые модели
+type Fixture struct {
+	Number   uint64            `json:"number"`
+	Block    json.RawMessage   `json:"block"`
+	Receipts json.RawMessage   `json:"receipts"`
+	Uncles   []json.RawMessage `json:"uncles,omitempty"`
+	BlockRLP hexutil.Bytes     `json:"block_rlp"`
+	Expected *Converted        `json:"expected,omitempty"`
+}
+
+// Source собирает Source по сырым ответам, как это делает батч коллектора
+func (fx Fixture) Source() (Source, error) {
+	src, err := SourceFromJSON(fx.Block, fx.Receipts)
+	if err != nil {
+		return Source{}, err
+	}
+	if len(src.Uncles) > 0 {
+		if err := src.SetUncleHeaders(fx.Uncles); err != nil {
+			return Source{}, err
+		}
+	}
+	return src, nil
+}
+
+// Conform прогоняет блок через оба пути преобразования: go-ethereum типы
+// (блок из RLP, квитанции декодируются так же, как в ethclient) и сырой JSON.
+// Возвращает модели пути JSON и расхождения между путями.
+func (fx Fixture) Conform() (Converted, []string, error) {
+	var block types.Block
+	if err := rlp.DecodeBytes(fx.BlockRLP, &block); err != nil {
+		return Converted{}, nil, fmt.Errorf("decode rlp: %w", err)
+	}
+	var receipts []*types.Receipt
+	if err := json.Unmarshal(fx.Receipts, &receipts); err != nil {
+		return Converted{}, nil, fmt.Errorf("decode receipts: %w", err)
+	}
+	viaTypes, err := SourceFromBlock(&block, receipts).Convert()
+	if err != nil {
+		return Converted{}, nil, fmt.Errorf("go-ethereum path: %w", err)
+	}
+
+	src, err := fx.Source()
+	if err != nil {
+		return Converted{}, nil, fmt.Errorf("json path: %w", err)
+	}
+	viaJSON, err := src.Convert()
+	if err != nil {
+		return Converted{}, nil, fmt.Errorf("json path: %w", err)
+	}
+	return viaJSON, Diff(viaTypes, viaJSON), nil
+}
+
+// Diff сравнивает модели поле за полем и возвращает расхождения в виде
+// "путь: want != got"; пустой результат — модели совпадают
+func Diff(want, got Converted) []string {
+	var diffs []string
+	diffValues("", reflect.ValueOf(want), reflect.ValueOf(got), &diffs)
+	return diffs
+}
+
+func diffValues(path string, want, got reflect.Value, diffs *[]string) {
+	// Время сравнивается как момент, без учёта часового пояса после JSON
+	if w, ok := want.Interface().(time.Time); ok {
+		if g := got.Interface().(time.Time); !w.Equal(g) {
+			*diffs = append(*diffs, fmt.Sprintf("%s: %v != %v", path, w, g))
+		}
+		return
+	}
+
+	switch want.Kind() {
+	case reflect.Struct:
+		for i := range want.NumField() {
+			name := want.Type().Field(i).Name
+			diffValues(joinPath(path, name), want.Field(i), got.Field(i), diffs)
+		}
+	case reflect.Slice:
+		if want.Len() != got.Len() {
+			*diffs = append(*diffs, fmt.Sprintf("%s: len %d != %d", path, want.Len(), got.Len()))
+			return
+		}
+		for i := range want.Len() {
+			diffValues(fmt.Sprintf("%s[%d]", path, i), want.Index(i), got.Index(i), diffs)
+		}
+	case reflect.Pointer:
+		switch {
+		case want.IsNil() && got.IsNil():
+		case want.IsNil() || got.IsNil():
+			*diffs = append(*diffs, fmt.Sprintf("%s: %s != %s", path, describe(want), describe(got)))
+		default:
+			diffValues(path, want.Elem(), got.Elem(), diffs)
+		}
+	default:
+		if !reflect.DeepEqual(want.Interface(), got.Interface()) {
+			*diffs = append(*diffs, fmt.Sprintf("%s: %v != %v", path, want.Interface(), got.Interface()))
+		}
+	}
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return strings.Join([]string{path, name}, ".")
+}
+
+func describe(v reflect.Value) string {
+	if v.IsNil() {
+		return "nil"
+	}
+	return fmt.Sprintf("%v", v.Elem().Interface())
+}
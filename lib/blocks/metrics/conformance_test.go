@@ -0,0 +1,135 @@
+package metrics
+
+import (
+	"encoding/json"
+	"lib/models"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// TestGoldenBlocks прогоняет golden-файлы testdata через оба пути
+// преобразования и сравнивает результат с записанными моделями.
+// Набор (legacy, access_list, dynamic_fee, blob, set_code, uncle) записан
+// с RPC встроенного узла geth (chain id 1337). Ожидаемые модели построены
+// из полей ответов узла, а не конвертером, поэтому check -update для них
+// не используется: расхождение с ними — ошибка конвертера.
+func TestGoldenBlocks(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatal("no golden files in testdata")
+	}
+
+	txTypes := make(map[uint]bool)
+	uncles := 0
+	for _, path := range files {
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var fx Fixture
+			if err := json.Unmarshal(data, &fx); err != nil {
+				t.Fatal(err)
+			}
+			if fx.Expected == nil {
+				t.Fatal("no expected models, run conformance check -update")
+			}
+
+			got, diffs, err := fx.Conform()
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, d := range diffs {
+				t.Errorf("types vs json: %s", d)
+			}
+			for _, d := range Diff(*fx.Expected, got) {
+				t.Errorf("golden vs json: %s", d)
+			}
+
+			for _, tx := range got.Txs {
+				txTypes[tx.Type] = true
+			}
+			uncles += len(got.Block.UncleHeaders)
+		})
+	}
+
+	// Набор должен покрывать все типы транзакций и блок с дядей
+	for _, txType := range []uint{types.LegacyTxType, types.AccessListTxType, types.DynamicFeeTxType, types.BlobTxType, types.SetCodeTxType} {
+		if !txTypes[txType] {
+			t.Errorf("no golden block with transaction type %d", txType)
+		}
+	}
+	if uncles == 0 {
+		t.Error("no golden block with uncles")
+	}
+}
+
+// TestGasPriceIsEffectivePrice сверяет gas_price с ценой, которую вернул узел:
+// у транзакций EIP-1559 это base fee + tip, а не max fee
+func TestGasPriceIsEffectivePrice(t *testing.T) {
+	tests := []struct {
+		fixture  string
+		tx       int
+		txType   uint
+		gasPrice uint
+		maxFee   uint
+	}{
+		// base fee 1 gwei, tip 0.5 gwei, max fee 2 gwei
+		{"dynamic_fee", 1, types.DynamicFeeTxType, 1_500_000_000, 2_000_000_000},
+		// base fee 0.875 gwei, tip 1 gwei, max fee 50 gwei
+		{"set_code", 0, types.DynamicFeeTxType, 1_875_000_000, 50_000_000_000},
+		// base fee 0.875 gwei, tip 2 gwei, max fee 30 gwei
+		{"set_code", 1, types.SetCodeTxType, 2_875_000_000, 30_000_000_000},
+	}
+	for _, tt := range tests {
+		data, err := os.ReadFile(filepath.Join("testdata", tt.fixture+".json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var fx Fixture
+		if err := json.Unmarshal(data, &fx); err != nil {
+			t.Fatal(err)
+		}
+		src, err := fx.Source()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		withReceipts, err := src.Txs()
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Старый узел не отдаёт effectiveGasPrice в квитанции
+		for _, r := range src.Receipts {
+			r.EffectiveGasPrice = nil
+		}
+		oldNode, err := src.Txs()
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Без квитанций цена считается по base fee блока
+		src.Receipts = nil
+		withoutReceipts, err := src.Txs()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, tx := range []models.Tx{withReceipts[tt.tx], withoutReceipts[tt.tx]} {
+			if tx.Type != tt.txType || tx.GasPrice != tt.gasPrice || tx.MaxFeePerGas == nil || *tx.MaxFeePerGas != tt.maxFee {
+				t.Errorf("%s tx %d: type %d, gas price %d, max fee %v; want type %d, gas price %d, max fee %d",
+					tt.fixture, tt.tx, tx.Type, tx.GasPrice, tx.MaxFeePerGas, tt.txType, tt.gasPrice, tt.maxFee)
+			}
+		}
+		for _, tx := range []models.Tx{withReceipts[tt.tx], oldNode[tt.tx]} {
+			if got := tx.Receipt.EffectiveGasPrice; got != tt.gasPrice {
+				t.Errorf("%s tx %d: effective gas price %d, want %d", tt.fixture, tt.tx, got, tt.gasPrice)
+			}
+		}
+	}
+}
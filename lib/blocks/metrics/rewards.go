@@ -0,0 +1,138 @@
+package metrics
+
+import (
+	"fmt"
+	"lib/models"
+	"math/big"
+)
+
+// Номера блоков mainnet, на которых менялась статическая награда за блок
+const (
+	byzantiumBlock      = 4_370_000 // EIP-649: 5 → 3 ETH
+	constantinopleBlock = 7_280_000 // EIP-1234: 3 → 2 ETH
+)
+
+var (
+	frontierBlockReward       = big.NewInt(5e18)
+	byzantiumBlockReward      = big.NewInt(3e18)
+	constantinopleBlockReward = big.NewInt(2e18)
+)
+
+// BlockReward возвращает статическую награду за блок number со сложностью
+// difficulty в wei. Блок PoS (difficulty 0) награды не получает — так The Merge
+// распознаётся в любой сети, а не по номеру блока mainnet. Блок PoW получает
+// награду по расписанию mainnet.
+func BlockReward(number uint64, difficulty *big.Int) *big.Int {
+	if difficulty == nil || difficulty.Sign() == 0 {
+		return new(big.Int)
+	}
+	return powBlockReward(number)
+}
+
+// powBlockReward — статическая награда за блок PoW по расписанию mainnet
+func powBlockReward(number uint64) *big.Int {
+	switch {
+	case number >= constantinopleBlock:
+		return new(big.Int).Set(constantinopleBlockReward)
+	case number >= byzantiumBlock:
+		return new(big.Int).Set(byzantiumBlockReward)
+	default:
+		return new(big.Int).Set(frontierBlockReward)
+	}
+}
+
+// UncleInclusionReward возвращает награду майнеру блока blockNumber
+// за включение одного дяди: BlockReward / 32. Дяди бывают только в PoW.
+func UncleInclusionReward(blockNumber uint64) *big.Int {
+	reward := powBlockReward(blockNumber)
+	return reward.Div(reward, big.NewInt(32))
+}
+
+// UncleReward возвращает награду майнеру дяди uncleNumber, включённого в блок
+// blockNumber: (uncleNumber + 8 - blockNumber) * BlockReward / 8
+func UncleReward(uncleNumber, blockNumber uint64) *big.Int {
+	if uncleNumber+8 <= blockNumber {
+		return new(big.Int)
+	}
+	reward := powBlockReward(blockNumber)
+	reward.Mul(reward, new(big.Int).SetUint64(uncleNumber+8-blockNumber))
+	return reward.Div(reward, big.NewInt(8))
+}
+
+// BlockRewards считает экономику блока по модели блока и транзакциям с квитанциями:
+//   - сожжённая базовая комиссия: BaseFeePerGas * GasUsed;
+//   - чаевые получателю комиссий: сумма (EffectiveGasPrice - BaseFeePerGas) * GasUsed
+//     по квитанциям (до London вся цена газа идёт майнеру);
+//   - статическая награда и награды за дядей, если блок PoW (difficulty > 0);
+//   - сожжённые комиссии за blob-газ: сумма BlobGasUsed * BlobGasPrice.
+//
+// У блока с дядями должны быть заполнены UncleHeaders: награда майнера дяди
+// зависит от номера дяди.
+func BlockRewards(block models.Block, txs []models.Tx) (models.BlockReward, error) {
+	number := uint64(block.Number)
+	if len(block.UncleHeaders) != len(block.Uncles) {
+		return models.BlockReward{}, fmt.Errorf("%w: block %d: rewards need %d uncle headers, got %d",
+			ErrConvert, number, len(block.Uncles), len(block.UncleHeaders))
+	}
+
+	baseFee := new(big.Int)
+	if block.BaseFeePerGas != nil {
+		baseFee.SetUint64(uint64(*block.BaseFeePerGas))
+	}
+
+	priorityFees, blobFees := new(big.Int), new(big.Int)
+	for _, tx := range txs {
+		r := tx.Receipt
+		if r == nil {
+			return models.BlockReward{}, fmt.Errorf("%w: block %d: tx %s has no receipt", ErrConvert, number, tx.Hash)
+		}
+
+		// EffectiveGasPrice уже заполнен конвертером, в том числе для старых
+		// узлов, которые не отдают его в квитанциях
+		tip := new(big.Int).SetUint64(uint64(r.EffectiveGasPrice))
+		tip.Sub(tip, baseFee)
+		if tip.Sign() < 0 {
+			return models.BlockReward{}, fmt.Errorf("%w: block %d: tx %s pays below base fee", ErrConvert, number, tx.Hash)
+		}
+		priorityFees.Add(priorityFees, tip.Mul(tip, new(big.Int).SetUint64(uint64(r.GasUsed))))
+
+		blobFee := new(big.Int).SetUint64(uint64(r.BlobGasUsed))
+		blobFees.Add(blobFees, blobFee.Mul(blobFee, new(big.Int).SetUint64(uint64(r.BlobGasPrice))))
+	}
+
+	unclesReward := new(big.Int)
+	for _, u := range block.UncleHeaders {
+		reward, ok := new(big.Int).SetString(u.Reward, 10)
+		if !ok {
+			return models.BlockReward{}, fmt.Errorf("%w: block %d: invalid reward %q of uncle %s",
+				ErrConvert, number, u.Reward, u.Hash)
+		}
+		unclesReward.Add(unclesReward, reward)
+	}
+
+	difficulty, ok := new(big.Int).SetString(block.Difficulty, 10)
+	if !ok {
+		return models.BlockReward{}, fmt.Errorf("%w: block %d: invalid difficulty %q", ErrConvert, number, block.Difficulty)
+	}
+	static := BlockReward(number, difficulty)
+	inclusion := UncleInclusionReward(number)
+	inclusion.Mul(inclusion, big.NewInt(int64(len(block.Uncles))))
+	burned := baseFee.Mul(baseFee, new(big.Int).SetUint64(uint64(block.GasUsed)))
+
+	miner := new(big.Int).Add(static, inclusion)
+	miner.Add(miner, priorityFees)
+
+	return models.BlockReward{
+		BlockNumber:          block.Number,
+		BlockHash:            block.Hash,
+		Miner:                block.Miner,
+		StaticReward:         static.String(),
+		UncleInclusionReward: inclusion.String(),
+		UnclesReward:         unclesReward.String(),
+		PriorityFees:         priorityFees.String(),
+		BaseFeeBurned:        burned.String(),
+		BlobFeesBurned:       blobFees.String(),
+		MinerReward:          miner.String(),
+		BlockTimestamp:       block.Timestamp,
+	}, nil
+}
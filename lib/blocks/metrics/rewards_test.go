@@ -0,0 +1,50 @@
+package metrics
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestBlockReward(t *testing.T) {
+	pow := big.NewInt(131072)
+	tests := []struct {
+		name       string
+		number     uint64
+		difficulty *big.Int
+		want       string
+	}{
+		{"frontier", 1, pow, "5000000000000000000"},
+		{"byzantium", 4_370_000, pow, "3000000000000000000"},
+		{"constantinople", 7_280_000, pow, "2000000000000000000"},
+		// PoW-сеть после номера The Merge в mainnet продолжает платить награду
+		{"pow after mainnet merge number", 20_000_000, pow, "2000000000000000000"},
+		// PoS-блок не получает награды при любом номере
+		{"pos dev chain", 1, new(big.Int), "0"},
+		{"pos mainnet", 15_537_394, new(big.Int), "0"},
+		{"unknown difficulty", 1, nil, "0"},
+	}
+	for _, tt := range tests {
+		if got := BlockReward(tt.number, tt.difficulty); got.String() != tt.want {
+			t.Errorf("%s: BlockReward(%d, %v) = %s, want %s", tt.name, tt.number, tt.difficulty, got, tt.want)
+		}
+	}
+}
+
+func TestUncleReward(t *testing.T) {
+	tests := []struct {
+		uncle, block uint64
+		want         string
+	}{
+		{99, 100, "4375000000000000000"}, // 7/8 от 5 ETH
+		{93, 100, "625000000000000000"},  // 1/8
+		{92, 100, "0"},                   // слишком старый дядя
+	}
+	for _, tt := range tests {
+		if got := UncleReward(tt.uncle, tt.block); got.String() != tt.want {
+			t.Errorf("UncleReward(%d, %d) = %s, want %s", tt.uncle, tt.block, got, tt.want)
+		}
+	}
+	if got := UncleInclusionReward(100); got.String() != "156250000000000000" {
+		t.Errorf("UncleInclusionReward(100) = %s, want 5 ETH / 32", got)
+	}
+}
@@ -0,0 +1,173 @@
+package broker
+
+import (
+	"context"
+	"fmt"
+	"lib/models"
+	"lib/utils/logging"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DLQSuffix добавляется к имени топика для получения dead-letter топика
+const DLQSuffix = ".dlq"
+
+// defaultDLQAttempts — число попыток обработки, если в политике оно не ограничено
+const defaultDLQAttempts = 5
+
+// Заголовки, которыми снабжается сообщение при отправке в dead-letter топик
+const (
+	HeaderDLQError             = "dlq-error"
+	HeaderDLQAttempts          = "dlq-attempts"
+	HeaderDLQOriginalTopic     = "dlq-original-topic"
+	HeaderDLQOriginalPartition = "dlq-original-partition"
+	HeaderDLQOriginalOffset    = "dlq-original-offset"
+	HeaderDLQFailedAt          = "dlq-failed-at"
+	HeaderDLQReplayedAt        = "dlq-replayed-at"
+)
+
+// MaxDeliveries возвращает, сколько раз брокер с повторной доставкой (Redis Streams,
+// NATS) доставляет сообщение, прежде чем отдать его в DLQ; 0 — без ограничения.
+// С DLQ ограничение обязательно, иначе сообщение никогда туда не попадёт.
+func MaxDeliveries(cfg models.Broker) int {
+	if cfg.MaxDeliveries > 0 {
+		return cfg.MaxDeliveries
+	}
+	if cfg.DeadLetter {
+		return defaultDLQAttempts
+	}
+	return 0
+}
+
+// ManagesDeadLetters сообщает, повторяет ли клиент брокера brokerType обработку
+// и отправляет ли сообщения в DLQ сам (dead_letter); обработчики для остальных
+// клиентов оборачиваются в DeadLetterQueue.Wrap
+func ManagesDeadLetters(brokerType string) bool {
+	return brokerType == "kafka" || brokerType == "redis" || brokerType == "nats"
+}
+
+// DLQTopic возвращает имя dead-letter топика для topic
+func DLQTopic(topic string) string {
+	return topic + DLQSuffix
+}
+
+// OriginalTopic возвращает топик, из которого сообщение попало в DLQ
+func OriginalTopic(msg models.MessageBroker) string {
+	if topic := msg.Headers[HeaderDLQOriginalTopic]; topic != "" {
+		return topic
+	}
+	return strings.TrimSuffix(msg.Topic, DLQSuffix)
+}
+
+// DeadLetter строит сообщение для dead-letter топика: исходные ключ, тело
+// и заголовки плюс причина ошибки, число попыток и исходная позиция
+func DeadLetter(msg models.MessageBroker, cause error, attempts int) models.MessageBroker {
+	headers := make(map[string]string, len(msg.Headers)+6)
+	for k, v := range msg.Headers {
+		headers[k] = v
+	}
+	headers[HeaderDLQError] = cause.Error()
+	headers[HeaderDLQAttempts] = strconv.Itoa(attempts)
+	headers[HeaderDLQOriginalTopic] = msg.Topic
+	headers[HeaderDLQOriginalPartition] = strconv.Itoa(msg.Partition)
+	headers[HeaderDLQOriginalOffset] = strconv.FormatInt(msg.Offset, 10)
+	headers[HeaderDLQFailedAt] = time.Now().UTC().Format(time.RFC3339Nano)
+
+	return models.MessageBroker{
+		Key:     msg.Key,
+		Value:   msg.Value,
+		Headers: headers,
+		Topic:   DLQTopic(msg.Topic),
+	}
+}
+
+// Revive восстанавливает исходное сообщение из dead-letter сообщения
+// для повторной отправки в основной топик
+func Revive(msg models.MessageBroker) models.MessageBroker {
+	headers := make(map[string]string, len(msg.Headers))
+	for k, v := range msg.Headers {
+		if strings.HasPrefix(k, "dlq-") {
+			continue
+		}
+		headers[k] = v
+	}
+	headers[HeaderDLQReplayedAt] = time.Now().UTC().Format(time.RFC3339Nano)
+
+	return models.MessageBroker{
+		Key:     msg.Key,
+		Value:   msg.Value,
+		Headers: headers,
+		Topic:   OriginalTopic(msg),
+	}
+}
+
+// DeadLetterQueue обрабатывает сообщения с повторами и отправляет в
+// dead-letter топик те, что так и не удалось обработать
+type DeadLetterQueue struct {
+	producer BrokerClient
+	retry    RetryPolicy
+	logger   *logging.Logger
+}
+
+// NewDeadLetterQueue создаёт DLQ; producer используется для отправки в <topic>.dlq
+func NewDeadLetterQueue(producer BrokerClient, retry RetryPolicy, logger *logging.Logger) *DeadLetterQueue {
+	// Без ограничения попыток сообщение никогда не попадёт в DLQ
+	if retry.MaxAttempts <= 0 {
+		retry.MaxAttempts = defaultDLQAttempts
+	}
+	return &DeadLetterQueue{
+		producer: producer,
+		retry:    retry,
+		logger:   logger,
+	}
+}
+
+// Handle вызывает handler с повторами; если обработка так и не удалась,
+// сообщение отправляется в DLQ. Ошибка возвращается, только если сообщение
+// не обработано и не попало в DLQ — в этом случае его нельзя подтверждать.
+func (d *DeadLetterQueue) Handle(ctx context.Context, msg models.MessageBroker, handler models.MessageHandlerBroker) error {
+	attempts, err := d.retry.Run(ctx, func(attempt int) error {
+		return handler(ctx, msg)
+	})
+	if err == nil {
+		return nil
+	}
+	if ctx.Err() != nil {
+		return err
+	}
+
+	return d.Send(ctx, msg, err, attempts)
+}
+
+// Send отправляет сообщение, которое не удалось обработать за attempts попыток,
+// в DLQ. Отправка повторяется, пока не удастся или не будет отменён ctx.
+func (d *DeadLetterQueue) Send(ctx context.Context, msg models.MessageBroker, cause error, attempts int) error {
+	d.logger.Warnf("Message %s/%d@%d failed after %d attempts, moving to %s: %v",
+		msg.Topic, msg.Partition, msg.Offset, attempts, DLQTopic(msg.Topic), cause)
+
+	dead := DeadLetter(msg, cause, attempts)
+
+	// Отправку в DLQ повторяем без ограничения: иначе сообщение будет потеряно
+	sendRetry := d.retry
+	sendRetry.MaxAttempts = 0
+	_, sendErr := sendRetry.Run(ctx, func(attempt int) error {
+		if err := d.producer.SendMessage(ctx, dead); err != nil {
+			d.logger.Errorf("Failed to send message to %s (attempt %d): %v", dead.Topic, attempt, err)
+			return err
+		}
+		return nil
+	})
+	if sendErr != nil {
+		return fmt.Errorf("message neither processed nor dead-lettered: %w", sendErr)
+	}
+	return nil
+}
+
+// Wrap возвращает обработчик для любого BrokerClient: ошибка из него
+// возвращается только если сообщение не удалось отправить в DLQ
+func (d *DeadLetterQueue) Wrap(handler models.MessageHandlerBroker) models.MessageHandlerBroker {
+	return func(ctx context.Context, msg models.MessageBroker) error {
+		return d.Handle(ctx, msg, handler)
+	}
+}
@@ -0,0 +1,164 @@
+package broker
+
+import (
+	"context"
+	"lib/models"
+	"lib/utils/metrics"
+	"lib/utils/tracing"
+	"maps"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentedClient пишет метрики и спаны отправки и обработки сообщений.
+// При отправке контекст трассировки добавляется в заголовки сообщения,
+// при обработке — восстанавливается из них, так что спан консьюмера
+// продолжает трассу продюсера.
+type instrumentedClient struct {
+	BrokerClient
+	name string
+}
+
+// instrumentedBatchClient сохраняет поддержку BatchSubscriber у обёрнутого клиента
+type instrumentedBatchClient struct {
+	*instrumentedClient
+	batch BatchSubscriber
+}
+
+// Instrument оборачивает клиент метриками и трассировкой; name — тип брокера
+// для метки broker. Если клиент реализует BatchSubscriber, обёртка тоже его реализует.
+func Instrument(client BrokerClient, name string) BrokerClient {
+	instrumented := &instrumentedClient{BrokerClient: client, name: name}
+	if batch, ok := client.(BatchSubscriber); ok {
+		return &instrumentedBatchClient{instrumentedClient: instrumented, batch: batch}
+	}
+	return instrumented
+}
+
+// Unwrap возвращает обёрнутый клиент
+func (c *instrumentedClient) Unwrap() BrokerClient {
+	return c.BrokerClient
+}
+
+func (c *instrumentedClient) SendMessage(ctx context.Context, msg models.MessageBroker) error {
+	ctx, span := c.startProduce(ctx, msg.Topic, 1)
+	msg.Headers = withTraceHeaders(ctx, msg.Headers)
+
+	start := time.Now()
+	err := c.BrokerClient.SendMessage(ctx, msg)
+
+	metrics.BrokerProduceLatency.WithLabelValues(c.name, msg.Topic).Observe(metrics.Since(start))
+	metrics.BrokerProduced.WithLabelValues(c.name, msg.Topic, metrics.Status(err)).Inc()
+	tracing.End(span, err)
+	return err
+}
+
+func (c *instrumentedClient) SendMessages(ctx context.Context, msgs []models.MessageBroker) error {
+	if len(msgs) == 0 {
+		return c.BrokerClient.SendMessages(ctx, msgs)
+	}
+
+	ctx, span := c.startProduce(ctx, msgs[0].Topic, len(msgs))
+
+	// Копируем слайс, чтобы не менять заголовки в сообщениях вызывающего
+	traced := make([]models.MessageBroker, len(msgs))
+	counts := make(map[string]int)
+	for i, msg := range msgs {
+		msg.Headers = withTraceHeaders(ctx, msg.Headers)
+		traced[i] = msg
+		counts[msg.Topic]++
+	}
+
+	start := time.Now()
+	err := c.BrokerClient.SendMessages(ctx, traced)
+	elapsed := metrics.Since(start)
+
+	status := metrics.Status(err)
+	for topic, n := range counts {
+		metrics.BrokerProduceLatency.WithLabelValues(c.name, topic).Observe(elapsed)
+		metrics.BrokerProduced.WithLabelValues(c.name, topic, status).Add(float64(n))
+	}
+	tracing.End(span, err)
+	return err
+}
+
+func (c *instrumentedClient) Subscribe(ctx context.Context, topic string, handler models.MessageHandlerBroker) error {
+	return c.BrokerClient.Subscribe(ctx, topic, c.wrap(topic, handler))
+}
+
+func (c *instrumentedClient) SubscribeWithGroup(ctx context.Context, topic, groupID string, handler models.MessageHandlerBroker) error {
+	return c.BrokerClient.SubscribeWithGroup(ctx, topic, groupID, c.wrap(topic, handler))
+}
+
+func (c *instrumentedClient) wrap(topic string, handler models.MessageHandlerBroker) models.MessageHandlerBroker {
+	return func(ctx context.Context, msg models.MessageBroker) error {
+		ctx, span := tracing.Start(tracing.Extract(ctx, msg.Headers), "consume "+topic,
+			trace.WithSpanKind(trace.SpanKindConsumer),
+			trace.WithAttributes(c.attributes(topic)...),
+			trace.WithAttributes(
+				attribute.Int("messaging.destination.partition.id", msg.Partition),
+				attribute.Int64("messaging.message.offset", msg.Offset),
+			),
+		)
+
+		start := time.Now()
+		err := handler(ctx, msg)
+
+		metrics.BrokerConsumeLatency.WithLabelValues(c.name, topic).Observe(metrics.Since(start))
+		metrics.BrokerConsumed.WithLabelValues(c.name, topic, metrics.Status(err)).Inc()
+		tracing.End(span, err)
+		return err
+	}
+}
+
+func (c *instrumentedBatchClient) SubscribeBatchWithGroup(ctx context.Context, topic, groupID string, batchSize int, maxWait time.Duration, handler models.BatchMessageHandlerBroker) error {
+	wrapped := func(ctx context.Context, msgs []models.MessageBroker) error {
+		// У пачки нет одного родителя: связываем спан со всеми трассами продюсеров
+		links := make([]trace.Link, 0, len(msgs))
+		for _, msg := range msgs {
+			if sc := trace.SpanContextFromContext(tracing.Extract(ctx, msg.Headers)); sc.IsValid() {
+				links = append(links, trace.Link{SpanContext: sc})
+			}
+		}
+		ctx, span := tracing.Start(ctx, "consume "+topic,
+			trace.WithSpanKind(trace.SpanKindConsumer),
+			trace.WithAttributes(c.attributes(topic)...),
+			trace.WithAttributes(attribute.Int("messaging.batch.message_count", len(msgs))),
+			trace.WithLinks(links...),
+		)
+
+		start := time.Now()
+		err := handler(ctx, msgs)
+
+		metrics.BrokerConsumeLatency.WithLabelValues(c.name, topic).Observe(metrics.Since(start))
+		metrics.BrokerConsumed.WithLabelValues(c.name, topic, metrics.Status(err)).Add(float64(len(msgs)))
+		tracing.End(span, err)
+		return err
+	}
+	return c.batch.SubscribeBatchWithGroup(ctx, topic, groupID, batchSize, maxWait, wrapped)
+}
+
+func (c *instrumentedClient) startProduce(ctx context.Context, topic string, count int) (context.Context, trace.Span) {
+	return tracing.Start(ctx, "produce "+topic,
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(c.attributes(topic)...),
+		trace.WithAttributes(attribute.Int("messaging.batch.message_count", count)),
+	)
+}
+
+func (c *instrumentedClient) attributes(topic string) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String("messaging.system", c.name),
+		attribute.String("messaging.destination.name", topic),
+	}
+}
+
+// withTraceHeaders возвращает копию заголовков с контекстом трассировки из ctx
+func withTraceHeaders(ctx context.Context, headers map[string]string) map[string]string {
+	traced := make(map[string]string, len(headers)+2)
+	maps.Copy(traced, headers)
+	tracing.Inject(ctx, traced)
+	return traced
+}
@@ -2,100 +2,189 @@ package kafka
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"lib/clients/broker"
 	"lib/models"
 	"lib/utils/logging"
-	"log"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/segmentio/kafka-go"
 )
 
-// KafkaBroker реализация Broker для Apache Kafka
+const (
+	fetchErrorDelay = time.Second
+	// commitTimeout ограничивает коммит, который не прерывается отменой подписки
+	commitTimeout = 10 * time.Second
+)
+
+// ErrClosed возвращается при обращении к закрытому клиенту
+var ErrClosed = errors.New("kafka broker is closed")
+
+// KafkaBroker реализация Broker для Apache Kafka.
+// Безопасен для одновременного использования из нескольких горутин.
 type KafkaBroker struct {
-	config  models.Broker
-	writers map[string]*kafka.Writer
-	readers map[string]*kafka.Reader
-	admin   *kafka.Client
+	config models.Broker
+	conn   connOptions
+	retry  broker.RetryPolicy
+	dlq    *broker.DeadLetterQueue
+	logger *logging.Logger
+	admin  *kafka.Client
+
+	// writers — по одному на топик, создаются newWriter при первой отправке
+	mu        sync.RWMutex
+	writers   map[string]messageWriter
+	newWriter func(topic string) messageWriter
+	closed    bool
+
+	// done отменяет циклы чтения при Close, loops ждёт их завершения
+	done  context.Context
+	stop  context.CancelFunc
+	loops sync.WaitGroup
+
+	// stalled — причина остановки подписки на необработанном сообщении;
+	// пока она есть, HealthCheck возвращает ошибку
+	stalled atomic.Pointer[error]
+
+	// Параметры продюсера, проверенные при создании клиента
+	balancer     kafka.Balancer
+	compression  kafka.Compression
+	requiredAcks kafka.RequiredAcks
 }
 
-// NewKafkaBroker создает новый Kafka брокер
-func NewKafkaBroker(cfg models.Broker, logger *logging.Logger) broker.BrokerClient {
+// messageWriter — продюсер одного топика (*kafka.Writer)
+type messageWriter interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+	Close() error
+}
+
+var (
+	_ broker.BatchSubscriber = (*KafkaBroker)(nil)
+	_ broker.LagReporter     = (*KafkaBroker)(nil)
+)
 
-	return &KafkaBroker{
+// NewKafkaBroker создает новый Kafka брокер. Возвращает ошибку при
+// неверных настройках TLS/SASL или неизвестных значениях параметров продюсера.
+func NewKafkaBroker(cfg models.Broker, logger *logging.Logger) (broker.BrokerClient, error) {
+	conn, err := newConnOptions(cfg)
+	if err != nil {
+		return nil, err
+	}
+	balancer, err := newBalancer(cfg.Balancer)
+	if err != nil {
+		return nil, err
+	}
+	compression, err := compressionCodec(cfg.Compression)
+	if err != nil {
+		return nil, err
+	}
+	acks, err := requiredAcks(cfg.RequiredAcks)
+	if err != nil {
+		return nil, err
+	}
+	// kafka.NewReader паникует на таких настройках, проверяем заранее
+	if cfg.ReaderMaxBytes > 0 && cfg.ReaderMinBytes > cfg.ReaderMaxBytes {
+		return nil, fmt.Errorf("reader_min_bytes (%d) is greater than reader_max_bytes (%d)", cfg.ReaderMinBytes, cfg.ReaderMaxBytes)
+	}
+
+	done, stop := context.WithCancel(context.Background())
+	k := &KafkaBroker{
 		config:  cfg,
-		writers: make(map[string]*kafka.Writer),
-		readers: make(map[string]*kafka.Reader),
+		conn:    conn,
+		retry:   broker.NewRetryPolicy(cfg),
+		logger:  logger,
+		writers: make(map[string]messageWriter),
+		done:    done,
+		stop:    stop,
 		admin: &kafka.Client{
-			Addr: kafka.TCP(cfg.Brokers...),
+			Addr:      kafka.TCP(cfg.Brokers...),
+			Transport: conn.transport,
 		},
+		balancer:     balancer,
+		compression:  compression,
+		requiredAcks: acks,
 	}
-}
 
-// SendMessage отправляет одно сообщение
-func (k *KafkaBroker) SendMessage(ctx context.Context, msg models.MessageBroker) error {
-	writer := k.getWriter(msg.Topic)
+	k.newWriter = k.kafkaWriter
 
-	kafkaMsg := kafka.Message{
-		Key:   msg.Key,
-		Value: msg.Value,
-		Topic: msg.Topic,
+	if cfg.DeadLetter {
+		k.dlq = broker.NewDeadLetterQueue(k, k.retry, logger)
 	}
+	return k, nil
+}
 
-	// Конвертируем headers
-	for key, value := range msg.Headers {
-		kafkaMsg.Headers = append(kafkaMsg.Headers, kafka.Header{
-			Key:   key,
-			Value: []byte(value),
-		})
+// SendMessage отправляет одно сообщение
+func (k *KafkaBroker) SendMessage(ctx context.Context, msg models.MessageBroker) error {
+	writer, err := k.getWriter(msg.Topic)
+	if err != nil {
+		return err
 	}
 
-	return writer.WriteMessages(ctx, kafkaMsg)
+	return writer.WriteMessages(ctx, toKafkaMessage(msg))
 }
 
-// SendMessages отправляет несколько сообщений
+// SendMessages отправляет несколько сообщений. Сообщения группируются по
+// топикам, порядок внутри топика сохраняется; ошибки по топикам объединяются.
 func (k *KafkaBroker) SendMessages(ctx context.Context, msgs []models.MessageBroker) error {
 	if len(msgs) == 0 {
 		return nil
 	}
 
-	topic := msgs[0].Topic
-	writer := k.getWriter(topic)
-
-	kafkaMsgs := make([]kafka.Message, len(msgs))
-	for i, msg := range msgs {
-		kafkaMsgs[i] = kafka.Message{
-			Key:   msg.Key,
-			Value: msg.Value,
-			Topic: msg.Topic,
+	var topics []string
+	byTopic := make(map[string][]kafka.Message)
+	for _, msg := range msgs {
+		if _, ok := byTopic[msg.Topic]; !ok {
+			topics = append(topics, msg.Topic)
 		}
+		byTopic[msg.Topic] = append(byTopic[msg.Topic], toKafkaMessage(msg))
+	}
 
-		// Конвертируем headers
-		for key, value := range msg.Headers {
-			kafkaMsgs[i].Headers = append(kafkaMsgs[i].Headers, kafka.Header{
-				Key:   key,
-				Value: []byte(value),
-			})
+	var errs []error
+	for _, topic := range topics {
+		writer, err := k.getWriter(topic)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if err := writer.WriteMessages(ctx, byTopic[topic]...); err != nil {
+			errs = append(errs, fmt.Errorf("topic %s: %w", topic, err))
 		}
 	}
-
-	return writer.WriteMessages(ctx, kafkaMsgs...)
+	return errors.Join(errs...)
 }
 
 // Subscribe подписывается на топик без consumer group
 func (k *KafkaBroker) Subscribe(ctx context.Context, topic string, handler models.MessageHandlerBroker) error {
-	reader := k.getReader(topic, "")
-
-	go k.consumeLoop(ctx, reader, handler)
-	return nil
+	return k.startLoop(ctx, topic, "", func(ctx context.Context, reader *kafka.Reader) {
+		k.consumeLoop(ctx, reader, handler)
+	})
 }
 
-// SubscribeWithGroup подписывается на топик с consumer group
+// SubscribeWithGroup подписывается на топик с consumer group.
+// Offset коммитится только после успешной обработки (at-least-once).
 func (k *KafkaBroker) SubscribeWithGroup(ctx context.Context, topic, groupID string, handler models.MessageHandlerBroker) error {
-	reader := k.getReader(topic, groupID)
+	return k.startLoop(ctx, topic, groupID, func(ctx context.Context, reader *kafka.Reader) {
+		k.consumeLoop(ctx, reader, handler)
+	})
+}
 
-	go k.consumeLoop(ctx, reader, handler)
-	return nil
+// SubscribeBatchWithGroup подписывается на топик с consumer group и передаёт
+// обработчику до batchSize сообщений, накопленных не дольше maxWait.
+// Пачка коммитится целиком после успешной обработки.
+func (k *KafkaBroker) SubscribeBatchWithGroup(ctx context.Context, topic, groupID string, batchSize int, maxWait time.Duration, handler models.BatchMessageHandlerBroker) error {
+	if groupID == "" {
+		return fmt.Errorf("batch subscription to %s requires a consumer group", topic)
+	}
+	if batchSize <= 0 {
+		return fmt.Errorf("invalid batch size %d", batchSize)
+	}
+
+	return k.startLoop(ctx, topic, groupID, func(ctx context.Context, reader *kafka.Reader) {
+		k.consumeBatchLoop(ctx, reader, batchSize, maxWait, handler)
+	})
 }
 
 // CreateTopic создает топик
@@ -123,30 +212,102 @@ func (k *KafkaBroker) CreateTopic(ctx context.Context, topic string, partitions,
 
 // HealthCheck проверяет доступность брокера
 func (k *KafkaBroker) HealthCheck(ctx context.Context) error {
+	if stalled := k.stalled.Load(); stalled != nil {
+		return *stalled
+	}
 	_, err := k.admin.Metadata(ctx, &kafka.MetadataRequest{})
 	return err
 }
 
-// Close закрывает все соединения
-func (k *KafkaBroker) Close() error {
-	var errs []error
+// ConsumerLag возвращает сумму по партициям топика разницы между концом
+// партиции и закоммиченным offset'ом группы. Партиции без коммита
+// считаются непрочитанными с начала.
+func (k *KafkaBroker) ConsumerLag(ctx context.Context, topic, groupID string) (int64, error) {
+	meta, err := k.admin.Metadata(ctx, &kafka.MetadataRequest{Topics: []string{topic}})
+	if err != nil {
+		return 0, fmt.Errorf("failed to get metadata for %s: %w", topic, err)
+	}
+	if len(meta.Topics) == 0 {
+		return 0, fmt.Errorf("topic %s not found", topic)
+	}
+	if meta.Topics[0].Error != nil {
+		return 0, meta.Topics[0].Error
+	}
 
-	// Закрываем writers
-	for _, writer := range k.writers {
-		if err := writer.Close(); err != nil {
-			errs = append(errs, err)
+	partitions := make([]int, 0, len(meta.Topics[0].Partitions))
+	requests := make([]kafka.OffsetRequest, 0, len(meta.Topics[0].Partitions))
+	for _, p := range meta.Topics[0].Partitions {
+		partitions = append(partitions, p.ID)
+		requests = append(requests, kafka.FirstOffsetOf(p.ID), kafka.LastOffsetOf(p.ID))
+	}
+
+	committed, err := k.admin.OffsetFetch(ctx, &kafka.OffsetFetchRequest{
+		GroupID: groupID,
+		Topics:  map[string][]int{topic: partitions},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch offsets of group %s: %w", groupID, err)
+	}
+	if committed.Error != nil {
+		return 0, committed.Error
+	}
+
+	offsets, err := k.admin.ListOffsets(ctx, &kafka.ListOffsetsRequest{
+		Topics: map[string][]kafka.OffsetRequest{topic: requests},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to list offsets of %s: %w", topic, err)
+	}
+
+	type bounds struct{ first, last int64 }
+	ends := make(map[int]bounds)
+	for _, p := range offsets.Topics[topic] {
+		if p.Error != nil {
+			return 0, fmt.Errorf("failed to list offsets of %s[%d]: %w", topic, p.Partition, p.Error)
 		}
+		ends[p.Partition] = bounds{first: p.FirstOffset, last: p.LastOffset}
 	}
 
-	// Закрываем readers
-	for _, reader := range k.readers {
-		if err := reader.Close(); err != nil {
-			errs = append(errs, err)
+	var lag int64
+	for _, p := range committed.Topics[topic] {
+		if p.Error != nil {
+			return 0, fmt.Errorf("failed to fetch offset of %s[%d]: %w", topic, p.Partition, p.Error)
+		}
+		end := ends[p.Partition]
+		// Без коммита (-1) или после удаления старых сегментов читаем с первого доступного
+		lag += max(end.last-max(p.CommittedOffset, end.first), 0)
+	}
+	return lag, nil
+}
+
+// Close останавливает циклы чтения, дожидаясь завершения текущих
+// обработчиков, затем закрывает writers (асинхронные дописывают накопленное)
+func (k *KafkaBroker) Close() error {
+	k.mu.Lock()
+	if k.closed {
+		k.mu.Unlock()
+		return nil
+	}
+	k.closed = true
+	k.mu.Unlock()
+
+	// Обработчики и DLQ ещё могут отправлять сообщения, поэтому writers закрываем после них
+	k.stop()
+	k.loops.Wait()
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	var errs []error
+	for topic, writer := range k.writers {
+		if err := writer.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("writer %s: %w", topic, err))
 		}
 	}
+	k.writers = make(map[string]messageWriter)
 
 	if len(errs) > 0 {
-		return fmt.Errorf("errors closing kafka connections: %v", errs)
+		return fmt.Errorf("errors closing kafka connections: %w", errors.Join(errs...))
 	}
 
 	return nil
@@ -154,72 +315,321 @@ func (k *KafkaBroker) Close() error {
 
 // Вспомогательные методы
 
-func (k *KafkaBroker) getWriter(topic string) *kafka.Writer {
+func (k *KafkaBroker) getWriter(topic string) (messageWriter, error) {
+	k.mu.RLock()
+	writer, exists := k.writers[topic]
+	closed := k.closed
+	k.mu.RUnlock()
+
+	if closed {
+		return nil, ErrClosed
+	}
+	if exists {
+		return writer, nil
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	// Пока ждали блокировку, writer мог создать другой поток
+	if k.closed {
+		return nil, ErrClosed
+	}
 	if writer, exists := k.writers[topic]; exists {
-		return writer
+		return writer, nil
 	}
 
+	writer = k.newWriter(topic)
+	k.writers[topic] = writer
+	return writer, nil
+}
+
+// kafkaWriter создаёт writer топика с параметрами продюсера из конфигурации
+func (k *KafkaBroker) kafkaWriter(topic string) messageWriter {
 	writer := &kafka.Writer{
 		Addr:         kafka.TCP(k.config.Brokers...),
 		Topic:        topic,
-		Balancer:     &kafka.LeastBytes{},
+		Balancer:     k.balancer,
 		BatchSize:    k.config.BatchSize,
 		BatchTimeout: k.config.BatchTimeout,
 		Async:        k.config.Async,
+		Compression:  k.compression,
+		RequiredAcks: k.requiredAcks,
+		MaxAttempts:  k.config.MaxWriteRetries,
+		WriteTimeout: k.config.WriteTimeout,
+		// BatchBytes ограничивает размер запроса, а значит и максимальный размер сообщения
+		BatchBytes: k.config.MaxMessageBytes,
+		Transport:  k.conn.transport,
+	}
+	if k.config.Async {
+		// В асинхронном режиме WriteMessages не возвращает ошибок доставки
+		writer.Completion = func(messages []kafka.Message, err error) {
+			if err != nil {
+				k.logger.Errorf("Async write of %d messages to %s failed: %v", len(messages), topic, err)
+			}
+		}
 	}
-
-	k.writers[topic] = writer
 	return writer
 }
 
-func (k *KafkaBroker) getReader(topic, groupID string) *kafka.Reader {
-	key := topic + "_" + groupID
-	if reader, exists := k.readers[key]; exists {
-		return reader
+// startLoop создаёт reader для подписки и запускает цикл чтения.
+// Цикл завершается при отмене ctx или Close, reader закрывается при выходе.
+func (k *KafkaBroker) startLoop(ctx context.Context, topic, groupID string, loop func(ctx context.Context, reader *kafka.Reader)) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if k.closed {
+		return ErrClosed
 	}
 
+	// У каждой подписки свой reader: общий reader делил бы сообщения между подписками
+	reader := k.newReader(topic, groupID)
+
+	loopCtx, cancel := context.WithCancel(ctx)
+	stopOnClose := context.AfterFunc(k.done, cancel)
+
+	k.loops.Add(1)
+	go func() {
+		defer k.loops.Done()
+		defer cancel()
+		defer stopOnClose()
+
+		loop(loopCtx, reader)
+
+		if err := reader.Close(); err != nil {
+			k.logger.Errorf("Error closing reader for %s: %v", topic, err)
+		}
+	}()
+	return nil
+}
+
+func (k *KafkaBroker) newReader(topic, groupID string) *kafka.Reader {
 	readerConfig := kafka.ReaderConfig{
-		Brokers: k.config.Brokers,
-		Topic:   topic,
-		GroupID: groupID,
+		Brokers:        k.config.Brokers,
+		Topic:          topic,
+		GroupID:        groupID,
+		Dialer:         k.conn.dialer,
+		MinBytes:       k.config.ReaderMinBytes,
+		MaxBytes:       k.config.ReaderMaxBytes,
+		MaxWait:        k.config.ReaderMaxWait,
+		CommitInterval: k.config.CommitInterval,
 	}
 
 	if k.config.StartOffset > 0 {
 		readerConfig.StartOffset = k.config.StartOffset
 	}
 
-	reader := kafka.NewReader(readerConfig)
-	k.readers[key] = reader
-	return reader
+	return kafka.NewReader(readerConfig)
 }
 
 func (k *KafkaBroker) consumeLoop(ctx context.Context, reader *kafka.Reader, handler models.MessageHandlerBroker) {
+	withGroup := reader.Config().GroupID != ""
+
 	for {
-		select {
-		case <-ctx.Done():
-			return
-		default:
-			kafkaMsg, err := reader.ReadMessage(ctx)
-			if err != nil {
-				log.Printf("Error reading message: %v", err)
-				continue
+		// FetchMessage не коммитит offset, в отличие от ReadMessage
+		kafkaMsg, err := reader.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, io.EOF) {
+				return
 			}
+			k.logger.Errorf("Error fetching message: %v", err)
+			k.sleep(ctx, fetchErrorDelay)
+			continue
+		}
 
-			msg := models.MessageBroker{
-				Key:     kafkaMsg.Key,
-				Value:   kafkaMsg.Value,
-				Topic:   kafkaMsg.Topic,
-				Headers: make(map[string]string),
+		if !k.handle(ctx, toMessage(kafkaMsg), handler) {
+			// Offset не закоммичен: сообщение будет прочитано заново после перезапуска
+			return
+		}
+
+		// Без consumer group коммитить некуда
+		if !withGroup {
+			continue
+		}
+		if err := commit(ctx, reader, kafkaMsg); err != nil {
+			k.logger.Errorf("Error committing message %s/%d@%d: %v",
+				kafkaMsg.Topic, kafkaMsg.Partition, kafkaMsg.Offset, err)
+		}
+	}
+}
+
+func (k *KafkaBroker) consumeBatchLoop(ctx context.Context, reader *kafka.Reader, batchSize int, maxWait time.Duration, handler models.BatchMessageHandlerBroker) {
+	for ctx.Err() == nil {
+		kafkaMsgs, err := k.fetchBatch(ctx, reader, batchSize, maxWait)
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, io.EOF) {
+				return
 			}
+			k.logger.Errorf("Error fetching batch: %v", err)
+			k.sleep(ctx, fetchErrorDelay)
+		}
+		if len(kafkaMsgs) == 0 {
+			continue
+		}
 
-			// Конвертируем headers
-			for _, header := range kafkaMsg.Headers {
-				msg.Headers[header.Key] = string(header.Value)
+		msgs := make([]models.MessageBroker, len(kafkaMsgs))
+		for i, kafkaMsg := range kafkaMsgs {
+			msgs[i] = toMessage(kafkaMsg)
+		}
+		if !k.handleBatch(ctx, msgs, handler) {
+			// Offset'ы пачки не закоммичены: сообщения будут прочитаны заново после перезапуска
+			return
+		}
+
+		if err := commit(ctx, reader, kafkaMsgs...); err != nil {
+			k.logger.Errorf("Error committing batch of %d messages: %v", len(kafkaMsgs), err)
+		}
+	}
+}
+
+// handleBatch обрабатывает пачку с повторами; если пачка так и не обработалась,
+// сообщения обрабатываются по одному через DLQ. Возвращает false, если пачку
+// нельзя коммитить.
+func (k *KafkaBroker) handleBatch(ctx context.Context, msgs []models.MessageBroker, handler models.BatchMessageHandlerBroker) bool {
+	attempts, err := k.retry.Run(ctx, func(attempt int) error {
+		return handler(ctx, msgs)
+	})
+	if err == nil {
+		return true
+	}
+	if ctx.Err() != nil {
+		return false
+	}
+
+	first, last := msgs[0], msgs[len(msgs)-1]
+	if k.dlq == nil {
+		// Без DLQ пачку нельзя закоммитить, не потеряв сообщения
+		k.stall(fmt.Errorf("batch of %d messages (%s/%d@%d..%d) failed after %d attempts and dead letter queue is disabled: %w",
+			len(msgs), first.Topic, first.Partition, first.Offset, last.Offset, attempts, err))
+		return false
+	}
+	k.logger.Errorf("Batch of %d messages (%s/%d@%d..%d) failed after %d attempts, handling one by one: %v",
+		len(msgs), first.Topic, first.Partition, first.Offset, last.Offset, attempts, err)
+
+	// Пачка целиком не обработалась — обрабатываем сообщения по одному,
+	// чтобы в DLQ попали только действительно сбойные
+	single := func(ctx context.Context, msg models.MessageBroker) error {
+		return handler(ctx, []models.MessageBroker{msg})
+	}
+	for _, msg := range msgs {
+		if !k.handle(ctx, msg, single) {
+			return false
+		}
+	}
+	return true
+}
+
+// handle обрабатывает сообщение с повторами (и DLQ, если он включён).
+// Возвращает false, если сообщение нельзя коммитить.
+func (k *KafkaBroker) handle(ctx context.Context, msg models.MessageBroker, handler models.MessageHandlerBroker) bool {
+	if k.dlq != nil {
+		if err := k.dlq.Handle(ctx, msg, handler); err != nil {
+			if ctx.Err() == nil {
+				// Сообщение не обработано и не попало в DLQ: чтение остановлено,
+				// HealthCheck сообщает об этом
+				k.stall(fmt.Errorf("message %s/%d@%d was not moved to the dead letter queue: %w",
+					msg.Topic, msg.Partition, msg.Offset, err))
 			}
+			return false
+		}
+		return true
+	}
 
-			if err := handler(ctx, msg); err != nil {
-				log.Printf("Error handling message: %v", err)
+	attempts, err := k.retry.Run(ctx, func(attempt int) error {
+		return handler(ctx, msg)
+	})
+	if err != nil {
+		if ctx.Err() != nil {
+			return false
+		}
+		// Без DLQ сообщение некуда отложить: оставляем offset незакоммиченным
+		// и останавливаем чтение, чтобы не потерять сообщение
+		k.stall(fmt.Errorf("message %s/%d@%d failed after %d attempts and dead letter queue is disabled: %w",
+			msg.Topic, msg.Partition, msg.Offset, attempts, err))
+		return false
+	}
+	return true
+}
+
+// commit коммитит обработанные сообщения. Отмена ctx во время обработки
+// не прерывает коммит: иначе обработанное сообщение прочиталось бы повторно.
+func commit(ctx context.Context, reader *kafka.Reader, msgs ...kafka.Message) error {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), commitTimeout)
+	defer cancel()
+	return reader.CommitMessages(ctx, msgs...)
+}
+
+// stall фиксирует остановку подписки: offset не коммитится, сообщение
+// будет прочитано заново после перезапуска
+func (k *KafkaBroker) stall(err error) {
+	k.logger.Errorf("Stopping consumer, offset left uncommitted: %v", err)
+	k.stalled.Store(&err)
+}
+
+// fetchBatch набирает до batchSize сообщений, ожидая не дольше maxWait
+// после получения первого из них
+func (k *KafkaBroker) fetchBatch(ctx context.Context, reader *kafka.Reader, batchSize int, maxWait time.Duration) ([]kafka.Message, error) {
+	first, err := reader.FetchMessage(ctx)
+	if err != nil {
+		return nil, err
+	}
+	batch := []kafka.Message{first}
+
+	waitCtx, cancel := context.WithTimeout(ctx, maxWait)
+	defer cancel()
+
+	for len(batch) < batchSize {
+		kafkaMsg, err := reader.FetchMessage(waitCtx)
+		if err != nil {
+			if waitCtx.Err() != nil && ctx.Err() == nil {
+				// Истекло время ожидания — отдаём то, что набрали
+				return batch, nil
 			}
+			return batch, err
 		}
+		batch = append(batch, kafkaMsg)
+	}
+	return batch, nil
+}
+
+// toKafkaMessage конвертирует сообщение для writer. Topic не заполняется:
+// он задан у writer, а kafka-go запрещает указывать его в обоих местах.
+func toKafkaMessage(msg models.MessageBroker) kafka.Message {
+	kafkaMsg := kafka.Message{
+		Key:   msg.Key,
+		Value: msg.Value,
+	}
+
+	// Конвертируем headers
+	for key, value := range msg.Headers {
+		kafkaMsg.Headers = append(kafkaMsg.Headers, kafka.Header{
+			Key:   key,
+			Value: []byte(value),
+		})
+	}
+	return kafkaMsg
+}
+
+func toMessage(kafkaMsg kafka.Message) models.MessageBroker {
+	msg := models.MessageBroker{
+		Key:       kafkaMsg.Key,
+		Value:     kafkaMsg.Value,
+		Topic:     kafkaMsg.Topic,
+		Headers:   make(map[string]string),
+		Partition: kafkaMsg.Partition,
+		Offset:    kafkaMsg.Offset,
+	}
+
+	// Конвертируем headers
+	for _, header := range kafkaMsg.Headers {
+		msg.Headers[header.Key] = string(header.Value)
+	}
+	return msg
+}
+
+func (k *KafkaBroker) sleep(ctx context.Context, d time.Duration) {
+	select {
+	case <-ctx.Done():
+	case <-time.After(d):
 	}
 }
@@ -0,0 +1,144 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"lib/clients/broker"
+	"lib/models"
+	"lib/utils/logging"
+)
+
+func TestHandleWithoutDeadLetterKeepsMessageUncommitted(t *testing.T) {
+	k := &KafkaBroker{
+		retry:  broker.RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond},
+		logger: logging.GetLogger(),
+	}
+
+	calls := 0
+	handler := func(context.Context, models.MessageBroker) error {
+		calls++
+		return errors.New("clickhouse is down")
+	}
+	msg := models.MessageBroker{Topic: "blocks", Partition: 1, Offset: 42}
+
+	if k.handle(context.Background(), msg, handler) {
+		t.Fatal("failed message without dead letter queue is reported as committable")
+	}
+	if calls != 2 {
+		t.Errorf("handler called %d times, want 2", calls)
+	}
+	if err := k.HealthCheck(context.Background()); err == nil {
+		t.Error("health check passes after the consumer stopped")
+	}
+}
+
+func TestHandleSucceedsAfterRetry(t *testing.T) {
+	k := &KafkaBroker{
+		retry:  broker.RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond},
+		logger: logging.GetLogger(),
+	}
+
+	calls := 0
+	handler := func(context.Context, models.MessageBroker) error {
+		calls++
+		if calls == 1 {
+			return errors.New("temporary failure")
+		}
+		return nil
+	}
+
+	if !k.handle(context.Background(), models.MessageBroker{Topic: "blocks"}, handler) {
+		t.Fatal("message handled on the second attempt is not committable")
+	}
+	if k.stalled.Load() != nil {
+		t.Error("consumer marked as stalled after successful retry")
+	}
+}
+
+// failingProducer не может отправить сообщение в DLQ
+type failingProducer struct {
+	broker.BrokerClient
+	sent int
+}
+
+func (p *failingProducer) SendMessage(context.Context, models.MessageBroker) error {
+	p.sent++
+	return broker.Permanent(errors.New("dlq topic is not writable"))
+}
+
+func TestDeadLetterFailureStallsConsumer(t *testing.T) {
+	failing := func(context.Context, models.MessageBroker) error { return errors.New("clickhouse is down") }
+	failingBatch := func(context.Context, []models.MessageBroker) error { return errors.New("clickhouse is down") }
+	msgs := []models.MessageBroker{
+		{Topic: "blocks", Partition: 1, Offset: 42},
+		{Topic: "blocks", Partition: 1, Offset: 43},
+	}
+
+	tests := []struct {
+		name   string
+		handle func(k *KafkaBroker) bool
+	}{
+		{"single message", func(k *KafkaBroker) bool { return k.handle(context.Background(), msgs[0], failing) }},
+		{"batch", func(k *KafkaBroker) bool { return k.handleBatch(context.Background(), msgs, failingBatch) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			retry := broker.RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond}
+			producer := &failingProducer{}
+			k := &KafkaBroker{retry: retry, logger: logging.GetLogger()}
+			k.dlq = broker.NewDeadLetterQueue(producer, retry, k.logger)
+
+			if tt.handle(k) {
+				t.Fatal("message that failed to reach the dead letter queue is reported as committable")
+			}
+			if producer.sent != 1 {
+				t.Errorf("dead letter sent %d times, want 1 (stopped on the first failed message)", producer.sent)
+			}
+			if err := k.HealthCheck(context.Background()); err == nil {
+				t.Error("health check passes after the consumer stopped")
+			}
+		})
+	}
+}
+
+func TestHandleBatchDeadLettersFailingMessage(t *testing.T) {
+	retry := broker.RetryPolicy{MaxAttempts: 1, InitialBackoff: time.Millisecond}
+	producer := &capturingProducer{}
+	k := &KafkaBroker{retry: retry, logger: logging.GetLogger()}
+	k.dlq = broker.NewDeadLetterQueue(producer, retry, k.logger)
+
+	// Пачка падает из-за одного сообщения — в DLQ уходит только оно
+	handler := func(_ context.Context, msgs []models.MessageBroker) error {
+		for _, msg := range msgs {
+			if msg.Offset == 43 {
+				return errors.New("cannot decode block")
+			}
+		}
+		return nil
+	}
+	msgs := []models.MessageBroker{
+		{Topic: "blocks", Offset: 42}, {Topic: "blocks", Offset: 43}, {Topic: "blocks", Offset: 44},
+	}
+	if !k.handleBatch(context.Background(), msgs, handler) {
+		t.Fatal("batch with a dead-lettered message is not committable")
+	}
+	if len(producer.msgs) != 1 || producer.msgs[0].Headers[broker.HeaderDLQOriginalOffset] != "43" {
+		t.Errorf("dead letters = %+v, want only offset 43", producer.msgs)
+	}
+	if k.stalled.Load() != nil {
+		t.Error("consumer marked as stalled after a successful dead letter")
+	}
+}
+
+type capturingProducer struct {
+	broker.BrokerClient
+	msgs []models.MessageBroker
+}
+
+func (p *capturingProducer) SendMessage(_ context.Context, msg models.MessageBroker) error {
+	p.msgs = append(p.msgs, msg)
+	return nil
+}
@@ -0,0 +1,125 @@
+package kafka
+
+import (
+	"fmt"
+	"lib/clients/broker"
+	"lib/models"
+	"strings"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl"
+	"github.com/segmentio/kafka-go/sasl/plain"
+	"github.com/segmentio/kafka-go/sasl/scram"
+)
+
+const dialTimeout = 10 * time.Second
+
+// connOptions — общие для writer, reader и admin клиента параметры подключения
+type connOptions struct {
+	transport *kafka.Transport
+	dialer    *kafka.Dialer
+}
+
+func newConnOptions(cfg models.Broker) (connOptions, error) {
+	tlsConfig, err := broker.NewTLSConfig(cfg.TLS)
+	if err != nil {
+		return connOptions{}, err
+	}
+	mechanism, err := saslMechanism(cfg.SASL)
+	if err != nil {
+		return connOptions{}, err
+	}
+
+	return connOptions{
+		transport: &kafka.Transport{
+			DialTimeout: dialTimeout,
+			TLS:         tlsConfig,
+			SASL:        mechanism,
+		},
+		dialer: &kafka.Dialer{
+			Timeout:       dialTimeout,
+			DualStack:     true,
+			TLS:           tlsConfig,
+			SASLMechanism: mechanism,
+		},
+	}, nil
+}
+
+func saslMechanism(cfg models.SASL) (sasl.Mechanism, error) {
+	switch strings.ToLower(cfg.Mechanism) {
+	case "":
+		return nil, nil
+	case "plain":
+		return plain.Mechanism{Username: cfg.Username, Password: cfg.Password}, nil
+	case "scram-sha-256":
+		return scram.Mechanism(scram.SHA256, cfg.Username, cfg.Password)
+	case "scram-sha-512":
+		return scram.Mechanism(scram.SHA512, cfg.Username, cfg.Password)
+	default:
+		return nil, fmt.Errorf("unknown sasl mechanism %q", cfg.Mechanism)
+	}
+}
+
+func compressionCodec(name string) (kafka.Compression, error) {
+	switch strings.ToLower(name) {
+	case "", "none":
+		return 0, nil
+	case "gzip":
+		return kafka.Gzip, nil
+	case "snappy":
+		return kafka.Snappy, nil
+	case "lz4":
+		return kafka.Lz4, nil
+	case "zstd":
+		return kafka.Zstd, nil
+	default:
+		return 0, fmt.Errorf("unknown kafka compression %q", name)
+	}
+}
+
+func requiredAcks(name string) (kafka.RequiredAcks, error) {
+	switch strings.ToLower(name) {
+	// По умолчанию ждём подтверждения всех реплик — блоки терять нельзя
+	case "", "all":
+		return kafka.RequireAll, nil
+	case "one":
+		return kafka.RequireOne, nil
+	case "none":
+		return kafka.RequireNone, nil
+	default:
+		return 0, fmt.Errorf("unknown kafka required_acks %q", name)
+	}
+}
+
+func newBalancer(name string) (kafka.Balancer, error) {
+	switch strings.ToLower(name) {
+	case "", "hash":
+		return &kafka.Hash{}, nil
+	case "network":
+		return &networkBalancer{}, nil
+	case "least_bytes":
+		return &kafka.LeastBytes{}, nil
+	case "round_robin":
+		return &kafka.RoundRobin{}, nil
+	default:
+		return nil, fmt.Errorf("unknown kafka balancer %q", name)
+	}
+}
+
+// networkBalancer направляет все сообщения одной сети в одну партицию,
+// чтобы блоки сети читались в порядке отправки. Сообщения без заголовка
+// network распределяются по ключу.
+type networkBalancer struct {
+	hash kafka.Hash
+}
+
+func (b *networkBalancer) Balance(msg kafka.Message, partitions ...int) int {
+	for _, header := range msg.Headers {
+		if header.Key == models.HeaderNetwork && len(header.Value) > 0 {
+			msg.Key = header.Value
+			break
+		}
+	}
+	return b.hash.Balance(msg, partitions...)
+}
@@ -0,0 +1,190 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"lib/models"
+	"lib/utils/logging"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// fakeWriter запоминает записанные сообщения вместо отправки в Kafka
+type fakeWriter struct {
+	mu     sync.Mutex
+	msgs   []kafka.Message
+	closed atomic.Int32
+}
+
+func (w *fakeWriter) WriteMessages(_ context.Context, msgs ...kafka.Message) error {
+	if w.closed.Load() > 0 {
+		// Как kafka.Writer после Close
+		return io.ErrClosedPipe
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.msgs = append(w.msgs, msgs...)
+	return nil
+}
+
+func (w *fakeWriter) Close() error {
+	w.closed.Add(1)
+	return nil
+}
+
+// fakeWriters подменяет создание writers и считает их по топикам
+type fakeWriters struct {
+	mu      sync.Mutex
+	writers map[string][]*fakeWriter
+}
+
+func (f *fakeWriters) newWriter(topic string) messageWriter {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	w := &fakeWriter{}
+	f.writers[topic] = append(f.writers[topic], w)
+	return w
+}
+
+func newFakeBroker() (*KafkaBroker, *fakeWriters) {
+	fakes := &fakeWriters{writers: make(map[string][]*fakeWriter)}
+	done, stop := context.WithCancel(context.Background())
+	return &KafkaBroker{
+		logger:    logging.GetLogger(),
+		writers:   make(map[string]messageWriter),
+		newWriter: fakes.newWriter,
+		done:      done,
+		stop:      stop,
+	}, fakes
+}
+
+func TestConcurrentProducers(t *testing.T) {
+	const producers, perProducer = 32, 50
+	topics := []string{"blocks", "transactions", "logs"}
+	k, fakes := newFakeBroker()
+
+	ctx := context.Background()
+	var wg sync.WaitGroup
+	errs := make(chan error, 2*producers)
+	for p := 0; p < producers; p++ {
+		wg.Add(2)
+
+		// Одиночные сообщения во все топики по очереди
+		go func() {
+			defer wg.Done()
+			for i := 0; i < perProducer; i++ {
+				msg := models.MessageBroker{
+					Topic: topics[i%len(topics)],
+					Key:   []byte(fmt.Sprintf("single-%d", p)),
+					Value: []byte(fmt.Sprint(i)),
+				}
+				if err := k.SendMessage(ctx, msg); err != nil {
+					errs <- err
+					return
+				}
+			}
+		}()
+
+		// Пачки с сообщениями нескольких топиков
+		go func() {
+			defer wg.Done()
+			for i := 0; i < perProducer; i += len(topics) {
+				batch := make([]models.MessageBroker, 0, 2*len(topics))
+				for j := 0; j < 2; j++ {
+					for _, topic := range topics {
+						batch = append(batch, models.MessageBroker{
+							Topic: topic,
+							Key:   []byte(fmt.Sprintf("batch-%d", p)),
+							Value: []byte(fmt.Sprint(i + j)),
+						})
+					}
+				}
+				if err := k.SendMessages(ctx, batch); err != nil {
+					errs <- err
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatalf("send: %v", err)
+	}
+
+	total := 0
+	for _, topic := range topics {
+		writers := fakes.writers[topic]
+		if len(writers) != 1 {
+			t.Fatalf("topic %s has %d writers, want 1", topic, len(writers))
+		}
+		total += len(writers[0].msgs)
+
+		// Сообщения каждого продюсера в топике сохраняют порядок отправки
+		last := make(map[string]int)
+		for _, msg := range writers[0].msgs {
+			value, _ := strconv.Atoi(string(msg.Value))
+			if prev, ok := last[string(msg.Key)]; ok && value <= prev {
+				t.Errorf("topic %s: %s value %d after %d", topic, msg.Key, value, prev)
+			}
+			last[string(msg.Key)] = value
+		}
+	}
+	batches := (perProducer + len(topics) - 1) / len(topics)
+	if want := producers * (perProducer + batches*2*len(topics)); total != want {
+		t.Errorf("written %d messages, want %d", total, want)
+	}
+}
+
+func TestCloseWhileProducing(t *testing.T) {
+	k, fakes := newFakeBroker()
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for p := 0; p < 16; p++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; ; i++ {
+				err := k.SendMessage(ctx, models.MessageBroker{Topic: fmt.Sprintf("topic-%d", i%4), Value: []byte("v")})
+				// Writer, полученный до Close, может быть закрыт к моменту записи
+				if errors.Is(err, ErrClosed) || errors.Is(err, io.ErrClosedPipe) {
+					return
+				}
+				if err != nil {
+					t.Errorf("send: %v", err)
+					return
+				}
+			}
+		}()
+	}
+
+	if err := k.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	wg.Wait()
+
+	if err := k.SendMessage(ctx, models.MessageBroker{Topic: "blocks"}); !errors.Is(err, ErrClosed) {
+		t.Errorf("send after close = %v, want ErrClosed", err)
+	}
+	if err := k.Close(); err != nil {
+		t.Errorf("second close: %v", err)
+	}
+
+	fakes.mu.Lock()
+	defer fakes.mu.Unlock()
+	for topic, writers := range fakes.writers {
+		for _, w := range writers {
+			if n := w.closed.Load(); n != 1 {
+				t.Errorf("writer of %s closed %d times, want 1", topic, n)
+			}
+		}
+	}
+}
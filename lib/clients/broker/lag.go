@@ -0,0 +1,30 @@
+package broker
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrLagUnsupported — брокер не умеет считать отставание консьюмеров
+var ErrLagUnsupported = errors.New("broker does not report consumer lag")
+
+// LagReporter реализуют клиенты, которые умеют считать число
+// непрочитанных группой сообщений топика
+type LagReporter interface {
+	ConsumerLag(ctx context.Context, topic, groupID string) (int64, error)
+}
+
+// ConsumerLag возвращает отставание группы groupID по топику topic.
+// Обёртки (Instrument) снимаются, чтобы добраться до клиента брокера.
+func ConsumerLag(ctx context.Context, client BrokerClient, topic, groupID string) (int64, error) {
+	for {
+		if reporter, ok := client.(LagReporter); ok {
+			return reporter.ConsumerLag(ctx, topic, groupID)
+		}
+		wrapper, ok := client.(interface{ Unwrap() BrokerClient })
+		if !ok {
+			return 0, ErrLagUnsupported
+		}
+		client = wrapper.Unwrap()
+	}
+}
@@ -0,0 +1,395 @@
+package memoryBroker
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"lib/models"
+	"sync"
+	"time"
+)
+
+const (
+	defaultPartitions = 1
+	defaultRetention  = 10000
+)
+
+var (
+	ErrOffsetOutOfRange = errors.New("offset is out of retained range")
+	ErrUnknownPartition = errors.New("unknown partition")
+	ErrClosed           = errors.New("memory broker client is closed")
+	ErrRetentionChanged = errors.New("shared memory broker already created with another retention")
+)
+
+// record — сообщение, сохранённое в партиции
+type record struct {
+	offset  int64
+	key     []byte
+	value   []byte
+	headers map[string]string
+	time    time.Time
+}
+
+// partition — упорядоченный лог с ограниченным хранением:
+// старые записи вытесняются, first указывает на первый доступный offset.
+// Доступные записи — records[head:]; вытесненные обнуляются и удаляются
+// из начала среза, когда их становится не меньше доступных.
+type partition struct {
+	records []record
+	head    int
+	first   int64
+}
+
+func (p *partition) size() int {
+	return len(p.records) - p.head
+}
+
+func (p *partition) next() int64 {
+	return p.first + int64(p.size())
+}
+
+func (p *partition) at(offset int64) (record, bool) {
+	if offset < p.first || offset >= p.next() {
+		return record{}, false
+	}
+	return p.records[p.head+int(offset-p.first)], true
+}
+
+// trim вытесняет самые старые записи сверх retention. Сдвиг хранимых записей
+// выполняется не чаще, чем раз в retention публикаций, поэтому амортизированно O(1).
+func (p *partition) trim(retention int) {
+	excess := p.size() - retention
+	if excess <= 0 {
+		return
+	}
+	clear(p.records[p.head : p.head+excess])
+	p.head += excess
+	p.first += int64(excess)
+
+	if p.head >= p.size() {
+		n := copy(p.records, p.records[p.head:])
+		clear(p.records[n:])
+		p.records = p.records[:n]
+		p.head = 0
+	}
+}
+
+// group хранит закоммиченные offset'ы consumer group по партициям
+// и признак того, что партиция сейчас обрабатывается одним из участников
+type group struct {
+	offsets []int64
+	busy    []bool
+}
+
+type topic struct {
+	partitions []*partition
+	groups     map[string]*group
+	roundRobin int
+
+	// notify закрывается и пересоздаётся при каждой новой записи,
+	// чтобы разбудить всех ожидающих подписчиков
+	notify chan struct{}
+}
+
+// Broker — in-process брокер: топики, партиции по хешу ключа, consumer groups
+// с независимыми offset'ами и ограниченное хранение. Несколько клиентов
+// (NewClient) поверх одного Broker обмениваются сообщениями в пределах процесса.
+type Broker struct {
+	mu        sync.Mutex
+	topics    map[string]*topic
+	retention int
+}
+
+// NewBroker создаёт брокер; retention — максимум записей в партиции
+func NewBroker(retention int) *Broker {
+	if retention <= 0 {
+		retention = defaultRetention
+	}
+	return &Broker{
+		topics:    make(map[string]*topic),
+		retention: retention,
+	}
+}
+
+var (
+	defaultBroker *Broker
+	defaultMu     sync.Mutex
+)
+
+// Default возвращает общий для процесса брокер, через который
+// связываются компоненты, созданные фабрикой с brocker_type: memory.
+// Брокер создаётся при первом вызове; запрос с другим retention
+// возвращает ErrRetentionChanged, а не молча использует прежний.
+func Default(retention int) (*Broker, error) {
+	if retention <= 0 {
+		retention = defaultRetention
+	}
+
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	if defaultBroker == nil {
+		defaultBroker = NewBroker(retention)
+	}
+	if defaultBroker.retention != retention {
+		return nil, fmt.Errorf("%w: %d, requested %d", ErrRetentionChanged, defaultBroker.retention, retention)
+	}
+	return defaultBroker, nil
+}
+
+// CreateTopic создаёт топик с указанным числом партиций; существующий топик не изменяется
+func (b *Broker) CreateTopic(name string, partitions int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.topicLocked(name, partitions)
+}
+
+func (b *Broker) topicLocked(name string, partitions int) *topic {
+	if t, ok := b.topics[name]; ok {
+		return t
+	}
+	if partitions <= 0 {
+		partitions = defaultPartitions
+	}
+
+	t := &topic{
+		partitions: make([]*partition, partitions),
+		groups:     make(map[string]*group),
+		notify:     make(chan struct{}),
+	}
+	for i := range t.partitions {
+		t.partitions[i] = &partition{}
+	}
+	b.topics[name] = t
+	return t
+}
+
+// Publish дописывает сообщение в партицию, выбранную по хешу ключа
+// (без ключа — по кругу), и возвращает партицию и offset
+func (b *Broker) Publish(msg models.MessageBroker) (int, int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	t := b.topicLocked(msg.Topic, 0)
+	idx := t.partitionFor(msg.Key)
+	p := t.partitions[idx]
+
+	offset := p.next()
+	p.records = append(p.records, record{
+		offset:  offset,
+		key:     append([]byte(nil), msg.Key...),
+		value:   append([]byte(nil), msg.Value...),
+		headers: copyHeaders(msg.Headers),
+		time:    time.Now(),
+	})
+
+	// Ограниченное хранение: вытесняем самые старые записи
+	p.trim(b.retention)
+
+	close(t.notify)
+	t.notify = make(chan struct{})
+	return idx, offset
+}
+
+func (t *topic) partitionFor(key []byte) int {
+	if len(key) == 0 {
+		idx := t.roundRobin % len(t.partitions)
+		t.roundRobin++
+		return idx
+	}
+	h := fnv.New32a()
+	h.Write(key)
+	return int(h.Sum32() % uint32(len(t.partitions)))
+}
+
+// Offsets возвращает первый доступный и следующий (ещё не записанный) offset партиции
+func (b *Broker) Offsets(topicName string, partitionIdx int) (first, next int64, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	t := b.topicLocked(topicName, 0)
+	if partitionIdx < 0 || partitionIdx >= len(t.partitions) {
+		return 0, 0, fmt.Errorf("%w: %s/%d", ErrUnknownPartition, topicName, partitionIdx)
+	}
+	p := t.partitions[partitionIdx]
+	return p.first, p.next(), nil
+}
+
+// Partitions возвращает число партиций топика
+func (b *Broker) Partitions(topicName string) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.topicLocked(topicName, 0).partitions)
+}
+
+// SetGroupOffset переставляет offset consumer group, например, для повторного чтения
+func (b *Broker) SetGroupOffset(topicName, groupID string, partitionIdx int, offset int64) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	t := b.topicLocked(topicName, 0)
+	if partitionIdx < 0 || partitionIdx >= len(t.partitions) {
+		return fmt.Errorf("%w: %s/%d", ErrUnknownPartition, topicName, partitionIdx)
+	}
+	p := t.partitions[partitionIdx]
+	if offset < p.first || offset > p.next() {
+		return fmt.Errorf("%w: %s/%d offset %d (available %d-%d)", ErrOffsetOutOfRange, topicName, partitionIdx, offset, p.first, p.next())
+	}
+
+	g := t.groupLocked(groupID, false)
+	g.offsets[partitionIdx] = offset
+	return nil
+}
+
+// GroupLag возвращает суммарное число непрочитанных группой сообщений топика
+func (b *Broker) GroupLag(topicName, groupID string) int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	t := b.topicLocked(topicName, 0)
+	g, ok := t.groups[groupID]
+	if !ok {
+		return 0
+	}
+
+	var lag int64
+	for i, p := range t.partitions {
+		lag += p.next() - max(g.offsets[i], p.first)
+	}
+	return lag
+}
+
+// groupLocked возвращает группу, создавая её с offset'ами на конце
+// партиций (fromLatest) или на первой доступной записи
+func (t *topic) groupLocked(groupID string, fromLatest bool) *group {
+	if g, ok := t.groups[groupID]; ok {
+		return g
+	}
+
+	g := &group{
+		offsets: make([]int64, len(t.partitions)),
+		busy:    make([]bool, len(t.partitions)),
+	}
+	for i, p := range t.partitions {
+		if fromLatest {
+			g.offsets[i] = p.next()
+		} else {
+			g.offsets[i] = p.first
+		}
+	}
+	t.groups[groupID] = g
+	return g
+}
+
+// claim выбирает следующую запись для участника группы: первую свободную
+// партицию (начиная с start) с непрочитанными сообщениями. Партиция помечается
+// занятой до releaseGroup, поэтому порядок внутри партиции сохраняется.
+// Если записей нет, возвращается канал, который закроется при новой записи.
+func (b *Broker) claim(topicName, groupID string, fromLatest bool, start int) (record, int, bool, <-chan struct{}) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	t := b.topicLocked(topicName, 0)
+	g := t.groupLocked(groupID, fromLatest)
+
+	for i := range t.partitions {
+		idx := (start + i) % len(t.partitions)
+		if g.busy[idx] {
+			continue
+		}
+		p := t.partitions[idx]
+
+		// Записи, вытесненные по retention, пропускаем
+		if g.offsets[idx] < p.first {
+			g.offsets[idx] = p.first
+		}
+		rec, ok := p.at(g.offsets[idx])
+		if !ok {
+			continue
+		}
+		g.busy[idx] = true
+		return rec, idx, true, nil
+	}
+	return record{}, 0, false, t.notify
+}
+
+// release освобождает партицию; при commit offset группы сдвигается за запись
+func (b *Broker) release(topicName, groupID string, idx int, offset int64, commit bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	t := b.topics[topicName]
+	g := t.groups[groupID]
+	g.busy[idx] = false
+
+	// Если offset группы переставили (SetGroupOffset) во время обработки, коммит отбрасывается
+	if commit && g.offsets[idx] == offset {
+		g.offsets[idx] = offset + 1
+	}
+
+	// Освобождённую партицию сразу забирает ожидающий участник группы
+	close(t.notify)
+	t.notify = make(chan struct{})
+}
+
+// fetch работает как claim, но по собственным курсорам подписчика без группы.
+// Партиции с отрицательным курсором не читаются.
+func (b *Broker) fetch(topicName string, cursors []int64, start int) (record, int, bool, <-chan struct{}) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	t := b.topicLocked(topicName, 0)
+	for i := range cursors {
+		idx := (start + i) % len(cursors)
+		if cursors[idx] < 0 {
+			continue
+		}
+		p := t.partitions[idx]
+
+		if cursors[idx] < p.first {
+			cursors[idx] = p.first
+		}
+		rec, ok := p.at(cursors[idx])
+		if !ok {
+			continue
+		}
+		cursors[idx]++
+		return rec, idx, true, nil
+	}
+	return record{}, 0, false, t.notify
+}
+
+// cursors возвращает начальные позиции чтения всех партиций топика
+func (b *Broker) cursors(topicName string, fromLatest bool) []int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	t := b.topicLocked(topicName, 0)
+	cursors := make([]int64, len(t.partitions))
+	for i, p := range t.partitions {
+		if fromLatest {
+			cursors[i] = p.next()
+		} else {
+			cursors[i] = p.first
+		}
+	}
+	return cursors
+}
+
+func copyHeaders(headers map[string]string) map[string]string {
+	out := make(map[string]string, len(headers))
+	for k, v := range headers {
+		out[k] = v
+	}
+	return out
+}
+
+func (r record) message(topicName string, partitionIdx int) models.MessageBroker {
+	return models.MessageBroker{
+		Key:       append([]byte(nil), r.key...),
+		Value:     append([]byte(nil), r.value...),
+		Headers:   copyHeaders(r.headers),
+		Topic:     topicName,
+		Partition: partitionIdx,
+		Offset:    r.offset,
+	}
+}
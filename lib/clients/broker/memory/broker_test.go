@@ -0,0 +1,271 @@
+package memoryBroker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sync"
+	"testing"
+	"time"
+
+	"lib/models"
+	"lib/utils/logging"
+)
+
+func eventually(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// received собирает сообщения, полученные подписчиками
+type received struct {
+	mu   sync.Mutex
+	msgs []models.MessageBroker
+}
+
+func (r *received) handler(_ context.Context, msg models.MessageBroker) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.msgs = append(r.msgs, msg)
+	return nil
+}
+
+func (r *received) get() []models.MessageBroker {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]models.MessageBroker(nil), r.msgs...)
+}
+
+func TestRetentionKeepsNewestRecords(t *testing.T) {
+	const retention, published = 4, 11
+	b := NewBroker(retention)
+
+	for i := 0; i < published; i++ {
+		b.Publish(models.MessageBroker{Topic: "blocks", Key: []byte("k"), Value: []byte(fmt.Sprint(i))})
+
+		first, next, err := b.Offsets("blocks", 0)
+		if err != nil {
+			t.Fatalf("offsets: %v", err)
+		}
+		if want := int64(max(0, i+1-retention)); first != want || next != int64(i+1) {
+			t.Fatalf("after %d publishes offsets = %d-%d, want %d-%d", i+1, first, next, want, i+1)
+		}
+	}
+
+	p := b.topics["blocks"].partitions[0]
+	if p.size() != retention || len(p.records) > 2*retention {
+		t.Errorf("partition holds %d records in slice of %d, retention %d", p.size(), len(p.records), retention)
+	}
+	for offset := int64(published - retention); offset < published; offset++ {
+		rec, ok := p.at(offset)
+		if !ok || rec.offset != offset || string(rec.value) != fmt.Sprint(offset) {
+			t.Errorf("record at %d = %+v, %v", offset, rec, ok)
+		}
+	}
+	if _, ok := p.at(published - retention - 1); ok {
+		t.Errorf("evicted record %d is still readable", published-retention-1)
+	}
+
+	err := b.SetGroupOffset("blocks", "g", 0, 0)
+	if !errors.Is(err, ErrOffsetOutOfRange) {
+		t.Errorf("SetGroupOffset to evicted offset = %v, want ErrOffsetOutOfRange", err)
+	}
+}
+
+func TestKeyHashPartitioning(t *testing.T) {
+	const partitions = 4
+	b := NewBroker(0)
+	b.CreateTopic("blocks", partitions)
+
+	for _, key := range []string{"0xabc", "0xdef", "ethereum", "1"} {
+		h := fnv.New32a()
+		h.Write([]byte(key))
+		want := int(h.Sum32() % partitions)
+
+		for i := 0; i < 3; i++ {
+			idx, _ := b.Publish(models.MessageBroker{Topic: "blocks", Key: []byte(key), Value: []byte("v")})
+			if idx != want {
+				t.Errorf("key %q published to partition %d, want %d", key, idx, want)
+			}
+		}
+	}
+
+	// Сообщения без ключа распределяются по кругу
+	for i := 0; i < 2*partitions; i++ {
+		if idx, _ := b.Publish(models.MessageBroker{Topic: "blocks", Value: []byte("v")}); idx != i%partitions {
+			t.Errorf("message %d without key published to partition %d, want %d", i, idx, i%partitions)
+		}
+	}
+}
+
+func TestGroupClaimsEachPartitionOnce(t *testing.T) {
+	b := NewBroker(0)
+	b.CreateTopic("blocks", 2)
+	b.topics["blocks"].partitions[0].records = []record{{offset: 0}, {offset: 1}}
+	b.topics["blocks"].partitions[1].records = []record{{offset: 0}}
+
+	// Участники группы получают разные партиции; занятая партиция не выдаётся
+	recA, idxA, ok, _ := b.claim("blocks", "g", false, 0)
+	if !ok || idxA != 0 || recA.offset != 0 {
+		t.Fatalf("first member claimed %d@%d (%v), want 0@0", idxA, recA.offset, ok)
+	}
+	recB, idxB, ok, _ := b.claim("blocks", "g", false, 0)
+	if !ok || idxB != 1 || recB.offset != 0 {
+		t.Fatalf("second member claimed %d@%d (%v), want 1@0", idxB, recB.offset, ok)
+	}
+	if _, idx, ok, _ := b.claim("blocks", "g", false, 0); ok {
+		t.Fatalf("third member claimed busy partition %d", idx)
+	}
+
+	// Другая группа читает те же партиции независимо
+	if _, idx, ok, _ := b.claim("blocks", "other", false, 0); !ok || idx != 0 {
+		t.Errorf("other group claimed %d (%v), want partition 0", idx, ok)
+	}
+
+	// Без коммита запись выдаётся повторно, после коммита — следующая
+	b.release("blocks", "g", 0, 0, false)
+	if rec, idx, ok, _ := b.claim("blocks", "g", false, 0); !ok || idx != 0 || rec.offset != 0 {
+		t.Fatalf("after failed handling claimed %d@%d (%v), want 0@0 again", idx, rec.offset, ok)
+	}
+	b.release("blocks", "g", 0, 0, true)
+	if rec, idx, ok, _ := b.claim("blocks", "g", false, 0); !ok || idx != 0 || rec.offset != 1 {
+		t.Fatalf("after commit claimed %d@%d (%v), want 0@1", idx, rec.offset, ok)
+	}
+}
+
+func TestReleaseWakesWaitingGroupMember(t *testing.T) {
+	b := NewBroker(0)
+	b.Publish(models.MessageBroker{Topic: "blocks", Value: []byte("1")})
+	b.Publish(models.MessageBroker{Topic: "blocks", Value: []byte("2")})
+
+	rec, idx, ok, _ := b.claim("blocks", "g", false, 0)
+	if !ok {
+		t.Fatal("first member claimed nothing")
+	}
+
+	// Единственная партиция занята: второй участник ждёт уведомления
+	_, _, ok, wait := b.claim("blocks", "g", false, 0)
+	if ok || wait == nil {
+		t.Fatal("second member claimed a busy partition")
+	}
+	select {
+	case <-wait:
+		t.Fatal("waiting member woken before the partition was released")
+	default:
+	}
+
+	b.release("blocks", "g", idx, rec.offset, true)
+	select {
+	case <-wait:
+	default:
+		t.Fatal("release did not wake the waiting member")
+	}
+	if rec, _, ok, _ := b.claim("blocks", "g", false, 0); !ok || string(rec.value) != "2" {
+		t.Errorf("woken member claimed %q (%v), want the next record", rec.value, ok)
+	}
+}
+
+func TestGroupMembersShareTopic(t *testing.T) {
+	b := NewBroker(0)
+	b.CreateTopic("blocks", 3)
+
+	var members [2]received
+	for i := range members {
+		c := NewClient(b, models.Broker{}, logging.GetLogger())
+		t.Cleanup(func() { _ = c.Close() })
+		if err := c.SubscribeWithGroup(context.Background(), "blocks", "g", members[i].handler); err != nil {
+			t.Fatalf("subscribe: %v", err)
+		}
+	}
+
+	const published = 30
+	for i := 0; i < published; i++ {
+		b.Publish(models.MessageBroker{Topic: "blocks", Key: []byte(fmt.Sprint(i % 5)), Value: []byte(fmt.Sprint(i))})
+	}
+	eventually(t, "all messages", func() bool { return len(members[0].get())+len(members[1].get()) == published })
+
+	// Каждое сообщение обработано одним участником, порядок внутри партиции сохранён
+	seen := make(map[string]bool)
+	last := make(map[int]int64)
+	all := append(members[0].get(), members[1].get()...)
+	for _, msg := range all {
+		if seen[string(msg.Value)] {
+			t.Errorf("message %s delivered twice", msg.Value)
+		}
+		seen[string(msg.Value)] = true
+	}
+	for i := range members {
+		for _, msg := range members[i].get() {
+			if prev, ok := last[msg.Partition]; ok && msg.Offset <= prev {
+				t.Errorf("partition %d: offset %d after %d", msg.Partition, msg.Offset, prev)
+			}
+			last[msg.Partition] = msg.Offset
+		}
+		clear(last)
+	}
+	if lag := b.GroupLag("blocks", "g"); lag != 0 {
+		t.Errorf("group lag = %d, want 0", lag)
+	}
+}
+
+func TestSubscribeFromOffsetReplaysPartition(t *testing.T) {
+	b := NewBroker(0)
+	b.CreateTopic("blocks", 2)
+	var partition int
+	for i := 0; i < 6; i++ {
+		partition, _ = b.Publish(models.MessageBroker{Topic: "blocks", Key: []byte("a"), Value: []byte(fmt.Sprint(i))})
+	}
+	// Записи другой партиции в повтор не попадают
+	for _, key := range []string{"b", "d", "f", "h"} {
+		if idx, _ := b.Publish(models.MessageBroker{Topic: "blocks", Key: []byte(key), Value: []byte("other")}); idx == partition {
+			t.Fatalf("key %q shares partition %d with key a", key, idx)
+		}
+	}
+
+	c := NewClient(b, models.Broker{}, logging.GetLogger())
+	t.Cleanup(func() { _ = c.Close() })
+
+	ctx := context.Background()
+	if err := c.SubscribeFromOffset(ctx, "blocks", partition, 7, nil); !errors.Is(err, ErrOffsetOutOfRange) {
+		t.Errorf("subscribe past the end = %v, want ErrOffsetOutOfRange", err)
+	}
+	if err := c.SubscribeFromOffset(ctx, "blocks", 2, 0, nil); !errors.Is(err, ErrUnknownPartition) {
+		t.Errorf("subscribe to missing partition = %v, want ErrUnknownPartition", err)
+	}
+
+	var got received
+	if err := c.SubscribeFromOffset(ctx, "blocks", partition, 3, got.handler); err != nil {
+		t.Fatalf("subscribe from offset: %v", err)
+	}
+	eventually(t, "replayed messages", func() bool { return len(got.get()) == 3 })
+
+	// Подписка на новые записи той же партиции продолжается
+	b.Publish(models.MessageBroker{Topic: "blocks", Key: []byte("a"), Value: []byte("6")})
+	eventually(t, "new message", func() bool { return len(got.get()) == 4 })
+
+	for i, msg := range got.get() {
+		if msg.Partition != partition || msg.Offset != int64(3+i) || string(msg.Value) != fmt.Sprint(3+i) {
+			t.Errorf("message %d = %s/%d@%d, want %d/%d@%d", i, msg.Value, msg.Partition, msg.Offset, 3+i, partition, 3+i)
+		}
+	}
+}
+
+func TestDefaultRejectsOtherRetention(t *testing.T) {
+	first, err := Default(0)
+	if err != nil {
+		t.Fatalf("default: %v", err)
+	}
+	if again, err := Default(defaultRetention); err != nil || again != first {
+		t.Errorf("default with the same retention = %p, %v, want the shared broker", again, err)
+	}
+	if _, err := Default(defaultRetention + 1); !errors.Is(err, ErrRetentionChanged) {
+		t.Errorf("default with another retention = %v, want ErrRetentionChanged", err)
+	}
+}
@@ -0,0 +1,240 @@
+package memoryBroker
+
+import (
+	"context"
+	"fmt"
+	"lib/clients/broker"
+	"lib/models"
+	"lib/utils/logging"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	retryDelay   = 500 * time.Millisecond
+	pollInterval = time.Second
+)
+
+// Client реализация BrokerClient поверх in-process Broker.
+// Close останавливает только подписки этого клиента, сам Broker продолжает работать.
+type Client struct {
+	broker *Broker
+	config models.Broker
+	logger *logging.Logger
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+	closed atomic.Bool
+}
+
+// NewClient создает клиента in-process брокера
+func NewClient(b *Broker, cfg models.Broker, logger *logging.Logger) *Client {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Client{
+		broker: b,
+		config: cfg,
+		logger: logger,
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+var (
+	_ broker.BrokerClient = (*Client)(nil)
+	_ broker.LagReporter  = (*Client)(nil)
+)
+
+// Broker возвращает брокер, с которым работает клиент
+func (c *Client) Broker() *Broker {
+	return c.broker
+}
+
+// SendMessage отправляет одно сообщение
+func (c *Client) SendMessage(ctx context.Context, msg models.MessageBroker) error {
+	if c.closed.Load() {
+		return ErrClosed
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	c.broker.Publish(msg)
+	return nil
+}
+
+// SendMessages отправляет несколько сообщений, топики могут различаться
+func (c *Client) SendMessages(ctx context.Context, msgs []models.MessageBroker) error {
+	for _, msg := range msgs {
+		if err := c.SendMessage(ctx, msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Subscribe подписывается на топик без consumer group; ошибки обработчика
+// логируются, сообщение не доставляется повторно
+func (c *Client) Subscribe(ctx context.Context, topic string, handler models.MessageHandlerBroker) error {
+	if c.closed.Load() {
+		return ErrClosed
+	}
+
+	cursors := c.broker.cursors(topic, c.fromLatest())
+	c.startLoop(ctx, func(ctx context.Context) {
+		c.readLoop(ctx, topic, cursors, handler)
+	})
+	return nil
+}
+
+// SubscribeFromOffset перечитывает партицию топика начиная с offset без consumer group
+func (c *Client) SubscribeFromOffset(ctx context.Context, topic string, partition int, offset int64, handler models.MessageHandlerBroker) error {
+	if c.closed.Load() {
+		return ErrClosed
+	}
+
+	first, next, err := c.broker.Offsets(topic, partition)
+	if err != nil {
+		return err
+	}
+	if offset < first || offset > next {
+		return fmt.Errorf("%w: %s/%d offset %d (available %d-%d)", ErrOffsetOutOfRange, topic, partition, offset, first, next)
+	}
+
+	// Читаем только выбранную партицию
+	cursors := make([]int64, c.broker.Partitions(topic))
+	for i := range cursors {
+		cursors[i] = -1
+	}
+	cursors[partition] = offset
+
+	c.startLoop(ctx, func(ctx context.Context) {
+		c.readLoop(ctx, topic, cursors, handler)
+	})
+	return nil
+}
+
+// SubscribeWithGroup подписывается на топик с consumer group. Offset группы
+// сдвигается только после успешной обработки; при ошибке сообщение доставляется повторно.
+func (c *Client) SubscribeWithGroup(ctx context.Context, topic, groupID string, handler models.MessageHandlerBroker) error {
+	if c.closed.Load() {
+		return ErrClosed
+	}
+
+	c.startLoop(ctx, func(ctx context.Context) {
+		c.groupLoop(ctx, topic, groupID, handler)
+	})
+	return nil
+}
+
+// CreateTopic создает топик; replicationFactor не имеет смысла в памяти и игнорируется
+func (c *Client) CreateTopic(ctx context.Context, topic string, partitions, replicationFactor int) error {
+	if c.closed.Load() {
+		return ErrClosed
+	}
+
+	c.broker.CreateTopic(topic, partitions)
+	return nil
+}
+
+// HealthCheck проверяет, что клиент не закрыт
+func (c *Client) HealthCheck(ctx context.Context) error {
+	if c.closed.Load() {
+		return ErrClosed
+	}
+	return nil
+}
+
+// ConsumerLag возвращает число непрочитанных группой сообщений топика
+func (c *Client) ConsumerLag(ctx context.Context, topic, groupID string) (int64, error) {
+	if c.closed.Load() {
+		return 0, ErrClosed
+	}
+	return c.broker.GroupLag(topic, groupID), nil
+}
+
+// Close останавливает подписки клиента и дожидается их завершения
+func (c *Client) Close() error {
+	if c.closed.Swap(true) {
+		return nil
+	}
+	c.cancel()
+	c.wg.Wait()
+	return nil
+}
+
+// Вспомогательные методы
+
+func (c *Client) fromLatest() bool {
+	return c.config.StartOffset == -1
+}
+
+// startLoop запускает цикл, который завершается при отмене ctx или при Close
+func (c *Client) startLoop(ctx context.Context, loop func(ctx context.Context)) {
+	loopCtx, cancel := context.WithCancel(ctx)
+
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		defer cancel()
+
+		stop := context.AfterFunc(c.ctx, cancel)
+		defer stop()
+
+		loop(loopCtx)
+	}()
+}
+
+func (c *Client) groupLoop(ctx context.Context, topic, groupID string, handler models.MessageHandlerBroker) {
+	start := 0
+	for ctx.Err() == nil {
+		rec, idx, ok, wait := c.broker.claim(topic, groupID, c.fromLatest(), start)
+		if !ok {
+			c.wait(ctx, wait)
+			continue
+		}
+		start = idx + 1
+
+		err := handler(ctx, rec.message(topic, idx))
+		c.broker.release(topic, groupID, idx, rec.offset, err == nil)
+
+		if err != nil {
+			c.logger.Errorf("Error handling message %s/%d@%d (group %s): %v", topic, idx, rec.offset, groupID, err)
+			c.sleep(ctx, retryDelay)
+		}
+	}
+}
+
+func (c *Client) readLoop(ctx context.Context, topic string, cursors []int64, handler models.MessageHandlerBroker) {
+	start := 0
+	for ctx.Err() == nil {
+		rec, idx, ok, wait := c.broker.fetch(topic, cursors, start)
+		if !ok {
+			c.wait(ctx, wait)
+			continue
+		}
+		start = idx + 1
+
+		if err := handler(ctx, rec.message(topic, idx)); err != nil {
+			c.logger.Errorf("Error handling message %s/%d@%d: %v", topic, idx, rec.offset, err)
+		}
+	}
+}
+
+// wait ждёт новой записи или освобождения партиции другим участником группы;
+// периодический опрос подстраховывает пропущенное уведомление
+func (c *Client) wait(ctx context.Context, notify <-chan struct{}) {
+	select {
+	case <-ctx.Done():
+	case <-notify:
+	case <-time.After(pollInterval):
+	}
+}
+
+func (c *Client) sleep(ctx context.Context, d time.Duration) {
+	select {
+	case <-ctx.Done():
+	case <-time.After(d):
+	}
+}
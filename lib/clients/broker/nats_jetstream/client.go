@@ -0,0 +1,468 @@
+package natsJetStream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"lib/clients/broker"
+	"lib/models"
+	"lib/utils/logging"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// keyHeader — заголовок, в котором передаётся models.MessageBroker.Key
+const keyHeader = "BlockHub-Key"
+
+const (
+	defaultAckWait   = 30 * time.Second
+	defaultFetchSize = 10
+)
+
+// JetStreamBroker реализация Broker для NATS JetStream.
+// Топик соответствует subject, для каждого топика создаётся отдельный стрим.
+type JetStreamBroker struct {
+	config models.Broker
+	conn   *nats.Conn
+	js     jetstream.JetStream
+	logger *logging.Logger
+	retry  broker.RetryPolicy
+	dlq    *broker.DeadLetterQueue // nil — DLQ выключен
+
+	// stalled — причина остановки подписки на сообщении, которое исчерпало
+	// доставки без DLQ; HealthCheck возвращает её
+	stalled atomic.Pointer[error]
+
+	mu       sync.Mutex
+	streams  map[string]bool
+	consumes []jetstream.ConsumeContext
+}
+
+// NewJetStreamBroker подключается к NATS по адресам из cfg.Brokers.
+// Соединение переподключается в фоне, поэтому недоступный сервер не является ошибкой.
+func NewJetStreamBroker(cfg models.Broker, logger *logging.Logger) (broker.BrokerClient, error) {
+	url := nats.DefaultURL
+	if len(cfg.Brokers) > 0 {
+		url = strings.Join(cfg.Brokers, ",")
+	}
+
+	opts := []nats.Option{
+		nats.Name("blockhub"),
+		nats.RetryOnFailedConnect(true),
+		nats.MaxReconnects(-1),
+	}
+	switch {
+	case cfg.Username != "":
+		opts = append(opts, nats.UserInfo(cfg.Username, cfg.Password))
+	case cfg.Password != "":
+		opts = append(opts, nats.Token(cfg.Password))
+	}
+
+	conn, err := nats.Connect(url, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to nats %s: %w", url, err)
+	}
+
+	return NewJetStreamBrokerFromConn(conn, cfg, logger)
+}
+
+// NewJetStreamBrokerFromConn создаёт брокер поверх готового соединения,
+// например, к встроенному серверу (nats.InProcessServer)
+func NewJetStreamBrokerFromConn(conn *nats.Conn, cfg models.Broker, logger *logging.Logger) (broker.BrokerClient, error) {
+	js, err := jetstream.New(conn)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to init jetstream: %w", err)
+	}
+
+	j := &JetStreamBroker{
+		config:  cfg,
+		conn:    conn,
+		js:      js,
+		logger:  logger,
+		retry:   broker.NewRetryPolicy(cfg),
+		streams: make(map[string]bool),
+	}
+	if cfg.DeadLetter {
+		j.dlq = broker.NewDeadLetterQueue(j, j.retry, logger)
+	}
+	return j, nil
+}
+
+// SendMessage отправляет одно сообщение и дожидается подтверждения стрима
+func (j *JetStreamBroker) SendMessage(ctx context.Context, msg models.MessageBroker) error {
+	if err := j.ensureStream(ctx, msg.Topic); err != nil {
+		return err
+	}
+
+	_, err := j.js.PublishMsg(ctx, toNatsMsg(msg))
+	return err
+}
+
+// SendMessages отправляет несколько сообщений асинхронно и ждёт подтверждения всех
+func (j *JetStreamBroker) SendMessages(ctx context.Context, msgs []models.MessageBroker) error {
+	if len(msgs) == 0 {
+		return nil
+	}
+
+	futures := make([]jetstream.PubAckFuture, 0, len(msgs))
+	for _, msg := range msgs {
+		if err := j.ensureStream(ctx, msg.Topic); err != nil {
+			return err
+		}
+		future, err := j.js.PublishMsgAsync(toNatsMsg(msg))
+		if err != nil {
+			return err
+		}
+		futures = append(futures, future)
+	}
+
+	var errs []error
+	for _, future := range futures {
+		select {
+		case <-future.Ok():
+		case err := <-future.Err():
+			errs = append(errs, err)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to publish %d of %d messages: %w", len(errs), len(msgs), errors.Join(errs...))
+	}
+	return nil
+}
+
+// Subscribe подписывается на топик без durable consumer: читает только новые
+// сообщения через ordered consumer, подтверждения не требуются
+func (j *JetStreamBroker) Subscribe(ctx context.Context, topic string, handler models.MessageHandlerBroker) error {
+	if err := j.ensureStream(ctx, topic); err != nil {
+		return err
+	}
+
+	consumer, err := j.js.OrderedConsumer(ctx, streamName(topic), jetstream.OrderedConsumerConfig{
+		FilterSubjects: []string{topic},
+		DeliverPolicy:  jetstream.DeliverNewPolicy,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create ordered consumer for %s: %w", topic, err)
+	}
+
+	return j.consume(ctx, consumer, topic, handler, false)
+}
+
+// SubscribeWithGroup подписывается на топик через durable consumer с именем groupID.
+// Сообщение подтверждается только после успешной обработки, при ошибке
+// отправляется Nak с задержкой по политике повторов. Исчерпавшее MaxDeliveries
+// сообщение уходит в DLQ, а без DLQ подписка останавливается.
+func (j *JetStreamBroker) SubscribeWithGroup(ctx context.Context, topic, groupID string, handler models.MessageHandlerBroker) error {
+	if err := j.ensureStream(ctx, topic); err != nil {
+		return err
+	}
+
+	deliver := jetstream.DeliverAllPolicy
+	if j.config.StartOffset == -1 {
+		deliver = jetstream.DeliverNewPolicy
+	}
+
+	// Задержки между доставками задаёт NakWithDelay: BackOff консьюмера заменил бы
+	// AckWait и отдал бы долго обрабатываемое сообщение повторно. MaxDeliver —
+	// страховка для консьюмера, который падает на сообщении: доставка сверх
+	// лимита уходит в DLQ без вызова обработчика. Без DLQ сообщение терять нельзя,
+	// поэтому JetStream доставляет его без ограничения.
+	maxDeliver := -1
+	if limit := broker.MaxDeliveries(j.config); limit > 0 && j.dlq != nil {
+		maxDeliver = limit + 1
+	}
+
+	consumer, err := j.js.CreateOrUpdateConsumer(ctx, streamName(topic), jetstream.ConsumerConfig{
+		Durable:       sanitizeName(groupID),
+		FilterSubject: topic,
+		AckPolicy:     jetstream.AckExplicitPolicy,
+		AckWait:       j.ackWait(),
+		MaxDeliver:    maxDeliver,
+		DeliverPolicy: deliver,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create durable consumer %s for %s: %w", groupID, topic, err)
+	}
+
+	return j.consume(ctx, consumer, topic, handler, true)
+}
+
+// CreateTopic создает стрим для топика. Партиции в JetStream не используются,
+// replicationFactor задаёт число реплик стрима.
+func (j *JetStreamBroker) CreateTopic(ctx context.Context, topic string, partitions, replicationFactor int) error {
+	cfg := j.streamConfig(topic)
+	if replicationFactor > 0 {
+		cfg.Replicas = replicationFactor
+	}
+
+	if _, err := j.js.CreateOrUpdateStream(ctx, cfg); err != nil {
+		return fmt.Errorf("failed to create stream for %s: %w", topic, err)
+	}
+
+	j.mu.Lock()
+	j.streams[topic] = true
+	j.mu.Unlock()
+	return nil
+}
+
+// HealthCheck проверяет доступность JetStream и то, что подписки не остановлены
+func (j *JetStreamBroker) HealthCheck(ctx context.Context) error {
+	if stalled := j.stalled.Load(); stalled != nil {
+		return *stalled
+	}
+	_, err := j.js.AccountInfo(ctx)
+	return err
+}
+
+// ConsumerLag возвращает число сообщений, ещё не доставленных durable
+// consumer'у groupID, плюс доставленные, но не подтверждённые
+func (j *JetStreamBroker) ConsumerLag(ctx context.Context, topic, groupID string) (int64, error) {
+	consumer, err := j.js.Consumer(ctx, streamName(topic), sanitizeName(groupID))
+	if err != nil {
+		return 0, fmt.Errorf("failed to get consumer %s for %s: %w", groupID, topic, err)
+	}
+	info, err := consumer.Info(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get consumer %s info: %w", groupID, err)
+	}
+	return int64(info.NumPending) + int64(info.NumAckPending), nil
+}
+
+// Close останавливает подписки, дожидается отправки буфера и закрывает соединение
+func (j *JetStreamBroker) Close() error {
+	j.mu.Lock()
+	consumes := j.consumes
+	j.consumes = nil
+	j.mu.Unlock()
+
+	for _, cc := range consumes {
+		cc.Stop()
+		<-cc.Closed()
+	}
+
+	err := j.conn.Flush()
+	j.conn.Close()
+	if err != nil && !errors.Is(err, nats.ErrConnectionClosed) {
+		return fmt.Errorf("error closing nats connection: %w", err)
+	}
+	return nil
+}
+
+// Вспомогательные методы
+
+func (j *JetStreamBroker) consume(ctx context.Context, consumer jetstream.Consumer, topic string, handler models.MessageHandlerBroker, ack bool) error {
+	var cc jetstream.ConsumeContext
+	var stopped atomic.Bool
+	cc, err := consumer.Consume(func(m jetstream.Msg) {
+		msg := fromNatsMsg(topic, m)
+		if !ack {
+			if err := handler(ctx, msg); err != nil {
+				j.logger.Errorf("Error handling message from %s: %v", topic, err)
+			}
+			return
+		}
+		// После остановки подписки уже выданные сообщения не обрабатываются
+		// и доставляются повторно по истечении AckWait
+		if stopped.Load() {
+			return
+		}
+		if !j.handle(ctx, m, msg, handler) {
+			stopped.Store(true)
+			cc.Stop()
+		}
+	},
+		jetstream.PullMaxMessages(j.fetchSize()),
+		jetstream.ConsumeErrHandler(func(_ jetstream.ConsumeContext, err error) {
+			j.logger.Warnf("Consumer error on %s: %v", topic, err)
+		}),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to start consuming %s: %w", topic, err)
+	}
+
+	j.mu.Lock()
+	j.consumes = append(j.consumes, cc)
+	j.mu.Unlock()
+
+	// Останавливаем подписку при отмене контекста вызывающей стороны
+	go func() {
+		select {
+		case <-ctx.Done():
+			cc.Stop()
+		case <-cc.Closed():
+		}
+	}()
+	return nil
+}
+
+// handle обрабатывает сообщение durable consumer'а и подтверждает его. После ошибки
+// отправляет Nak с задержкой по политике повторов; когда доставки исчерпаны,
+// отправляет сообщение в DLQ, а без DLQ возвращает false — подписку нужно остановить.
+func (j *JetStreamBroker) handle(ctx context.Context, m jetstream.Msg, msg models.MessageBroker, handler models.MessageHandlerBroker) bool {
+	deliveries := 1
+	if meta, err := m.Metadata(); err == nil {
+		deliveries = int(meta.NumDelivered)
+	}
+
+	limit := broker.MaxDeliveries(j.config)
+	if limit > 0 && deliveries > limit {
+		// Сообщение уже исчерпало доставки (консьюмер падал на нём)
+		return j.giveUp(ctx, m, msg, fmt.Errorf("delivered %d times without acknowledgement", deliveries), deliveries-1)
+	}
+
+	err := handler(ctx, msg)
+	if err == nil {
+		if err := m.Ack(); err != nil {
+			j.logger.Errorf("Error acknowledging message from %s: %v", msg.Topic, err)
+		}
+		return true
+	}
+
+	j.logger.Errorf("Error handling message %d from %s (delivery %d): %v", msg.Offset, msg.Topic, deliveries, err)
+	if ctx.Err() == nil && (broker.IsPermanent(err) || (limit > 0 && deliveries >= limit)) {
+		return j.giveUp(ctx, m, msg, err, deliveries)
+	}
+	j.nak(m, msg, deliveries)
+	return true
+}
+
+// giveUp отправляет необработанное сообщение в DLQ и подтверждает его;
+// без DLQ фиксирует остановку подписки, не подтверждая сообщение
+func (j *JetStreamBroker) giveUp(ctx context.Context, m jetstream.Msg, msg models.MessageBroker, cause error, deliveries int) bool {
+	if j.dlq == nil {
+		err := fmt.Errorf("message %d from %s failed after %d deliveries and dead letter queue is disabled: %w",
+			msg.Offset, msg.Topic, deliveries, cause)
+		j.logger.Errorf("Stopping consumer, message left unacknowledged: %v", err)
+		j.stalled.Store(&err)
+		return false
+	}
+
+	if err := j.dlq.Send(ctx, msg, cause, deliveries); err != nil {
+		j.logger.Errorf("Message %d from %s left unacknowledged: %v", msg.Offset, msg.Topic, err)
+		j.nak(m, msg, deliveries)
+		return true
+	}
+	if err := m.Ack(); err != nil {
+		j.logger.Errorf("Error acknowledging dead-lettered message from %s: %v", msg.Topic, err)
+	}
+	return true
+}
+
+// nak возвращает сообщение на повторную доставку через задержку политики повторов
+func (j *JetStreamBroker) nak(m jetstream.Msg, msg models.MessageBroker, deliveries int) {
+	if err := m.NakWithDelay(j.retry.Backoff(deliveries + 1)); err != nil {
+		j.logger.Errorf("Error sending nak for message from %s: %v", msg.Topic, err)
+	}
+}
+
+// ensureStream лениво создаёт стрим для топика при первом обращении
+func (j *JetStreamBroker) ensureStream(ctx context.Context, topic string) error {
+	j.mu.Lock()
+	exists := j.streams[topic]
+	j.mu.Unlock()
+
+	if exists {
+		return nil
+	}
+	return j.CreateTopic(ctx, topic, 0, 0)
+}
+
+func (j *JetStreamBroker) streamConfig(topic string) jetstream.StreamConfig {
+	cfg := jetstream.StreamConfig{
+		Name:      streamName(topic),
+		Subjects:  []string{topic},
+		MaxMsgs:   j.config.StreamMaxLen,
+		MaxAge:    j.config.StreamMaxAge,
+		MaxBytes:  j.config.StreamMaxBytes,
+		Retention: jetstream.LimitsPolicy,
+		Storage:   jetstream.FileStorage,
+	}
+
+	if cfg.MaxMsgs == 0 {
+		cfg.MaxMsgs = -1
+	}
+	if cfg.MaxBytes == 0 {
+		cfg.MaxBytes = -1
+	}
+
+	switch strings.ToLower(j.config.StreamRetention) {
+	case "interest":
+		cfg.Retention = jetstream.InterestPolicy
+	case "workqueue":
+		cfg.Retention = jetstream.WorkQueuePolicy
+	}
+	if strings.ToLower(j.config.StreamStorage) == "memory" {
+		cfg.Storage = jetstream.MemoryStorage
+	}
+	return cfg
+}
+
+func (j *JetStreamBroker) ackWait() time.Duration {
+	if j.config.ClaimMinIdle > 0 {
+		return j.config.ClaimMinIdle
+	}
+	return defaultAckWait
+}
+
+func (j *JetStreamBroker) fetchSize() int {
+	if j.config.StreamReadCount > 0 {
+		return j.config.StreamReadCount
+	}
+	return defaultFetchSize
+}
+
+// streamName строит имя стрима из топика: имена стримов не могут содержать '.', '*', '>'
+func streamName(topic string) string {
+	return sanitizeName(topic)
+}
+
+func sanitizeName(name string) string {
+	return strings.NewReplacer(".", "_", "*", "_", ">", "_", " ", "_").Replace(name)
+}
+
+func toNatsMsg(msg models.MessageBroker) *nats.Msg {
+	m := nats.NewMsg(msg.Topic)
+	m.Data = msg.Value
+
+	// Конвертируем headers
+	for key, value := range msg.Headers {
+		m.Header.Set(key, value)
+	}
+	if len(msg.Key) > 0 {
+		m.Header.Set(keyHeader, string(msg.Key))
+	}
+	return m
+}
+
+func fromNatsMsg(topic string, m jetstream.Msg) models.MessageBroker {
+	msg := models.MessageBroker{
+		Value:   m.Data(),
+		Topic:   topic,
+		Headers: make(map[string]string),
+	}
+	if meta, err := m.Metadata(); err == nil {
+		msg.Offset = int64(meta.Sequence.Stream)
+	}
+
+	// Конвертируем headers
+	for key, values := range m.Headers() {
+		if len(values) == 0 {
+			continue
+		}
+		if key == keyHeader {
+			msg.Key = []byte(values[0])
+			continue
+		}
+		msg.Headers[key] = values[0]
+	}
+	return msg
+}
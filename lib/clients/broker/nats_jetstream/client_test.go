@@ -0,0 +1,272 @@
+package natsJetStream
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"lib/clients/broker"
+	"lib/models"
+	"lib/utils/logging"
+
+	"github.com/nats-io/nats-server/v2/server"
+	natsTest "github.com/nats-io/nats-server/v2/test"
+	"github.com/nats-io/nats.go"
+)
+
+// runJetStream запускает встроенный сервер NATS с JetStream и брокер поверх него
+func runJetStream(t *testing.T, cfg models.Broker) *JetStreamBroker {
+	t.Helper()
+
+	opts := natsTest.DefaultTestOptions
+	opts.Port = server.RANDOM_PORT
+	opts.JetStream = true
+	opts.StoreDir = t.TempDir()
+	srv := natsTest.RunServer(&opts)
+	t.Cleanup(srv.Shutdown)
+
+	conn, err := nats.Connect(srv.ClientURL())
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+
+	cfg.StreamStorage = "memory"
+	cfg.ClaimMinIdle = time.Second
+	client, err := NewJetStreamBrokerFromConn(conn, cfg, logging.GetLogger())
+	if err != nil {
+		t.Fatalf("new broker: %v", err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+	return client.(*JetStreamBroker)
+}
+
+// deliveries собирает доставленные сообщения по значению
+type deliveries struct {
+	mu   sync.Mutex
+	seen map[string][]models.MessageBroker
+}
+
+func (d *deliveries) add(msg models.MessageBroker) int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.seen == nil {
+		d.seen = make(map[string][]models.MessageBroker)
+	}
+	d.seen[string(msg.Value)] = append(d.seen[string(msg.Value)], msg)
+	return len(d.seen[string(msg.Value)])
+}
+
+func (d *deliveries) get(value string) []models.MessageBroker {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]models.MessageBroker(nil), d.seen[value]...)
+}
+
+func eventually(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestJetStreamDurableSubscribeAckRedelivery(t *testing.T) {
+	const topic, group = "blocks", "clickhouse-service"
+	b := runJetStream(t, models.Broker{})
+
+	var got deliveries
+	ctx, cancel := context.WithCancel(context.Background())
+	handler := func(_ context.Context, msg models.MessageBroker) error {
+		// Первая доставка "2" завершается ошибкой: сообщение получает Nak
+		if got.add(msg) == 1 && string(msg.Value) == "2" {
+			return errors.New("temporary failure")
+		}
+		return nil
+	}
+	if err := b.SubscribeWithGroup(ctx, topic, group, handler); err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+
+	msgs := []models.MessageBroker{
+		{Topic: topic, Key: []byte("k1"), Value: []byte("1"), Headers: map[string]string{"network": "eth"}},
+		{Topic: topic, Key: []byte("k2"), Value: []byte("2")},
+		{Topic: topic, Key: []byte("k3"), Value: []byte("3")},
+	}
+	if err := b.SendMessages(ctx, msgs); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+
+	eventually(t, "all messages", func() bool {
+		return len(got.get("1")) > 0 && len(got.get("2")) >= 2 && len(got.get("3")) > 0
+	})
+
+	first := got.get("1")[0]
+	if string(first.Key) != "k1" || first.Headers["network"] != "eth" || first.Offset != 1 {
+		t.Errorf("message 1 = key %q headers %v offset %d", first.Key, first.Headers, first.Offset)
+	}
+	if redelivered := got.get("2"); redelivered[0].Offset != redelivered[1].Offset {
+		t.Errorf("redelivered message offset %d, want %d", redelivered[1].Offset, redelivered[0].Offset)
+	}
+
+	// Все сообщения подтверждены
+	eventually(t, "acks", func() bool {
+		lag, err := b.ConsumerLag(context.Background(), topic, group)
+		return err == nil && lag == 0
+	})
+	for _, value := range []string{"1", "3"} {
+		if n := len(got.get(value)); n != 1 {
+			t.Errorf("message %s delivered %d times, want 1", value, n)
+		}
+	}
+
+	// Durable consumer продолжает с последнего подтверждённого сообщения
+	cancel()
+	if err := b.SendMessage(context.Background(), models.MessageBroker{Topic: topic, Value: []byte("4")}); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+
+	var resumed deliveries
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	err := b.SubscribeWithGroup(ctx, topic, group, func(_ context.Context, msg models.MessageBroker) error {
+		resumed.add(msg)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("resubscribe: %v", err)
+	}
+
+	eventually(t, "message after resubscribe", func() bool { return len(resumed.get("4")) > 0 })
+	for _, value := range []string{"1", "2", "3"} {
+		if n := len(resumed.get(value)); n != 0 {
+			t.Errorf("acknowledged message %s delivered again after resubscribe", value)
+		}
+	}
+}
+
+func TestJetStreamDelaysRedeliveryThenDeadLetters(t *testing.T) {
+	const topic, group = "blocks", "clickhouse-service"
+	const backoff = 200 * time.Millisecond
+	b := runJetStream(t, models.Broker{
+		DeadLetter:             true,
+		MaxDeliveries:          3,
+		HandlerRetryBackoff:    backoff,
+		HandlerMaxRetryBackoff: backoff,
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var got deliveries
+	var mu sync.Mutex
+	var at []time.Time
+	failing := errors.New("clickhouse is down")
+	if err := b.SubscribeWithGroup(ctx, topic, group, func(_ context.Context, msg models.MessageBroker) error {
+		mu.Lock()
+		at = append(at, time.Now())
+		mu.Unlock()
+		got.add(msg)
+		return failing
+	}); err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+
+	var dead deliveries
+	if err := b.SubscribeWithGroup(ctx, broker.DLQTopic(topic), "dlq-reader", func(_ context.Context, msg models.MessageBroker) error {
+		dead.add(msg)
+		return nil
+	}); err != nil {
+		t.Fatalf("subscribe to dlq: %v", err)
+	}
+
+	if err := b.SendMessage(ctx, models.MessageBroker{Topic: topic, Value: []byte("poison")}); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+
+	eventually(t, "dead letter", func() bool { return len(dead.get("poison")) == 1 })
+	eventually(t, "ack of the dead-lettered message", func() bool {
+		lag, err := b.ConsumerLag(context.Background(), topic, group)
+		return err == nil && lag == 0
+	})
+
+	if n := len(got.get("poison")); n != 3 {
+		t.Errorf("poison message handled %d times, want 3 (max_deliveries)", n)
+	}
+	// Повторные доставки разнесены задержкой политики повторов
+	mu.Lock()
+	for i := 1; i < len(at); i++ {
+		if gap := at[i].Sub(at[i-1]); gap < backoff {
+			t.Errorf("delivery %d came %v after the previous one, want at least %v", i+1, gap, backoff)
+		}
+	}
+	mu.Unlock()
+
+	msg := dead.get("poison")[0]
+	if msg.Headers[broker.HeaderDLQAttempts] != "3" || msg.Headers[broker.HeaderDLQOriginalTopic] != topic ||
+		msg.Headers[broker.HeaderDLQError] != failing.Error() {
+		t.Errorf("dead letter headers = %v", msg.Headers)
+	}
+	if err := b.HealthCheck(ctx); err != nil {
+		t.Errorf("health check: %v", err)
+	}
+}
+
+func TestJetStreamStallsWithoutDeadLetter(t *testing.T) {
+	const topic, group = "blocks", "clickhouse-service"
+	b := runJetStream(t, models.Broker{
+		MaxDeliveries:          2,
+		HandlerRetryBackoff:    10 * time.Millisecond,
+		HandlerMaxRetryBackoff: 10 * time.Millisecond,
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var got deliveries
+	if err := b.SubscribeWithGroup(ctx, topic, group, func(_ context.Context, msg models.MessageBroker) error {
+		got.add(msg)
+		return errors.New("clickhouse is down")
+	}); err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+	if err := b.SendMessage(ctx, models.MessageBroker{Topic: topic, Value: []byte("block")}); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+
+	eventually(t, "stalled health check", func() bool { return b.HealthCheck(ctx) != nil })
+	if n := len(got.get("block")); n != 2 {
+		t.Errorf("message handled %d times, want 2", n)
+	}
+	if lag, err := b.ConsumerLag(ctx, topic, group); err != nil || lag != 1 {
+		t.Errorf("consumer lag = %d, %v, want the failed message left unacknowledged", lag, err)
+	}
+}
+
+func TestJetStreamUserInfo(t *testing.T) {
+	opts := natsTest.DefaultTestOptions
+	opts.Port = server.RANDOM_PORT
+	opts.JetStream = true
+	opts.StoreDir = t.TempDir()
+	opts.Username = "blockhub"
+	opts.Password = "secret"
+	srv := natsTest.RunServer(&opts)
+	defer srv.Shutdown()
+
+	cfg := models.Broker{Brokers: []string{srv.ClientURL()}, Username: "blockhub", Password: "secret"}
+	client, err := NewJetStreamBroker(cfg, logging.GetLogger())
+	if err != nil {
+		t.Fatalf("new broker: %v", err)
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.HealthCheck(ctx); err != nil {
+		t.Fatalf("health check with credentials: %v", err)
+	}
+}
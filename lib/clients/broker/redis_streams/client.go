@@ -0,0 +1,502 @@
+package redisStreams
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"lib/clients/broker"
+	"lib/models"
+	"lib/utils/logging"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Поля записи стрима, в которые раскладывается models.MessageBroker
+const (
+	fieldKey     = "key"
+	fieldValue   = "value"
+	headerPrefix = "header:"
+)
+
+const (
+	defaultReadCount    = 10
+	defaultBlockTimeout = 2 * time.Second
+	defaultClaimMinIdle = time.Minute
+	readErrorDelay      = time.Second
+
+	// Служебная группа, через которую CreateTopic создаёт пустой стрим
+	createTopicGroup = "blockhub-create-topic"
+)
+
+// RedisStreamsBroker реализация Broker поверх Redis Streams
+type RedisStreamsBroker struct {
+	config   models.Broker
+	client   *redis.Client
+	logger   *logging.Logger
+	consumer string
+	retry    broker.RetryPolicy
+	dlq      *broker.DeadLetterQueue // nil — DLQ выключен
+
+	// stalled — причина остановки подписки на сообщении, которое исчерпало
+	// доставки без DLQ; HealthCheck возвращает её
+	stalled atomic.Pointer[error]
+
+	wg     sync.WaitGroup
+	cancel context.CancelFunc
+	ctx    context.Context
+}
+
+// NewRedisStreamsBroker создает новый брокер на Redis Streams.
+// Адрес Redis берётся из первого элемента cfg.Brokers.
+func NewRedisStreamsBroker(cfg models.Broker, logger *logging.Logger) broker.BrokerClient {
+	addr := "localhost:6379"
+	if len(cfg.Brokers) > 0 {
+		addr = cfg.Brokers[0]
+	}
+
+	hostname, _ := os.Hostname()
+
+	return newBroker(redis.NewClient(&redis.Options{
+		Addr:     addr,
+		Password: cfg.Password,
+	}), cfg, fmt.Sprintf("%s-%d", hostname, os.Getpid()), logger)
+}
+
+func newBroker(client *redis.Client, cfg models.Broker, consumer string, logger *logging.Logger) *RedisStreamsBroker {
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &RedisStreamsBroker{
+		config:   cfg,
+		client:   client,
+		logger:   logger,
+		consumer: consumer,
+		retry:    broker.NewRetryPolicy(cfg),
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+	if cfg.DeadLetter {
+		r.dlq = broker.NewDeadLetterQueue(r, r.retry, logger)
+	}
+	return r
+}
+
+// SendMessage отправляет одно сообщение
+func (r *RedisStreamsBroker) SendMessage(ctx context.Context, msg models.MessageBroker) error {
+	return r.client.XAdd(ctx, r.xaddArgs(msg)).Err()
+}
+
+// SendMessages отправляет несколько сообщений одним пайплайном;
+// сообщения могут относиться к разным топикам
+func (r *RedisStreamsBroker) SendMessages(ctx context.Context, msgs []models.MessageBroker) error {
+	if len(msgs) == 0 {
+		return nil
+	}
+
+	_, err := r.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, msg := range msgs {
+			pipe.XAdd(ctx, r.xaddArgs(msg))
+		}
+		return nil
+	})
+	return err
+}
+
+// Subscribe подписывается на топик без consumer group: читает только новые
+// записи и не подтверждает их, как Kafka reader без GroupID
+func (r *RedisStreamsBroker) Subscribe(ctx context.Context, topic string, handler models.MessageHandlerBroker) error {
+	r.wg.Add(1)
+	go r.readLoop(r.loopContext(ctx), topic, handler)
+	return nil
+}
+
+// SubscribeWithGroup подписывается на топик с consumer group.
+// Сообщение подтверждается (XACK) только после успешной обработки. После ошибки
+// оно доставляется повторно (XCLAIM) с задержкой по политике повторов; сообщения
+// упавших консьюмеров забираются через XAUTOCLAIM. Исчерпавшее MaxDeliveries
+// сообщение уходит в DLQ, а без DLQ подписка останавливается.
+func (r *RedisStreamsBroker) SubscribeWithGroup(ctx context.Context, topic, groupID string, handler models.MessageHandlerBroker) error {
+	if err := r.createGroup(ctx, topic, groupID); err != nil {
+		return err
+	}
+
+	r.wg.Add(1)
+	go r.consumeLoop(r.loopContext(ctx), topic, groupID, handler)
+	return nil
+}
+
+// CreateTopic создает пустой стрим. Партиции и фактор репликации
+// в Redis Streams не используются и игнорируются.
+func (r *RedisStreamsBroker) CreateTopic(ctx context.Context, topic string, partitions, replicationFactor int) error {
+	if err := r.createGroup(ctx, topic, createTopicGroup); err != nil {
+		return err
+	}
+	return r.client.XGroupDestroy(ctx, topic, createTopicGroup).Err()
+}
+
+// HealthCheck проверяет доступность брокера и то, что подписки не остановлены
+func (r *RedisStreamsBroker) HealthCheck(ctx context.Context) error {
+	if stalled := r.stalled.Load(); stalled != nil {
+		return *stalled
+	}
+	return r.client.Ping(ctx).Err()
+}
+
+// ConsumerLag возвращает число сообщений стрима, ещё не выданных группе,
+// плюс выданные, но не подтверждённые (XINFO GROUPS, Redis 7+)
+func (r *RedisStreamsBroker) ConsumerLag(ctx context.Context, topic, groupID string) (int64, error) {
+	groups, err := r.client.XInfoGroups(ctx, topic).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get groups of stream %s: %w", topic, err)
+	}
+	for _, g := range groups {
+		if g.Name == groupID {
+			return max(g.Lag, 0) + g.Pending, nil
+		}
+	}
+	return 0, fmt.Errorf("group %s not found in stream %s", groupID, topic)
+}
+
+// Close останавливает циклы чтения и закрывает соединение
+func (r *RedisStreamsBroker) Close() error {
+	r.cancel()
+	r.wg.Wait()
+
+	if err := r.client.Close(); err != nil {
+		return fmt.Errorf("error closing redis streams connection: %w", err)
+	}
+	return nil
+}
+
+// Вспомогательные методы
+
+// loopContext возвращает контекст, который отменяется и при отмене ctx, и при Close
+func (r *RedisStreamsBroker) loopContext(ctx context.Context) context.Context {
+	loopCtx, cancel := context.WithCancel(ctx)
+	go func() {
+		select {
+		case <-r.ctx.Done():
+		case <-loopCtx.Done():
+		}
+		cancel()
+	}()
+	return loopCtx
+}
+
+func (r *RedisStreamsBroker) xaddArgs(msg models.MessageBroker) *redis.XAddArgs {
+	values := make([]any, 0, 4+2*len(msg.Headers))
+	values = append(values, fieldKey, msg.Key, fieldValue, msg.Value)
+	for key, value := range msg.Headers {
+		values = append(values, headerPrefix+key, value)
+	}
+
+	args := &redis.XAddArgs{
+		Stream: msg.Topic,
+		Values: values,
+	}
+	if r.config.StreamMaxLen > 0 {
+		args.MaxLen = r.config.StreamMaxLen
+		args.Approx = true
+	}
+	return args
+}
+
+// toMessage собирает models.MessageBroker из записи стрима
+func toMessage(topic string, xmsg redis.XMessage) models.MessageBroker {
+	msg := models.MessageBroker{
+		Topic:   topic,
+		Headers: make(map[string]string),
+	}
+
+	for field, raw := range xmsg.Values {
+		value, _ := raw.(string)
+		switch {
+		case field == fieldKey:
+			msg.Key = []byte(value)
+		case field == fieldValue:
+			msg.Value = []byte(value)
+		case strings.HasPrefix(field, headerPrefix):
+			msg.Headers[strings.TrimPrefix(field, headerPrefix)] = value
+		}
+	}
+	return msg
+}
+
+// startID определяет, с какого места новая группа начинает читать стрим:
+// StartOffset == -1 (kafka.LastOffset) — только новые записи, иначе с начала
+func (r *RedisStreamsBroker) startID() string {
+	if r.config.StartOffset == -1 {
+		return "$"
+	}
+	return "0"
+}
+
+func (r *RedisStreamsBroker) createGroup(ctx context.Context, topic, groupID string) error {
+	err := r.client.XGroupCreateMkStream(ctx, topic, groupID, r.startID()).Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return fmt.Errorf("failed to create consumer group %s for stream %s: %w", groupID, topic, err)
+	}
+	return nil
+}
+
+func (r *RedisStreamsBroker) readCount() int64 {
+	if r.config.StreamReadCount > 0 {
+		return int64(r.config.StreamReadCount)
+	}
+	return defaultReadCount
+}
+
+func (r *RedisStreamsBroker) blockTimeout() time.Duration {
+	if r.config.StreamBlockTimeout > 0 {
+		return r.config.StreamBlockTimeout
+	}
+	return defaultBlockTimeout
+}
+
+func (r *RedisStreamsBroker) claimMinIdle() time.Duration {
+	if r.config.ClaimMinIdle > 0 {
+		return r.config.ClaimMinIdle
+	}
+	return defaultClaimMinIdle
+}
+
+func (r *RedisStreamsBroker) consumeLoop(ctx context.Context, topic, groupID string, handler models.MessageHandlerBroker) {
+	defer r.wg.Done()
+
+	// Перед чтением новых записей забираем зависшие сообщения, а затем
+	// повторяем это раз в claimMinIdle
+	claimTicker := time.NewTicker(r.claimMinIdle())
+	defer claimTicker.Stop()
+	if !r.reclaimPending(ctx, topic, groupID, handler) {
+		return
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-claimTicker.C:
+			if !r.reclaimPending(ctx, topic, groupID, handler) {
+				return
+			}
+		default:
+		}
+
+		streams, err := r.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+			Group:    groupID,
+			Consumer: r.consumer,
+			Streams:  []string{topic, ">"},
+			Count:    r.readCount(),
+			Block:    r.blockTimeout(),
+		}).Result()
+		if err != nil {
+			if errors.Is(err, redis.Nil) {
+				continue
+			}
+			if ctx.Err() != nil {
+				return
+			}
+			r.logger.Errorf("Error reading stream %s (group %s): %v", topic, groupID, err)
+			r.sleep(ctx, readErrorDelay)
+			continue
+		}
+
+		for _, stream := range streams {
+			for _, xmsg := range stream.Messages {
+				if !r.handle(ctx, topic, groupID, xmsg, 1, handler) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// reclaimPending забирает себе сообщения, которые висят неподтверждёнными
+// дольше claimMinIdle (упавший консьюмер), и обрабатывает их заново.
+// Возвращает false, если подписка остановлена.
+func (r *RedisStreamsBroker) reclaimPending(ctx context.Context, topic, groupID string, handler models.MessageHandlerBroker) bool {
+	start := "0-0"
+	for {
+		msgs, next, err := r.client.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+			Stream:   topic,
+			Group:    groupID,
+			Consumer: r.consumer,
+			MinIdle:  r.claimMinIdle(),
+			Start:    start,
+			Count:    r.readCount(),
+		}).Result()
+		if err != nil {
+			if ctx.Err() != nil {
+				return false
+			}
+			r.logger.Errorf("Error claiming pending messages from stream %s (group %s): %v", topic, groupID, err)
+			return true
+		}
+
+		for _, xmsg := range msgs {
+			// XAUTOCLAIM не возвращает счётчик доставок: сообщение, которое
+			// роняет консьюмер, иначе забиралось бы бесконечно
+			deliveries, err := r.deliveries(ctx, topic, groupID, xmsg.ID)
+			if err != nil {
+				r.logger.Errorf("Error reading delivery count of message %s from stream %s: %v", xmsg.ID, topic, err)
+				deliveries = 1
+			}
+			if !r.handle(ctx, topic, groupID, xmsg, deliveries, handler) {
+				return false
+			}
+		}
+
+		if next == "0-0" || len(msgs) == 0 {
+			return true
+		}
+		start = next
+	}
+}
+
+// handle обрабатывает сообщение, которое доставлено deliveries раз, и подтверждает его.
+// После ошибки сообщение доставляется повторно через XCLAIM (счётчик доставок в PEL
+// растёт) с задержкой по политике повторов. Когда доставки исчерпаны, сообщение
+// уходит в DLQ; без DLQ подписка останавливается и handle возвращает false.
+func (r *RedisStreamsBroker) handle(ctx context.Context, topic, groupID string, xmsg redis.XMessage, deliveries int64, handler models.MessageHandlerBroker) bool {
+	limit := int64(broker.MaxDeliveries(r.config))
+	var err error
+	for {
+		msg := toMessage(topic, xmsg)
+		if limit > 0 && deliveries > limit {
+			// Сообщение уже исчерпало доставки (консьюмер падал на нём)
+			return r.giveUp(ctx, topic, groupID, msg, xmsg.ID, fmt.Errorf("delivered %d times without acknowledgement", deliveries), deliveries-1)
+		}
+
+		if err = handler(ctx, msg); err == nil {
+			break
+		}
+		if ctx.Err() != nil {
+			return false
+		}
+		r.logger.Errorf("Error handling message %s from stream %s (delivery %d): %v", xmsg.ID, topic, deliveries, err)
+		if broker.IsPermanent(err) || (limit > 0 && deliveries >= limit) {
+			return r.giveUp(ctx, topic, groupID, msg, xmsg.ID, err, deliveries)
+		}
+
+		r.sleep(ctx, r.retry.Backoff(int(deliveries)+1))
+		claimed, claimErr := r.client.XClaim(ctx, &redis.XClaimArgs{
+			Stream:   topic,
+			Group:    groupID,
+			Consumer: r.consumer,
+			Messages: []string{xmsg.ID},
+		}).Result()
+		if claimErr != nil || len(claimed) == 0 {
+			if ctx.Err() != nil {
+				return false
+			}
+			// Сообщение подтверждено или удалено из стрима, либо Redis недоступен:
+			// тогда его заберёт XAUTOCLAIM
+			r.logger.Warnf("Message %s from stream %s not redelivered: %v", xmsg.ID, topic, claimErr)
+			return true
+		}
+		xmsg = claimed[0]
+		deliveries++
+	}
+
+	r.ack(ctx, topic, groupID, xmsg.ID)
+	return true
+}
+
+// giveUp отправляет необработанное сообщение в DLQ и подтверждает его;
+// без DLQ останавливает подписку, оставляя сообщение в PEL
+func (r *RedisStreamsBroker) giveUp(ctx context.Context, topic, groupID string, msg models.MessageBroker, id string, cause error, deliveries int64) bool {
+	if r.dlq == nil {
+		r.stall(fmt.Errorf("message %s from stream %s failed after %d deliveries and dead letter queue is disabled: %w",
+			id, topic, deliveries, cause))
+		return false
+	}
+
+	if err := r.dlq.Send(ctx, msg, cause, int(deliveries)); err != nil {
+		r.logger.Errorf("Message %s from stream %s left pending: %v", id, topic, err)
+		return false
+	}
+	r.ack(ctx, topic, groupID, id)
+	return true
+}
+
+// ack подтверждает сообщение. Подтверждение не прерывается отменой подписки:
+// иначе обработанное сообщение будет забрано и обработано повторно
+func (r *RedisStreamsBroker) ack(ctx context.Context, topic, groupID, id string) {
+	if err := r.client.XAck(context.WithoutCancel(ctx), topic, groupID, id).Err(); err != nil {
+		r.logger.Errorf("Error acknowledging message %s from stream %s: %v", id, topic, err)
+	}
+}
+
+// deliveries возвращает, сколько раз сообщение id доставлялось группе (XPENDING)
+func (r *RedisStreamsBroker) deliveries(ctx context.Context, topic, groupID, id string) (int64, error) {
+	pending, err := r.client.XPendingExt(ctx, &redis.XPendingExtArgs{
+		Stream: topic,
+		Group:  groupID,
+		Start:  id,
+		End:    id,
+		Count:  1,
+	}).Result()
+	if err != nil {
+		return 0, err
+	}
+	if len(pending) == 0 {
+		return 1, nil
+	}
+	return pending[0].RetryCount, nil
+}
+
+// stall фиксирует остановку подписки: сообщение остаётся в PEL и будет
+// обработано после перезапуска
+func (r *RedisStreamsBroker) stall(err error) {
+	r.logger.Errorf("Stopping consumer, message left pending: %v", err)
+	r.stalled.Store(&err)
+}
+
+func (r *RedisStreamsBroker) readLoop(ctx context.Context, topic string, handler models.MessageHandlerBroker) {
+	defer r.wg.Done()
+
+	lastID := "$"
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+
+		streams, err := r.client.XRead(ctx, &redis.XReadArgs{
+			Streams: []string{topic, lastID},
+			Count:   r.readCount(),
+			Block:   r.blockTimeout(),
+		}).Result()
+		if err != nil {
+			if errors.Is(err, redis.Nil) {
+				continue
+			}
+			if ctx.Err() != nil {
+				return
+			}
+			r.logger.Errorf("Error reading stream %s: %v", topic, err)
+			r.sleep(ctx, readErrorDelay)
+			continue
+		}
+
+		for _, stream := range streams {
+			for _, xmsg := range stream.Messages {
+				lastID = xmsg.ID
+				if err := handler(ctx, toMessage(topic, xmsg)); err != nil {
+					r.logger.Errorf("Error handling message %s from stream %s: %v", xmsg.ID, topic, err)
+				}
+			}
+		}
+	}
+}
+
+func (r *RedisStreamsBroker) sleep(ctx context.Context, d time.Duration) {
+	select {
+	case <-ctx.Done():
+	case <-time.After(d):
+	}
+}
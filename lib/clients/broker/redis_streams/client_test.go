@@ -0,0 +1,212 @@
+package redisStreams
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"lib/clients/broker"
+	"lib/models"
+	"lib/utils/logging"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+const topic, group = "blocks", "clickhouse-service"
+
+// runRedis запускает miniredis и брокер поверх него
+func runRedis(t *testing.T, cfg models.Broker) (*RedisStreamsBroker, *redis.Client) {
+	t.Helper()
+
+	srv := miniredis.RunT(t)
+	cfg.Brokers = []string{srv.Addr()}
+	cfg.StreamBlockTimeout = 50 * time.Millisecond
+	cfg.HandlerRetryBackoff = 10 * time.Millisecond
+	cfg.HandlerMaxRetryBackoff = 20 * time.Millisecond
+
+	b := NewRedisStreamsBroker(cfg, logging.GetLogger()).(*RedisStreamsBroker)
+	t.Cleanup(func() { _ = b.Close() })
+
+	raw := redis.NewClient(&redis.Options{Addr: srv.Addr()})
+	t.Cleanup(func() { _ = raw.Close() })
+	return b, raw
+}
+
+// calls считает вызовы обработчика по значению сообщения
+type calls struct {
+	mu   sync.Mutex
+	seen map[string][]models.MessageBroker
+}
+
+func (c *calls) add(msg models.MessageBroker) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.seen == nil {
+		c.seen = make(map[string][]models.MessageBroker)
+	}
+	c.seen[string(msg.Value)] = append(c.seen[string(msg.Value)], msg)
+	return len(c.seen[string(msg.Value)])
+}
+
+func (c *calls) get(value string) []models.MessageBroker {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]models.MessageBroker(nil), c.seen[value]...)
+}
+
+func eventually(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func pending(t *testing.T, raw *redis.Client) int64 {
+	t.Helper()
+	p, err := raw.XPending(context.Background(), topic, group).Result()
+	if err != nil {
+		t.Fatalf("xpending: %v", err)
+	}
+	return p.Count
+}
+
+func TestRedisStreamsGroupDeliversAndAcks(t *testing.T) {
+	ctx := context.Background()
+	b, raw := runRedis(t, models.Broker{})
+
+	var got calls
+	if err := b.SubscribeWithGroup(ctx, topic, group, func(_ context.Context, msg models.MessageBroker) error {
+		got.add(msg)
+		return nil
+	}); err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+
+	sent := models.MessageBroker{Topic: topic, Key: []byte("1"), Value: []byte("block-1"), Headers: map[string]string{"network": "ethereum"}}
+	if err := b.SendMessages(ctx, []models.MessageBroker{sent, {Topic: topic, Value: []byte("block-2")}}); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+
+	eventually(t, "both messages", func() bool { return len(got.get("block-1")) == 1 && len(got.get("block-2")) == 1 })
+	msg := got.get("block-1")[0]
+	if string(msg.Key) != "1" || msg.Headers["network"] != "ethereum" || msg.Topic != topic {
+		t.Errorf("received %+v, want key, headers and topic of the sent message", msg)
+	}
+	eventually(t, "acknowledgements", func() bool { return pending(t, raw) == 0 })
+	if err := b.HealthCheck(ctx); err != nil {
+		t.Errorf("health check: %v", err)
+	}
+}
+
+func TestRedisStreamsRetriesThenDeadLetters(t *testing.T) {
+	ctx := context.Background()
+	b, raw := runRedis(t, models.Broker{DeadLetter: true, MaxDeliveries: 3})
+
+	var got calls
+	failing := errors.New("clickhouse is down")
+	if err := b.SubscribeWithGroup(ctx, topic, group, func(_ context.Context, msg models.MessageBroker) error {
+		if string(msg.Value) == "poison" {
+			got.add(msg)
+			return failing
+		}
+		// Второе сообщение обрабатывается со второй доставки
+		if got.add(msg) < 2 {
+			return failing
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+
+	for _, value := range []string{"poison", "flaky"} {
+		if err := b.SendMessage(ctx, models.MessageBroker{Topic: topic, Value: []byte(value)}); err != nil {
+			t.Fatalf("send: %v", err)
+		}
+	}
+
+	eventually(t, "dead letter", func() bool { return raw.XLen(ctx, broker.DLQTopic(topic)).Val() == 1 })
+	eventually(t, "acknowledgements", func() bool { return pending(t, raw) == 0 })
+
+	if n := len(got.get("poison")); n != 3 {
+		t.Errorf("poison message handled %d times, want 3 (max_deliveries)", n)
+	}
+	if n := len(got.get("flaky")); n != 2 {
+		t.Errorf("flaky message handled %d times, want 2", n)
+	}
+
+	dead, err := raw.XRange(ctx, broker.DLQTopic(topic), "-", "+").Result()
+	if err != nil || len(dead) != 1 {
+		t.Fatalf("dlq = %v, %v", dead, err)
+	}
+	msg := toMessage(broker.DLQTopic(topic), dead[0])
+	if string(msg.Value) != "poison" || msg.Headers[broker.HeaderDLQAttempts] != "3" ||
+		msg.Headers[broker.HeaderDLQOriginalTopic] != topic || msg.Headers[broker.HeaderDLQError] != failing.Error() {
+		t.Errorf("dead letter = %+v", msg)
+	}
+}
+
+func TestRedisStreamsStallsWithoutDeadLetter(t *testing.T) {
+	ctx := context.Background()
+	b, raw := runRedis(t, models.Broker{MaxDeliveries: 2})
+
+	var got calls
+	if err := b.SubscribeWithGroup(ctx, topic, group, func(_ context.Context, msg models.MessageBroker) error {
+		got.add(msg)
+		return errors.New("clickhouse is down")
+	}); err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+	if err := b.SendMessage(ctx, models.MessageBroker{Topic: topic, Value: []byte("block")}); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+
+	eventually(t, "stalled health check", func() bool { return b.HealthCheck(ctx) != nil })
+	if n := len(got.get("block")); n != 2 {
+		t.Errorf("message handled %d times, want 2", n)
+	}
+	if n := pending(t, raw); n != 1 {
+		t.Errorf("%d pending messages, want the failed one left unacknowledged", n)
+	}
+	if raw.Exists(ctx, broker.DLQTopic(topic)).Val() != 0 {
+		t.Error("dead letter stream created with dead_letter disabled")
+	}
+}
+
+func TestRedisStreamsDeadLettersOverDeliveredPendingMessage(t *testing.T) {
+	ctx := context.Background()
+	b, raw := runRedis(t, models.Broker{DeadLetter: true, MaxDeliveries: 2, ClaimMinIdle: 50 * time.Millisecond})
+
+	// Консьюмер, который падает на сообщении: оно доставлено трижды и не подтверждено
+	if err := raw.XGroupCreateMkStream(ctx, topic, group, "0").Err(); err != nil {
+		t.Fatalf("create group: %v", err)
+	}
+	id := raw.XAdd(ctx, &redis.XAddArgs{Stream: topic, Values: []any{fieldValue, "crasher"}}).Val()
+	if err := raw.XReadGroup(ctx, &redis.XReadGroupArgs{Group: group, Consumer: "crashed", Streams: []string{topic, ">"}}).Err(); err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		raw.XClaim(ctx, &redis.XClaimArgs{Stream: topic, Group: group, Consumer: "crashed", Messages: []string{id}})
+	}
+	time.Sleep(60 * time.Millisecond)
+
+	var got calls
+	if err := b.SubscribeWithGroup(ctx, topic, group, func(_ context.Context, msg models.MessageBroker) error {
+		got.add(msg)
+		return nil
+	}); err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+
+	eventually(t, "dead letter", func() bool { return raw.XLen(ctx, broker.DLQTopic(topic)).Val() == 1 })
+	eventually(t, "acknowledgement", func() bool { return pending(t, raw) == 0 })
+	if n := len(got.get("crasher")); n != 0 {
+		t.Errorf("over-delivered message handled %d times, want it dead-lettered unhandled", n)
+	}
+}
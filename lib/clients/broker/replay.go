@@ -0,0 +1,81 @@
+package broker
+
+import (
+	"context"
+	"lib/models"
+	"sync"
+	"time"
+)
+
+const defaultReplayIdle = 10 * time.Second
+
+// ReplayDeadLetters переносит сообщения из <topic>.dlq обратно в основной топик.
+// Чтение идёт через consumer group groupID, поэтому уже перенесённые сообщения
+// не повторяются при следующем запуске. Останавливается, когда перенесено
+// limit сообщений (0 — без ограничения) или новых нет дольше idle.
+func ReplayDeadLetters(ctx context.Context, client BrokerClient, topic, groupID string, limit int, idle time.Duration) (int, error) {
+	if idle <= 0 {
+		idle = defaultReplayIdle
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		mu       sync.Mutex
+		replayed int
+		lastSeen = time.Now()
+	)
+
+	// Обработчик только сообщает о достижении limit; подписка отменяется в цикле
+	// ниже. Отмена из обработчика прервала бы коммит последнего сообщения,
+	// и оно было бы перенесено повторно при следующем запуске.
+	limitReached := make(chan struct{})
+
+	err := client.SubscribeWithGroup(ctx, DLQTopic(topic), groupID, func(ctx context.Context, msg models.MessageBroker) error {
+		mu.Lock()
+		if limit > 0 && replayed >= limit {
+			mu.Unlock()
+			// Сверх лимита сообщения не переносятся и не подтверждаются
+			<-ctx.Done()
+			return ctx.Err()
+		}
+		defer mu.Unlock()
+
+		if err := client.SendMessage(ctx, Revive(msg)); err != nil {
+			return err
+		}
+
+		replayed++
+		lastSeen = time.Now()
+		if limit > 0 && replayed == limit {
+			close(limitReached)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	ticker := time.NewTicker(idle / 4)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			mu.Lock()
+			defer mu.Unlock()
+			return replayed, nil
+		case <-limitReached:
+			cancel()
+			limitReached = nil
+		case <-ticker.C:
+			mu.Lock()
+			done := time.Since(lastSeen) > idle
+			mu.Unlock()
+			if done {
+				cancel()
+			}
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package broker_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"lib/clients/broker"
+	memoryBroker "lib/clients/broker/memory"
+	"lib/models"
+	"lib/utils/logging"
+)
+
+func TestReplayDeadLettersLimitCommitsLastMessage(t *testing.T) {
+	const topic, group = "blocks", "blocks.dlq-replay"
+	b := memoryBroker.NewBroker(0)
+	client := memoryBroker.NewClient(b, models.Broker{}, logging.GetLogger())
+	defer client.Close()
+
+	ctx := context.Background()
+	for i := 0; i < 5; i++ {
+		msg := models.MessageBroker{Topic: topic, Key: []byte(fmt.Sprint(i)), Value: []byte(fmt.Sprint(i))}
+		if err := client.SendMessage(ctx, broker.DeadLetter(msg, errors.New("boom"), 3)); err != nil {
+			t.Fatalf("send dead letter: %v", err)
+		}
+	}
+
+	replayed, err := broker.ReplayDeadLetters(ctx, client, topic, group, 3, time.Second)
+	if err != nil || replayed != 3 {
+		t.Fatalf("first replay = %d, %v; want 3", replayed, err)
+	}
+
+	// Остаток переносится следующим запуском без повторов
+	replayed, err = broker.ReplayDeadLetters(ctx, client, topic, group, 0, 200*time.Millisecond)
+	if err != nil || replayed != 2 {
+		t.Fatalf("second replay = %d, %v; want 2", replayed, err)
+	}
+
+	first, next, err := b.Offsets(topic, 0)
+	if err != nil {
+		t.Fatalf("offsets: %v", err)
+	}
+	if next-first != 5 {
+		t.Errorf("%d messages replayed to %s, want 5", next-first, topic)
+	}
+	if lag := b.GroupLag(broker.DLQTopic(topic), group); lag != 0 {
+		t.Errorf("dead letter lag after replay = %d, want 0", lag)
+	}
+}
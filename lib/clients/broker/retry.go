@@ -0,0 +1,98 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"lib/models"
+	"time"
+)
+
+const (
+	defaultInitialBackoff = 500 * time.Millisecond
+	defaultMaxBackoff     = 30 * time.Second
+)
+
+// RetryPolicy описывает повторную обработку сообщения, для которого обработчик вернул ошибку
+type RetryPolicy struct {
+	// MaxAttempts — максимальное число попыток; 0 — повторять, пока обработка не завершится успешно
+	MaxAttempts int
+	// InitialBackoff — задержка перед второй попыткой, далее удваивается до MaxBackoff
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+// NewRetryPolicy собирает политику повторов из конфигурации брокера
+func NewRetryPolicy(cfg models.Broker) RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    cfg.HandlerMaxAttempts,
+		InitialBackoff: cfg.HandlerRetryBackoff,
+		MaxBackoff:     cfg.HandlerMaxRetryBackoff,
+	}
+}
+
+// Backoff возвращает задержку перед попыткой attempt (начиная с 1)
+func (p RetryPolicy) Backoff(attempt int) time.Duration {
+	initial := p.InitialBackoff
+	if initial <= 0 {
+		initial = defaultInitialBackoff
+	}
+	maxBackoff := p.MaxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = defaultMaxBackoff
+	}
+
+	delay := initial
+	for i := 1; i < attempt-1 && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, maxBackoff)
+}
+
+// Run вызывает fn, повторяя его согласно политике. Возвращает число
+// выполненных попыток и последнюю ошибку. Ошибки, помеченные Permanent,
+// и отмена ctx прекращают повторы сразу.
+func (p RetryPolicy) Run(ctx context.Context, fn func(attempt int) error) (int, error) {
+	for attempt := 1; ; attempt++ {
+		err := fn(attempt)
+		if err == nil || IsPermanent(err) {
+			return attempt, err
+		}
+		if p.MaxAttempts > 0 && attempt >= p.MaxAttempts {
+			return attempt, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return attempt, errors.Join(err, ctx.Err())
+		case <-time.After(p.Backoff(attempt + 1)):
+		}
+	}
+}
+
+// permanentError — ошибка, которую бессмысленно повторять (например, битый payload)
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent помечает ошибку обработчика как неустранимую повтором
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// IsPermanent проверяет, помечена ли ошибка как неустранимая
+func IsPermanent(err error) bool {
+	var p *permanentError
+	return errors.As(err, &p)
+}
+
+// BatchSubscriber — необязательное расширение BrokerClient для пакетной обработки:
+// обработчик получает до batchSize сообщений, которые подтверждаются вместе
+type BatchSubscriber interface {
+	SubscribeBatchWithGroup(ctx context.Context, topic, groupID string, batchSize int, maxWait time.Duration, handler models.BatchMessageHandlerBroker) error
+}
@@ -0,0 +1,244 @@
+package broker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"lib/models"
+	"lib/utils/logging"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	spoolFileExt       = ".msg"
+	spoolTmpExt        = ".tmp"
+	defaultSpoolPeriod = 10 * time.Second
+)
+
+// Spool — локальный дисковый буфер для сообщений, которые не удалось отправить.
+// Каждое сообщение хранится отдельным файлом; Flush повторно отправляет их
+// в порядке записи и удаляет файл только после успешной отправки.
+type Spool struct {
+	dir      string
+	producer BrokerClient
+	logger   *logging.Logger
+
+	mu      sync.Mutex
+	seq     atomic.Uint64
+	pending atomic.Int64 // сообщений в буфере, включая оставшиеся с прошлого запуска
+}
+
+// NewSpool создаёт буфер в каталоге dir
+func NewSpool(dir string, producer BrokerClient, logger *logging.Logger) (*Spool, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create spool dir %s: %w", dir, err)
+	}
+	s := &Spool{
+		dir:      dir,
+		producer: producer,
+		logger:   logger,
+	}
+
+	files, err := s.files()
+	if err != nil {
+		return nil, err
+	}
+	s.pending.Store(int64(len(files)))
+	return s, nil
+}
+
+// Put сохраняет сообщение на диск
+func (s *Spool) Put(msg models.MessageBroker) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to serialize spooled message: %w", err)
+	}
+
+	// Имя файла задаёт порядок повторной отправки
+	name := fmt.Sprintf("%020d-%06d", time.Now().UnixNano(), s.seq.Add(1)%1_000_000)
+	tmp := filepath.Join(s.dir, name+spoolTmpExt)
+
+	// Пишем во временный файл и переименовываем, чтобы не оставить обрезанную запись
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write spooled message: %w", err)
+	}
+	if err := os.Rename(tmp, filepath.Join(s.dir, name+spoolFileExt)); err != nil {
+		return fmt.Errorf("failed to commit spooled message: %w", err)
+	}
+	s.pending.Add(1)
+	return nil
+}
+
+// Len возвращает число сообщений в буфере
+func (s *Spool) Len() (int, error) {
+	files, err := s.files()
+	return len(files), err
+}
+
+// Flush пытается отправить все сообщения из буфера. Останавливается на первой
+// ошибке отправки, чтобы сохранить порядок, и возвращает число отправленных.
+func (s *Spool) Flush(ctx context.Context) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	files, err := s.files()
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, path := range files {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return sent, fmt.Errorf("failed to read spooled message %s: %w", path, err)
+		}
+
+		var msg models.MessageBroker
+		if err := json.Unmarshal(data, &msg); err != nil {
+			// Повреждённый файл не блокирует остальные сообщения
+			s.logger.Errorf("Discarding corrupted spooled message %s: %v", path, err)
+			os.Rename(path, path+".corrupted")
+			s.pending.Add(-1)
+			continue
+		}
+
+		if err := s.producer.SendMessage(ctx, msg); err != nil {
+			return sent, err
+		}
+		if err := os.Remove(path); err != nil {
+			return sent, fmt.Errorf("failed to remove spooled message %s: %w", path, err)
+		}
+		s.pending.Add(-1)
+		sent++
+	}
+	return sent, nil
+}
+
+// Run периодически повторяет отправку буфера, пока не отменён ctx
+func (s *Spool) Run(ctx context.Context, period time.Duration) {
+	if period <= 0 {
+		period = defaultSpoolPeriod
+	}
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			sent, err := s.Flush(ctx)
+			if sent > 0 {
+				s.logger.Infof("Resent %d spooled messages", sent)
+			}
+			if err != nil && ctx.Err() == nil {
+				s.logger.Warnf("Spool flush stopped: %v", err)
+			}
+		}
+	}
+}
+
+func (s *Spool) files() ([]string, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list spool dir %s: %w", s.dir, err)
+	}
+
+	var files []string
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), spoolFileExt) {
+			continue
+		}
+		files = append(files, filepath.Join(s.dir, e.Name()))
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// spooledClient отправляет сообщения через обёрнутый клиент, а неотправленные
+// сохраняет в Spool. Пока в буфере есть сообщения, новые тоже пишутся в него,
+// чтобы досылка сохранила порядок отправки.
+type spooledClient struct {
+	BrokerClient
+	spool  *Spool
+	logger *logging.Logger
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// spooledBatchClient сохраняет поддержку BatchSubscriber у обёрнутого клиента
+type spooledBatchClient struct {
+	*spooledClient
+	BatchSubscriber
+}
+
+// WithSpool оборачивает клиент дисковым буфером в каталоге dir: сообщение,
+// которое не удалось отправить, сохраняется на диск и досылается в фоне.
+// Close останавливает досылку; буфер переживает перезапуск процесса.
+func WithSpool(client BrokerClient, dir string, logger *logging.Logger) (BrokerClient, error) {
+	spool, err := NewSpool(dir, client, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	spooled := &spooledClient{
+		BrokerClient: client,
+		spool:        spool,
+		logger:       logger,
+		cancel:       cancel,
+		done:         make(chan struct{}),
+	}
+	go func() {
+		defer close(spooled.done)
+		spool.Run(ctx, 0)
+	}()
+
+	if batch, ok := client.(BatchSubscriber); ok {
+		return &spooledBatchClient{spooledClient: spooled, BatchSubscriber: batch}, nil
+	}
+	return spooled, nil
+}
+
+// Unwrap возвращает обёрнутый клиент
+func (c *spooledClient) Unwrap() BrokerClient {
+	return c.BrokerClient
+}
+
+func (c *spooledClient) SendMessage(ctx context.Context, msg models.MessageBroker) error {
+	return c.SendMessages(ctx, []models.MessageBroker{msg})
+}
+
+// SendMessages сохраняет в буфер всю пачку, если её не удалось отправить:
+// часть пачки могла дойти, консьюмеры дедуплицируют повторы по ключу
+func (c *spooledClient) SendMessages(ctx context.Context, msgs []models.MessageBroker) error {
+	if c.spool.pending.Load() == 0 {
+		err := c.BrokerClient.SendMessages(ctx, msgs)
+		// Отменённую отправку вызывающий видит сам, в буфер её не пишем
+		if err == nil || ctx.Err() != nil {
+			return err
+		}
+		c.logger.Warnf("Failed to send %d messages, spooling to %s: %v", len(msgs), c.spool.dir, err)
+	}
+
+	for _, msg := range msgs {
+		if err := c.spool.Put(msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close останавливает досылку буфера и закрывает обёрнутый клиент
+func (c *spooledClient) Close() error {
+	c.cancel()
+	<-c.done
+	return c.BrokerClient.Close()
+}
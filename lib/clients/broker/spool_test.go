@@ -0,0 +1,137 @@
+package broker_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+
+	"lib/clients/broker"
+	"lib/models"
+	"lib/utils/logging"
+)
+
+// flakyProducer не отправляет сообщения, пока down
+type flakyProducer struct {
+	broker.MockBrokerClient
+	mu   sync.Mutex
+	down bool
+	sent []string
+}
+
+func (p *flakyProducer) SendMessage(ctx context.Context, msg models.MessageBroker) error {
+	return p.SendMessages(ctx, []models.MessageBroker{msg})
+}
+
+func (p *flakyProducer) SendMessages(_ context.Context, msgs []models.MessageBroker) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.down {
+		return errors.New("broker is unreachable")
+	}
+	for _, msg := range msgs {
+		p.sent = append(p.sent, string(msg.Value))
+	}
+	return nil
+}
+
+func (p *flakyProducer) setDown(down bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.down = down
+}
+
+func (p *flakyProducer) get() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]string(nil), p.sent...)
+}
+
+func TestSpooledClientKeepsFailedProducesInOrder(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	producer := &flakyProducer{down: true}
+
+	client, err := broker.WithSpool(producer, dir, logging.GetLogger())
+	if err != nil {
+		t.Fatalf("with spool: %v", err)
+	}
+
+	send := func(values ...string) {
+		t.Helper()
+		msgs := make([]models.MessageBroker, len(values))
+		for i, v := range values {
+			msgs[i] = models.MessageBroker{Topic: "blocks", Key: []byte(v), Value: []byte(v), Headers: map[string]string{"network": "eth"}}
+		}
+		if err := client.SendMessages(ctx, msgs); err != nil {
+			t.Fatalf("send %v: %v", values, err)
+		}
+	}
+
+	// Брокер недоступен — сообщения уходят на диск, отправка не падает
+	send("1", "2")
+	if err := client.SendMessage(ctx, models.MessageBroker{Topic: "blocks", Value: []byte("3")}); err != nil {
+		t.Fatalf("send 3: %v", err)
+	}
+
+	// Брокер вернулся, но буфер не пуст: новое сообщение встаёт в очередь за ним
+	producer.setDown(false)
+	send("4")
+	if got := producer.get(); len(got) != 0 {
+		t.Fatalf("messages %v sent past the spooled ones", got)
+	}
+	if err := client.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	// Буфер переживает перезапуск и досылается в порядке записи
+	os.WriteFile(filepath.Join(dir, "00000000000000000000-000000.msg"), []byte("{broken"), 0o644)
+	spool, err := broker.NewSpool(dir, producer, logging.GetLogger())
+	if err != nil {
+		t.Fatalf("reopen spool: %v", err)
+	}
+	sent, err := spool.Flush(ctx)
+	if err != nil || sent != 4 {
+		t.Fatalf("flush = %d, %v, want 4 sent", sent, err)
+	}
+	if got := producer.get(); !reflect.DeepEqual(got, []string{"1", "2", "3", "4"}) {
+		t.Errorf("resent %v, want [1 2 3 4]", got)
+	}
+	if n, err := spool.Len(); err != nil || n != 0 {
+		t.Errorf("spool holds %d messages after flush (%v)", n, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "00000000000000000000-000000.msg.corrupted")); err != nil {
+		t.Errorf("corrupted message not set aside: %v", err)
+	}
+}
+
+func TestSpoolFlushStopsOnFirstFailure(t *testing.T) {
+	ctx := context.Background()
+	producer := &flakyProducer{}
+	spool, err := broker.NewSpool(t.TempDir(), producer, logging.GetLogger())
+	if err != nil {
+		t.Fatalf("new spool: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if err := spool.Put(models.MessageBroker{Topic: "blocks", Value: []byte(fmt.Sprint(i))}); err != nil {
+			t.Fatalf("put: %v", err)
+		}
+	}
+
+	producer.setDown(true)
+	if sent, err := spool.Flush(ctx); err == nil || sent != 0 {
+		t.Fatalf("flush with broker down = %d, %v, want an error", sent, err)
+	}
+	if n, _ := spool.Len(); n != 3 {
+		t.Errorf("spool holds %d messages after a failed flush, want 3", n)
+	}
+
+	producer.setDown(false)
+	if sent, err := spool.Flush(ctx); err != nil || sent != 3 {
+		t.Fatalf("flush = %d, %v, want 3", sent, err)
+	}
+}
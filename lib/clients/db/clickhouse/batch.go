@@ -0,0 +1,55 @@
+package clickhouseClient
+
+import (
+	"context"
+	"lib/utils/metrics"
+	"lib/utils/tracing"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var insertTableRe = regexp.MustCompile(`(?i)^\s*INSERT\s+INTO\s+([\w.` + "`" + `"]+)`)
+
+// instrumentedBatch records batch size and insert latency on Send and
+// traces the insert as a child of the span that prepared the batch
+type instrumentedBatch struct {
+	driver.Batch
+	ctx   context.Context
+	table string
+}
+
+func (b *instrumentedBatch) Send() error {
+	rows := b.Rows()
+	metrics.ClickhouseBatchSize.WithLabelValues(b.table).Observe(float64(rows))
+
+	_, span := tracing.Start(b.ctx, "clickhouse insert "+b.table,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "clickhouse"),
+			attribute.String("db.collection.name", b.table),
+			attribute.Int("db.operation.batch.size", rows),
+		),
+	)
+
+	start := time.Now()
+	err := b.Batch.Send()
+
+	metrics.ClickhouseInsertLatency.WithLabelValues(b.table).Observe(metrics.Since(start))
+	metrics.ClickhouseInserts.WithLabelValues(b.table, metrics.Status(err)).Inc()
+	tracing.End(span, err)
+	return err
+}
+
+// insertTable extracts the target table from an INSERT query for the metric label
+func insertTable(query string) string {
+	m := insertTableRe.FindStringSubmatch(query)
+	if m == nil {
+		return "unknown"
+	}
+	return strings.Trim(m[1], "`\"")
+}
@@ -63,9 +63,14 @@ func (c *Client) QueryRow(ctx context.Context, query string, args ...any) driver
 	return c.conn.QueryRow(ctx, query, args...)
 }
 
-// PrepareBatch prepares a batch for insert operations
+// PrepareBatch prepares a batch for insert operations.
+// Sending the batch records its size and latency in metrics and a trace span.
 func (c *Client) PrepareBatch(ctx context.Context, query string, opts ...driver.PrepareBatchOption) (driver.Batch, error) {
-	return c.conn.PrepareBatch(ctx, query, opts...)
+	batch, err := c.conn.PrepareBatch(ctx, query, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &instrumentedBatch{Batch: batch, ctx: ctx, table: insertTable(query)}, nil
 }
 
 // Exec executes a query without returning results
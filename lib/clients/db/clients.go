@@ -23,7 +23,10 @@ type ClickhouseClient interface {
 	Close() error
 }
 
-type CashClient interface {
+// CashCmdable — набор команд, доступных как напрямую у клиента,
+// так и внутри пайплайна/транзакции (см. CashClient.Pipelined)
+type CashCmdable interface {
+	// Строки и ключи
 	Get(ctx context.Context, key string) *redis.StringCmd
 	Set(ctx context.Context, key string, value any, expiration time.Duration) *redis.StatusCmd
 	Del(ctx context.Context, keys ...string) *redis.IntCmd
@@ -33,6 +36,78 @@ type CashClient interface {
 	SetNX(ctx context.Context, key string, value any, expiration time.Duration) *redis.BoolCmd
 	Incr(ctx context.Context, key string) *redis.IntCmd
 	Decr(ctx context.Context, key string) *redis.IntCmd
+
+	// Хеши
+	HGet(ctx context.Context, key, field string) *redis.StringCmd
+	HSet(ctx context.Context, key string, values ...any) *redis.IntCmd
+	HGetAll(ctx context.Context, key string) *redis.MapStringStringCmd
+	HMGet(ctx context.Context, key string, fields ...string) *redis.SliceCmd
+	HDel(ctx context.Context, key string, fields ...string) *redis.IntCmd
+	HExists(ctx context.Context, key, field string) *redis.BoolCmd
+	HIncrBy(ctx context.Context, key, field string, incr int64) *redis.IntCmd
+	HLen(ctx context.Context, key string) *redis.IntCmd
+	HKeys(ctx context.Context, key string) *redis.StringSliceCmd
+
+	// Сортированные множества (например, упорядочивание по номеру блока)
+	ZAdd(ctx context.Context, key string, members ...redis.Z) *redis.IntCmd
+	ZRem(ctx context.Context, key string, members ...any) *redis.IntCmd
+	ZScore(ctx context.Context, key, member string) *redis.FloatCmd
+	ZCard(ctx context.Context, key string) *redis.IntCmd
+	ZIncrBy(ctx context.Context, key string, increment float64, member string) *redis.FloatCmd
+	ZRange(ctx context.Context, key string, start, stop int64) *redis.StringSliceCmd
+	ZRangeWithScores(ctx context.Context, key string, start, stop int64) *redis.ZSliceCmd
+	ZRevRange(ctx context.Context, key string, start, stop int64) *redis.StringSliceCmd
+	ZRevRangeWithScores(ctx context.Context, key string, start, stop int64) *redis.ZSliceCmd
+	ZRangeByScore(ctx context.Context, key string, opt *redis.ZRangeBy) *redis.StringSliceCmd
+	ZRemRangeByScore(ctx context.Context, key, min, max string) *redis.IntCmd
+
+	// Списки
+	LPush(ctx context.Context, key string, values ...any) *redis.IntCmd
+	RPush(ctx context.Context, key string, values ...any) *redis.IntCmd
+	LPop(ctx context.Context, key string) *redis.StringCmd
+	RPop(ctx context.Context, key string) *redis.StringCmd
+	LRange(ctx context.Context, key string, start, stop int64) *redis.StringSliceCmd
+	LLen(ctx context.Context, key string) *redis.IntCmd
+	LTrim(ctx context.Context, key string, start, stop int64) *redis.StatusCmd
+	LRem(ctx context.Context, key string, count int64, value any) *redis.IntCmd
+
+	// Стримы
+	XAdd(ctx context.Context, a *redis.XAddArgs) *redis.StringCmd
+	XLen(ctx context.Context, stream string) *redis.IntCmd
+	XRange(ctx context.Context, stream, start, stop string) *redis.XMessageSliceCmd
+	XRangeN(ctx context.Context, stream, start, stop string, count int64) *redis.XMessageSliceCmd
+	XDel(ctx context.Context, stream string, ids ...string) *redis.IntCmd
+	XTrimMaxLen(ctx context.Context, key string, maxLen int64) *redis.IntCmd
+
+	// Группы потребителей стримов
+	XGroupCreateMkStream(ctx context.Context, stream, group, start string) *redis.StatusCmd
+	XReadGroup(ctx context.Context, a *redis.XReadGroupArgs) *redis.XStreamSliceCmd
+	XAck(ctx context.Context, stream, group string, ids ...string) *redis.IntCmd
+}
+
+// CashSubscription — подписка на каналы pub/sub
+type CashSubscription interface {
+	Channel() <-chan *redis.Message
+	Close() error
+}
+
+type CashClient interface {
+	CashCmdable
+
+	// Lua-скрипты
+	Eval(ctx context.Context, script string, keys []string, args ...any) *redis.Cmd
+	EvalSha(ctx context.Context, sha1 string, keys []string, args ...any) *redis.Cmd
+	ScriptLoad(ctx context.Context, script string) *redis.StringCmd
+
+	// Пайплайны: Pipelined отправляет команды одним пакетом,
+	// TxPipelined дополнительно оборачивает их в MULTI/EXEC
+	Pipelined(ctx context.Context, fn func(pipe CashCmdable) error) ([]redis.Cmder, error)
+	TxPipelined(ctx context.Context, fn func(pipe CashCmdable) error) ([]redis.Cmder, error)
+
+	// Pub/Sub
+	Publish(ctx context.Context, channel string, message any) *redis.IntCmd
+	Subscribe(ctx context.Context, channels ...string) (CashSubscription, error)
+
 	Ping(ctx context.Context) *redis.StatusCmd
 	Close() error
 }
@@ -0,0 +1,256 @@
+package memoryClient
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	clientsDB "lib/clients/db"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	errWrongType  = errors.New("WRONGTYPE Operation against a key holding the wrong kind of value")
+	errNotInteger = errors.New("ERR value is not an integer or out of range")
+	errNotFloat   = errors.New("ERR value is not a valid float")
+)
+
+// Client is an in-memory implementation of CashClient intended for unit tests.
+// It mirrors Redis semantics (types, TTLs, empty-key removal, redis.Nil) closely
+// enough that code written against the Redis client behaves the same way.
+type Client struct {
+	cmdable
+
+	mu      sync.Mutex
+	store   *store
+	scripts map[string]ScriptFunc
+	loaded  map[string]bool
+	closed  bool
+}
+
+// NewClient creates an empty in-memory client
+func NewClient() *Client {
+	c := &Client{
+		store:   newStore(time.Now),
+		scripts: make(map[string]ScriptFunc),
+		loaded:  make(map[string]bool),
+	}
+	c.cmdable = cmdable{do: c.exec}
+	return c
+}
+
+var _ clientsDB.CashClient = (*Client)(nil)
+
+// cmdable implements every CashCmdable command on top of do, which decides
+// whether the command runs immediately (client) or is queued (pipeline)
+type cmdable struct {
+	do func(cmd redis.Cmder, fn func(s *store))
+}
+
+// exec runs fn against the store under the client lock
+func (c *Client) exec(cmd redis.Cmder, fn func(s *store)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		cmd.SetErr(redis.ErrClosed)
+		return
+	}
+	fn(c.store)
+}
+
+// SetNow overrides the clock used for TTLs and stream IDs
+func (c *Client) SetNow(now func() time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.store.now = now
+}
+
+// FlushAll removes all keys
+func (c *Client) FlushAll() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.store.data = make(map[string]*entry)
+}
+
+// Ping checks the connection to the server
+func (c *Client) Ping(ctx context.Context) *redis.StatusCmd {
+	cmd := redis.NewStatusCmd(ctx, "ping")
+	c.exec(cmd, func(s *store) {
+		cmd.SetVal("PONG")
+	})
+	return cmd
+}
+
+// Close closes all subscriptions; subsequent commands fail with redis.ErrClosed
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return redis.ErrClosed
+	}
+	c.closed = true
+	c.store.closeSubscriptions()
+	return nil
+}
+
+// Get returns the value of key
+func (c cmdable) Get(ctx context.Context, key string) *redis.StringCmd {
+	cmd := redis.NewStringCmd(ctx, "get", key)
+	c.do(cmd, func(s *store) {
+		val, ok, err := s.getString(key)
+		switch {
+		case err != nil:
+			cmd.SetErr(err)
+		case !ok:
+			cmd.SetErr(redis.Nil)
+		default:
+			cmd.SetVal(val)
+		}
+	})
+	return cmd
+}
+
+// Set sets the value of key
+func (c cmdable) Set(ctx context.Context, key string, value any, expiration time.Duration) *redis.StatusCmd {
+	cmd := redis.NewStatusCmd(ctx, "set", key, value)
+	c.do(cmd, func(s *store) {
+		val, err := toString(value)
+		if err != nil {
+			cmd.SetErr(err)
+			return
+		}
+		s.set(key, val, expiration)
+		cmd.SetVal("OK")
+	})
+	return cmd
+}
+
+// Del removes the specified keys
+func (c cmdable) Del(ctx context.Context, keys ...string) *redis.IntCmd {
+	cmd := redis.NewIntCmd(ctx, "del")
+	c.do(cmd, func(s *store) {
+		var n int64
+		for _, key := range keys {
+			if s.lookup(key) != nil {
+				delete(s.data, key)
+				n++
+			}
+		}
+		cmd.SetVal(n)
+	})
+	return cmd
+}
+
+// Exists checks if key exists
+func (c cmdable) Exists(ctx context.Context, keys ...string) *redis.IntCmd {
+	cmd := redis.NewIntCmd(ctx, "exists")
+	c.do(cmd, func(s *store) {
+		var n int64
+		for _, key := range keys {
+			if s.lookup(key) != nil {
+				n++
+			}
+		}
+		cmd.SetVal(n)
+	})
+	return cmd
+}
+
+// Expire sets an expiration time on key
+func (c cmdable) Expire(ctx context.Context, key string, expiration time.Duration) *redis.BoolCmd {
+	cmd := redis.NewBoolCmd(ctx, "expire", key, expiration)
+	c.do(cmd, func(s *store) {
+		e := s.lookup(key)
+		if e == nil {
+			cmd.SetVal(false)
+			return
+		}
+		if expiration <= 0 {
+			delete(s.data, key)
+		} else {
+			e.expireAt = s.now().Add(expiration)
+		}
+		cmd.SetVal(true)
+	})
+	return cmd
+}
+
+// TTL returns the remaining time to live of a key
+func (c cmdable) TTL(ctx context.Context, key string) *redis.DurationCmd {
+	cmd := redis.NewDurationCmd(ctx, time.Second, "ttl", key)
+	c.do(cmd, func(s *store) {
+		e := s.lookup(key)
+		switch {
+		case e == nil:
+			cmd.SetVal(-2)
+		case e.expireAt.IsZero():
+			cmd.SetVal(-1)
+		default:
+			cmd.SetVal(e.expireAt.Sub(s.now()).Round(time.Second))
+		}
+	})
+	return cmd
+}
+
+// SetNX sets the value of key, only if the key does not exist
+func (c cmdable) SetNX(ctx context.Context, key string, value any, expiration time.Duration) *redis.BoolCmd {
+	cmd := redis.NewBoolCmd(ctx, "set", key, value, "nx")
+	c.do(cmd, func(s *store) {
+		if s.lookup(key) != nil {
+			cmd.SetVal(false)
+			return
+		}
+		val, err := toString(value)
+		if err != nil {
+			cmd.SetErr(err)
+			return
+		}
+		s.set(key, val, expiration)
+		cmd.SetVal(true)
+	})
+	return cmd
+}
+
+// Incr increments the number stored at key by one
+func (c cmdable) Incr(ctx context.Context, key string) *redis.IntCmd {
+	cmd := redis.NewIntCmd(ctx, "incr", key)
+	c.do(cmd, func(s *store) {
+		s.incrBy(cmd, key, 1)
+	})
+	return cmd
+}
+
+// Decr decrements the number stored at key by one
+func (c cmdable) Decr(ctx context.Context, key string) *redis.IntCmd {
+	cmd := redis.NewIntCmd(ctx, "decr", key)
+	c.do(cmd, func(s *store) {
+		s.incrBy(cmd, key, -1)
+	})
+	return cmd
+}
+
+func (s *store) incrBy(cmd *redis.IntCmd, key string, delta int64) {
+	val, ok, err := s.getString(key)
+	if err != nil {
+		cmd.SetErr(err)
+		return
+	}
+
+	var n int64
+	if ok {
+		if n, err = strconv.ParseInt(val, 10, 64); err != nil {
+			cmd.SetErr(errNotInteger)
+			return
+		}
+	}
+	n += delta
+
+	// INCR сохраняет TTL ключа
+	s.set(key, strconv.FormatInt(n, 10), redis.KeepTTL)
+	cmd.SetVal(n)
+}
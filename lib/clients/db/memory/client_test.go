@@ -0,0 +1,310 @@
+package memoryClient
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	clientsDB "lib/clients/db"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// clock — управляемое время для проверки TTL
+type clock struct{ now time.Time }
+
+func (c *clock) Now() time.Time          { return c.now }
+func (c *clock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+func newClockClient() (*Client, *clock) {
+	c := NewClient()
+	clk := &clock{now: time.Unix(1_700_000_000, 0)}
+	c.SetNow(clk.Now)
+	return c, clk
+}
+
+func TestTTLExpiry(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		setup   func(c *Client)
+		advance time.Duration
+		alive   bool
+		ttl     time.Duration
+	}{
+		{
+			name:    "set with ttl before expiry",
+			setup:   func(c *Client) { c.Set(ctx, "k", "v", 10*time.Second) },
+			advance: 9 * time.Second,
+			alive:   true,
+			ttl:     time.Second,
+		},
+		{
+			name:    "set with ttl at expiry",
+			setup:   func(c *Client) { c.Set(ctx, "k", "v", 10*time.Second) },
+			advance: 10 * time.Second,
+			ttl:     -2,
+		},
+		{
+			name:    "set without ttl",
+			setup:   func(c *Client) { c.Set(ctx, "k", "v", 0) },
+			advance: time.Hour,
+			alive:   true,
+			ttl:     -1,
+		},
+		{
+			name: "expire on existing key",
+			setup: func(c *Client) {
+				c.Set(ctx, "k", "v", 0)
+				c.Expire(ctx, "k", 5*time.Second)
+			},
+			advance: 5 * time.Second,
+			ttl:     -2,
+		},
+		{
+			name: "incr keeps ttl",
+			setup: func(c *Client) {
+				c.Set(ctx, "k", "1", 10*time.Second)
+				c.Incr(ctx, "k")
+			},
+			advance: 10 * time.Second,
+			ttl:     -2,
+		},
+		{
+			name: "set without ttl clears ttl",
+			setup: func(c *Client) {
+				c.Set(ctx, "k", "v", 10*time.Second)
+				c.Set(ctx, "k", "v", 0)
+			},
+			advance: 10 * time.Second,
+			alive:   true,
+			ttl:     -1,
+		},
+		{
+			name: "hash keeps ttl after hset",
+			setup: func(c *Client) {
+				c.HSet(ctx, "k", "f", "1")
+				c.Expire(ctx, "k", 10*time.Second)
+				c.HSet(ctx, "k", "g", "2")
+			},
+			advance: 10 * time.Second,
+			ttl:     -2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, clk := newClockClient()
+			tt.setup(c)
+			clk.Advance(tt.advance)
+
+			if n := c.Exists(ctx, "k").Val(); (n == 1) != tt.alive {
+				t.Errorf("exists = %d, want alive %v", n, tt.alive)
+			}
+			if ttl := c.TTL(ctx, "k").Val(); ttl != tt.ttl {
+				t.Errorf("ttl = %v, want %v", ttl, tt.ttl)
+			}
+		})
+	}
+}
+
+func TestZRangeByScoreBounds(t *testing.T) {
+	ctx := context.Background()
+	c := NewClient()
+	c.ZAdd(ctx, "blocks",
+		redis.Z{Score: 1, Member: "a"},
+		redis.Z{Score: 2, Member: "b"},
+		redis.Z{Score: 2, Member: "c"},
+		redis.Z{Score: 3, Member: "d"},
+	)
+
+	tests := []struct {
+		name string
+		opt  redis.ZRangeBy
+		want []string
+	}{
+		{"inclusive", redis.ZRangeBy{Min: "1", Max: "2"}, []string{"a", "b", "c"}},
+		{"exclusive min", redis.ZRangeBy{Min: "(1", Max: "3"}, []string{"b", "c", "d"}},
+		{"exclusive max", redis.ZRangeBy{Min: "1", Max: "(3"}, []string{"a", "b", "c"}},
+		{"both exclusive", redis.ZRangeBy{Min: "(1", Max: "(3"}, []string{"b", "c"}},
+		{"infinite", redis.ZRangeBy{Min: "-inf", Max: "+inf"}, []string{"a", "b", "c", "d"}},
+		{"empty", redis.ZRangeBy{Min: "(2", Max: "(3"}, []string{}},
+		{"limit", redis.ZRangeBy{Min: "-inf", Max: "+inf", Offset: 1, Count: 2}, []string{"b", "c"}},
+		{"limit past end", redis.ZRangeBy{Min: "-inf", Max: "+inf", Offset: 10, Count: 2}, []string{}},
+		{"negative count", redis.ZRangeBy{Min: "-inf", Max: "+inf", Offset: 2, Count: -1}, []string{"c", "d"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := c.ZRangeByScore(ctx, "blocks", &tt.opt).Result()
+			if err != nil {
+				t.Fatalf("zrangebyscore: %v", err)
+			}
+			if len(got) != 0 || len(tt.want) != 0 {
+				if !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("zrangebyscore %s..%s = %v, want %v", tt.opt.Min, tt.opt.Max, got, tt.want)
+				}
+			}
+		})
+	}
+
+	if err := c.ZRangeByScore(ctx, "blocks", &redis.ZRangeBy{Min: "x", Max: "1"}).Err(); err == nil {
+		t.Error("zrangebyscore with invalid bound succeeded")
+	}
+	if got := c.ZRangeByScore(ctx, "missing", &redis.ZRangeBy{Min: "-inf", Max: "+inf"}).Val(); len(got) != 0 {
+		t.Errorf("zrangebyscore on missing key = %v", got)
+	}
+}
+
+func TestXReadGroupAndXAck(t *testing.T) {
+	ctx := context.Background()
+	c := NewClient()
+
+	for _, v := range []string{"1", "2", "3"} {
+		if err := c.XAdd(ctx, &redis.XAddArgs{Stream: "s", Values: []any{"v", v}}).Err(); err != nil {
+			t.Fatalf("xadd: %v", err)
+		}
+	}
+	if err := c.XGroupCreateMkStream(ctx, "s", "g", "0").Err(); err != nil {
+		t.Fatalf("create group: %v", err)
+	}
+	if err := c.XGroupCreateMkStream(ctx, "s", "g", "0").Err(); err == nil {
+		t.Error("creating an existing group succeeded")
+	}
+
+	read := func(consumer, id string, count int64) ([]redis.XMessage, error) {
+		streams, err := c.XReadGroup(ctx, &redis.XReadGroupArgs{
+			Group: "g", Consumer: consumer, Streams: []string{"s", id}, Count: count,
+		}).Result()
+		if err != nil {
+			return nil, err
+		}
+		return streams[0].Messages, nil
+	}
+	values := func(msgs []redis.XMessage) []string {
+		out := make([]string, len(msgs))
+		for i, m := range msgs {
+			out[i] = m.Values["v"].(string)
+		}
+		return out
+	}
+
+	steps := []struct {
+		name     string
+		consumer string
+		id       string
+		count    int64
+		want     []string
+		wantErr  error
+	}{
+		{"new entries are split between consumers", "a", ">", 2, []string{"1", "2"}, nil},
+		{"second consumer gets the rest", "b", ">", 0, []string{"3"}, nil},
+		{"nothing new", "a", ">", 0, nil, redis.Nil},
+		{"pending history of a", "a", "0", 0, []string{"1", "2"}, nil},
+		{"pending history after id", "a", "", 0, []string{"2"}, nil},
+		{"pending history of b", "b", "0", 0, []string{"3"}, nil},
+	}
+
+	var firstID string
+	for _, step := range steps {
+		id := step.id
+		if id == "" {
+			id = firstID
+		}
+		got, err := read(step.consumer, id, step.count)
+		if !errors.Is(err, step.wantErr) {
+			t.Fatalf("%s: err = %v, want %v", step.name, err, step.wantErr)
+		}
+		if step.wantErr == nil && !reflect.DeepEqual(values(got), step.want) {
+			t.Fatalf("%s: read %v, want %v", step.name, values(got), step.want)
+		}
+		if firstID == "" && len(got) > 0 {
+			firstID = got[0].ID
+		}
+	}
+
+	if n := c.XAck(ctx, "s", "g", firstID, firstID).Val(); n != 1 {
+		t.Errorf("xack = %d, want 1", n)
+	}
+	got, err := read("a", "0", 0)
+	if err != nil || !reflect.DeepEqual(values(got), []string{"2"}) {
+		t.Errorf("pending after ack = %v, %v, want [2]", values(got), err)
+	}
+
+	// Новые записи после создания группы с "$" и ошибки без группы
+	if err := c.XGroupCreateMkStream(ctx, "s", "tail", "$").Err(); err != nil {
+		t.Fatalf("create tail group: %v", err)
+	}
+	c.XAdd(ctx, &redis.XAddArgs{Stream: "s", Values: []any{"v", "4"}})
+	streams, err := c.XReadGroup(ctx, &redis.XReadGroupArgs{Group: "tail", Consumer: "a", Streams: []string{"s", ">"}}).Result()
+	if err != nil || !reflect.DeepEqual(values(streams[0].Messages), []string{"4"}) {
+		t.Errorf("tail group read = %v, %v, want [4]", streams, err)
+	}
+	if err := c.XReadGroup(ctx, &redis.XReadGroupArgs{Group: "missing", Consumer: "a", Streams: []string{"s", ">"}}).Err(); err == nil || errors.Is(err, redis.Nil) {
+		t.Errorf("read from missing group = %v, want NOGROUP", err)
+	}
+	if err := c.XGroupCreateMkStream(ctx, "new", "g", "$").Err(); err != nil || c.Exists(ctx, "new").Val() != 1 {
+		t.Errorf("create group with mkstream = %v, stream exists %d", err, c.Exists(ctx, "new").Val())
+	}
+}
+
+func TestPipelineOrdering(t *testing.T) {
+	ctx := context.Background()
+	c := NewClient()
+
+	var get *redis.StringCmd
+	cmds, err := c.Pipelined(ctx, func(pipe clientsDB.CashCmdable) error {
+		pipe.Set(ctx, "k", "1", 0)
+		pipe.Incr(ctx, "k")
+		get = pipe.Get(ctx, "k")
+		pipe.RPush(ctx, "l", "a")
+		pipe.LPush(ctx, "l", "b")
+		pipe.Del(ctx, "k")
+
+		// До выполнения пайплайна команды не применены
+		if c.Exists(ctx, "k").Val() != 0 {
+			t.Error("pipeline command applied before Pipelined returned")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("pipelined: %v", err)
+	}
+	if len(cmds) != 6 {
+		t.Fatalf("%d results, want 6", len(cmds))
+	}
+	if get.Val() != "2" {
+		t.Errorf("get inside pipeline = %q, want 2 (commands applied in order)", get.Val())
+	}
+	if got := c.LRange(ctx, "l", 0, -1).Val(); !reflect.DeepEqual(got, []string{"b", "a"}) {
+		t.Errorf("list = %v, want [b a]", got)
+	}
+	if c.Exists(ctx, "k").Val() != 0 {
+		t.Error("key deleted by the last pipeline command still exists")
+	}
+
+	// Ошибка одной команды не отменяет остальные; возвращается первая ошибка
+	c.HSet(ctx, "h", "f", "v")
+	cmds, err = c.Pipelined(ctx, func(pipe clientsDB.CashCmdable) error {
+		pipe.Incr(ctx, "h")
+		pipe.Set(ctx, "after", "x", 0)
+		return nil
+	})
+	if err == nil || err.Error() != errWrongType.Error() {
+		t.Errorf("pipeline error = %v, want WRONGTYPE", err)
+	}
+	if cmds[1].Err() != nil || c.Get(ctx, "after").Val() != "x" {
+		t.Errorf("command after a failed one: %v", cmds[1].Err())
+	}
+
+	// Ошибка fn — ничего не выполняется
+	failed := errors.New("abort")
+	if _, err := c.Pipelined(ctx, func(pipe clientsDB.CashCmdable) error {
+		pipe.Set(ctx, "aborted", "x", 0)
+		return failed
+	}); !errors.Is(err, failed) || c.Exists(ctx, "aborted").Val() != 0 {
+		t.Errorf("aborted pipeline = %v, key exists %d", err, c.Exists(ctx, "aborted").Val())
+	}
+}
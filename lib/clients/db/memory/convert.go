@@ -0,0 +1,113 @@
+package memoryClient
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// toString formats an argument the same way go-redis writes it to the wire
+func toString(v any) (string, error) {
+	switch v := v.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case []byte:
+		return string(v), nil
+	case int:
+		return strconv.FormatInt(int64(v), 10), nil
+	case int8:
+		return strconv.FormatInt(int64(v), 10), nil
+	case int16:
+		return strconv.FormatInt(int64(v), 10), nil
+	case int32:
+		return strconv.FormatInt(int64(v), 10), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case uint:
+		return strconv.FormatUint(uint64(v), 10), nil
+	case uint8:
+		return strconv.FormatUint(uint64(v), 10), nil
+	case uint16:
+		return strconv.FormatUint(uint64(v), 10), nil
+	case uint32:
+		return strconv.FormatUint(uint64(v), 10), nil
+	case uint64:
+		return strconv.FormatUint(v, 10), nil
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case bool:
+		if v {
+			return "1", nil
+		}
+		return "0", nil
+	case time.Time:
+		return v.Format(time.RFC3339Nano), nil
+	case time.Duration:
+		return strconv.FormatInt(v.Nanoseconds(), 10), nil
+	case encoding.BinaryMarshaler:
+		b, err := v.MarshalBinary()
+		if err != nil {
+			return "", err
+		}
+		return string(b), nil
+	default:
+		return "", fmt.Errorf("redis: can't marshal %T (implement encoding.BinaryMarshaler)", v)
+	}
+}
+
+// toPairs flattens HSET/XADD style arguments into field/value pairs.
+// Accepted forms: "k1", "v1", "k2", "v2"; []string; []any; map[string]any; map[string]string.
+func toPairs(values []any) ([][2]string, error) {
+	if len(values) == 1 {
+		switch v := values[0].(type) {
+		case map[string]any:
+			pairs := make([][2]string, 0, len(v))
+			for field, val := range v {
+				str, err := toString(val)
+				if err != nil {
+					return nil, err
+				}
+				pairs = append(pairs, [2]string{field, str})
+			}
+			return pairs, nil
+		case map[string]string:
+			pairs := make([][2]string, 0, len(v))
+			for field, val := range v {
+				pairs = append(pairs, [2]string{field, val})
+			}
+			return pairs, nil
+		case []string:
+			flat := make([]any, len(v))
+			for i, s := range v {
+				flat[i] = s
+			}
+			values = flat
+		case []any:
+			values = v
+		}
+	}
+
+	if len(values) == 0 || len(values)%2 != 0 {
+		return nil, errors.New("ERR wrong number of arguments")
+	}
+
+	pairs := make([][2]string, 0, len(values)/2)
+	for i := 0; i < len(values); i += 2 {
+		field, err := toString(values[i])
+		if err != nil {
+			return nil, err
+		}
+		val, err := toString(values[i+1])
+		if err != nil {
+			return nil, err
+		}
+		pairs = append(pairs, [2]string{field, val})
+	}
+	return pairs, nil
+}
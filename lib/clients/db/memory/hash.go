@@ -0,0 +1,199 @@
+package memoryClient
+
+import (
+	"context"
+	"sort"
+	"strconv"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// HGet returns the value of field in the hash stored at key
+func (c cmdable) HGet(ctx context.Context, key, field string) *redis.StringCmd {
+	cmd := redis.NewStringCmd(ctx, "hget", key, field)
+	c.do(cmd, func(s *store) {
+		h, _, err := typed[map[string]string](s, key)
+		if err != nil {
+			cmd.SetErr(err)
+			return
+		}
+		val, ok := h[field]
+		if !ok {
+			cmd.SetErr(redis.Nil)
+			return
+		}
+		cmd.SetVal(val)
+	})
+	return cmd
+}
+
+// HSet sets fields in the hash stored at key
+func (c cmdable) HSet(ctx context.Context, key string, values ...any) *redis.IntCmd {
+	cmd := redis.NewIntCmd(ctx, "hset", key)
+	c.do(cmd, func(s *store) {
+		pairs, err := toPairs(values)
+		if err != nil {
+			cmd.SetErr(err)
+			return
+		}
+		h, ok, err := typed[map[string]string](s, key)
+		if err != nil {
+			cmd.SetErr(err)
+			return
+		}
+		if !ok {
+			h = make(map[string]string)
+		}
+
+		var added int64
+		for _, p := range pairs {
+			if _, exists := h[p[0]]; !exists {
+				added++
+			}
+			h[p[0]] = p[1]
+		}
+		s.put(key, h, false)
+		cmd.SetVal(added)
+	})
+	return cmd
+}
+
+// HGetAll returns all fields and values of the hash stored at key
+func (c cmdable) HGetAll(ctx context.Context, key string) *redis.MapStringStringCmd {
+	cmd := redis.NewMapStringStringCmd(ctx, "hgetall", key)
+	c.do(cmd, func(s *store) {
+		h, _, err := typed[map[string]string](s, key)
+		if err != nil {
+			cmd.SetErr(err)
+			return
+		}
+		out := make(map[string]string, len(h))
+		for field, val := range h {
+			out[field] = val
+		}
+		cmd.SetVal(out)
+	})
+	return cmd
+}
+
+// HMGet returns the values associated with the specified fields
+func (c cmdable) HMGet(ctx context.Context, key string, fields ...string) *redis.SliceCmd {
+	cmd := redis.NewSliceCmd(ctx, "hmget", key)
+	c.do(cmd, func(s *store) {
+		h, _, err := typed[map[string]string](s, key)
+		if err != nil {
+			cmd.SetErr(err)
+			return
+		}
+		out := make([]any, len(fields))
+		for i, field := range fields {
+			if val, ok := h[field]; ok {
+				out[i] = val
+			}
+		}
+		cmd.SetVal(out)
+	})
+	return cmd
+}
+
+// HDel removes the specified fields from the hash stored at key
+func (c cmdable) HDel(ctx context.Context, key string, fields ...string) *redis.IntCmd {
+	cmd := redis.NewIntCmd(ctx, "hdel", key)
+	c.do(cmd, func(s *store) {
+		h, ok, err := typed[map[string]string](s, key)
+		if err != nil {
+			cmd.SetErr(err)
+			return
+		}
+		if !ok {
+			cmd.SetVal(0)
+			return
+		}
+		var removed int64
+		for _, field := range fields {
+			if _, exists := h[field]; exists {
+				delete(h, field)
+				removed++
+			}
+		}
+		s.put(key, h, len(h) == 0)
+		cmd.SetVal(removed)
+	})
+	return cmd
+}
+
+// HExists checks if field exists in the hash stored at key
+func (c cmdable) HExists(ctx context.Context, key, field string) *redis.BoolCmd {
+	cmd := redis.NewBoolCmd(ctx, "hexists", key, field)
+	c.do(cmd, func(s *store) {
+		h, _, err := typed[map[string]string](s, key)
+		if err != nil {
+			cmd.SetErr(err)
+			return
+		}
+		_, ok := h[field]
+		cmd.SetVal(ok)
+	})
+	return cmd
+}
+
+// HIncrBy increments the number stored at field by incr
+func (c cmdable) HIncrBy(ctx context.Context, key, field string, incr int64) *redis.IntCmd {
+	cmd := redis.NewIntCmd(ctx, "hincrby", key, field, incr)
+	c.do(cmd, func(s *store) {
+		h, ok, err := typed[map[string]string](s, key)
+		if err != nil {
+			cmd.SetErr(err)
+			return
+		}
+		if !ok {
+			h = make(map[string]string)
+		}
+
+		var n int64
+		if val, exists := h[field]; exists {
+			if n, err = strconv.ParseInt(val, 10, 64); err != nil {
+				cmd.SetErr(errNotInteger)
+				return
+			}
+		}
+		n += incr
+		h[field] = strconv.FormatInt(n, 10)
+		s.put(key, h, false)
+		cmd.SetVal(n)
+	})
+	return cmd
+}
+
+// HLen returns the number of fields in the hash stored at key
+func (c cmdable) HLen(ctx context.Context, key string) *redis.IntCmd {
+	cmd := redis.NewIntCmd(ctx, "hlen", key)
+	c.do(cmd, func(s *store) {
+		h, _, err := typed[map[string]string](s, key)
+		if err != nil {
+			cmd.SetErr(err)
+			return
+		}
+		cmd.SetVal(int64(len(h)))
+	})
+	return cmd
+}
+
+// HKeys returns all field names in the hash stored at key
+func (c cmdable) HKeys(ctx context.Context, key string) *redis.StringSliceCmd {
+	cmd := redis.NewStringSliceCmd(ctx, "hkeys", key)
+	c.do(cmd, func(s *store) {
+		h, _, err := typed[map[string]string](s, key)
+		if err != nil {
+			cmd.SetErr(err)
+			return
+		}
+		keys := make([]string, 0, len(h))
+		for field := range h {
+			keys = append(keys, field)
+		}
+		sort.Strings(keys)
+		cmd.SetVal(keys)
+	})
+	return cmd
+}
@@ -0,0 +1,186 @@
+package memoryClient
+
+import (
+	"context"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func (s *store) push(cmd *redis.IntCmd, key string, values []any, left bool) {
+	list, _, err := typed[[]string](s, key)
+	if err != nil {
+		cmd.SetErr(err)
+		return
+	}
+
+	for _, v := range values {
+		val, err := toString(v)
+		if err != nil {
+			cmd.SetErr(err)
+			return
+		}
+		if left {
+			list = append([]string{val}, list...)
+		} else {
+			list = append(list, val)
+		}
+	}
+	s.put(key, list, len(list) == 0)
+	cmd.SetVal(int64(len(list)))
+}
+
+func (s *store) pop(cmd *redis.StringCmd, key string, left bool) {
+	list, ok, err := typed[[]string](s, key)
+	if err != nil {
+		cmd.SetErr(err)
+		return
+	}
+	if !ok {
+		cmd.SetErr(redis.Nil)
+		return
+	}
+
+	var val string
+	if left {
+		val, list = list[0], list[1:]
+	} else {
+		val, list = list[len(list)-1], list[:len(list)-1]
+	}
+	s.put(key, list, len(list) == 0)
+	cmd.SetVal(val)
+}
+
+// LPush prepends values to the list stored at key
+func (c cmdable) LPush(ctx context.Context, key string, values ...any) *redis.IntCmd {
+	cmd := redis.NewIntCmd(ctx, "lpush", key)
+	c.do(cmd, func(s *store) {
+		s.push(cmd, key, values, true)
+	})
+	return cmd
+}
+
+// RPush appends values to the list stored at key
+func (c cmdable) RPush(ctx context.Context, key string, values ...any) *redis.IntCmd {
+	cmd := redis.NewIntCmd(ctx, "rpush", key)
+	c.do(cmd, func(s *store) {
+		s.push(cmd, key, values, false)
+	})
+	return cmd
+}
+
+// LPop removes and returns the first element of the list stored at key
+func (c cmdable) LPop(ctx context.Context, key string) *redis.StringCmd {
+	cmd := redis.NewStringCmd(ctx, "lpop", key)
+	c.do(cmd, func(s *store) {
+		s.pop(cmd, key, true)
+	})
+	return cmd
+}
+
+// RPop removes and returns the last element of the list stored at key
+func (c cmdable) RPop(ctx context.Context, key string) *redis.StringCmd {
+	cmd := redis.NewStringCmd(ctx, "rpop", key)
+	c.do(cmd, func(s *store) {
+		s.pop(cmd, key, false)
+	})
+	return cmd
+}
+
+// LRange returns the specified elements of the list stored at key
+func (c cmdable) LRange(ctx context.Context, key string, start, stop int64) *redis.StringSliceCmd {
+	cmd := redis.NewStringSliceCmd(ctx, "lrange", key, start, stop)
+	c.do(cmd, func(s *store) {
+		list, _, err := typed[[]string](s, key)
+		if err != nil {
+			cmd.SetErr(err)
+			return
+		}
+		lo, hi := normalizeRange(start, stop, len(list))
+		cmd.SetVal(append([]string{}, list[lo:hi]...))
+	})
+	return cmd
+}
+
+// LLen returns the length of the list stored at key
+func (c cmdable) LLen(ctx context.Context, key string) *redis.IntCmd {
+	cmd := redis.NewIntCmd(ctx, "llen", key)
+	c.do(cmd, func(s *store) {
+		list, _, err := typed[[]string](s, key)
+		if err != nil {
+			cmd.SetErr(err)
+			return
+		}
+		cmd.SetVal(int64(len(list)))
+	})
+	return cmd
+}
+
+// LTrim trims the list so that it contains only the specified range
+func (c cmdable) LTrim(ctx context.Context, key string, start, stop int64) *redis.StatusCmd {
+	cmd := redis.NewStatusCmd(ctx, "ltrim", key, start, stop)
+	c.do(cmd, func(s *store) {
+		list, ok, err := typed[[]string](s, key)
+		if err != nil {
+			cmd.SetErr(err)
+			return
+		}
+		if ok {
+			lo, hi := normalizeRange(start, stop, len(list))
+			list = append([]string{}, list[lo:hi]...)
+			s.put(key, list, len(list) == 0)
+		}
+		cmd.SetVal("OK")
+	})
+	return cmd
+}
+
+// LRem removes count occurrences of value from the list stored at key.
+// count > 0 removes from head to tail, count < 0 from tail to head, 0 removes all.
+func (c cmdable) LRem(ctx context.Context, key string, count int64, value any) *redis.IntCmd {
+	cmd := redis.NewIntCmd(ctx, "lrem", key, count, value)
+	c.do(cmd, func(s *store) {
+		list, ok, err := typed[[]string](s, key)
+		if err != nil {
+			cmd.SetErr(err)
+			return
+		}
+		val, err := toString(value)
+		if err != nil {
+			cmd.SetErr(err)
+			return
+		}
+		if !ok {
+			cmd.SetVal(0)
+			return
+		}
+
+		limit := count
+		if limit < 0 {
+			limit = -limit
+		}
+
+		keep := make([]bool, len(list))
+		var removed int64
+		for i := range list {
+			idx := i
+			if count < 0 {
+				idx = len(list) - 1 - i
+			}
+			if list[idx] == val && (limit == 0 || removed < limit) {
+				removed++
+				continue
+			}
+			keep[idx] = true
+		}
+
+		out := make([]string, 0, len(list)-int(removed))
+		for i, v := range list {
+			if keep[i] {
+				out = append(out, v)
+			}
+		}
+		s.put(key, out, len(out) == 0)
+		cmd.SetVal(removed)
+	})
+	return cmd
+}
@@ -0,0 +1,54 @@
+package memoryClient
+
+import (
+	"context"
+
+	clientsDB "lib/clients/db"
+
+	"github.com/redis/go-redis/v9"
+)
+
+type queued struct {
+	cmd   redis.Cmder
+	apply func(s *store)
+}
+
+// Pipelined queues the commands issued by fn and applies them in one step.
+// Command results are available only after Pipelined returns, as with Redis.
+func (c *Client) Pipelined(ctx context.Context, fn func(pipe clientsDB.CashCmdable) error) ([]redis.Cmder, error) {
+	var queue []queued
+	pipe := cmdable{do: func(cmd redis.Cmder, apply func(s *store)) {
+		queue = append(queue, queued{cmd: cmd, apply: apply})
+	}}
+
+	if err := fn(pipe); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cmds := make([]redis.Cmder, len(queue))
+	for i, q := range queue {
+		cmds[i] = q.cmd
+		if c.closed {
+			q.cmd.SetErr(redis.ErrClosed)
+			continue
+		}
+		q.apply(c.store)
+	}
+
+	// Как и go-redis, возвращаем ошибку первой неуспешной команды
+	for _, cmd := range cmds {
+		if err := cmd.Err(); err != nil {
+			return cmds, err
+		}
+	}
+	return cmds, nil
+}
+
+// TxPipelined works like Pipelined; the in-memory store applies
+// every pipeline atomically, so MULTI/EXEC needs no extra handling
+func (c *Client) TxPipelined(ctx context.Context, fn func(pipe clientsDB.CashCmdable) error) ([]redis.Cmder, error) {
+	return c.Pipelined(ctx, fn)
+}
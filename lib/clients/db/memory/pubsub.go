@@ -0,0 +1,101 @@
+package memoryClient
+
+import (
+	"context"
+	"sync"
+
+	clientsDB "lib/clients/db"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// subscriptionBuffer — размер буфера канала подписки; при переполнении
+// сообщения отбрасываются, как Redis отключает медленных подписчиков
+const subscriptionBuffer = 100
+
+type subscription struct {
+	client   *Client
+	channels []string
+	ch       chan *redis.Message
+	once     sync.Once
+}
+
+// Publish posts a message to the given channel and returns the number of receivers
+func (c *Client) Publish(ctx context.Context, channel string, message any) *redis.IntCmd {
+	cmd := redis.NewIntCmd(ctx, "publish", channel, message)
+	c.exec(cmd, func(s *store) {
+		payload, err := toString(message)
+		if err != nil {
+			cmd.SetErr(err)
+			return
+		}
+
+		var receivers int64
+		for sub := range s.subs[channel] {
+			select {
+			case sub.ch <- &redis.Message{Channel: channel, Payload: payload}:
+				receivers++
+			default:
+			}
+		}
+		cmd.SetVal(receivers)
+	})
+	return cmd
+}
+
+// Subscribe subscribes to the given channels
+func (c *Client) Subscribe(ctx context.Context, channels ...string) (clientsDB.CashSubscription, error) {
+	sub := &subscription{
+		client:   c,
+		channels: channels,
+		ch:       make(chan *redis.Message, subscriptionBuffer),
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return nil, redis.ErrClosed
+	}
+	for _, channel := range channels {
+		if c.store.subs[channel] == nil {
+			c.store.subs[channel] = make(map[*subscription]struct{})
+		}
+		c.store.subs[channel][sub] = struct{}{}
+	}
+	return sub, nil
+}
+
+// Channel returns the channel of received messages
+func (s *subscription) Channel() <-chan *redis.Message {
+	return s.ch
+}
+
+// Close unsubscribes from all channels
+func (s *subscription) Close() error {
+	s.client.mu.Lock()
+	defer s.client.mu.Unlock()
+	s.client.store.unsubscribe(s)
+	return nil
+}
+
+// unsubscribe is called with the client lock held
+func (st *store) unsubscribe(sub *subscription) {
+	sub.once.Do(func() {
+		for _, channel := range sub.channels {
+			delete(st.subs[channel], sub)
+			if len(st.subs[channel]) == 0 {
+				delete(st.subs, channel)
+			}
+		}
+		close(sub.ch)
+	})
+}
+
+func (st *store) closeSubscriptions() {
+	for _, subs := range st.subs {
+		for sub := range subs {
+			st.unsubscribe(sub)
+		}
+	}
+}
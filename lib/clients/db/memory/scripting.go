@@ -0,0 +1,104 @@
+package memoryClient
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+
+	clientsDB "lib/clients/db"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	errNoScript      = errors.New("NOSCRIPT No matching script. Please use EVAL.")
+	errLuaNotSupport = errors.New("memoryClient: lua is not interpreted, register a Go implementation with RegisterScript")
+)
+
+// ScriptFunc is a Go stand-in for a Lua script. It runs atomically:
+// commands issued through tx are applied immediately while the client is locked.
+type ScriptFunc func(ctx context.Context, tx clientsDB.CashCmdable, keys []string, args ...any) (any, error)
+
+// RegisterScript binds a Go implementation to the given Lua source,
+// so that Eval/EvalSha of that script behave as on a real server
+func (c *Client) RegisterScript(script string, fn ScriptFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.scripts[scriptSHA(script)] = fn
+}
+
+func scriptSHA(script string) string {
+	sum := sha1.Sum([]byte(script))
+	return hex.EncodeToString(sum[:])
+}
+
+// Eval executes a registered script
+func (c *Client) Eval(ctx context.Context, script string, keys []string, args ...any) *redis.Cmd {
+	cmd := redis.NewCmd(ctx, "eval", script, len(keys))
+	c.exec(cmd, func(s *store) {
+		sha := scriptSHA(script)
+		c.markLoaded(sha)
+		c.runScript(ctx, cmd, s, sha, keys, args)
+	})
+	return cmd
+}
+
+// EvalSha executes a script previously loaded with ScriptLoad or Eval.
+// Scripts without a Go implementation are never loaded, so EvalSha
+// returns NOSCRIPT for them and go-redis falls back to Eval.
+func (c *Client) EvalSha(ctx context.Context, sha1 string, keys []string, args ...any) *redis.Cmd {
+	cmd := redis.NewCmd(ctx, "evalsha", sha1, len(keys))
+	c.exec(cmd, func(s *store) {
+		if !c.loaded[sha1] {
+			cmd.SetErr(errNoScript)
+			return
+		}
+		c.runScript(ctx, cmd, s, sha1, keys, args)
+	})
+	return cmd
+}
+
+// ScriptLoad marks the script as loaded and returns its SHA1
+func (c *Client) ScriptLoad(ctx context.Context, script string) *redis.StringCmd {
+	cmd := redis.NewStringCmd(ctx, "script", "load", script)
+	c.exec(cmd, func(s *store) {
+		sha := scriptSHA(script)
+		c.markLoaded(sha)
+		cmd.SetVal(sha)
+	})
+	return cmd
+}
+
+// markLoaded is called with the client lock held; only scripts
+// registered with RegisterScript can be loaded
+func (c *Client) markLoaded(sha string) {
+	if _, ok := c.scripts[sha]; ok {
+		c.loaded[sha] = true
+	}
+}
+
+// runScript is called with the client lock held
+func (c *Client) runScript(ctx context.Context, cmd *redis.Cmd, s *store, sha string, keys []string, args []any) {
+	fn, ok := c.scripts[sha]
+	if !ok {
+		cmd.SetErr(errLuaNotSupport)
+		return
+	}
+
+	tx := cmdable{do: func(_ redis.Cmder, apply func(s *store)) {
+		apply(s)
+	}}
+
+	res, err := fn(ctx, tx, keys, args...)
+	if err != nil {
+		cmd.SetErr(err)
+		return
+	}
+	if res == nil {
+		// Lua nil превращается в redis.Nil, как на настоящем сервере
+		cmd.SetErr(redis.Nil)
+		return
+	}
+	cmd.SetVal(res)
+}
@@ -0,0 +1,350 @@
+package memoryClient
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+)
+
+type sortedSet struct {
+	scores map[string]float64
+}
+
+// sorted returns members ordered by score, ties broken lexicographically
+func (z *sortedSet) sorted() []redis.Z {
+	out := make([]redis.Z, 0, len(z.scores))
+	for member, score := range z.scores {
+		out = append(out, redis.Z{Score: score, Member: member})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Score != out[j].Score {
+			return out[i].Score < out[j].Score
+		}
+		return out[i].Member.(string) < out[j].Member.(string)
+	})
+	return out
+}
+
+// scoreBound parses a ZRANGEBYSCORE bound: "-inf", "+inf", "1.5" or "(1.5"
+func scoreBound(bound string) (float64, bool, error) {
+	exclusive := strings.HasPrefix(bound, "(")
+	bound = strings.TrimPrefix(bound, "(")
+
+	switch strings.ToLower(bound) {
+	case "-inf":
+		return math.Inf(-1), exclusive, nil
+	case "+inf", "inf":
+		return math.Inf(1), exclusive, nil
+	}
+
+	val, err := strconv.ParseFloat(bound, 64)
+	if err != nil {
+		return 0, false, errors.New("ERR min or max is not a float")
+	}
+	return val, exclusive, nil
+}
+
+// byScore returns members whose score lies within [min, max] respecting exclusive bounds
+func (z *sortedSet) byScore(min, max string) ([]redis.Z, error) {
+	lo, loEx, err := scoreBound(min)
+	if err != nil {
+		return nil, err
+	}
+	hi, hiEx, err := scoreBound(max)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []redis.Z
+	for _, m := range z.sorted() {
+		if m.Score < lo || (loEx && m.Score == lo) {
+			continue
+		}
+		if m.Score > hi || (hiEx && m.Score == hi) {
+			continue
+		}
+		out = append(out, m)
+	}
+	return out, nil
+}
+
+func members(zs []redis.Z) []string {
+	out := make([]string, len(zs))
+	for i, m := range zs {
+		out[i] = m.Member.(string)
+	}
+	return out
+}
+
+func reversed(zs []redis.Z) []redis.Z {
+	out := make([]redis.Z, len(zs))
+	for i, m := range zs {
+		out[len(zs)-1-i] = m
+	}
+	return out
+}
+
+// rangeOf returns the members between start and stop (inclusive, Redis indexes)
+func (s *store) rangeOf(key string, start, stop int64, rev bool) ([]redis.Z, error) {
+	z, ok, err := typed[*sortedSet](s, key)
+	if err != nil || !ok {
+		return nil, err
+	}
+	all := z.sorted()
+	if rev {
+		all = reversed(all)
+	}
+	lo, hi := normalizeRange(start, stop, len(all))
+	return all[lo:hi], nil
+}
+
+// ZAdd adds members with their scores to the sorted set stored at key
+func (c cmdable) ZAdd(ctx context.Context, key string, members ...redis.Z) *redis.IntCmd {
+	cmd := redis.NewIntCmd(ctx, "zadd", key)
+	c.do(cmd, func(s *store) {
+		z, ok, err := typed[*sortedSet](s, key)
+		if err != nil {
+			cmd.SetErr(err)
+			return
+		}
+		if !ok {
+			z = &sortedSet{scores: make(map[string]float64)}
+		}
+
+		var added int64
+		for _, m := range members {
+			member, err := toString(m.Member)
+			if err != nil {
+				cmd.SetErr(err)
+				return
+			}
+			if _, exists := z.scores[member]; !exists {
+				added++
+			}
+			z.scores[member] = m.Score
+		}
+		s.put(key, z, len(z.scores) == 0)
+		cmd.SetVal(added)
+	})
+	return cmd
+}
+
+// ZRem removes the specified members from the sorted set stored at key
+func (c cmdable) ZRem(ctx context.Context, key string, members ...any) *redis.IntCmd {
+	cmd := redis.NewIntCmd(ctx, "zrem", key)
+	c.do(cmd, func(s *store) {
+		z, ok, err := typed[*sortedSet](s, key)
+		if err != nil {
+			cmd.SetErr(err)
+			return
+		}
+		if !ok {
+			cmd.SetVal(0)
+			return
+		}
+
+		var removed int64
+		for _, m := range members {
+			member, err := toString(m)
+			if err != nil {
+				cmd.SetErr(err)
+				return
+			}
+			if _, exists := z.scores[member]; exists {
+				delete(z.scores, member)
+				removed++
+			}
+		}
+		s.put(key, z, len(z.scores) == 0)
+		cmd.SetVal(removed)
+	})
+	return cmd
+}
+
+// ZScore returns the score of member in the sorted set stored at key
+func (c cmdable) ZScore(ctx context.Context, key, member string) *redis.FloatCmd {
+	cmd := redis.NewFloatCmd(ctx, "zscore", key, member)
+	c.do(cmd, func(s *store) {
+		z, ok, err := typed[*sortedSet](s, key)
+		if err != nil {
+			cmd.SetErr(err)
+			return
+		}
+		if !ok {
+			cmd.SetErr(redis.Nil)
+			return
+		}
+		score, exists := z.scores[member]
+		if !exists {
+			cmd.SetErr(redis.Nil)
+			return
+		}
+		cmd.SetVal(score)
+	})
+	return cmd
+}
+
+// ZCard returns the number of members in the sorted set stored at key
+func (c cmdable) ZCard(ctx context.Context, key string) *redis.IntCmd {
+	cmd := redis.NewIntCmd(ctx, "zcard", key)
+	c.do(cmd, func(s *store) {
+		z, ok, err := typed[*sortedSet](s, key)
+		if err != nil {
+			cmd.SetErr(err)
+			return
+		}
+		if !ok {
+			cmd.SetVal(0)
+			return
+		}
+		cmd.SetVal(int64(len(z.scores)))
+	})
+	return cmd
+}
+
+// ZIncrBy increments the score of member by increment
+func (c cmdable) ZIncrBy(ctx context.Context, key string, increment float64, member string) *redis.FloatCmd {
+	cmd := redis.NewFloatCmd(ctx, "zincrby", key, increment, member)
+	c.do(cmd, func(s *store) {
+		z, ok, err := typed[*sortedSet](s, key)
+		if err != nil {
+			cmd.SetErr(err)
+			return
+		}
+		if !ok {
+			z = &sortedSet{scores: make(map[string]float64)}
+		}
+		score := z.scores[member] + increment
+		if math.IsNaN(score) {
+			cmd.SetErr(errNotFloat)
+			return
+		}
+		z.scores[member] = score
+		s.put(key, z, false)
+		cmd.SetVal(score)
+	})
+	return cmd
+}
+
+// ZRange returns members in the specified index range, ordered by score ascending
+func (c cmdable) ZRange(ctx context.Context, key string, start, stop int64) *redis.StringSliceCmd {
+	cmd := redis.NewStringSliceCmd(ctx, "zrange", key, start, stop)
+	c.do(cmd, func(s *store) {
+		zs, err := s.rangeOf(key, start, stop, false)
+		if err != nil {
+			cmd.SetErr(err)
+			return
+		}
+		cmd.SetVal(members(zs))
+	})
+	return cmd
+}
+
+// ZRangeWithScores returns members with scores in the specified index range
+func (c cmdable) ZRangeWithScores(ctx context.Context, key string, start, stop int64) *redis.ZSliceCmd {
+	cmd := redis.NewZSliceCmd(ctx, "zrange", key, start, stop, "withscores")
+	c.do(cmd, func(s *store) {
+		zs, err := s.rangeOf(key, start, stop, false)
+		if err != nil {
+			cmd.SetErr(err)
+			return
+		}
+		cmd.SetVal(zs)
+	})
+	return cmd
+}
+
+// ZRevRange returns members in the specified index range, ordered by score descending
+func (c cmdable) ZRevRange(ctx context.Context, key string, start, stop int64) *redis.StringSliceCmd {
+	cmd := redis.NewStringSliceCmd(ctx, "zrevrange", key, start, stop)
+	c.do(cmd, func(s *store) {
+		zs, err := s.rangeOf(key, start, stop, true)
+		if err != nil {
+			cmd.SetErr(err)
+			return
+		}
+		cmd.SetVal(members(zs))
+	})
+	return cmd
+}
+
+// ZRevRangeWithScores returns members with scores ordered by score descending
+func (c cmdable) ZRevRangeWithScores(ctx context.Context, key string, start, stop int64) *redis.ZSliceCmd {
+	cmd := redis.NewZSliceCmd(ctx, "zrevrange", key, start, stop, "withscores")
+	c.do(cmd, func(s *store) {
+		zs, err := s.rangeOf(key, start, stop, true)
+		if err != nil {
+			cmd.SetErr(err)
+			return
+		}
+		cmd.SetVal(zs)
+	})
+	return cmd
+}
+
+// ZRangeByScore returns members with a score between opt.Min and opt.Max
+func (c cmdable) ZRangeByScore(ctx context.Context, key string, opt *redis.ZRangeBy) *redis.StringSliceCmd {
+	cmd := redis.NewStringSliceCmd(ctx, "zrangebyscore", key, opt.Min, opt.Max)
+	c.do(cmd, func(s *store) {
+		z, ok, err := typed[*sortedSet](s, key)
+		if err != nil {
+			cmd.SetErr(err)
+			return
+		}
+		if !ok {
+			cmd.SetVal([]string{})
+			return
+		}
+		zs, err := z.byScore(opt.Min, opt.Max)
+		if err != nil {
+			cmd.SetErr(err)
+			return
+		}
+
+		// LIMIT применяется только если задан offset или count, как в go-redis
+		if opt.Offset != 0 || opt.Count != 0 {
+			if opt.Offset >= int64(len(zs)) {
+				zs = nil
+			} else {
+				zs = zs[opt.Offset:]
+				if opt.Count >= 0 && opt.Count < int64(len(zs)) {
+					zs = zs[:opt.Count]
+				}
+			}
+		}
+		cmd.SetVal(members(zs))
+	})
+	return cmd
+}
+
+// ZRemRangeByScore removes members with a score between min and max
+func (c cmdable) ZRemRangeByScore(ctx context.Context, key, min, max string) *redis.IntCmd {
+	cmd := redis.NewIntCmd(ctx, "zremrangebyscore", key, min, max)
+	c.do(cmd, func(s *store) {
+		z, ok, err := typed[*sortedSet](s, key)
+		if err != nil {
+			cmd.SetErr(err)
+			return
+		}
+		if !ok {
+			cmd.SetVal(0)
+			return
+		}
+		zs, err := z.byScore(min, max)
+		if err != nil {
+			cmd.SetErr(err)
+			return
+		}
+		for _, m := range zs {
+			delete(z.scores, m.Member.(string))
+		}
+		s.put(key, z, len(z.scores) == 0)
+		cmd.SetVal(int64(len(zs)))
+	})
+	return cmd
+}
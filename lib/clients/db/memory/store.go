@@ -0,0 +1,119 @@
+package memoryClient
+
+import (
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// entry is a single key; value is one of string, map[string]string,
+// *sortedSet, []string (list) or *streamData
+type entry struct {
+	value    any
+	expireAt time.Time
+}
+
+type store struct {
+	data map[string]*entry
+	subs map[string]map[*subscription]struct{}
+	now  func() time.Time
+}
+
+func newStore(now func() time.Time) *store {
+	return &store{
+		data: make(map[string]*entry),
+		subs: make(map[string]map[*subscription]struct{}),
+		now:  now,
+	}
+}
+
+// lookup returns the live entry for key, evicting it if it has expired
+func (s *store) lookup(key string) *entry {
+	e, ok := s.data[key]
+	if !ok {
+		return nil
+	}
+	if !e.expireAt.IsZero() && !s.now().Before(e.expireAt) {
+		delete(s.data, key)
+		return nil
+	}
+	return e
+}
+
+func (s *store) getString(key string) (string, bool, error) {
+	e := s.lookup(key)
+	if e == nil {
+		return "", false, nil
+	}
+	val, ok := e.value.(string)
+	if !ok {
+		return "", false, errWrongType
+	}
+	return val, true, nil
+}
+
+// set stores a string value; expiration follows SET semantics
+// (0 — no TTL, redis.KeepTTL — keep the current TTL)
+func (s *store) set(key, val string, expiration time.Duration) {
+	var expireAt time.Time
+	switch {
+	case expiration == redis.KeepTTL:
+		if e := s.lookup(key); e != nil {
+			expireAt = e.expireAt
+		}
+	case expiration > 0:
+		expireAt = s.now().Add(expiration)
+	}
+	s.data[key] = &entry{value: val, expireAt: expireAt}
+}
+
+// typed looks up key and checks that it holds a value of type T.
+// A missing key yields the zero value and ok == false.
+func typed[T any](s *store, key string) (T, bool, error) {
+	var zero T
+	e := s.lookup(key)
+	if e == nil {
+		return zero, false, nil
+	}
+	val, ok := e.value.(T)
+	if !ok {
+		return zero, false, errWrongType
+	}
+	return val, true, nil
+}
+
+// put stores a container value preserving the key's TTL,
+// or removes the key when the container became empty
+func (s *store) put(key string, val any, empty bool) {
+	if empty {
+		delete(s.data, key)
+		return
+	}
+	if e := s.lookup(key); e != nil {
+		e.value = val
+		return
+	}
+	s.data[key] = &entry{value: val}
+}
+
+// normalizeRange converts Redis inclusive start/stop indexes (negative
+// values count from the end) into a half-open [lo, hi) slice range
+func normalizeRange(start, stop int64, n int) (int, int) {
+	size := int64(n)
+	if start < 0 {
+		start += size
+	}
+	if stop < 0 {
+		stop += size
+	}
+	if start < 0 {
+		start = 0
+	}
+	if stop >= size {
+		stop = size - 1
+	}
+	if start > stop || start >= size {
+		return 0, 0
+	}
+	return int(start), int(stop) + 1
+}
@@ -0,0 +1,341 @@
+package memoryClient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var errStreamID = errors.New("ERR Invalid stream ID specified as stream command argument")
+
+// streamID is a parsed "<ms>-<seq>" stream entry ID
+type streamID struct {
+	ms, seq uint64
+}
+
+func (id streamID) String() string {
+	return fmt.Sprintf("%d-%d", id.ms, id.seq)
+}
+
+func (id streamID) less(other streamID) bool {
+	if id.ms != other.ms {
+		return id.ms < other.ms
+	}
+	return id.seq < other.seq
+}
+
+// parseStreamID parses an entry ID; a missing sequence defaults to defaultSeq
+func parseStreamID(raw string, defaultSeq uint64) (streamID, error) {
+	msPart, seqPart, hasSeq := strings.Cut(raw, "-")
+	ms, err := strconv.ParseUint(msPart, 10, 64)
+	if err != nil {
+		return streamID{}, errStreamID
+	}
+	if !hasSeq {
+		return streamID{ms: ms, seq: defaultSeq}, nil
+	}
+	seq, err := strconv.ParseUint(seqPart, 10, 64)
+	if err != nil {
+		return streamID{}, errStreamID
+	}
+	return streamID{ms: ms, seq: seq}, nil
+}
+
+// rangeBound parses an XRANGE bound: "-", "+", "<id>" or exclusive "(<id>"
+func rangeBound(raw string, start bool) (streamID, error) {
+	switch raw {
+	case "-":
+		return streamID{}, nil
+	case "+":
+		return streamID{ms: math.MaxUint64, seq: math.MaxUint64}, nil
+	}
+
+	exclusive := strings.HasPrefix(raw, "(")
+	defaultSeq := uint64(0)
+	if !start {
+		defaultSeq = math.MaxUint64
+	}
+	id, err := parseStreamID(strings.TrimPrefix(raw, "("), defaultSeq)
+	if err != nil || !exclusive {
+		return id, err
+	}
+
+	// Исключающая граница сдвигается на одну позицию внутрь диапазона
+	if start {
+		if id.seq == math.MaxUint64 {
+			return streamID{ms: id.ms + 1}, nil
+		}
+		id.seq++
+		return id, nil
+	}
+	if id.seq == 0 {
+		if id.ms == 0 {
+			return streamID{}, nil
+		}
+		return streamID{ms: id.ms - 1, seq: math.MaxUint64}, nil
+	}
+	id.seq--
+	return id, nil
+}
+
+type streamEntry struct {
+	id     streamID
+	values map[string]any
+}
+
+type streamData struct {
+	entries []streamEntry
+	last    streamID
+	groups  map[string]*streamGroup
+}
+
+// nextID picks the ID for a new entry: auto-generated for "" / "*",
+// otherwise the explicit ID, which must be greater than the last one
+func (st *streamData) nextID(raw string, nowMs uint64) (streamID, error) {
+	if raw == "" || raw == "*" {
+		if nowMs > st.last.ms {
+			return streamID{ms: nowMs}, nil
+		}
+		return streamID{ms: st.last.ms, seq: st.last.seq + 1}, nil
+	}
+
+	var id streamID
+	if msPart, ok := strings.CutSuffix(raw, "-*"); ok {
+		ms, err := strconv.ParseUint(msPart, 10, 64)
+		if err != nil {
+			return streamID{}, errStreamID
+		}
+		id = streamID{ms: ms}
+		if ms == st.last.ms {
+			id.seq = st.last.seq + 1
+		}
+	} else {
+		var err error
+		if id, err = parseStreamID(raw, 0); err != nil {
+			return streamID{}, err
+		}
+	}
+
+	if !st.last.less(id) {
+		return streamID{}, errors.New("ERR The ID specified in XADD is equal or smaller than the target stream top item")
+	}
+	return id, nil
+}
+
+func (st *streamData) between(start, stop streamID, count int64) []redis.XMessage {
+	out := []redis.XMessage{}
+	for _, e := range st.entries {
+		if e.id.less(start) || stop.less(e.id) {
+			continue
+		}
+		if count > 0 && int64(len(out)) >= count {
+			break
+		}
+		values := make(map[string]any, len(e.values))
+		for k, v := range e.values {
+			values[k] = v
+		}
+		out = append(out, redis.XMessage{ID: e.id.String(), Values: values})
+	}
+	return out
+}
+
+func (st *streamData) trimMaxLen(maxLen int64) int64 {
+	excess := int64(len(st.entries)) - maxLen
+	if excess <= 0 {
+		return 0
+	}
+	st.entries = st.entries[excess:]
+	return excess
+}
+
+func (st *streamData) trimMinID(minID streamID) int64 {
+	var n int64
+	for n < int64(len(st.entries)) && st.entries[n].id.less(minID) {
+		n++
+	}
+	st.entries = st.entries[n:]
+	return n
+}
+
+// XAdd appends an entry to the stream described by a
+func (c cmdable) XAdd(ctx context.Context, a *redis.XAddArgs) *redis.StringCmd {
+	cmd := redis.NewStringCmd(ctx, "xadd", a.Stream)
+	c.do(cmd, func(s *store) {
+		st, ok, err := typed[*streamData](s, a.Stream)
+		if err != nil {
+			cmd.SetErr(err)
+			return
+		}
+		if !ok {
+			if a.NoMkStream {
+				cmd.SetErr(redis.Nil)
+				return
+			}
+			st = &streamData{}
+		}
+
+		values, err := streamValues(a.Values)
+		if err != nil {
+			cmd.SetErr(err)
+			return
+		}
+		id, err := st.nextID(a.ID, uint64(s.now().UnixMilli()))
+		if err != nil {
+			cmd.SetErr(err)
+			return
+		}
+
+		st.entries = append(st.entries, streamEntry{id: id, values: values})
+		st.last = id
+
+		switch {
+		case a.MaxLen > 0:
+			st.trimMaxLen(a.MaxLen)
+		case a.MinID != "":
+			minID, err := parseStreamID(a.MinID, 0)
+			if err != nil {
+				cmd.SetErr(err)
+				return
+			}
+			st.trimMinID(minID)
+		}
+
+		s.put(a.Stream, st, false)
+		cmd.SetVal(id.String())
+	})
+	return cmd
+}
+
+func streamValues(v any) (map[string]any, error) {
+	var args []any
+	switch v := v.(type) {
+	case []any:
+		args = v
+	default:
+		args = []any{v}
+	}
+
+	pairs, err := toPairs(args)
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]any, len(pairs))
+	for _, p := range pairs {
+		values[p[0]] = p[1]
+	}
+	return values, nil
+}
+
+// XLen returns the number of entries in the stream
+func (c cmdable) XLen(ctx context.Context, stream string) *redis.IntCmd {
+	cmd := redis.NewIntCmd(ctx, "xlen", stream)
+	c.do(cmd, func(s *store) {
+		st, ok, err := typed[*streamData](s, stream)
+		if err != nil {
+			cmd.SetErr(err)
+			return
+		}
+		if !ok {
+			cmd.SetVal(0)
+			return
+		}
+		cmd.SetVal(int64(len(st.entries)))
+	})
+	return cmd
+}
+
+// XRange returns stream entries with IDs between start and stop
+func (c cmdable) XRange(ctx context.Context, stream, start, stop string) *redis.XMessageSliceCmd {
+	return c.XRangeN(ctx, stream, start, stop, 0)
+}
+
+// XRangeN returns at most count stream entries with IDs between start and stop
+func (c cmdable) XRangeN(ctx context.Context, stream, start, stop string, count int64) *redis.XMessageSliceCmd {
+	cmd := redis.NewXMessageSliceCmd(ctx, "xrange", stream, start, stop)
+	c.do(cmd, func(s *store) {
+		st, ok, err := typed[*streamData](s, stream)
+		if err != nil {
+			cmd.SetErr(err)
+			return
+		}
+		from, err := rangeBound(start, true)
+		if err != nil {
+			cmd.SetErr(err)
+			return
+		}
+		to, err := rangeBound(stop, false)
+		if err != nil {
+			cmd.SetErr(err)
+			return
+		}
+		if !ok {
+			cmd.SetVal([]redis.XMessage{})
+			return
+		}
+		cmd.SetVal(st.between(from, to, count))
+	})
+	return cmd
+}
+
+// XDel removes the specified entries from the stream
+func (c cmdable) XDel(ctx context.Context, stream string, ids ...string) *redis.IntCmd {
+	cmd := redis.NewIntCmd(ctx, "xdel", stream)
+	c.do(cmd, func(s *store) {
+		st, ok, err := typed[*streamData](s, stream)
+		if err != nil {
+			cmd.SetErr(err)
+			return
+		}
+		if !ok {
+			cmd.SetVal(0)
+			return
+		}
+
+		remove := make(map[streamID]bool, len(ids))
+		for _, raw := range ids {
+			id, err := parseStreamID(raw, 0)
+			if err != nil {
+				cmd.SetErr(err)
+				return
+			}
+			remove[id] = true
+		}
+
+		kept := st.entries[:0]
+		var removed int64
+		for _, e := range st.entries {
+			if remove[e.id] {
+				removed++
+				continue
+			}
+			kept = append(kept, e)
+		}
+		st.entries = kept
+		cmd.SetVal(removed)
+	})
+	return cmd
+}
+
+// XTrimMaxLen trims the stream to at most maxLen entries
+func (c cmdable) XTrimMaxLen(ctx context.Context, key string, maxLen int64) *redis.IntCmd {
+	cmd := redis.NewIntCmd(ctx, "xtrim", key, "maxlen", maxLen)
+	c.do(cmd, func(s *store) {
+		st, ok, err := typed[*streamData](s, key)
+		if err != nil {
+			cmd.SetErr(err)
+			return
+		}
+		if !ok {
+			cmd.SetVal(0)
+			return
+		}
+		cmd.SetVal(st.trimMaxLen(maxLen))
+	})
+	return cmd
+}
@@ -0,0 +1,176 @@
+package memoryClient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// streamGroup is a consumer group: the last entry delivered to the group and
+// the pending entries list (delivered but not yet acknowledged)
+type streamGroup struct {
+	lastDelivered streamID
+	pending       map[streamID]string // ID -> consumer
+}
+
+// streamEnd is the largest possible entry ID ("+")
+var streamEnd = streamID{ms: math.MaxUint64, seq: math.MaxUint64}
+
+func errNoGroup(stream, group string) error {
+	return fmt.Errorf("NOGROUP No such key '%s' or consumer group '%s' in XREADGROUP with GROUP option", stream, group)
+}
+
+// XGroupCreateMkStream creates a consumer group starting at start, creating the stream if needed
+func (c cmdable) XGroupCreateMkStream(ctx context.Context, stream, group, start string) *redis.StatusCmd {
+	cmd := redis.NewStatusCmd(ctx, "xgroup", "create", stream, group, start, "mkstream")
+	c.do(cmd, func(s *store) {
+		st, ok, err := typed[*streamData](s, stream)
+		if err != nil {
+			cmd.SetErr(err)
+			return
+		}
+		if !ok {
+			st = &streamData{}
+		}
+		if _, exists := st.groups[group]; exists {
+			cmd.SetErr(errors.New("BUSYGROUP Consumer Group name already exists"))
+			return
+		}
+
+		var last streamID
+		if start == "$" {
+			last = st.last
+		} else if last, err = parseStreamID(start, 0); err != nil {
+			cmd.SetErr(err)
+			return
+		}
+
+		if st.groups == nil {
+			st.groups = make(map[string]*streamGroup)
+		}
+		st.groups[group] = &streamGroup{lastDelivered: last, pending: make(map[streamID]string)}
+		s.put(stream, st, false)
+		cmd.SetVal("OK")
+	})
+	return cmd
+}
+
+// XReadGroup reads entries for a consumer of a group. ">" returns entries never
+// delivered to the group and adds them to the pending list; any other ID returns
+// the consumer's pending entries after it. Block is not supported: when there is
+// nothing new the command returns redis.Nil immediately.
+func (c cmdable) XReadGroup(ctx context.Context, a *redis.XReadGroupArgs) *redis.XStreamSliceCmd {
+	cmd := redis.NewXStreamSliceCmd(ctx, "xreadgroup", "group", a.Group, a.Consumer)
+	c.do(cmd, func(s *store) {
+		if len(a.Streams)%2 != 0 {
+			cmd.SetErr(errors.New("ERR Unbalanced 'xreadgroup' list of streams: for each stream key an ID or '>' must be specified"))
+			return
+		}
+		keys, ids := a.Streams[:len(a.Streams)/2], a.Streams[len(a.Streams)/2:]
+
+		var out []redis.XStream
+		onlyNew := true
+		for i, key := range keys {
+			st, ok, err := typed[*streamData](s, key)
+			if err != nil {
+				cmd.SetErr(err)
+				return
+			}
+			g := st.groupOrNil(ok, a.Group)
+			if g == nil {
+				cmd.SetErr(errNoGroup(key, a.Group))
+				return
+			}
+
+			if ids[i] != ">" {
+				onlyNew = false
+				from, err := rangeBound("("+ids[i], true)
+				if err != nil {
+					cmd.SetErr(err)
+					return
+				}
+				out = append(out, redis.XStream{Stream: key, Messages: st.pendingOf(g, a.Consumer, from, a.Count)})
+				continue
+			}
+
+			from, _ := rangeBound("("+g.lastDelivered.String(), true)
+			msgs := st.between(from, streamEnd, a.Count)
+			if len(msgs) == 0 {
+				continue
+			}
+			for _, m := range msgs {
+				id, _ := parseStreamID(m.ID, 0)
+				g.lastDelivered = id
+				if !a.NoAck {
+					g.pending[id] = a.Consumer
+				}
+			}
+			out = append(out, redis.XStream{Stream: key, Messages: msgs})
+		}
+
+		if len(out) == 0 && onlyNew {
+			cmd.SetErr(redis.Nil)
+			return
+		}
+		cmd.SetVal(out)
+	})
+	return cmd
+}
+
+func (st *streamData) groupOrNil(ok bool, group string) *streamGroup {
+	if !ok {
+		return nil
+	}
+	return st.groups[group]
+}
+
+// pendingOf returns the entries pending for consumer with IDs starting at from
+func (st *streamData) pendingOf(g *streamGroup, consumer string, from streamID, count int64) []redis.XMessage {
+	out := []redis.XMessage{}
+	for _, m := range st.between(from, streamEnd, 0) {
+		id, _ := parseStreamID(m.ID, 0)
+		if g.pending[id] != consumer {
+			continue
+		}
+		if count > 0 && int64(len(out)) >= count {
+			break
+		}
+		out = append(out, m)
+	}
+	return out
+}
+
+// XAck removes entries from the pending list of the group
+func (c cmdable) XAck(ctx context.Context, stream, group string, ids ...string) *redis.IntCmd {
+	cmd := redis.NewIntCmd(ctx, "xack", stream, group)
+	c.do(cmd, func(s *store) {
+		st, ok, err := typed[*streamData](s, stream)
+		if err != nil {
+			cmd.SetErr(err)
+			return
+		}
+		g := st.groupOrNil(ok, group)
+		if g == nil {
+			cmd.SetVal(0)
+			return
+		}
+
+		var n int64
+		for _, raw := range ids {
+			id, err := parseStreamID(raw, 0)
+			if err != nil {
+				cmd.SetErr(err)
+				return
+			}
+			if _, pending := g.pending[id]; pending {
+				delete(g.pending, id)
+				n++
+			}
+		}
+		cmd.SetVal(n)
+	})
+	return cmd
+}
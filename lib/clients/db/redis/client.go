@@ -22,6 +22,7 @@ func NewClient(ctx context.Context, cfg models.Redis) (clientsDB.CashClient, err
 		Password: cfg.Password,
 		DB:       0,
 	})
+	rdb.AddHook(cacheMetricsHook{})
 
 	// Проверяем соединение
 	if err := rdb.Ping(ctx).Err(); err != nil {
@@ -0,0 +1,52 @@
+package redisClient
+
+import (
+	"context"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// HGet returns the value of field in the hash stored at key
+func (c *Client) HGet(ctx context.Context, key, field string) *redis.StringCmd {
+	return c.client.HGet(ctx, key, field)
+}
+
+// HSet sets fields in the hash stored at key
+func (c *Client) HSet(ctx context.Context, key string, values ...any) *redis.IntCmd {
+	return c.client.HSet(ctx, key, values...)
+}
+
+// HGetAll returns all fields and values of the hash stored at key
+func (c *Client) HGetAll(ctx context.Context, key string) *redis.MapStringStringCmd {
+	return c.client.HGetAll(ctx, key)
+}
+
+// HMGet returns the values associated with the specified fields
+func (c *Client) HMGet(ctx context.Context, key string, fields ...string) *redis.SliceCmd {
+	return c.client.HMGet(ctx, key, fields...)
+}
+
+// HDel removes the specified fields from the hash stored at key
+func (c *Client) HDel(ctx context.Context, key string, fields ...string) *redis.IntCmd {
+	return c.client.HDel(ctx, key, fields...)
+}
+
+// HExists checks if field exists in the hash stored at key
+func (c *Client) HExists(ctx context.Context, key, field string) *redis.BoolCmd {
+	return c.client.HExists(ctx, key, field)
+}
+
+// HIncrBy increments the number stored at field by incr
+func (c *Client) HIncrBy(ctx context.Context, key, field string, incr int64) *redis.IntCmd {
+	return c.client.HIncrBy(ctx, key, field, incr)
+}
+
+// HLen returns the number of fields in the hash stored at key
+func (c *Client) HLen(ctx context.Context, key string) *redis.IntCmd {
+	return c.client.HLen(ctx, key)
+}
+
+// HKeys returns all field names in the hash stored at key
+func (c *Client) HKeys(ctx context.Context, key string) *redis.StringSliceCmd {
+	return c.client.HKeys(ctx, key)
+}
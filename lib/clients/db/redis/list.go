@@ -0,0 +1,47 @@
+package redisClient
+
+import (
+	"context"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// LPush prepends values to the list stored at key
+func (c *Client) LPush(ctx context.Context, key string, values ...any) *redis.IntCmd {
+	return c.client.LPush(ctx, key, values...)
+}
+
+// RPush appends values to the list stored at key
+func (c *Client) RPush(ctx context.Context, key string, values ...any) *redis.IntCmd {
+	return c.client.RPush(ctx, key, values...)
+}
+
+// LPop removes and returns the first element of the list stored at key
+func (c *Client) LPop(ctx context.Context, key string) *redis.StringCmd {
+	return c.client.LPop(ctx, key)
+}
+
+// RPop removes and returns the last element of the list stored at key
+func (c *Client) RPop(ctx context.Context, key string) *redis.StringCmd {
+	return c.client.RPop(ctx, key)
+}
+
+// LRange returns the specified elements of the list stored at key
+func (c *Client) LRange(ctx context.Context, key string, start, stop int64) *redis.StringSliceCmd {
+	return c.client.LRange(ctx, key, start, stop)
+}
+
+// LLen returns the length of the list stored at key
+func (c *Client) LLen(ctx context.Context, key string) *redis.IntCmd {
+	return c.client.LLen(ctx, key)
+}
+
+// LTrim trims the list so that it contains only the specified range
+func (c *Client) LTrim(ctx context.Context, key string, start, stop int64) *redis.StatusCmd {
+	return c.client.LTrim(ctx, key, start, stop)
+}
+
+// LRem removes count occurrences of value from the list stored at key
+func (c *Client) LRem(ctx context.Context, key string, count int64, value any) *redis.IntCmd {
+	return c.client.LRem(ctx, key, count, value)
+}
@@ -0,0 +1,57 @@
+package redisClient
+
+import (
+	"context"
+	"errors"
+	"lib/utils/metrics"
+	"net"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const cacheName = "redis"
+
+// cacheMetricsHook counts cache hits and misses for key lookups, including pipelined ones
+type cacheMetricsHook struct{}
+
+func (cacheMetricsHook) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return next(ctx, network, addr)
+	}
+}
+
+func (cacheMetricsHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		err := next(ctx, cmd)
+		observeLookup(cmd)
+		return err
+	}
+}
+
+func (cacheMetricsHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		err := next(ctx, cmds)
+		for _, cmd := range cmds {
+			observeLookup(cmd)
+		}
+		return err
+	}
+}
+
+// observeLookup records the result of GET/HGET: redis.Nil is a miss
+func observeLookup(cmd redis.Cmder) {
+	switch cmd.Name() {
+	case "get", "hget":
+	default:
+		return
+	}
+
+	result := "hit"
+	if err := cmd.Err(); err != nil {
+		result = "miss"
+		if !errors.Is(err, redis.Nil) {
+			result = metrics.StatusError
+		}
+	}
+	metrics.CacheRequests.WithLabelValues(cacheName, result).Inc()
+}
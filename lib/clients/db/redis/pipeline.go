@@ -0,0 +1,22 @@
+package redisClient
+
+import (
+	"context"
+	clientsDB "lib/clients/db"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Pipelined sends all commands queued by fn to the server in a single round trip
+func (c *Client) Pipelined(ctx context.Context, fn func(pipe clientsDB.CashCmdable) error) ([]redis.Cmder, error) {
+	return c.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		return fn(pipe)
+	})
+}
+
+// TxPipelined works like Pipelined but wraps the queued commands in MULTI/EXEC
+func (c *Client) TxPipelined(ctx context.Context, fn func(pipe clientsDB.CashCmdable) error) ([]redis.Cmder, error) {
+	return c.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		return fn(pipe)
+	})
+}
@@ -0,0 +1,42 @@
+package redisClient
+
+import (
+	"context"
+	"fmt"
+	clientsDB "lib/clients/db"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// subscription adapts *redis.PubSub to the CashSubscription interface
+type subscription struct {
+	pubsub *redis.PubSub
+}
+
+// Publish posts a message to the given channel
+func (c *Client) Publish(ctx context.Context, channel string, message any) *redis.IntCmd {
+	return c.client.Publish(ctx, channel, message)
+}
+
+// Subscribe subscribes to the given channels and waits for the server confirmation
+func (c *Client) Subscribe(ctx context.Context, channels ...string) (clientsDB.CashSubscription, error) {
+	pubsub := c.client.Subscribe(ctx, channels...)
+
+	// Дожидаемся подтверждения подписки, чтобы не потерять первые сообщения
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return nil, fmt.Errorf("failed to subscribe to %v: %w", channels, err)
+	}
+
+	return &subscription{pubsub: pubsub}, nil
+}
+
+// Channel returns the channel of received messages
+func (s *subscription) Channel() <-chan *redis.Message {
+	return s.pubsub.Channel()
+}
+
+// Close unsubscribes from all channels
+func (s *subscription) Close() error {
+	return s.pubsub.Close()
+}
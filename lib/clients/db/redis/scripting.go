@@ -0,0 +1,22 @@
+package redisClient
+
+import (
+	"context"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Eval executes a Lua script on the server
+func (c *Client) Eval(ctx context.Context, script string, keys []string, args ...any) *redis.Cmd {
+	return c.client.Eval(ctx, script, keys, args...)
+}
+
+// EvalSha executes a Lua script previously loaded with ScriptLoad
+func (c *Client) EvalSha(ctx context.Context, sha1 string, keys []string, args ...any) *redis.Cmd {
+	return c.client.EvalSha(ctx, sha1, keys, args...)
+}
+
+// ScriptLoad loads a Lua script into the script cache and returns its SHA1
+func (c *Client) ScriptLoad(ctx context.Context, script string) *redis.StringCmd {
+	return c.client.ScriptLoad(ctx, script)
+}
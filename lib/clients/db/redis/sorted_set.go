@@ -0,0 +1,62 @@
+package redisClient
+
+import (
+	"context"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ZAdd adds members with their scores to the sorted set stored at key
+func (c *Client) ZAdd(ctx context.Context, key string, members ...redis.Z) *redis.IntCmd {
+	return c.client.ZAdd(ctx, key, members...)
+}
+
+// ZRem removes the specified members from the sorted set stored at key
+func (c *Client) ZRem(ctx context.Context, key string, members ...any) *redis.IntCmd {
+	return c.client.ZRem(ctx, key, members...)
+}
+
+// ZScore returns the score of member in the sorted set stored at key
+func (c *Client) ZScore(ctx context.Context, key, member string) *redis.FloatCmd {
+	return c.client.ZScore(ctx, key, member)
+}
+
+// ZCard returns the number of members in the sorted set stored at key
+func (c *Client) ZCard(ctx context.Context, key string) *redis.IntCmd {
+	return c.client.ZCard(ctx, key)
+}
+
+// ZIncrBy increments the score of member by increment
+func (c *Client) ZIncrBy(ctx context.Context, key string, increment float64, member string) *redis.FloatCmd {
+	return c.client.ZIncrBy(ctx, key, increment, member)
+}
+
+// ZRange returns members in the specified index range, ordered by score ascending
+func (c *Client) ZRange(ctx context.Context, key string, start, stop int64) *redis.StringSliceCmd {
+	return c.client.ZRange(ctx, key, start, stop)
+}
+
+// ZRangeWithScores returns members with scores in the specified index range
+func (c *Client) ZRangeWithScores(ctx context.Context, key string, start, stop int64) *redis.ZSliceCmd {
+	return c.client.ZRangeWithScores(ctx, key, start, stop)
+}
+
+// ZRevRange returns members in the specified index range, ordered by score descending
+func (c *Client) ZRevRange(ctx context.Context, key string, start, stop int64) *redis.StringSliceCmd {
+	return c.client.ZRevRange(ctx, key, start, stop)
+}
+
+// ZRevRangeWithScores returns members with scores ordered by score descending
+func (c *Client) ZRevRangeWithScores(ctx context.Context, key string, start, stop int64) *redis.ZSliceCmd {
+	return c.client.ZRevRangeWithScores(ctx, key, start, stop)
+}
+
+// ZRangeByScore returns members with a score between opt.Min and opt.Max
+func (c *Client) ZRangeByScore(ctx context.Context, key string, opt *redis.ZRangeBy) *redis.StringSliceCmd {
+	return c.client.ZRangeByScore(ctx, key, opt)
+}
+
+// ZRemRangeByScore removes members with a score between min and max
+func (c *Client) ZRemRangeByScore(ctx context.Context, key, min, max string) *redis.IntCmd {
+	return c.client.ZRemRangeByScore(ctx, key, min, max)
+}
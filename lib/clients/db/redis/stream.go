@@ -0,0 +1,52 @@
+package redisClient
+
+import (
+	"context"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// XAdd appends an entry to the stream described by a
+func (c *Client) XAdd(ctx context.Context, a *redis.XAddArgs) *redis.StringCmd {
+	return c.client.XAdd(ctx, a)
+}
+
+// XLen returns the number of entries in the stream
+func (c *Client) XLen(ctx context.Context, stream string) *redis.IntCmd {
+	return c.client.XLen(ctx, stream)
+}
+
+// XRange returns stream entries with IDs between start and stop
+func (c *Client) XRange(ctx context.Context, stream, start, stop string) *redis.XMessageSliceCmd {
+	return c.client.XRange(ctx, stream, start, stop)
+}
+
+// XRangeN returns at most count stream entries with IDs between start and stop
+func (c *Client) XRangeN(ctx context.Context, stream, start, stop string, count int64) *redis.XMessageSliceCmd {
+	return c.client.XRangeN(ctx, stream, start, stop, count)
+}
+
+// XDel removes the specified entries from the stream
+func (c *Client) XDel(ctx context.Context, stream string, ids ...string) *redis.IntCmd {
+	return c.client.XDel(ctx, stream, ids...)
+}
+
+// XTrimMaxLen trims the stream to at most maxLen entries
+func (c *Client) XTrimMaxLen(ctx context.Context, key string, maxLen int64) *redis.IntCmd {
+	return c.client.XTrimMaxLen(ctx, key, maxLen)
+}
+
+// XGroupCreateMkStream creates a consumer group starting at start, creating the stream if needed
+func (c *Client) XGroupCreateMkStream(ctx context.Context, stream, group, start string) *redis.StatusCmd {
+	return c.client.XGroupCreateMkStream(ctx, stream, group, start)
+}
+
+// XReadGroup reads entries for a consumer of a group
+func (c *Client) XReadGroup(ctx context.Context, a *redis.XReadGroupArgs) *redis.XStreamSliceCmd {
+	return c.client.XReadGroup(ctx, a)
+}
+
+// XAck removes entries from the pending list of the group
+func (c *Client) XAck(ctx context.Context, stream, group string, ids ...string) *redis.IntCmd {
+	return c.client.XAck(ctx, stream, group, ids...)
+}
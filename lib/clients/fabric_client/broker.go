@@ -3,21 +3,69 @@ package fabricClient
 import (
 	"lib/clients/broker"
 	"lib/clients/broker/kafka"
+	memoryBroker "lib/clients/broker/memory"
+	natsJetStream "lib/clients/broker/nats_jetstream"
+	redisStreams "lib/clients/broker/redis_streams"
 	"lib/models"
 	"lib/utils/logging"
 )
 
 const (
-	kafkaBrokerType = "kafka"
-	mock            = "mock"
-	// Можно добавить другие типы: RabbitMQBrokerType, NATSBrokerType и т.д.
+	kafkaBrokerType  = "kafka"
+	redisBrokerType  = "redis"
+	natsBrokerType   = "nats"
+	memoryBrokerType = "memory"
+	mock             = "mock"
+	// Можно добавить другие типы: RabbitMQBrokerType и т.д.
 )
 
-// NewBroker создает брокер указанного типа
+// NewBroker создает брокер указанного типа с метриками и трассировкой.
+// С spool_dir неотправленные сообщения сохраняются на диск и досылаются в фоне.
 func NewBroker(cfg models.Broker, logger *logging.Logger) broker.BrokerClient {
+	client := newBroker(cfg, logger)
+	if client == nil {
+		return nil
+	}
+	client = broker.Instrument(client, cfg.BrockerType)
+	if cfg.SpoolDir == "" {
+		return client
+	}
+
+	spooled, err := broker.WithSpool(client, cfg.SpoolDir, logger)
+	if err != nil {
+		logger.Errorf("Failed to create broker spool: %v", err)
+		client.Close()
+		return nil
+	}
+	return spooled
+}
+
+func newBroker(cfg models.Broker, logger *logging.Logger) broker.BrokerClient {
 	switch cfg.BrockerType {
 	case kafkaBrokerType:
-		return kafka.NewKafkaBroker(cfg, logger)
+		client, err := kafka.NewKafkaBroker(cfg, logger)
+		if err != nil {
+			logger.Errorf("Failed to create Kafka broker: %v", err)
+			return nil
+		}
+		return client
+	case redisBrokerType:
+		return redisStreams.NewRedisStreamsBroker(cfg, logger)
+	case natsBrokerType:
+		client, err := natsJetStream.NewJetStreamBroker(cfg, logger)
+		if err != nil {
+			logger.Errorf("Failed to create NATS JetStream broker: %v", err)
+			return nil
+		}
+		return client
+	case memoryBrokerType:
+		// Все клиенты процесса работают с общим in-process брокером
+		shared, err := memoryBroker.Default(int(cfg.StreamMaxLen))
+		if err != nil {
+			logger.Errorf("Failed to create memory broker: %v", err)
+			return nil
+		}
+		return memoryBroker.NewClient(shared, cfg, logger)
 	case mock:
 		return broker.NewMockBrokerClient()
 	default:
@@ -13,10 +13,20 @@ const (
 )
 
 func NewProvider(cfg models.Provider, logger *logging.Logger) (node.Provider, error) {
+	var (
+		provider node.Provider
+		err      error
+	)
+
 	switch cfg.ProviderType {
 	case alchemyType:
-		return alchemy.NewAlchemyClient(cfg, logger)
+		provider, err = alchemy.NewAlchemyClient(cfg, logger)
 	default:
 		return nil, fmt.Errorf("not found provider for client type: %s", cfg.ProviderType)
 	}
+	if err != nil {
+		return nil, err
+	}
+
+	return node.Instrument(provider), nil
 }
@@ -28,7 +28,7 @@ func NewAlchemyClient(cfg models.Provider, logger *logging.Logger) (node.Provide
 	logger.Infof("Initializing Alchemy client for network: %s", cfg.NetworkName)
 
 	fullURL := fmt.Sprintf("%s%s", cfg.BaseURL, cfg.ApiKey)
-	logger.Debugf("Connecting to Alchemy endpoint: %s...", fullURL)
+	logger.Debugf("Connecting to Alchemy endpoint: %s...", logging.RedactURL(fullURL))
 
 	rpcClient, err := rpc.Dial(fullURL)
 	if err != nil {
@@ -0,0 +1,93 @@
+package node
+
+import (
+	"context"
+	"lib/utils/metrics"
+	"lib/utils/tracing"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentedProvider открывает спан и пишет метрики задержки и ошибок
+// для каждого вызова провайдера
+type instrumentedProvider struct {
+	Provider
+}
+
+// Instrument оборачивает провайдера трассировкой и метриками вызовов
+func Instrument(p Provider) Provider {
+	return &instrumentedProvider{Provider: p}
+}
+
+func (p *instrumentedProvider) start(ctx context.Context, method string, attrs ...attribute.KeyValue) (context.Context, trace.Span, time.Time) {
+	attrs = append(attrs,
+		attribute.String("rpc.system", p.Name()),
+		attribute.String("rpc.method", method),
+	)
+	ctx, span := tracing.Start(ctx, "provider "+method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+	)
+	return ctx, span, time.Now()
+}
+
+func (p *instrumentedProvider) end(span trace.Span, method string, start time.Time, err error) {
+	metrics.ProviderLatency.WithLabelValues(p.Name(), method).Observe(metrics.Since(start))
+	metrics.ProviderRequests.WithLabelValues(p.Name(), method, metrics.Status(err)).Inc()
+	tracing.End(span, err)
+}
+
+func (p *instrumentedProvider) BlockByNumber(ctx context.Context, number *big.Int) (*types.Block, error) {
+	const method = "eth_getBlockByNumber"
+	var attrs []attribute.KeyValue
+	if number != nil {
+		attrs = append(attrs, tracing.BlockNumber(number.Uint64()))
+	}
+
+	ctx, span, start := p.start(ctx, method, attrs...)
+	block, err := p.Provider.BlockByNumber(ctx, number)
+	p.end(span, method, start, err)
+	return block, err
+}
+
+func (p *instrumentedProvider) BlockReceipts(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) ([]*types.Receipt, error) {
+	const method = "eth_getBlockReceipts"
+
+	ctx, span, start := p.start(ctx, method)
+	receipts, err := p.Provider.BlockReceipts(ctx, blockNrOrHash)
+	p.end(span, method, start, err)
+	return receipts, err
+}
+
+func (p *instrumentedProvider) SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error) {
+	const method = "eth_subscribe"
+
+	// Спан покрывает только установку подписки, а не всё время её жизни
+	_, span, start := p.start(ctx, method)
+	sub, err := p.Provider.SubscribeNewHead(ctx, ch)
+	p.end(span, method, start, err)
+	return sub, err
+}
+
+// BatchCallContext учитывает задержку всего батча под методом "batch",
+// а ошибки — по методам отдельных элементов
+func (p *instrumentedProvider) BatchCallContext(ctx context.Context, batch []rpc.BatchElem) error {
+	const method = "batch"
+
+	ctx, span, start := p.start(ctx, method, attribute.Int("rpc.batch.size", len(batch)))
+	err := p.Provider.BatchCallContext(ctx, batch)
+	p.end(span, method, start, err)
+
+	if err == nil {
+		for _, elem := range batch {
+			metrics.ProviderRequests.WithLabelValues(p.Name(), elem.Method, metrics.Status(elem.Error)).Inc()
+		}
+	}
+	return err
+}
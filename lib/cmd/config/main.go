@@ -0,0 +1,78 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"lib/models"
+	"lib/utils/logging"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+const usage = `Usage: config <command> [flags]
+
+Commands:
+  print     print the resolved config (file + env + secret files + defaults) as YAML
+  validate  check the config and list all errors
+  env       list environment variables the config reads
+
+Flags:
+`
+
+// config — утилита для проверки конфигурации сервисов.
+// Пример: config print -service realtime-miner -configs ./configs/configs.yaml --redacted
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	command := os.Args[1]
+
+	fs := flag.NewFlagSet(command, flag.ExitOnError)
+	configPath := models.ConfigFlag(fs)
+	service := fs.String("service", "", "service whose section is validated (empty — common sections only)")
+	redacted := fs.Bool("redacted", false, "mask API keys and passwords in the output")
+	fs.Usage = func() {
+		fmt.Fprint(os.Stderr, usage)
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(os.Args[2:])
+
+	switch command {
+	case "print":
+		cfg := load(*configPath, models.Service(*service))
+		var out any = cfg
+		if *redacted {
+			out = logging.Redact(cfg)
+		}
+		data, err := yaml.Marshal(out)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to encode config: %v\n", err)
+			os.Exit(1)
+		}
+		os.Stdout.Write(data)
+
+	case "validate":
+		load(*configPath, models.Service(*service))
+		fmt.Printf("%s: config is valid\n", models.ConfigPath(*configPath))
+
+	case "env":
+		for _, name := range models.EnvVars() {
+			fmt.Println(name)
+		}
+
+	default:
+		fs.Usage()
+		os.Exit(2)
+	}
+}
+
+func load(path string, service models.Service) *models.Config {
+	cfg, err := models.LoadConfig(path, service)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: invalid config:\n%v\n", models.ConfigPath(path), err)
+		os.Exit(1)
+	}
+	return cfg
+}
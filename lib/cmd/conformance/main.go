@@ -0,0 +1,226 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"lib/blocks/metrics"
+	fabricClient "lib/clients/fabric_client"
+	"lib/models"
+	"lib/utils/logging"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const usage = `Usage: conformance <command> [flags] [block numbers...]
+
+Commands:
+  record  fetch blocks from the realtime-miner provider and save them as golden files
+  check   convert every golden file through both paths (go-ethereum types and raw JSON)
+          and compare the results with each other and with the recorded models
+
+Flags:
+`
+
+// conformance — проверка того, что оба пути преобразования блока дают
+// одинаковые models.Block и models.Tx на записанных блоках mainnet.
+// Пример: conformance record -configs ./configs/configs.yaml 46147 4370000 12965000 17034870 19426587
+//
+//	conformance check -dir ./blocks/metrics/testdata
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	command := os.Args[1]
+
+	fs := flag.NewFlagSet(command, flag.ExitOnError)
+	configPath := models.ConfigFlag(fs)
+	dir := fs.String("dir", "./blocks/metrics/testdata", "directory with golden files")
+	update := fs.Bool("update", false, "check: rewrite expected models from the current conversion")
+	fs.Usage = func() {
+		fmt.Fprint(os.Stderr, usage)
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(os.Args[2:])
+
+	var err error
+	switch command {
+	case "record":
+		err = record(*configPath, *dir, fs.Args())
+	case "check":
+		err = check(*dir, *update)
+	default:
+		fs.Usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func record(configPath, dir string, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("no block numbers given")
+	}
+	cfg, err := models.LoadConfig(configPath, models.ServiceRealtimeMiner)
+	if err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+	provider, err := fabricClient.NewProvider(cfg.RealtimeMiner.Provider, logging.GetLogger())
+	if err != nil {
+		return err
+	}
+	defer provider.Close()
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	for _, arg := range args {
+		number, err := strconv.ParseUint(arg, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid block number %q", arg)
+		}
+		numHex := hexutil.EncodeUint64(number)
+
+		fx := metrics.Fixture{Number: number}
+		batch := []rpc.BatchElem{
+			{Method: "eth_getBlockByNumber", Args: []any{numHex, true}, Result: &fx.Block},
+			{Method: "eth_getBlockReceipts", Args: []any{numHex}, Result: &fx.Receipts},
+		}
+		if err := provider.BatchCallContext(ctx, batch); err != nil {
+			return fmt.Errorf("block %d: %w", number, err)
+		}
+		for _, elem := range batch {
+			if elem.Error != nil {
+				return fmt.Errorf("block %d: %s: %w", number, elem.Method, elem.Error)
+			}
+		}
+
+		block, err := provider.BlockByNumber(ctx, new(big.Int).SetUint64(number))
+		if err != nil {
+			return fmt.Errorf("block %d: %w", number, err)
+		}
+		if n := len(block.Uncles()); n > 0 {
+			fx.Uncles = make([]json.RawMessage, n)
+			uncles := make([]rpc.BatchElem, n)
+			for i := range uncles {
+				uncles[i] = rpc.BatchElem{
+					Method: "eth_getUncleByBlockNumberAndIndex",
+					Args:   []any{numHex, hexutil.Uint(i)},
+					Result: &fx.Uncles[i],
+				}
+			}
+			if err := provider.BatchCallContext(ctx, uncles); err != nil {
+				return fmt.Errorf("block %d: %w", number, err)
+			}
+			for i, elem := range uncles {
+				if elem.Error != nil {
+					return fmt.Errorf("block %d: uncle %d: %w", number, i, elem.Error)
+				}
+			}
+		}
+		if fx.BlockRLP, err = rlp.EncodeToBytes(block); err != nil {
+			return fmt.Errorf("block %d: encode rlp: %w", number, err)
+		}
+
+		src, err := fx.Source()
+		if err != nil {
+			return err
+		}
+		expected, err := src.Convert()
+		if err != nil {
+			return err
+		}
+		fx.Expected = &expected
+
+		if err := writeFixture(filepath.Join(dir, arg+".json"), fx); err != nil {
+			return err
+		}
+		fmt.Printf("recorded block %d: %d txs\n", number, len(expected.Txs))
+	}
+	return nil
+}
+
+func check(dir string, update bool) error {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("no golden files in %s, record them first", dir)
+	}
+	sort.Strings(files)
+
+	failed := 0
+	for _, path := range files {
+		diffs, err := checkFixture(path, update)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		if len(diffs) == 0 {
+			fmt.Printf("ok    %s\n", filepath.Base(path))
+			continue
+		}
+		failed++
+		fmt.Printf("FAIL  %s\n    %s\n", filepath.Base(path), strings.Join(diffs, "\n    "))
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d golden blocks do not conform", failed, len(files))
+	}
+	return nil
+}
+
+// checkFixture прогоняет блок через оба пути преобразования
+func checkFixture(path string, update bool) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var fx metrics.Fixture
+	if err := json.Unmarshal(data, &fx); err != nil {
+		return nil, err
+	}
+
+	viaJSON, typesDiffs, err := fx.Conform()
+	if err != nil {
+		return nil, err
+	}
+	var diffs []string
+	for _, d := range typesDiffs {
+		diffs = append(diffs, "types vs json: "+d)
+	}
+
+	if update {
+		fx.Expected = &viaJSON
+		return diffs, writeFixture(path, fx)
+	}
+	if fx.Expected == nil {
+		return append(diffs, "no expected models, run check -update"), nil
+	}
+	for _, d := range metrics.Diff(*fx.Expected, viaJSON) {
+		diffs = append(diffs, "golden vs json: "+d)
+	}
+	return diffs, nil
+}
+
+func writeFixture(path string, fx metrics.Fixture) error {
+	data, err := json.MarshalIndent(fx, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
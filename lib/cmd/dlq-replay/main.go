@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"lib/clients/broker"
+	fabricClient "lib/clients/fabric_client"
+	"lib/models"
+	"lib/utils/logging"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// dlq-replay переносит сообщения из <topic>.dlq обратно в <topic>.
+// Пример: dlq-replay -configs ./configs/configs.yaml -topic blocks -limit 100
+func main() {
+	topic := flag.String("topic", "", "main topic whose dead letters should be replayed")
+	groupID := flag.String("group", "", "consumer group for reading the DLQ (default <topic>.dlq-replay)")
+	limit := flag.Int("limit", 0, "maximum number of messages to replay (0 — all)")
+	idle := flag.Duration("idle", 10*time.Second, "stop after no new dead letters for this long")
+	configPath := models.ConfigFlag(flag.CommandLine)
+	flag.Parse()
+
+	logger := logging.GetLogger()
+	cfg := models.MustLoadConfig(*configPath, models.ServiceDLQReplay, logger)
+
+	if *topic == "" {
+		logger.Fatal("-topic is required")
+	}
+	if *groupID == "" {
+		*groupID = broker.DLQTopic(*topic) + "-replay"
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	client := fabricClient.NewBroker(cfg.Broker, logger)
+	if client == nil {
+		logger.Fatalf("Unsupported broker type: %s", cfg.Broker.BrockerType)
+	}
+	defer client.Close()
+
+	replayed, err := broker.ReplayDeadLetters(ctx, client, *topic, *groupID, *limit, *idle)
+	if err != nil {
+		logger.Fatalf("Failed to replay dead letters from %s: %v", broker.DLQTopic(*topic), err)
+	}
+	logger.Infof("Replayed %d messages from %s to %s", replayed, broker.DLQTopic(*topic), *topic)
+}
@@ -0,0 +1,347 @@
+package codec
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"lib/models"
+	"strings"
+	"time"
+)
+
+// Binary — компактный бинарный формат для блоков. Поля пишутся в фиксированном
+// порядке без тегов, hex-строки (хэши, адреса, bloom) хранятся как байты,
+// что почти вдвое уменьшает полный блок относительно JSON.
+//
+// Формат: байт версии формата, затем поля models.Block в порядке объявления.
+// Числа — uvarint, строки — байт вида (raw/hex) + uvarint длины + данные,
+// списки — uvarint числа элементов + элементы.
+// Версия 2 добавляет в конец заголовки дядей, версия 3 — награды блока;
+// payload предыдущих версий по-прежнему читается.
+type Binary struct{}
+
+const binaryFormatVersion = 3
+
+// Вид строки в бинарном формате
+const (
+	binaryStringRaw byte = iota
+	binaryStringHex      // "0x" + чётное число строчных hex-символов, хранится как байты
+)
+
+var errBinaryTruncated = errors.New("codec: truncated binary payload")
+
+func (Binary) ContentType() string { return models.ContentTypeBinary }
+
+func (Binary) Marshal(v any) ([]byte, error) {
+	switch b := v.(type) {
+	case *models.Block:
+		return marshalBlockBinary(b), nil
+	case models.Block:
+		return marshalBlockBinary(&b), nil
+	default:
+		return nil, fmt.Errorf("%w: %T", ErrUnsupportedType, v)
+	}
+}
+
+func (Binary) Unmarshal(data []byte, v any) error {
+	b, ok := v.(*models.Block)
+	if !ok {
+		return fmt.Errorf("%w: %T", ErrUnsupportedType, v)
+	}
+	return unmarshalBlockBinary(data, b)
+}
+
+func marshalBlockBinary(b *models.Block) []byte {
+	w := binaryWriter{buf: make([]byte, 0, 512+34*len(b.Transactions))}
+	w.buf = append(w.buf, binaryFormatVersion)
+
+	w.string(b.Hash)
+	w.uint(uint64(b.Number))
+	w.string(b.ParentHash)
+	w.uint(uint64(b.Nonce))
+	w.string(b.Sha3Uncles)
+	w.string(b.LogsBloom)
+	w.string(b.TransactionsRoot)
+	w.string(b.StateRoot)
+	w.string(b.ReceiptsRoot)
+	w.string(b.Miner)
+	w.string(b.Difficulty)
+	w.string(b.TotalDifficulty)
+	w.uint(uint64(b.Size))
+	w.string(b.ExtraData)
+	w.uint(uint64(b.GasLimit))
+	w.uint(uint64(b.GasUsed))
+	if b.BaseFeePerGas != nil {
+		w.buf = append(w.buf, 1)
+		w.uint(uint64(*b.BaseFeePerGas))
+	} else {
+		w.buf = append(w.buf, 0)
+	}
+	w.time(b.Timestamp)
+	w.string(b.MixHash)
+	w.strings(b.Transactions)
+	w.strings(b.Uncles)
+
+	w.uint(uint64(len(b.UncleHeaders)))
+	for _, u := range b.UncleHeaders {
+		w.string(u.Hash)
+		w.uint(uint64(u.Number))
+		w.string(u.ParentHash)
+		w.string(u.Miner)
+		w.string(u.Difficulty)
+		w.uint(uint64(u.GasLimit))
+		w.uint(uint64(u.GasUsed))
+		w.time(u.Timestamp)
+		w.uint(uint64(u.BlockNumber))
+		w.string(u.BlockHash)
+		w.uint(uint64(u.UncleIndex))
+		w.string(u.Reward)
+		w.time(u.BlockTimestamp)
+	}
+
+	if r := b.Rewards; r != nil {
+		w.buf = append(w.buf, 1)
+		w.uint(uint64(r.BlockNumber))
+		w.string(r.BlockHash)
+		w.string(r.Miner)
+		w.string(r.StaticReward)
+		w.string(r.UncleInclusionReward)
+		w.string(r.UnclesReward)
+		w.string(r.PriorityFees)
+		w.string(r.BaseFeeBurned)
+		w.string(r.BlobFeesBurned)
+		w.string(r.MinerReward)
+		w.time(r.BlockTimestamp)
+	} else {
+		w.buf = append(w.buf, 0)
+	}
+	return w.buf
+}
+
+func unmarshalBlockBinary(data []byte, b *models.Block) error {
+	if len(data) == 0 {
+		return errBinaryTruncated
+	}
+	version := data[0]
+	if version < 1 || version > binaryFormatVersion {
+		return fmt.Errorf("codec: unsupported binary format version %d", version)
+	}
+
+	r := binaryReader{buf: data[1:]}
+	*b = models.Block{
+		Hash:             r.string(),
+		Number:           uint(r.uint()),
+		ParentHash:       r.string(),
+		Nonce:            uint(r.uint()),
+		Sha3Uncles:       r.string(),
+		LogsBloom:        r.string(),
+		TransactionsRoot: r.string(),
+		StateRoot:        r.string(),
+		ReceiptsRoot:     r.string(),
+		Miner:            r.string(),
+		Difficulty:       r.string(),
+		TotalDifficulty:  r.string(),
+		Size:             uint(r.uint()),
+		ExtraData:        r.string(),
+		GasLimit:         uint(r.uint()),
+		GasUsed:          uint(r.uint()),
+	}
+	if r.byte() == 1 {
+		fee := uint(r.uint())
+		b.BaseFeePerGas = &fee
+	}
+	b.Timestamp = r.time()
+	b.MixHash = r.string()
+	b.Transactions = r.strings()
+	b.Uncles = r.strings()
+	if version >= 2 {
+		b.UncleHeaders = r.uncles()
+	}
+	if version >= 3 && r.byte() == 1 {
+		b.Rewards = &models.BlockReward{
+			BlockNumber:          uint(r.uint()),
+			BlockHash:            r.string(),
+			Miner:                r.string(),
+			StaticReward:         r.string(),
+			UncleInclusionReward: r.string(),
+			UnclesReward:         r.string(),
+			PriorityFees:         r.string(),
+			BaseFeeBurned:        r.string(),
+			BlobFeesBurned:       r.string(),
+			MinerReward:          r.string(),
+			BlockTimestamp:       r.time(),
+		}
+	}
+
+	return r.err
+}
+
+type binaryWriter struct {
+	buf []byte
+}
+
+func (w *binaryWriter) uint(v uint64) {
+	w.buf = binary.AppendUvarint(w.buf, v)
+}
+
+func (w *binaryWriter) string(s string) {
+	if isCompactHex(s) {
+		raw, _ := hex.DecodeString(s[2:])
+		w.buf = append(w.buf, binaryStringHex)
+		w.uint(uint64(len(raw)))
+		w.buf = append(w.buf, raw...)
+		return
+	}
+	w.buf = append(w.buf, binaryStringRaw)
+	w.uint(uint64(len(s)))
+	w.buf = append(w.buf, s...)
+}
+
+func (w *binaryWriter) strings(list []string) {
+	// nil и пустой список различаются, как и в JSON (null против [])
+	if list == nil {
+		w.uint(0)
+		return
+	}
+	w.uint(uint64(len(list)) + 1)
+	for _, s := range list {
+		w.string(s)
+	}
+}
+
+func (w *binaryWriter) time(t time.Time) {
+	if t.IsZero() {
+		w.buf = append(w.buf, 0)
+		return
+	}
+	w.buf = append(w.buf, 1)
+	w.buf = binary.AppendVarint(w.buf, t.UnixNano())
+}
+
+type binaryReader struct {
+	buf []byte
+	err error
+}
+
+func (r *binaryReader) fail() {
+	if r.err == nil {
+		r.err = errBinaryTruncated
+	}
+	r.buf = nil
+}
+
+func (r *binaryReader) byte() byte {
+	if len(r.buf) == 0 {
+		r.fail()
+		return 0
+	}
+	v := r.buf[0]
+	r.buf = r.buf[1:]
+	return v
+}
+
+func (r *binaryReader) uint() uint64 {
+	v, n := binary.Uvarint(r.buf)
+	if n <= 0 {
+		r.fail()
+		return 0
+	}
+	r.buf = r.buf[n:]
+	return v
+}
+
+func (r *binaryReader) bytes() []byte {
+	n := r.uint()
+	if n > uint64(len(r.buf)) {
+		r.fail()
+		return nil
+	}
+	v := r.buf[:n]
+	r.buf = r.buf[n:]
+	return v
+}
+
+func (r *binaryReader) string() string {
+	kind := r.byte()
+	raw := r.bytes()
+	if kind == binaryStringHex {
+		return "0x" + hex.EncodeToString(raw)
+	}
+	return string(raw)
+}
+
+func (r *binaryReader) strings() []string {
+	n := r.uint()
+	if n == 0 || r.err != nil {
+		return nil
+	}
+	// Каждая строка занимает минимум два байта — защита от огромной аллокации
+	if n-1 > uint64(len(r.buf)/2) {
+		r.fail()
+		return nil
+	}
+	list := make([]string, 0, n-1)
+	for i := uint64(1); i < n && r.err == nil; i++ {
+		list = append(list, r.string())
+	}
+	return list
+}
+
+func (r *binaryReader) uncles() []models.Uncle {
+	n := r.uint()
+	if n == 0 || r.err != nil {
+		return nil
+	}
+	// Заголовок дяди занимает заведомо больше 16 байт
+	if n > uint64(len(r.buf)/16) {
+		r.fail()
+		return nil
+	}
+	list := make([]models.Uncle, 0, n)
+	for i := uint64(0); i < n && r.err == nil; i++ {
+		list = append(list, models.Uncle{
+			Hash:           r.string(),
+			Number:         uint(r.uint()),
+			ParentHash:     r.string(),
+			Miner:          r.string(),
+			Difficulty:     r.string(),
+			GasLimit:       uint(r.uint()),
+			GasUsed:        uint(r.uint()),
+			Timestamp:      r.time(),
+			BlockNumber:    uint(r.uint()),
+			BlockHash:      r.string(),
+			UncleIndex:     uint(r.uint()),
+			Reward:         r.string(),
+			BlockTimestamp: r.time(),
+		})
+	}
+	return list
+}
+
+func (r *binaryReader) time() time.Time {
+	if r.byte() == 0 {
+		return time.Time{}
+	}
+	v, n := binary.Varint(r.buf)
+	if n <= 0 {
+		r.fail()
+		return time.Time{}
+	}
+	r.buf = r.buf[n:]
+	return time.Unix(0, v).UTC()
+}
+
+// isCompactHex — строку можно хранить байтами и восстановить без потерь
+func isCompactHex(s string) bool {
+	if len(s) < 2 || !strings.HasPrefix(s, "0x") || len(s)%2 != 0 {
+		return false
+	}
+	for i := 2; i < len(s); i++ {
+		c := s[i]
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,175 @@
+package codec
+
+import (
+	"errors"
+	"fmt"
+	"lib/models"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrUnsupportedType — кодек не умеет сериализовать переданный тип
+var ErrUnsupportedType = errors.New("codec: unsupported type")
+
+// Codec сериализует payload сообщения брокера
+type Codec interface {
+	// ContentType — значение заголовка content-type
+	ContentType() string
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+var (
+	codecsMu sync.RWMutex
+	codecs   = map[string]Codec{}
+	// Короткие имена для конфигурации: json, protobuf, binary
+	codecNames = map[string]string{}
+)
+
+func init() {
+	Register("json", JSON{})
+	Register("protobuf", Protobuf{})
+	Register("binary", Binary{})
+}
+
+// Register добавляет кодек под коротким именем name
+func Register(name string, c Codec) {
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+
+	codecs[c.ContentType()] = c
+	codecNames[name] = c.ContentType()
+}
+
+// ByContentType возвращает кодек по заголовку content-type
+func ByContentType(contentType string) (Codec, error) {
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+
+	c, ok := codecs[contentType]
+	if !ok {
+		return nil, fmt.Errorf("codec: unknown content type %q", contentType)
+	}
+	return c, nil
+}
+
+// ByName возвращает кодек по короткому имени из конфигурации
+func ByName(name string) (Codec, error) {
+	codecsMu.RLock()
+	contentType, ok := codecNames[name]
+	codecsMu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("codec: unknown codec %q (known: %v)", name, Names())
+	}
+	return ByContentType(contentType)
+}
+
+// Names возвращает короткие имена зарегистрированных кодеков
+func Names() []string {
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+
+	names := make([]string, 0, len(codecNames))
+	for name := range codecNames {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Encoder собирает сообщения брокера с конвертом
+type Encoder struct {
+	codec       Codec
+	compression Compression
+	network     string
+	producer    string
+}
+
+// NewEncoder создаёт кодировщик. Пустой codecName — json,
+// пустой compressionName — без сжатия.
+func NewEncoder(codecName, compressionName, network, producer string) (*Encoder, error) {
+	if codecName == "" {
+		codecName = "json"
+	}
+	c, err := ByName(codecName)
+	if err != nil {
+		return nil, err
+	}
+	comp, err := CompressionByName(compressionName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Encoder{
+		codec:       c,
+		compression: comp,
+		network:     network,
+		producer:    producer,
+	}, nil
+}
+
+// Encode сериализует v и возвращает сообщение для topic с заполненным конвертом
+func (e *Encoder) Encode(topic string, key []byte, messageType string, v any) (models.MessageBroker, error) {
+	data, err := e.codec.Marshal(v)
+	if err != nil {
+		return models.MessageBroker{}, fmt.Errorf("failed to marshal %s: %w", messageType, err)
+	}
+
+	env := models.Envelope{
+		SchemaVersion: models.CurrentSchemaVersion,
+		ContentType:   e.codec.ContentType(),
+		MessageType:   messageType,
+		Network:       e.network,
+		Producer:      e.producer,
+		ProducedAt:    time.Now(),
+	}
+	if e.compression != nil {
+		if data, err = e.compression.Compress(data); err != nil {
+			return models.MessageBroker{}, fmt.Errorf("failed to compress %s: %w", messageType, err)
+		}
+		env.ContentEncoding = e.compression.Name()
+	}
+
+	msg := models.MessageBroker{
+		Key:     key,
+		Value:   data,
+		Topic:   topic,
+		Headers: make(map[string]string),
+	}
+	env.SetHeaders(msg.Headers)
+	return msg, nil
+}
+
+// Decode читает конверт сообщения и десериализует payload в v.
+// Сообщения без конверта (версия 0) читаются как JSON.
+func Decode(msg models.MessageBroker, v any) (models.Envelope, error) {
+	env, err := models.EnvelopeFromHeaders(msg.Headers)
+	if err != nil {
+		return env, err
+	}
+	if env.SchemaVersion > models.CurrentSchemaVersion {
+		return env, fmt.Errorf("codec: unsupported schema version %d (max %d)", env.SchemaVersion, models.CurrentSchemaVersion)
+	}
+
+	data := msg.Value
+	if env.ContentEncoding != "" {
+		comp, err := CompressionByName(env.ContentEncoding)
+		if err != nil {
+			return env, err
+		}
+		if data, err = comp.Decompress(data); err != nil {
+			return env, fmt.Errorf("failed to decompress payload: %w", err)
+		}
+	}
+
+	c, err := ByContentType(env.ContentType)
+	if err != nil {
+		return env, err
+	}
+	if err := c.Unmarshal(data, v); err != nil {
+		return env, fmt.Errorf("failed to unmarshal %s payload: %w", env.ContentType, err)
+	}
+	return env, nil
+}
@@ -0,0 +1,393 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"lib/models"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func uintPtr(v uint) *uint { return &v }
+
+// testBlock — блок со всеми полями, которые пишут кодеки
+func testBlock() models.Block {
+	ts := time.Unix(1_700_000_000, 123).UTC()
+	return models.Block{
+		Hash:             "0x9b8e5d1f0c3a2b4e6d7f8a9b0c1d2e3f4a5b6c7d8e9f0a1b2c3d4e5f6a7b8c9d",
+		Number:           18_000_000,
+		ParentHash:       "0x1111111111111111111111111111111111111111111111111111111111111111",
+		Nonce:            0,
+		Sha3Uncles:       "0x1dcc4de8dec75d7aab85b567b6ccd41ad312451b948a7413f0a142fd40d49347",
+		LogsBloom:        "0x" + string(bytes.Repeat([]byte("0f"), 256)),
+		TransactionsRoot: "0x2222222222222222222222222222222222222222222222222222222222222222",
+		StateRoot:        "0x3333333333333333333333333333333333333333333333333333333333333333",
+		ReceiptsRoot:     "0x4444444444444444444444444444444444444444444444444444444444444444",
+		Miner:            "0x95222290dd7278aa3ddd389cc1e1d165cc4bafe5",
+		Difficulty:       "0",
+		TotalDifficulty:  "58750003716598352816469",
+		Size:             151_026,
+		ExtraData:        "beaverbuild.org", // не hex — хранится как есть
+		GasLimit:         30_000_000,
+		GasUsed:          12_345_678,
+		BaseFeePerGas:    uintPtr(17_000_000_000),
+		Timestamp:        ts,
+		MixHash:          "0xABCDEF", // hex в верхнем регистре не сжимается, но сохраняется без потерь
+		Transactions:     []string{"0xaa", "0xbb"},
+		Uncles:           []string{"0xcc"},
+		UncleHeaders: []models.Uncle{{
+			Hash: "0xcc", Number: 17_999_999, ParentHash: "0xdd", Miner: "0xee", Difficulty: "100",
+			GasLimit: 30_000_000, GasUsed: 21_000, Timestamp: ts.Add(-12 * time.Second),
+			BlockNumber: 18_000_000, BlockHash: "0x9b8e", UncleIndex: 0, Reward: "1750000000000000000", BlockTimestamp: ts,
+		}},
+		Rewards: &models.BlockReward{
+			BlockNumber: 18_000_000, BlockHash: "0x9b8e", Miner: "0x95222290dd7278aa3ddd389cc1e1d165cc4bafe5",
+			StaticReward: "0", UncleInclusionReward: "0", UnclesReward: "0", PriorityFees: "31234567890000000",
+			BaseFeeBurned: "209876526000000000", BlobFeesBurned: "0", MinerReward: "31234567890000000", BlockTimestamp: ts,
+		},
+	}
+}
+
+func TestCodecsRoundTrip(t *testing.T) {
+	blocks := map[string]models.Block{
+		"full block": testBlock(),
+		"pre-london block without base fee and rewards": func() models.Block {
+			b := testBlock()
+			b.BaseFeePerGas, b.Rewards, b.UncleHeaders = nil, nil, nil
+			return b
+		}(),
+		"empty lists": func() models.Block {
+			b := testBlock()
+			b.Transactions, b.Uncles, b.UncleHeaders = []string{}, []string{}, nil
+			return b
+		}(),
+	}
+
+	for _, name := range Names() {
+		c, err := ByName(name)
+		if err != nil {
+			t.Fatalf("codec %s: %v", name, err)
+		}
+		for blockName, block := range blocks {
+			t.Run(name+"/"+blockName, func(t *testing.T) {
+				data, err := c.Marshal(&block)
+				if err != nil {
+					t.Fatalf("marshal: %v", err)
+				}
+				var got models.Block
+				if err := c.Unmarshal(data, &got); err != nil {
+					t.Fatalf("unmarshal: %v", err)
+				}
+
+				want := block
+				// proto3 не различает nil и пустой список
+				if name == "protobuf" {
+					if len(want.Transactions) == 0 {
+						want.Transactions = nil
+					}
+					if len(want.Uncles) == 0 {
+						want.Uncles = nil
+					}
+				}
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("round trip mismatch:\n got  %+v\n want %+v", got, want)
+				}
+			})
+		}
+	}
+}
+
+func TestCodecsRejectUnsupportedType(t *testing.T) {
+	for _, c := range []Codec{Binary{}, Protobuf{}} {
+		if _, err := c.Marshal(models.Tx{}); !errors.Is(err, ErrUnsupportedType) {
+			t.Errorf("%s marshal tx = %v, want ErrUnsupportedType", c.ContentType(), err)
+		}
+		var tx models.Tx
+		if err := c.Unmarshal([]byte{1}, &tx); !errors.Is(err, ErrUnsupportedType) {
+			t.Errorf("%s unmarshal into tx = %v, want ErrUnsupportedType", c.ContentType(), err)
+		}
+	}
+}
+
+func TestEncodeDecodeWithCompression(t *testing.T) {
+	block := testBlock()
+
+	for _, codecName := range Names() {
+		for _, compression := range []string{"none", "zstd", "snappy"} {
+			t.Run(codecName+"/"+compression, func(t *testing.T) {
+				enc, err := NewEncoder(codecName, compression, "ethereum", "realtime-miner")
+				if err != nil {
+					t.Fatalf("new encoder: %v", err)
+				}
+				msg, err := enc.Encode("blocks", []byte(block.Hash), "block", &block)
+				if err != nil {
+					t.Fatalf("encode: %v", err)
+				}
+
+				var got models.Block
+				env, err := Decode(msg, &got)
+				if err != nil {
+					t.Fatalf("decode: %v", err)
+				}
+				if got.Hash != block.Hash || got.Rewards == nil || got.Rewards.MinerReward != block.Rewards.MinerReward {
+					t.Errorf("decoded block %s, rewards %+v", got.Hash, got.Rewards)
+				}
+				wantEncoding := compression
+				if compression == "none" {
+					wantEncoding = ""
+				}
+				if env.ContentEncoding != wantEncoding || env.Network != "ethereum" || env.SchemaVersion != models.CurrentSchemaVersion {
+					t.Errorf("envelope = %+v", env)
+				}
+			})
+		}
+	}
+}
+
+func TestCompressionRoundTrip(t *testing.T) {
+	payloads := [][]byte{nil, []byte("x"), bytes.Repeat([]byte("block"), 100_000)}
+	for _, name := range []string{"zstd", "snappy"} {
+		comp, err := CompressionByName(name)
+		if err != nil {
+			t.Fatalf("compression %s: %v", name, err)
+		}
+		for _, payload := range payloads {
+			compressed, err := comp.Compress(payload)
+			if err != nil {
+				t.Fatalf("%s compress: %v", name, err)
+			}
+			got, err := comp.Decompress(compressed)
+			if err != nil || !bytes.Equal(got, payload) {
+				t.Errorf("%s round trip of %d bytes = %d bytes, %v", name, len(payload), len(got), err)
+			}
+
+			// Обрезанный сжатый payload не разжимается
+			if len(compressed) > 4 {
+				if _, err := comp.Decompress(compressed[:len(compressed)/2]); err == nil {
+					t.Errorf("%s decompressed a truncated payload", name)
+				}
+			}
+		}
+	}
+
+	if comp, err := CompressionByName("none"); comp != nil || err != nil {
+		t.Errorf("compression none = %v, %v, want nil", comp, err)
+	}
+	if _, err := CompressionByName("lz4"); err == nil {
+		t.Error("unknown compression accepted")
+	}
+}
+
+// Payload'ы, записанные продюсерами прошлых версий бинарного формата.
+// Версия 1 — без заголовков дядей, версия 2 — без наград.
+const (
+	binaryV1Fixture = "01010201aac0843d010200ff2a0102beef010001011101012201013301142a65aca4d5fc5b5c859090a6c34d164135398226" +
+		"000e3132353439333332353039323237000080060118d783010303844765746887676f312e352e31856c696e7578d8dfbf01c488" +
+		"0301070180c8d4fbcd91d1b228010144020120ea1093d492a1dcb1bef708f771a99a96ff05dcab81ca76c31940300177fcf49f01"
+	binaryV2Fixture = "02010201aac0843d010200ff2a0102beef010001011101012201013301142a65aca4d5fc5b5c859090a6c34d164135398226" +
+		"000e3132353439333332353039323237000080060118d783010303844765746887676f312e352e31856c696e7578d8dfbf01c488" +
+		"0301070180c8d4fbcd91d1b228010144020120ea1093d492a1dcb1bef708f771a99a96ff05dcab81ca76c31940300177fcf49f02" +
+		"0101cc010101ccbf843d0101dd0101ee0003313030882788a401018080f68ac38ed1b228c0843d010201aa0000133433373530303030" +
+		"30303030303030303030300180c8d4fbcd91d1b228"
+)
+
+func fixtureBlock() models.Block {
+	return models.Block{
+		Hash:             "0x01aa",
+		Number:           1_000_000,
+		ParentHash:       "0x00ff",
+		Nonce:            42,
+		Sha3Uncles:       "0xbeef",
+		LogsBloom:        "0x",
+		TransactionsRoot: "0x11",
+		StateRoot:        "0x22",
+		ReceiptsRoot:     "0x33",
+		Miner:            "0x2a65aca4d5fc5b5c859090a6c34d164135398226",
+		Difficulty:       "12549332509227",
+		Size:             768,
+		ExtraData:        "0xd783010303844765746887676f312e352e31856c696e7578",
+		GasLimit:         3_141_592,
+		GasUsed:          50_244,
+		BaseFeePerGas:    uintPtr(7),
+		Timestamp:        time.Unix(1_455_404_053, 0).UTC(),
+		MixHash:          "0x44",
+		Transactions:     []string{"0xea1093d492a1dcb1bef708f771a99a96ff05dcab81ca76c31940300177fcf49f"},
+		Uncles:           []string{},
+	}
+}
+
+func TestBinaryReadsPreviousVersions(t *testing.T) {
+	v2 := fixtureBlock()
+	v2.Uncles = []string{"0xcc"}
+	v2.UncleHeaders = []models.Uncle{{
+		Hash: "0xcc", Number: 999_999, ParentHash: "0xdd", Miner: "0xee", Difficulty: "100",
+		GasLimit: 5000, GasUsed: 21_000, Timestamp: time.Unix(1_455_404_000, 0).UTC(),
+		BlockNumber: 1_000_000, BlockHash: "0x01aa", UncleIndex: 0, Reward: "4375000000000000000",
+		BlockTimestamp: time.Unix(1_455_404_053, 0).UTC(),
+	}}
+
+	tests := []struct {
+		name    string
+		fixture string
+		want    models.Block
+	}{
+		{"version 1", binaryV1Fixture, fixtureBlock()},
+		{"version 2", binaryV2Fixture, v2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := hex.DecodeString(tt.fixture)
+			if err != nil {
+				t.Fatalf("fixture: %v", err)
+			}
+			var got models.Block
+			if err := (Binary{}).Unmarshal(data, &got); err != nil {
+				t.Fatalf("unmarshal: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("decoded:\n got  %+v\n want %+v", got, tt.want)
+			}
+
+			// Перезапись читается текущей версией без потерь
+			again, _ := (Binary{}).Marshal(&got)
+			if again[0] != binaryFormatVersion {
+				t.Errorf("re-encoded with version %d, want %d", again[0], binaryFormatVersion)
+			}
+		})
+	}
+}
+
+func TestBinaryRejectsMalformedPayloads(t *testing.T) {
+	valid, _ := (Binary{}).Marshal(testBlock())
+
+	// Любой обрезанный payload — ошибка, а не паника или частично заполненный блок
+	for n := 0; n < len(valid); n++ {
+		var got models.Block
+		if err := (Binary{}).Unmarshal(valid[:n], &got); err == nil {
+			t.Fatalf("payload truncated to %d of %d bytes decoded without error", n, len(valid))
+		}
+	}
+
+	// Длины, которые больше самого payload, не приводят к огромным аллокациям
+	header := func(rest ...byte) []byte {
+		return append([]byte{binaryFormatVersion, binaryStringRaw}, rest...)
+	}
+	huge := binary.AppendUvarint(nil, 1<<62)
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"unknown version", []byte{binaryFormatVersion + 1}},
+		{"zero version", []byte{0}},
+		{"oversized string", header(huge...)},
+		{"oversized transaction list", overflowAt(t, "transactions")},
+		{"oversized uncle headers", overflowAt(t, "uncleHeaders")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got models.Block
+			if err := (Binary{}).Unmarshal(tt.data, &got); err == nil {
+				t.Errorf("malformed payload decoded: %+v", got)
+			}
+		})
+	}
+}
+
+// overflowAt подменяет счётчик списка в payload блока без дядей и наград
+// на заведомо больший, чем помещается в оставшиеся байты
+func overflowAt(t *testing.T, list string) []byte {
+	t.Helper()
+	b := fixtureBlock()
+	b.Transactions, b.Uncles = nil, nil
+	data, _ := (Binary{}).Marshal(&b)
+
+	// Хвост: transactions (0 = nil), uncles (0), uncleHeaders (0), rewards (0)
+	tail := len(data) - 4
+	if !bytes.Equal(data[tail:], []byte{0, 0, 0, 0}) {
+		t.Fatalf("unexpected payload tail %x", data[tail:])
+	}
+	prefix := append([]byte(nil), data[:tail]...)
+	count := binary.AppendUvarint(nil, 1<<40)
+	switch list {
+	case "transactions":
+		return append(append(prefix, count...), 0, 0, 0)
+	default:
+		return append(append(prefix, 0, 0), append(count, 0)...)
+	}
+}
+
+func TestProtobufRejectsMalformedPayloads(t *testing.T) {
+	valid, _ := (Protobuf{}).Marshal(testBlock())
+
+	for _, n := range []int{1, len(valid) / 3, len(valid) / 2, len(valid) - 1} {
+		var got models.Block
+		if err := (Protobuf{}).Unmarshal(valid[:n], &got); err == nil {
+			t.Errorf("payload truncated to %d of %d bytes decoded without error", n, len(valid))
+		}
+	}
+
+	// Поле bytes с длиной больше payload
+	oversized := protowire.AppendVarint(protowire.AppendTag(nil, pbBlockHash, protowire.BytesType), 1<<40)
+	var got models.Block
+	if err := (Protobuf{}).Unmarshal(oversized, &got); err == nil {
+		t.Error("field longer than the payload decoded without error")
+	}
+
+	// Неизвестное поле из более новой схемы пропускается
+	withUnknown := protowire.AppendVarint(protowire.AppendTag(append([]byte(nil), valid...), 99, protowire.VarintType), 1)
+	if err := (Protobuf{}).Unmarshal(withUnknown, &got); err != nil || got.Hash != testBlock().Hash {
+		t.Errorf("payload with unknown field = %v", err)
+	}
+}
+
+func TestDecodeRejectsUnknownEnvelope(t *testing.T) {
+	enc, err := NewEncoder("binary", "zstd", "ethereum", "realtime-miner")
+	if err != nil {
+		t.Fatalf("new encoder: %v", err)
+	}
+	block := testBlock()
+	msg, err := enc.Encode("blocks", nil, "block", &block)
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		header string
+		value  string
+	}{
+		{"newer schema", models.HeaderSchemaVersion, "99"},
+		{"invalid schema", models.HeaderSchemaVersion, "v1"},
+		{"unknown content type", models.HeaderContentType, "application/xml"},
+		{"unknown encoding", models.HeaderContentEncoding, "lz4"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			headers := make(map[string]string, len(msg.Headers))
+			for k, v := range msg.Headers {
+				headers[k] = v
+			}
+			headers[tt.header] = tt.value
+			broken := msg
+			broken.Headers = headers
+
+			var got models.Block
+			if _, err := Decode(broken, &got); err == nil {
+				t.Error("message decoded despite the broken envelope")
+			}
+		})
+	}
+
+	// Сообщение без конверта читается как JSON
+	var got models.Block
+	legacy := models.MessageBroker{Value: []byte(`{"hash":"0x01","number":5}`)}
+	if env, err := Decode(legacy, &got); err != nil || env.SchemaVersion != 0 || got.Number != 5 {
+		t.Errorf("legacy message = %+v, %v, block %+v", env, err, got)
+	}
+}
@@ -0,0 +1,84 @@
+package codec
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/klauspost/compress/s2"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Compression сжимает payload после сериализации
+type Compression interface {
+	// Name — значение заголовка content-encoding
+	Name() string
+	Compress(data []byte) ([]byte, error)
+	Decompress(data []byte) ([]byte, error)
+}
+
+// CompressionByName возвращает алгоритм сжатия; "" и "none" — без сжатия (nil)
+func CompressionByName(name string) (Compression, error) {
+	switch name {
+	case "", "none":
+		return nil, nil
+	case "zstd":
+		return Zstd{}, nil
+	case "snappy":
+		return Snappy{}, nil
+	default:
+		return nil, fmt.Errorf("codec: unknown compression %q", name)
+	}
+}
+
+// Zstd — сжатие zstd, лучшее соотношение для полных блоков
+type Zstd struct{}
+
+// Кодер и декодер zstd потокобезопасны для EncodeAll/DecodeAll и дороги в создании
+var (
+	zstdOnce    sync.Once
+	zstdEncoder *zstd.Encoder
+	zstdDecoder *zstd.Decoder
+	zstdErr     error
+)
+
+func zstdCodecs() (*zstd.Encoder, *zstd.Decoder, error) {
+	zstdOnce.Do(func() {
+		zstdEncoder, zstdErr = zstd.NewWriter(nil)
+		if zstdErr != nil {
+			return
+		}
+		zstdDecoder, zstdErr = zstd.NewReader(nil)
+	})
+	return zstdEncoder, zstdDecoder, zstdErr
+}
+
+func (Zstd) Name() string { return "zstd" }
+
+func (Zstd) Compress(data []byte) ([]byte, error) {
+	enc, _, err := zstdCodecs()
+	if err != nil {
+		return nil, err
+	}
+	return enc.EncodeAll(data, nil), nil
+}
+
+func (Zstd) Decompress(data []byte) ([]byte, error) {
+	_, dec, err := zstdCodecs()
+	if err != nil {
+		return nil, err
+	}
+	return dec.DecodeAll(data, nil)
+}
+
+// Snappy — быстрое сжатие в блочном формате snappy
+type Snappy struct{}
+
+func (Snappy) Name() string { return "snappy" }
+
+func (Snappy) Compress(data []byte) ([]byte, error) {
+	return s2.EncodeSnappy(nil, data), nil
+}
+
+func (Snappy) Decompress(data []byte) ([]byte, error) {
+	return s2.Decode(nil, data)
+}
@@ -0,0 +1,19 @@
+package codec
+
+import (
+	"encoding/json"
+	"lib/models"
+)
+
+// JSON — кодек по умолчанию, поддерживает любые типы
+type JSON struct{}
+
+func (JSON) ContentType() string { return models.ContentTypeJSON }
+
+func (JSON) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (JSON) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
@@ -0,0 +1,433 @@
+package codec
+
+import (
+	"fmt"
+	"lib/models"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Protobuf — кодек в wire-формате protobuf по схеме block.proto.
+// Сериализация написана вручную через protowire, чтобы не тянуть protoc
+// в сборку; при изменении схемы номера полей менять нельзя, только добавлять.
+type Protobuf struct{}
+
+// Номера полей message Block из block.proto
+const (
+	pbBlockHash             protowire.Number = 1
+	pbBlockNumber           protowire.Number = 2
+	pbBlockParentHash       protowire.Number = 3
+	pbBlockNonce            protowire.Number = 4
+	pbBlockSha3Uncles       protowire.Number = 5
+	pbBlockLogsBloom        protowire.Number = 6
+	pbBlockTransactionsRoot protowire.Number = 7
+	pbBlockStateRoot        protowire.Number = 8
+	pbBlockReceiptsRoot     protowire.Number = 9
+	pbBlockMiner            protowire.Number = 10
+	pbBlockDifficulty       protowire.Number = 11
+	pbBlockTotalDifficulty  protowire.Number = 12
+	pbBlockSize             protowire.Number = 13
+	pbBlockExtraData        protowire.Number = 14
+	pbBlockGasLimit         protowire.Number = 15
+	pbBlockGasUsed          protowire.Number = 16
+	pbBlockBaseFeePerGas    protowire.Number = 17
+	pbBlockTimestamp        protowire.Number = 18
+	pbBlockMixHash          protowire.Number = 19
+	pbBlockTransactions     protowire.Number = 20
+	pbBlockUncles           protowire.Number = 21
+	pbBlockUncleHeaders     protowire.Number = 22
+	pbBlockRewards          protowire.Number = 23
+)
+
+// Номера полей message Uncle из block.proto
+const (
+	pbUncleHash           protowire.Number = 1
+	pbUncleNumber         protowire.Number = 2
+	pbUncleParentHash     protowire.Number = 3
+	pbUncleMiner          protowire.Number = 4
+	pbUncleDifficulty     protowire.Number = 5
+	pbUncleGasLimit       protowire.Number = 6
+	pbUncleGasUsed        protowire.Number = 7
+	pbUncleTimestamp      protowire.Number = 8
+	pbUncleBlockNumber    protowire.Number = 9
+	pbUncleBlockHash      protowire.Number = 10
+	pbUncleIndex          protowire.Number = 11
+	pbUncleReward         protowire.Number = 12
+	pbUncleBlockTimestamp protowire.Number = 13
+)
+
+// Номера полей message BlockReward из block.proto
+const (
+	pbRewardBlockNumber          protowire.Number = 1
+	pbRewardBlockHash            protowire.Number = 2
+	pbRewardMiner                protowire.Number = 3
+	pbRewardStaticReward         protowire.Number = 4
+	pbRewardUncleInclusionReward protowire.Number = 5
+	pbRewardUnclesReward         protowire.Number = 6
+	pbRewardPriorityFees         protowire.Number = 7
+	pbRewardBaseFeeBurned        protowire.Number = 8
+	pbRewardBlobFeesBurned       protowire.Number = 9
+	pbRewardMinerReward          protowire.Number = 10
+	pbRewardBlockTimestamp       protowire.Number = 11
+)
+
+func (Protobuf) ContentType() string { return models.ContentTypeProtobuf }
+
+func (Protobuf) Marshal(v any) ([]byte, error) {
+	switch b := v.(type) {
+	case *models.Block:
+		return marshalBlockProto(b), nil
+	case models.Block:
+		return marshalBlockProto(&b), nil
+	default:
+		return nil, fmt.Errorf("%w: %T", ErrUnsupportedType, v)
+	}
+}
+
+func (Protobuf) Unmarshal(data []byte, v any) error {
+	b, ok := v.(*models.Block)
+	if !ok {
+		return fmt.Errorf("%w: %T", ErrUnsupportedType, v)
+	}
+	return unmarshalBlockProto(data, b)
+}
+
+// protoWriter дописывает поля proto3, пропуская нулевые значения
+type protoWriter struct {
+	buf []byte
+}
+
+func (w *protoWriter) string(num protowire.Number, s string) {
+	if s == "" {
+		return
+	}
+	w.buf = protowire.AppendTag(w.buf, num, protowire.BytesType)
+	w.buf = protowire.AppendString(w.buf, s)
+}
+
+func (w *protoWriter) uint(num protowire.Number, v uint64) {
+	if v == 0 {
+		return
+	}
+	w.buf = protowire.AppendTag(w.buf, num, protowire.VarintType)
+	w.buf = protowire.AppendVarint(w.buf, v)
+}
+
+func (w *protoWriter) time(num protowire.Number, t time.Time) {
+	if t.IsZero() {
+		return
+	}
+	w.buf = protowire.AppendTag(w.buf, num, protowire.VarintType)
+	w.buf = protowire.AppendVarint(w.buf, protowire.EncodeZigZag(t.UnixNano()))
+}
+
+func marshalBlockProto(b *models.Block) []byte {
+	w := protoWriter{}
+
+	w.string(pbBlockHash, b.Hash)
+	w.uint(pbBlockNumber, uint64(b.Number))
+	w.string(pbBlockParentHash, b.ParentHash)
+	w.uint(pbBlockNonce, uint64(b.Nonce))
+	w.string(pbBlockSha3Uncles, b.Sha3Uncles)
+	w.string(pbBlockLogsBloom, b.LogsBloom)
+	w.string(pbBlockTransactionsRoot, b.TransactionsRoot)
+	w.string(pbBlockStateRoot, b.StateRoot)
+	w.string(pbBlockReceiptsRoot, b.ReceiptsRoot)
+	w.string(pbBlockMiner, b.Miner)
+	w.string(pbBlockDifficulty, b.Difficulty)
+	w.string(pbBlockTotalDifficulty, b.TotalDifficulty)
+	w.uint(pbBlockSize, uint64(b.Size))
+	w.string(pbBlockExtraData, b.ExtraData)
+	w.uint(pbBlockGasLimit, uint64(b.GasLimit))
+	w.uint(pbBlockGasUsed, uint64(b.GasUsed))
+	// optional: ноль отличается от отсутствия поля (блоки до London)
+	if b.BaseFeePerGas != nil {
+		w.buf = protowire.AppendTag(w.buf, pbBlockBaseFeePerGas, protowire.VarintType)
+		w.buf = protowire.AppendVarint(w.buf, uint64(*b.BaseFeePerGas))
+	}
+	w.time(pbBlockTimestamp, b.Timestamp)
+	w.string(pbBlockMixHash, b.MixHash)
+	for _, tx := range b.Transactions {
+		w.buf = protowire.AppendTag(w.buf, pbBlockTransactions, protowire.BytesType)
+		w.buf = protowire.AppendString(w.buf, tx)
+	}
+	for _, uncle := range b.Uncles {
+		w.buf = protowire.AppendTag(w.buf, pbBlockUncles, protowire.BytesType)
+		w.buf = protowire.AppendString(w.buf, uncle)
+	}
+	for i := range b.UncleHeaders {
+		w.buf = protowire.AppendTag(w.buf, pbBlockUncleHeaders, protowire.BytesType)
+		w.buf = protowire.AppendBytes(w.buf, marshalUncleProto(&b.UncleHeaders[i]))
+	}
+	if b.Rewards != nil {
+		w.buf = protowire.AppendTag(w.buf, pbBlockRewards, protowire.BytesType)
+		w.buf = protowire.AppendBytes(w.buf, marshalRewardProto(b.Rewards))
+	}
+	return w.buf
+}
+
+func marshalUncleProto(u *models.Uncle) []byte {
+	w := protoWriter{}
+	w.string(pbUncleHash, u.Hash)
+	w.uint(pbUncleNumber, uint64(u.Number))
+	w.string(pbUncleParentHash, u.ParentHash)
+	w.string(pbUncleMiner, u.Miner)
+	w.string(pbUncleDifficulty, u.Difficulty)
+	w.uint(pbUncleGasLimit, uint64(u.GasLimit))
+	w.uint(pbUncleGasUsed, uint64(u.GasUsed))
+	w.time(pbUncleTimestamp, u.Timestamp)
+	w.uint(pbUncleBlockNumber, uint64(u.BlockNumber))
+	w.string(pbUncleBlockHash, u.BlockHash)
+	w.uint(pbUncleIndex, uint64(u.UncleIndex))
+	w.string(pbUncleReward, u.Reward)
+	w.time(pbUncleBlockTimestamp, u.BlockTimestamp)
+	return w.buf
+}
+
+func marshalRewardProto(r *models.BlockReward) []byte {
+	w := protoWriter{}
+	w.uint(pbRewardBlockNumber, uint64(r.BlockNumber))
+	w.string(pbRewardBlockHash, r.BlockHash)
+	w.string(pbRewardMiner, r.Miner)
+	w.string(pbRewardStaticReward, r.StaticReward)
+	w.string(pbRewardUncleInclusionReward, r.UncleInclusionReward)
+	w.string(pbRewardUnclesReward, r.UnclesReward)
+	w.string(pbRewardPriorityFees, r.PriorityFees)
+	w.string(pbRewardBaseFeeBurned, r.BaseFeeBurned)
+	w.string(pbRewardBlobFeesBurned, r.BlobFeesBurned)
+	w.string(pbRewardMinerReward, r.MinerReward)
+	w.time(pbRewardBlockTimestamp, r.BlockTimestamp)
+	return w.buf
+}
+
+func unmarshalBlockProto(data []byte, b *models.Block) error {
+	*b = models.Block{}
+
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		data = data[n:]
+
+		switch typ {
+		case protowire.BytesType:
+			raw, n := protowire.ConsumeBytes(data)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			data = data[n:]
+
+			if num == pbBlockUncleHeaders {
+				var u models.Uncle
+				if err := unmarshalUncleProto(raw, &u); err != nil {
+					return err
+				}
+				b.UncleHeaders = append(b.UncleHeaders, u)
+				continue
+			}
+			if num == pbBlockRewards {
+				b.Rewards = &models.BlockReward{}
+				if err := unmarshalRewardProto(raw, b.Rewards); err != nil {
+					return err
+				}
+				continue
+			}
+
+			s := string(raw)
+			switch num {
+			case pbBlockHash:
+				b.Hash = s
+			case pbBlockParentHash:
+				b.ParentHash = s
+			case pbBlockSha3Uncles:
+				b.Sha3Uncles = s
+			case pbBlockLogsBloom:
+				b.LogsBloom = s
+			case pbBlockTransactionsRoot:
+				b.TransactionsRoot = s
+			case pbBlockStateRoot:
+				b.StateRoot = s
+			case pbBlockReceiptsRoot:
+				b.ReceiptsRoot = s
+			case pbBlockMiner:
+				b.Miner = s
+			case pbBlockDifficulty:
+				b.Difficulty = s
+			case pbBlockTotalDifficulty:
+				b.TotalDifficulty = s
+			case pbBlockExtraData:
+				b.ExtraData = s
+			case pbBlockMixHash:
+				b.MixHash = s
+			case pbBlockTransactions:
+				b.Transactions = append(b.Transactions, s)
+			case pbBlockUncles:
+				b.Uncles = append(b.Uncles, s)
+			}
+
+		case protowire.VarintType:
+			v, n := protowire.ConsumeVarint(data)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			data = data[n:]
+
+			switch num {
+			case pbBlockNumber:
+				b.Number = uint(v)
+			case pbBlockNonce:
+				b.Nonce = uint(v)
+			case pbBlockSize:
+				b.Size = uint(v)
+			case pbBlockGasLimit:
+				b.GasLimit = uint(v)
+			case pbBlockGasUsed:
+				b.GasUsed = uint(v)
+			case pbBlockBaseFeePerGas:
+				fee := uint(v)
+				b.BaseFeePerGas = &fee
+			case pbBlockTimestamp:
+				b.Timestamp = time.Unix(0, protowire.DecodeZigZag(v)).UTC()
+			}
+
+		default:
+			// Неизвестные поля из более новой схемы пропускаем
+			n := protowire.ConsumeFieldValue(num, typ, data)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			data = data[n:]
+		}
+	}
+	return nil
+}
+
+func unmarshalUncleProto(data []byte, u *models.Uncle) error {
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		data = data[n:]
+
+		switch typ {
+		case protowire.BytesType:
+			s, n := protowire.ConsumeString(data)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			data = data[n:]
+
+			switch num {
+			case pbUncleHash:
+				u.Hash = s
+			case pbUncleParentHash:
+				u.ParentHash = s
+			case pbUncleMiner:
+				u.Miner = s
+			case pbUncleDifficulty:
+				u.Difficulty = s
+			case pbUncleBlockHash:
+				u.BlockHash = s
+			case pbUncleReward:
+				u.Reward = s
+			}
+
+		case protowire.VarintType:
+			v, n := protowire.ConsumeVarint(data)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			data = data[n:]
+
+			switch num {
+			case pbUncleNumber:
+				u.Number = uint(v)
+			case pbUncleGasLimit:
+				u.GasLimit = uint(v)
+			case pbUncleGasUsed:
+				u.GasUsed = uint(v)
+			case pbUncleTimestamp:
+				u.Timestamp = time.Unix(0, protowire.DecodeZigZag(v)).UTC()
+			case pbUncleBlockNumber:
+				u.BlockNumber = uint(v)
+			case pbUncleIndex:
+				u.UncleIndex = uint(v)
+			case pbUncleBlockTimestamp:
+				u.BlockTimestamp = time.Unix(0, protowire.DecodeZigZag(v)).UTC()
+			}
+
+		default:
+			n := protowire.ConsumeFieldValue(num, typ, data)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			data = data[n:]
+		}
+	}
+	return nil
+}
+
+func unmarshalRewardProto(data []byte, r *models.BlockReward) error {
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		data = data[n:]
+
+		switch typ {
+		case protowire.BytesType:
+			s, n := protowire.ConsumeString(data)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			data = data[n:]
+
+			switch num {
+			case pbRewardBlockHash:
+				r.BlockHash = s
+			case pbRewardMiner:
+				r.Miner = s
+			case pbRewardStaticReward:
+				r.StaticReward = s
+			case pbRewardUncleInclusionReward:
+				r.UncleInclusionReward = s
+			case pbRewardUnclesReward:
+				r.UnclesReward = s
+			case pbRewardPriorityFees:
+				r.PriorityFees = s
+			case pbRewardBaseFeeBurned:
+				r.BaseFeeBurned = s
+			case pbRewardBlobFeesBurned:
+				r.BlobFeesBurned = s
+			case pbRewardMinerReward:
+				r.MinerReward = s
+			}
+
+		case protowire.VarintType:
+			v, n := protowire.ConsumeVarint(data)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			data = data[n:]
+
+			switch num {
+			case pbRewardBlockNumber:
+				r.BlockNumber = uint(v)
+			case pbRewardBlockTimestamp:
+				r.BlockTimestamp = time.Unix(0, protowire.DecodeZigZag(v)).UTC()
+			}
+
+		default:
+			n := protowire.ConsumeFieldValue(num, typ, data)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			data = data[n:]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package contracts
+
+import (
+	"context"
+	"fmt"
+	"lib/models"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// codeChunk — контрактов в одном батче eth_getCode
+const codeChunk = 100
+
+// Caller — провайдер для трейсов и байткода (node.Provider)
+type Caller interface {
+	BatchCallContext(ctx context.Context, batch []rpc.BatchElem) error
+}
+
+// Options — что запрашивать у провайдера сверх квитанций
+type Options struct {
+	// Traces — трейсы блока (debug_traceBlockByNumber с callTracer):
+	// находят контракты, созданные фабриками во внутренних вызовах
+	Traces bool
+	// Code — runtime-байткод (eth_getCode) на блоке создания:
+	// хеш кода и интерфейсы токенов
+	Code bool
+}
+
+// Indexer строит индекс контрактов, созданных в блоке
+type Indexer struct {
+	caller Caller
+	opts   Options
+}
+
+func NewIndexer(caller Caller, opts Options) *Indexer {
+	return &Indexer{caller: caller, opts: opts}
+}
+
+// Index возвращает контракты, созданные в блоке. txs — транзакции блока
+// с квитанциями (metrics.Source.Txs); без квитанций и трейсов результат пуст.
+func (ix *Indexer) Index(ctx context.Context, block models.Block, txs []models.Tx) ([]models.Contract, error) {
+	contracts := FromReceipts(txs)
+
+	if ix.opts.Traces {
+		traced, err := ix.fromTraces(ctx, block, txs)
+		if err != nil {
+			return nil, err
+		}
+		contracts = merge(traced, contracts)
+	}
+
+	if ix.opts.Code && len(contracts) > 0 {
+		if err := ix.fetchCode(ctx, block.Number, contracts); err != nil {
+			return nil, err
+		}
+	}
+	return contracts, nil
+}
+
+// FromReceipts выделяет контракты, созданные транзакциями напрямую:
+// квитанции с contractAddress и успешным статусом
+func FromReceipts(txs []models.Tx) []models.Contract {
+	var contracts []models.Contract
+	for _, tx := range txs {
+		r := tx.Receipt
+		if r == nil || r.ContractAddress == nil || r.Status != 1 {
+			continue
+		}
+		// Адреса в checksum-виде, как у контрактов из трейсов
+		from := common.HexToAddress(r.From).Hex()
+		contracts = append(contracts, models.Contract{
+			Address:         common.HexToAddress(*r.ContractAddress).Hex(),
+			Deployer:        from,
+			TxFrom:          from,
+			CreationType:    models.ContractCreate,
+			Source:          models.ContractSourceReceipt,
+			BlockNumber:     r.BlockNumber,
+			BlockHash:       r.BlockHash,
+			TransactionHash: r.TransactionHash,
+			BlockTimestamp:  r.BlockTimestamp,
+		})
+	}
+	return contracts
+}
+
+// merge дополняет контракты из трейсов теми, что есть только в квитанциях.
+// Трейсы содержат и создания верхнего уровня, поэтому их записи приоритетнее.
+func merge(traced, receipts []models.Contract) []models.Contract {
+	seen := make(map[string]bool, len(traced))
+	for _, c := range traced {
+		seen[c.Address] = true
+	}
+	for _, c := range receipts {
+		if !seen[c.Address] {
+			traced = append(traced, c)
+		}
+	}
+	return traced
+}
+
+// fetchCode загружает runtime-байткод контрактов батчами eth_getCode по codeChunk
+// на блоке создания: позже контракт может самоуничтожиться
+func (ix *Indexer) fetchCode(ctx context.Context, blockNumber uint, contracts []models.Contract) error {
+	for start := 0; start < len(contracts); start += codeChunk {
+		chunk := contracts[start:min(start+codeChunk, len(contracts))]
+
+		batch := make([]rpc.BatchElem, len(chunk))
+		for i, c := range chunk {
+			batch[i] = rpc.BatchElem{
+				Method: "eth_getCode",
+				Args:   []any{c.Address, hexutil.Uint64(blockNumber)},
+				Result: new(hexutil.Bytes),
+			}
+		}
+		if err := ix.caller.BatchCallContext(ctx, batch); err != nil {
+			return fmt.Errorf("fetch code for block %d: %w", blockNumber, err)
+		}
+
+		for i, elem := range batch {
+			if elem.Error != nil {
+				return fmt.Errorf("fetch code of %s: %w", chunk[i].Address, elem.Error)
+			}
+			code := *elem.Result.(*hexutil.Bytes)
+			if len(code) == 0 {
+				continue
+			}
+			chunk[i].CodeHash = crypto.Keccak256Hash(code).Hex()
+			chunk[i].CodeSize = uint(len(code))
+			chunk[i].Standards = Standards(code)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package contracts
+
+import (
+	"lib/models"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// standards — обязательные методы интерфейсов токенов. Набор выбран так,
+// чтобы интерфейсы не пересекались: ERC-721 без allowance не считается
+// ERC-20, а ERC-1155 отличает balanceOf(address,uint256).
+var standards = []struct {
+	name      string
+	selectors [][4]byte
+}{
+	{models.ContractStandardERC20, selectors(
+		"totalSupply()",
+		"balanceOf(address)",
+		"transfer(address,uint256)",
+		"transferFrom(address,address,uint256)",
+		"approve(address,uint256)",
+		"allowance(address,address)",
+	)},
+	{models.ContractStandardERC721, selectors(
+		"balanceOf(address)",
+		"ownerOf(uint256)",
+		"safeTransferFrom(address,address,uint256)",
+		"transferFrom(address,address,uint256)",
+		"getApproved(uint256)",
+		"setApprovalForAll(address,bool)",
+		"isApprovedForAll(address,address)",
+	)},
+	{models.ContractStandardERC1155, selectors(
+		"balanceOf(address,uint256)",
+		"balanceOfBatch(address[],uint256[])",
+		"safeTransferFrom(address,address,uint256,uint256,bytes)",
+		"safeBatchTransferFrom(address,address,uint256[],uint256[],bytes)",
+		"setApprovalForAll(address,bool)",
+		"isApprovedForAll(address,address)",
+	)},
+}
+
+// Standards определяет интерфейсы токенов по селекторам в runtime-байткоде.
+// Прокси содержат только код делегирования, поэтому для них интерфейсы
+// не определяются.
+func Standards(code []byte) []string {
+	found := codeSelectors(code)
+	var result []string
+	for _, standard := range standards {
+		if containsAll(found, standard.selectors) {
+			result = append(result, standard.name)
+		}
+	}
+	return result
+}
+
+// codeSelectors собирает константы PUSH4 из байткода, пропуская данные
+// остальных PUSH. solc кладёт селектор со старшим нулевым байтом через PUSH3,
+// поэтому трёхбайтовые константы тоже учитываются.
+func codeSelectors(code []byte) map[[4]byte]bool {
+	const push1, push32 = 0x60, 0x7f
+
+	found := make(map[[4]byte]bool)
+	for i := 0; i < len(code); i++ {
+		op := code[i]
+		if op < push1 || op > push32 {
+			continue
+		}
+		n := int(op-push1) + 1
+		if i+n >= len(code) {
+			break
+		}
+		var selector [4]byte
+		switch n {
+		case 4:
+			copy(selector[:], code[i+1:i+5])
+			found[selector] = true
+		case 3:
+			copy(selector[1:], code[i+1:i+4])
+			found[selector] = true
+		}
+		i += n
+	}
+	return found
+}
+
+func containsAll(found map[[4]byte]bool, selectors [][4]byte) bool {
+	for _, s := range selectors {
+		if !found[s] {
+			return false
+		}
+	}
+	return true
+}
+
+func selectors(signatures ...string) [][4]byte {
+	result := make([][4]byte, len(signatures))
+	for i, sig := range signatures {
+		copy(result[i][:], crypto.Keccak256([]byte(sig))[:4])
+	}
+	return result
+}
@@ -0,0 +1,145 @@
+package contracts
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"lib/models"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// runtimeCode — runtime-байткод развёрнутого контракта из состояния сети
+// и интерфейсы, которые он реализует
+type runtimeCode struct {
+	Note      string        `json:"note"`
+	Address   string        `json:"address"`
+	ChainID   uint64        `json:"chainId"`
+	Block     uint64        `json:"block"`
+	Source    string        `json:"source"`
+	Standards []string      `json:"standards"`
+	Code      hexutil.Bytes `json:"code"`
+}
+
+func selector(signature string) []byte {
+	return crypto.Keccak256([]byte(signature))[:4]
+}
+
+// dispatcher собирает диспетчер функций в том виде, в каком его генерирует
+// solc: селектор со старшим нулевым байтом кладётся через PUSH3
+func dispatcher(signatures ...string) []byte {
+	code := []byte{0x60, 0x00, 0x35, 0x60, 0xe0, 0x1c} // PUSH1 0 CALLDATALOAD PUSH1 0xe0 SHR
+	for i, signature := range signatures {
+		sel := selector(signature)
+		code = append(code, 0x80) // DUP1
+		if sel[0] == 0 {
+			code = append(code, 0x62) // PUSH3
+			code = append(code, sel[1:]...)
+		} else {
+			code = append(code, 0x63) // PUSH4
+			code = append(code, sel...)
+		}
+		code = append(code, 0x14, 0x61, 0x01, byte(i), 0x57) // EQ PUSH2 dest JUMPI
+	}
+	return append(code, 0x60, 0x00, 0x80, 0xfd) // PUSH1 0 DUP1 REVERT
+}
+
+func TestCodeSelectors(t *testing.T) {
+	transfer := selector("transfer(address,uint256)")
+	balanceOf := selector("balanceOf(address,uint256)") // 0x00fdd58e
+
+	// PUSH32, в данных которого лежит PUSH4 transfer: это константа, а не код
+	push32 := append([]byte{0x7f, 0x63}, transfer...)
+	push32 = append(push32, make([]byte, 32-5)...)
+
+	tests := []struct {
+		name string
+		code []byte
+		want [][]byte
+	}{
+		{"push4", append([]byte{0x63}, transfer...), [][]byte{transfer}},
+		{"push3 with leading zero", append([]byte{0x62}, balanceOf[1:]...), [][]byte{balanceOf}},
+		{"push data is skipped", push32, nil},
+		{"push2 data is skipped", append([]byte{0x61, 0x63, transfer[0]}, transfer[1:]...), nil},
+		{"truncated push4", append([]byte{0x63}, transfer[:3]...), nil},
+		{"dispatcher", dispatcher("transfer(address,uint256)", "balanceOf(address,uint256)"), [][]byte{transfer, balanceOf}},
+	}
+	for _, tt := range tests {
+		found := codeSelectors(tt.code)
+		var want [4]byte
+		for _, sel := range tt.want {
+			copy(want[:], sel)
+			if !found[want] {
+				t.Errorf("%s: selector %x not found in %x", tt.name, sel, tt.code)
+			}
+		}
+		copy(want[:], transfer)
+		if len(tt.want) == 0 && found[want] {
+			t.Errorf("%s: selector %x found in push data of %x", tt.name, transfer, tt.code)
+		}
+	}
+}
+
+func TestStandardsOfDeployedCode(t *testing.T) {
+	raw, err := os.ReadFile(filepath.Join("testdata", "runtime_code.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var codes []runtimeCode
+	if err := json.Unmarshal(raw, &codes); err != nil {
+		t.Fatal(err)
+	}
+	if len(codes) == 0 {
+		t.Fatal("no runtime code in testdata")
+	}
+	for _, c := range codes {
+		if got := Standards(c.Code); !slices.Equal(got, c.Standards) {
+			t.Errorf("%s (%s, chain %d): standards = %v, want %v", c.Address, c.Note, c.ChainID, got, c.Standards)
+		}
+	}
+}
+
+// Байткода развёрнутых ERC-721 и ERC-1155 в testdata нет, поэтому их интерфейсы
+// проверяются на диспетчере в формате solc
+func TestStandardsOfDispatcher(t *testing.T) {
+	erc20 := []string{
+		"totalSupply()", "balanceOf(address)", "transfer(address,uint256)",
+		"transferFrom(address,address,uint256)", "approve(address,uint256)", "allowance(address,address)",
+	}
+	erc721 := []string{
+		"balanceOf(address)", "ownerOf(uint256)", "safeTransferFrom(address,address,uint256)",
+		"safeTransferFrom(address,address,uint256,bytes)", "transferFrom(address,address,uint256)",
+		"approve(address,uint256)", "getApproved(uint256)", "setApprovalForAll(address,bool)",
+		"isApprovedForAll(address,address)", "supportsInterface(bytes4)",
+	}
+	erc1155 := []string{
+		"balanceOf(address,uint256)", "balanceOfBatch(address[],uint256[])",
+		"safeTransferFrom(address,address,uint256,uint256,bytes)",
+		"safeBatchTransferFrom(address,address,uint256[],uint256[],bytes)",
+		"setApprovalForAll(address,bool)", "isApprovedForAll(address,address)", "uri(uint256)",
+		"supportsInterface(bytes4)",
+	}
+
+	tests := []struct {
+		name       string
+		signatures []string
+		want       []string
+	}{
+		{"erc20", erc20, []string{models.ContractStandardERC20}},
+		// approve и transferFrom совпадают с ERC-20, но allowance у ERC-721 нет
+		{"erc721", erc721, []string{models.ContractStandardERC721}},
+		// balanceOf(address,uint256) — 0x00fdd58e, solc кладёт его через PUSH3
+		{"erc1155", erc1155, []string{models.ContractStandardERC1155}},
+		{"erc20 without allowance", erc20[:5], nil},
+		{"erc1155 without balanceOfBatch", slices.Delete(slices.Clone(erc1155), 1, 2), nil},
+	}
+	for _, tt := range tests {
+		if got := Standards(dispatcher(tt.signatures...)); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: standards = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package contracts
+
+import (
+	"context"
+	"fmt"
+	"lib/models"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// callFrame — фрейм callTracer; для CREATE/CREATE2 To — адрес созданного контракта
+type callFrame struct {
+	Type  string      `json:"type"`
+	From  string      `json:"from"`
+	To    string      `json:"to"`
+	Error string      `json:"error"`
+	Calls []callFrame `json:"calls"`
+}
+
+// txTrace — трейс одной транзакции в ответе debug_traceBlockByNumber.
+// TxHash есть не во всех версиях клиентов, тогда транзакция определяется по порядку.
+type txTrace struct {
+	TxHash string     `json:"txHash"`
+	Result *callFrame `json:"result"`
+	Error  string     `json:"error"`
+}
+
+// fromTraces выделяет контракты из фреймов CREATE/CREATE2 трейсов блока
+func (ix *Indexer) fromTraces(ctx context.Context, block models.Block, txs []models.Tx) ([]models.Contract, error) {
+	var traces []txTrace
+	batch := []rpc.BatchElem{{
+		Method: "debug_traceBlockByNumber",
+		Args:   []any{hexutil.Uint64(block.Number), map[string]any{"tracer": "callTracer"}},
+		Result: &traces,
+	}}
+	if err := ix.caller.BatchCallContext(ctx, batch); err != nil {
+		return nil, fmt.Errorf("trace block %d: %w", block.Number, err)
+	}
+	if batch[0].Error != nil {
+		return nil, fmt.Errorf("trace block %d: %w", block.Number, batch[0].Error)
+	}
+	if len(traces) != len(txs) {
+		return nil, fmt.Errorf("trace block %d: %d traces for %d transactions", block.Number, len(traces), len(txs))
+	}
+
+	var contracts []models.Contract
+	for i, trace := range traces {
+		if trace.Error != "" {
+			return nil, fmt.Errorf("trace tx %s: %s", txs[i].Hash, trace.Error)
+		}
+		hash := trace.TxHash
+		if hash == "" {
+			hash = txs[i].Hash
+		}
+		walkCreates(trace.Result, func(frame *callFrame) {
+			contracts = append(contracts, models.Contract{
+				Address:         common.HexToAddress(frame.To).Hex(),
+				Deployer:        common.HexToAddress(frame.From).Hex(),
+				TxFrom:          common.HexToAddress(txs[i].From).Hex(),
+				CreationType:    strings.ToLower(frame.Type),
+				Source:          models.ContractSourceTrace,
+				BlockNumber:     block.Number,
+				BlockHash:       block.Hash,
+				TransactionHash: common.HexToHash(hash).Hex(),
+				BlockTimestamp:  block.Timestamp,
+			})
+		})
+	}
+	return contracts, nil
+}
+
+// walkCreates обходит успешные фреймы CREATE/CREATE2 в порядке выполнения.
+// Фрейм с ошибкой откатывает и все вложенные создания, поэтому не обходится.
+func walkCreates(frame *callFrame, visit func(*callFrame)) {
+	if frame == nil || frame.Error != "" {
+		return
+	}
+	if (frame.Type == "CREATE" || frame.Type == "CREATE2") && frame.To != "" {
+		visit(frame)
+	}
+	for i := range frame.Calls {
+		walkCreates(&frame.Calls[i], visit)
+	}
+}
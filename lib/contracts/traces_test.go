@@ -0,0 +1,124 @@
+package contracts
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"testing"
+	"time"
+
+	"lib/models"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Трейс транзакции фабрики: создаёт a1, внутри него a2; вызов b0 откатывается
+// вместе с созданными в нём b1 и b2; неудачный CREATE не оставляет контракта
+const factoryTrace = `{
+	"type": "CALL", "from": "0x00000000000000000000000000000000000000e0", "to": "0x00000000000000000000000000000000000000f0",
+	"calls": [
+		{"type": "CREATE", "from": "0x00000000000000000000000000000000000000f0", "to": "0x00000000000000000000000000000000000000a1",
+		 "calls": [
+			{"type": "CREATE2", "from": "0x00000000000000000000000000000000000000a1", "to": "0x00000000000000000000000000000000000000a2"}
+		 ]},
+		{"type": "CALL", "from": "0x00000000000000000000000000000000000000f0", "to": "0x00000000000000000000000000000000000000b0",
+		 "error": "execution reverted",
+		 "calls": [
+			{"type": "CREATE", "from": "0x00000000000000000000000000000000000000b0", "to": "0x00000000000000000000000000000000000000b1",
+			 "calls": [
+				{"type": "CREATE2", "from": "0x00000000000000000000000000000000000000b1", "to": "0x00000000000000000000000000000000000000b2"}
+			 ]}
+		 ]},
+		{"type": "CREATE", "from": "0x00000000000000000000000000000000000000f0", "error": "out of gas"},
+		{"type": "DELEGATECALL", "from": "0x00000000000000000000000000000000000000f0", "to": "0x00000000000000000000000000000000000000c0",
+		 "calls": [
+			{"type": "CREATE", "from": "0x00000000000000000000000000000000000000f0", "to": "0x00000000000000000000000000000000000000a3"}
+		 ]}
+	]
+}`
+
+func address(b byte) string {
+	return common.BytesToAddress([]byte{b}).Hex()
+}
+
+func TestWalkCreates(t *testing.T) {
+	var root callFrame
+	if err := json.Unmarshal([]byte(factoryTrace), &root); err != nil {
+		t.Fatal(err)
+	}
+
+	var created []string
+	walkCreates(&root, func(frame *callFrame) {
+		created = append(created, common.HexToAddress(frame.To).Hex())
+	})
+	want := []string{address(0xa1), address(0xa2), address(0xa3)}
+	if !slices.Equal(created, want) {
+		t.Errorf("created = %v, want %v", created, want)
+	}
+
+	// Откат всей транзакции отменяет все создания
+	root.Error = "execution reverted"
+	walkCreates(&root, func(frame *callFrame) { t.Errorf("visited %s in a reverted transaction", frame.To) })
+	walkCreates(nil, func(frame *callFrame) { t.Errorf("visited %s without a trace", frame.To) })
+}
+
+// traceCaller отвечает на debug_traceBlockByNumber
+type traceCaller struct {
+	traces json.RawMessage
+}
+
+func (c traceCaller) BatchCallContext(_ context.Context, batch []rpc.BatchElem) error {
+	for i := range batch {
+		if batch[i].Method != "debug_traceBlockByNumber" {
+			batch[i].Error = rpc.ErrNoResult
+			continue
+		}
+		if err := json.Unmarshal(c.traces, batch[i].Result); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestIndexFromTraces(t *testing.T) {
+	block := models.Block{Number: 10, Hash: common.Hash{0x0b}.Hex(), Timestamp: time.Unix(1700000000, 0).UTC()}
+	sender := address(0xe0)
+	deployed := address(0xd0)
+	txs := []models.Tx{
+		{Hash: common.Hash{0x01}.Hex(), From: sender},
+		{
+			// Прямое создание есть и в квитанции, и в трейсе
+			Hash: common.Hash{0x02}.Hex(), From: sender,
+			Receipt: &models.Receipt{
+				TransactionHash: common.Hash{0x02}.Hex(), From: sender, ContractAddress: &deployed, Status: 1,
+				BlockNumber: block.Number, BlockHash: block.Hash, BlockTimestamp: block.Timestamp,
+			},
+		},
+	}
+	// Клиент без txHash в трейсах: транзакция определяется по порядку
+	traces := `[{"result": ` + factoryTrace + `}, {"result": {"type": "CREATE", "from": "` + sender + `", "to": "` + deployed + `"}}]`
+
+	contracts, err := NewIndexer(traceCaller{traces: json.RawMessage(traces)}, Options{Traces: true}).Index(context.Background(), block, txs)
+	if err != nil {
+		t.Fatalf("index: %v", err)
+	}
+
+	want := []models.Contract{
+		{Address: address(0xa1), Deployer: address(0xf0), CreationType: models.ContractCreate, TransactionHash: txs[0].Hash},
+		{Address: address(0xa2), Deployer: address(0xa1), CreationType: models.ContractCreate2, TransactionHash: txs[0].Hash},
+		{Address: address(0xa3), Deployer: address(0xf0), CreationType: models.ContractCreate, TransactionHash: txs[0].Hash},
+		{Address: deployed, Deployer: sender, CreationType: models.ContractCreate, TransactionHash: txs[1].Hash},
+	}
+	if len(contracts) != len(want) {
+		t.Fatalf("%d contracts indexed, want %d: %+v", len(contracts), len(want), contracts)
+	}
+	for i, c := range contracts {
+		w := want[i]
+		if c.Address != w.Address || c.Deployer != w.Deployer || c.CreationType != w.CreationType ||
+			c.TransactionHash != w.TransactionHash || c.TxFrom != sender || c.Source != models.ContractSourceTrace ||
+			c.BlockNumber != block.Number || c.BlockHash != block.Hash {
+			t.Errorf("contract %d = %+v, want %+v from trace", i, c, w)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package decoding
+
+import (
+	"context"
+	"fmt"
+	"lib/models"
+	"time"
+
+	clientsDB "lib/clients/db"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Таблицы реестра в ClickHouse (см. clickhouse-service/db-schema/tables)
+const (
+	contractABIsTable = "contract_abis"
+	signaturesTable   = "signatures"
+)
+
+// ClickhouseStore хранит ABI контрактов и базу сигнатур в ClickHouse.
+// Реализует ABIStore и SignatureStore.
+type ClickhouseStore struct {
+	client clientsDB.ClickhouseClient
+}
+
+func NewClickhouseStore(client clientsDB.ClickhouseClient) *ClickhouseStore {
+	return &ClickhouseStore{client: client}
+}
+
+// LoadABI возвращает последнюю сохранённую версию ABI контракта
+func (s *ClickhouseStore) LoadABI(ctx context.Context, address common.Address) (models.ContractABI, bool, error) {
+	var rows []models.ContractABI
+	query := "SELECT address, name, abi FROM " + contractABIsTable +
+		" WHERE address = ? ORDER BY updated_at DESC LIMIT 1"
+	if err := s.client.Select(ctx, &rows, query, address.Hex()); err != nil {
+		return models.ContractABI{}, false, fmt.Errorf("select abi of %s: %w", address.Hex(), err)
+	}
+	if len(rows) == 0 {
+		return models.ContractABI{}, false, nil
+	}
+	return rows[0], true, nil
+}
+
+// SaveABI сохраняет ABI; предыдущие версии вытесняются ReplacingMergeTree
+func (s *ClickhouseStore) SaveABI(ctx context.Context, contract models.ContractABI) error {
+	batch, err := s.client.PrepareBatch(ctx, "INSERT INTO "+contractABIsTable+" VALUES")
+	if err != nil {
+		return fmt.Errorf("prepare abi insert: %w", err)
+	}
+	if err := batch.Append(contract.Address, contract.Name, contract.ABI, time.Now().UTC()); err != nil {
+		return fmt.Errorf("append abi of %s: %w", contract.Address, err)
+	}
+	if err := batch.Send(); err != nil {
+		return fmt.Errorf("insert abi of %s: %w", contract.Address, err)
+	}
+	return nil
+}
+
+// LookupSignatures возвращает сигнатуры вида kind с селектором или topic0 hash
+func (s *ClickhouseStore) LookupSignatures(ctx context.Context, kind, hash string) ([]string, error) {
+	var rows []struct {
+		Signature string `ch:"signature"`
+	}
+	query := "SELECT DISTINCT signature FROM " + signaturesTable + " WHERE kind = ? AND hash = ? ORDER BY signature"
+	if err := s.client.Select(ctx, &rows, query, kind, hash); err != nil {
+		return nil, fmt.Errorf("select signatures of %s: %w", hash, err)
+	}
+	texts := make([]string, len(rows))
+	for i, row := range rows {
+		texts[i] = row.Signature
+	}
+	return texts, nil
+}
+
+// SaveSignatures сохраняет канонические сигнатуры вида kind
+func (s *ClickhouseStore) SaveSignatures(ctx context.Context, kind string, signatures []string) error {
+	if len(signatures) == 0 {
+		return nil
+	}
+	batch, err := s.client.PrepareBatch(ctx, "INSERT INTO "+signaturesTable+" VALUES")
+	if err != nil {
+		return fmt.Errorf("prepare signatures insert: %w", err)
+	}
+	for _, text := range signatures {
+		sig, err := parseSignature(kind, text)
+		if err != nil {
+			return err
+		}
+		if err := batch.Append(kind, sig.hash, sig.text); err != nil {
+			return fmt.Errorf("append signature %s: %w", sig.text, err)
+		}
+	}
+	if err := batch.Send(); err != nil {
+		return fmt.Errorf("insert signatures: %w", err)
+	}
+	return nil
+}
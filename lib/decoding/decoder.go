@@ -0,0 +1,240 @@
+package decoding
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"lib/models"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+var (
+	// ErrUnknown — ни ABI контракта, ни сигнатуры из базы не подходят
+	ErrUnknown = errors.New("no matching abi or signature")
+	// ErrMalformed — лог или input не разбираются как hex
+	ErrMalformed = errors.New("malformed input")
+)
+
+// Decoder разбирает логи и input транзакций: сначала по ABI контракта
+// из реестра, затем по базе сигнатур (первая сигнатура, по которой данные
+// разбираются без ошибок и кодируются обратно в те же байты)
+type Decoder struct {
+	abis *Registry
+	sigs *Signatures
+}
+
+func NewDecoder(abis *Registry, sigs *Signatures) *Decoder {
+	return &Decoder{abis: abis, sigs: sigs}
+}
+
+// DecodeLog разбирает лог в именованное событие
+func (d *Decoder) DecodeLog(ctx context.Context, log models.Log) (models.DecodedEvent, error) {
+	if len(log.Topics) == 0 {
+		return models.DecodedEvent{}, fmt.Errorf("%w: anonymous log has no topic0", ErrUnknown)
+	}
+	topics := make([]common.Hash, len(log.Topics))
+	for i, t := range log.Topics {
+		raw, err := hexutil.Decode(t)
+		if err != nil || len(raw) != common.HashLength {
+			return models.DecodedEvent{}, fmt.Errorf("%w: topic %d %q", ErrMalformed, i, t)
+		}
+		topics[i] = common.BytesToHash(raw)
+	}
+	data, err := decodeHex(log.Data)
+	if err != nil {
+		return models.DecodedEvent{}, fmt.Errorf("%w: data: %v", ErrMalformed, err)
+	}
+
+	event := models.DecodedEvent{
+		Address:         log.Address,
+		Topic0:          topics[0].Hex(),
+		BlockNumber:     log.BlockNumber,
+		TransactionHash: log.TransactionHash,
+		LogIndex:        log.LogIndex,
+	}
+
+	if common.IsHexAddress(log.Address) {
+		contract, err := d.abis.lookup(ctx, common.HexToAddress(log.Address))
+		if err != nil {
+			return models.DecodedEvent{}, err
+		}
+		if contract != nil {
+			if ev, err := contract.abi.EventByID(topics[0]); err == nil {
+				args, err := decodeEventArgs(ev.Inputs, topics[1:], data)
+				if err != nil {
+					return models.DecodedEvent{}, fmt.Errorf("decode %s with abi of %s: %w", ev.Sig, log.Address, err)
+				}
+				event.Name, event.Signature, event.Args = ev.RawName, ev.Sig, args
+				event.Source = models.DecodeSourceABI
+				return event, nil
+			}
+		}
+	}
+
+	sigs, err := d.sigs.candidates(ctx, KindEvent, topics[0].Hex())
+	if err != nil {
+		return models.DecodedEvent{}, err
+	}
+	for _, sig := range sigs {
+		// Какие аргументы индексированы, сигнатура не говорит: считаем
+		// индексированными первые по числу топиков, как принято в стандартах
+		indexed := len(topics) - 1
+		if indexed > len(sig.inputs) {
+			continue
+		}
+		inputs := make(abi.Arguments, len(sig.inputs))
+		copy(inputs, sig.inputs)
+		for i := range indexed {
+			inputs[i].Indexed = true
+		}
+
+		args, err := decodeEventArgs(inputs, topics[1:], data)
+		if err != nil || !repacks(inputs.NonIndexed(), data) {
+			continue
+		}
+		event.Name, event.Signature, event.Args = sig.name, sig.text, args
+		event.Source = models.DecodeSourceSignature
+		return event, nil
+	}
+	return models.DecodedEvent{}, fmt.Errorf("%w: topic0 %s", ErrUnknown, event.Topic0)
+}
+
+// DecodeInput разбирает input транзакции в вызов функции; to — адрес
+// контракта (пустой — ABI не ищется)
+func (d *Decoder) DecodeInput(ctx context.Context, to, input string) (models.DecodedCall, error) {
+	data, err := decodeHex(input)
+	if err != nil {
+		return models.DecodedCall{}, fmt.Errorf("%w: input: %v", ErrMalformed, err)
+	}
+	if len(data) < 4 {
+		return models.DecodedCall{}, fmt.Errorf("%w: input is shorter than a selector", ErrUnknown)
+	}
+	selector, payload := data[:4], data[4:]
+
+	call := models.DecodedCall{To: to, Selector: hexutil.Encode(selector)}
+
+	if to != "" && common.IsHexAddress(to) {
+		contract, err := d.abis.lookup(ctx, common.HexToAddress(to))
+		if err != nil {
+			return models.DecodedCall{}, err
+		}
+		if contract != nil {
+			if method, err := contract.abi.MethodById(selector); err == nil {
+				args, err := decodeArgs(method.Inputs, payload)
+				if err != nil {
+					return models.DecodedCall{}, fmt.Errorf("decode %s with abi of %s: %w", method.Sig, to, err)
+				}
+				call.Name, call.Signature, call.Args = method.RawName, method.Sig, args
+				call.Source = models.DecodeSourceABI
+				return call, nil
+			}
+		}
+	}
+
+	sigs, err := d.sigs.candidates(ctx, KindFunction, call.Selector)
+	if err != nil {
+		return models.DecodedCall{}, err
+	}
+	for _, sig := range sigs {
+		args, err := decodeArgs(sig.inputs, payload)
+		if err != nil || !repacks(sig.inputs, payload) {
+			continue
+		}
+		call.Name, call.Signature, call.Args = sig.name, sig.text, args
+		call.Source = models.DecodeSourceSignature
+		return call, nil
+	}
+	return models.DecodedCall{}, fmt.Errorf("%w: selector %s", ErrUnknown, call.Selector)
+}
+
+// decodeArgs разбирает ABI-кодированные аргументы
+func decodeArgs(inputs abi.Arguments, data []byte) ([]models.DecodedArg, error) {
+	values, err := inputs.Unpack(data)
+	if err != nil {
+		return nil, err
+	}
+	args := make([]models.DecodedArg, len(inputs))
+	for i, input := range inputs {
+		args[i] = models.DecodedArg{Name: input.Name, Type: input.Type.String(), Value: jsonValue(input.Type, values[i])}
+	}
+	return args, nil
+}
+
+// decodeEventArgs разбирает аргументы события: индексированные — из топиков,
+// остальные — из data
+func decodeEventArgs(inputs abi.Arguments, topics []common.Hash, data []byte) ([]models.DecodedArg, error) {
+	indexed := 0
+	for _, input := range inputs {
+		if input.Indexed {
+			indexed++
+		}
+	}
+	if indexed != len(topics) {
+		return nil, fmt.Errorf("event has %d indexed arguments but log has %d topics", indexed, len(topics))
+	}
+
+	values, err := inputs.NonIndexed().Unpack(data)
+	if err != nil {
+		return nil, err
+	}
+
+	args := make([]models.DecodedArg, len(inputs))
+	nextTopic, nextValue := 0, 0
+	for i, input := range inputs {
+		arg := models.DecodedArg{Name: input.Name, Type: input.Type.String(), Indexed: input.Indexed}
+		if input.Indexed {
+			value, err := topicValue(input, topics[nextTopic])
+			if err != nil {
+				return nil, fmt.Errorf("topic %d: %w", nextTopic+1, err)
+			}
+			arg.Value = value
+			nextTopic++
+		} else {
+			arg.Value = jsonValue(input.Type, values[nextValue])
+			nextValue++
+		}
+		args[i] = arg
+	}
+	return args, nil
+}
+
+// topicValue разбирает индексированный аргумент; значения динамических типов
+// в топике заменены их keccak256, поэтому возвращается хеш
+func topicValue(input abi.Argument, topic common.Hash) (any, error) {
+	switch input.Type.T {
+	case abi.StringTy, abi.BytesTy, abi.SliceTy, abi.ArrayTy, abi.TupleTy:
+		return topic.Hex(), nil
+	}
+	input.Indexed = false
+	values, err := abi.Arguments{input}.Unpack(topic.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	return jsonValue(input.Type, values[0]), nil
+}
+
+// repacks проверяет, что значения кодируются обратно в те же байты:
+// отсекает сигнатуры с совпавшим селектором, но другими типами
+func repacks(inputs abi.Arguments, data []byte) bool {
+	values, err := inputs.Unpack(data)
+	if err != nil {
+		return false
+	}
+	packed, err := inputs.Pack(values...)
+	return err == nil && bytes.Equal(packed, data)
+}
+
+func decodeHex(s string) ([]byte, error) {
+	if s == "" || s == "0x" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(s, "0x") {
+		s = "0x" + s
+	}
+	return hexutil.Decode(s)
+}
@@ -0,0 +1,265 @@
+package decoding
+
+import (
+	"context"
+	"errors"
+	"lib/models"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+var (
+	token   = common.HexToAddress("0x00000000000000000000000000000000000000a0")
+	holder  = common.HexToAddress("0x00000000000000000000000000000000000000b1")
+	spender = common.HexToAddress("0x00000000000000000000000000000000000000b2")
+
+	transferTopic = crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+)
+
+// Фрагмент ABI токена: у Swap индексирован не первый аргумент, поэтому без ABI
+// событие разбирается неверно
+const tokenABI = `[
+	{"type":"event","name":"Transfer","anonymous":false,"inputs":[
+		{"name":"from","type":"address","indexed":true},
+		{"name":"to","type":"address","indexed":true},
+		{"name":"value","type":"uint256","indexed":false}]},
+	{"type":"event","name":"Swap","anonymous":false,"inputs":[
+		{"name":"amountIn","type":"uint256","indexed":false},
+		{"name":"sender","type":"address","indexed":true}]},
+	{"type":"function","name":"transfer","stateMutability":"nonpayable","inputs":[
+		{"name":"to","type":"address"},
+		{"name":"amount","type":"uint256"}],"outputs":[{"name":"","type":"bool"}]}
+]`
+
+// memStore — хранилище ABI в памяти
+type memStore struct {
+	abis  map[common.Address]models.ContractABI
+	loads int
+}
+
+func (s *memStore) LoadABI(_ context.Context, address common.Address) (models.ContractABI, bool, error) {
+	s.loads++
+	contract, ok := s.abis[address]
+	return contract, ok, nil
+}
+
+func (s *memStore) SaveABI(_ context.Context, contract models.ContractABI) error {
+	s.abis[common.HexToAddress(contract.Address)] = contract
+	return nil
+}
+
+func word(v int64) []byte {
+	return common.BigToHash(big.NewInt(v)).Bytes()
+}
+
+func addressTopic(a common.Address) string {
+	return common.BytesToHash(a.Bytes()).Hex()
+}
+
+func newLog(topics []string, data []byte) models.Log {
+	return models.Log{Address: token.Hex(), Topics: topics, Data: hexutil.Encode(data), LogIndex: 7}
+}
+
+func arg(name, typ string, value any, indexed bool) models.DecodedArg {
+	return models.DecodedArg{Name: name, Type: typ, Value: value, Indexed: indexed}
+}
+
+func newDecoder(t *testing.T, withABI bool) *Decoder {
+	t.Helper()
+	abis := NewRegistry(nil)
+	if withABI {
+		if _, err := abis.Add(token.Hex(), "Token", []byte(tokenABI)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return NewDecoder(abis, NewSignatures(nil))
+}
+
+func TestDecodeLog(t *testing.T) {
+	swapTopic := crypto.Keccak256Hash([]byte("Swap(uint256,address)")).Hex()
+	tokenID := common.BigToHash(big.NewInt(42)).Hex()
+	tests := []struct {
+		name    string
+		withABI bool
+		log     models.Log
+		source  string
+		sig     string
+		args    []models.DecodedArg
+		err     error
+	}{
+		{
+			// По сигнатуре индексированы первые аргументы по числу топиков
+			name:   "erc20 transfer by signature",
+			log:    newLog([]string{transferTopic.Hex(), addressTopic(holder), addressTopic(spender)}, word(1000)),
+			source: models.DecodeSourceSignature, sig: "Transfer(address,address,uint256)",
+			args: []models.DecodedArg{
+				arg("arg0", "address", holder.Hex(), true),
+				arg("arg1", "address", spender.Hex(), true),
+				arg("arg2", "uint256", "1000", false),
+			},
+		},
+		{
+			// Тот же topic0, но tokenId в топике: индексированы все три аргумента
+			name:   "erc721 transfer by signature",
+			log:    newLog([]string{transferTopic.Hex(), addressTopic(holder), addressTopic(spender), tokenID}, nil),
+			source: models.DecodeSourceSignature, sig: "Transfer(address,address,uint256)",
+			args: []models.DecodedArg{
+				arg("arg0", "address", holder.Hex(), true),
+				arg("arg1", "address", spender.Hex(), true),
+				arg("arg2", "uint256", "42", true),
+			},
+		},
+		{
+			// Три топика и непустые data не подходят ни под одно разбиение
+			name: "erc721 topics with leftover data",
+			log:  newLog([]string{transferTopic.Hex(), addressTopic(holder), addressTopic(spender), tokenID}, word(1)),
+			err:  ErrUnknown,
+		},
+		{
+			name: "erc20 transfer by abi", withABI: true,
+			log:    newLog([]string{transferTopic.Hex(), addressTopic(holder), addressTopic(spender)}, word(1000)),
+			source: models.DecodeSourceABI, sig: "Transfer(address,address,uint256)",
+			args: []models.DecodedArg{
+				arg("from", "address", holder.Hex(), true),
+				arg("to", "address", spender.Hex(), true),
+				arg("value", "uint256", "1000", false),
+			},
+		},
+		{
+			name: "abi places the indexed argument", withABI: true,
+			log:    newLog([]string{swapTopic, addressTopic(holder)}, word(5)),
+			source: models.DecodeSourceABI, sig: "Swap(uint256,address)",
+			args: []models.DecodedArg{
+				arg("amountIn", "uint256", "5", false),
+				arg("sender", "address", holder.Hex(), true),
+			},
+		},
+		{name: "unknown event", log: newLog([]string{swapTopic, addressTopic(holder)}, word(5)), err: ErrUnknown},
+		{name: "anonymous log", log: newLog(nil, word(5)), err: ErrUnknown},
+		{name: "short topic", log: newLog([]string{"0x1234"}, nil), err: ErrMalformed},
+		{name: "bad data", log: models.Log{Address: token.Hex(), Topics: []string{transferTopic.Hex()}, Data: "0xzz"}, err: ErrMalformed},
+	}
+	for _, tt := range tests {
+		event, err := newDecoder(t, tt.withABI).DecodeLog(context.Background(), tt.log)
+		if tt.err != nil {
+			if !errors.Is(err, tt.err) {
+				t.Errorf("%s: DecodeLog error = %v, want %v", tt.name, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: DecodeLog: %v", tt.name, err)
+		}
+		if event.Source != tt.source || event.Signature != tt.sig || event.LogIndex != 7 || event.Address != token.Hex() {
+			t.Errorf("%s: event = %+v, want %s from %s", tt.name, event, tt.sig, tt.source)
+		}
+		if !reflect.DeepEqual(event.Args, tt.args) {
+			t.Errorf("%s: args = %+v, want %+v", tt.name, event.Args, tt.args)
+		}
+	}
+}
+
+func TestDecodeInput(t *testing.T) {
+	selector := crypto.Keccak256([]byte("transfer(address,uint256)"))[:4]
+	input := append(append(append([]byte{}, selector...), common.LeftPadBytes(spender.Bytes(), 32)...), word(1000)...)
+	bySignature := []models.DecodedArg{arg("arg0", "address", spender.Hex(), false), arg("arg1", "uint256", "1000", false)}
+
+	tests := []struct {
+		name    string
+		withABI bool
+		to      string
+		input   string
+		source  string
+		args    []models.DecodedArg
+		err     error
+	}{
+		{name: "by signature", to: token.Hex(), input: hexutil.Encode(input), source: models.DecodeSourceSignature, args: bySignature},
+		{
+			name: "by abi", withABI: true, to: token.Hex(), input: hexutil.Encode(input),
+			source: models.DecodeSourceABI,
+			args:   []models.DecodedArg{arg("to", "address", spender.Hex(), false), arg("amount", "uint256", "1000", false)},
+		},
+		// Без адреса получателя ABI не ищется
+		{name: "no recipient ignores abi", withABI: true, input: hexutil.Encode(input), source: models.DecodeSourceSignature, args: bySignature},
+		// Лишнее слово не кодируется обратно теми же байтами
+		{name: "trailing data rejects signature", to: token.Hex(), input: hexutil.Encode(append(input, word(1)...)), err: ErrUnknown},
+		{name: "unknown selector", to: token.Hex(), input: "0xdeadbeef", err: ErrUnknown},
+		{name: "shorter than selector", to: token.Hex(), input: "0xdead", err: ErrUnknown},
+		{name: "not hex", to: token.Hex(), input: "0xzz", err: ErrMalformed},
+	}
+	for _, tt := range tests {
+		call, err := newDecoder(t, tt.withABI).DecodeInput(context.Background(), tt.to, tt.input)
+		if tt.err != nil {
+			if !errors.Is(err, tt.err) {
+				t.Errorf("%s: DecodeInput error = %v, want %v", tt.name, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: DecodeInput: %v", tt.name, err)
+		}
+		if call.Source != tt.source || call.Name != "transfer" || call.Selector != hexutil.Encode(selector) {
+			t.Errorf("%s: call = %+v, want transfer from %s", tt.name, call, tt.source)
+		}
+		if !reflect.DeepEqual(call.Args, tt.args) {
+			t.Errorf("%s: args = %+v, want %+v", tt.name, call.Args, tt.args)
+		}
+	}
+}
+
+func TestRegistryRegister(t *testing.T) {
+	ctx := context.Background()
+	store := &memStore{abis: make(map[common.Address]models.ContractABI)}
+
+	// Артефакт сборки: имя берётся из contractName
+	artifact := []byte(`{"contractName":"Token","abi":` + tokenABI + `}`)
+	info, err := NewRegistry(store).Register(ctx, "0x00000000000000000000000000000000000000A0", "", artifact)
+	if err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	if info.Address != token.Hex() || info.Name != "Token" {
+		t.Errorf("registered %+v, want checksummed address and artifact name", info)
+	}
+	if stored := store.abis[token]; stored != info {
+		t.Errorf("stored %+v, want %+v", stored, info)
+	}
+
+	// Другой экземпляр находит ABI в хранилище и запоминает его
+	abis := NewRegistry(store)
+	d := NewDecoder(abis, NewSignatures(nil))
+	log := newLog([]string{transferTopic.Hex(), addressTopic(holder), addressTopic(spender)}, word(1))
+	for range 2 {
+		event, err := d.DecodeLog(ctx, log)
+		if err != nil || event.Source != models.DecodeSourceABI {
+			t.Fatalf("decode with stored abi = %+v, %v", event, err)
+		}
+	}
+	if store.loads != 1 {
+		t.Errorf("%d store loads, want 1", store.loads)
+	}
+
+	// Отсутствие ABI тоже запоминается
+	other := common.HexToAddress("0x00000000000000000000000000000000000000c3")
+	for range 2 {
+		if _, err := abis.Get(ctx, other.Hex()); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("get unknown abi = %v, want ErrNotFound", err)
+		}
+	}
+	if store.loads != 2 {
+		t.Errorf("%d store loads, want 2", store.loads)
+	}
+
+	for _, raw := range []string{`{"abi":[]`, `{"contractName":"X"}`, `not json`} {
+		if _, err := abis.Register(ctx, token.Hex(), "", []byte(raw)); !errors.Is(err, ErrInvalidABI) {
+			t.Errorf("register %s = %v, want ErrInvalidABI", raw, err)
+		}
+	}
+	if _, err := abis.Register(ctx, "token", "", []byte(tokenABI)); !errors.Is(err, ErrInvalidABI) {
+		t.Errorf("register with invalid address = %v, want ErrInvalidABI", err)
+	}
+}
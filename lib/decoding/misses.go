@@ -0,0 +1,32 @@
+package decoding
+
+import "time"
+
+// missCache запоминает ключи, которых нет в хранилище, на missTTL. Ключи
+// приходят из логов и запросов к API, поэтому размер ограничен maxMisses:
+// при переполнении сначала удаляются устаревшие записи, затем любые.
+// Синхронизацию обеспечивает владелец кеша.
+type missCache[K comparable] map[K]time.Time
+
+// fresh сообщает, что ключ недавно не нашёлся в хранилище
+func (m missCache[K]) fresh(key K, now time.Time) bool {
+	missed, ok := m[key]
+	return ok && now.Sub(missed) < missTTL
+}
+
+func (m missCache[K]) add(key K, now time.Time) {
+	if _, ok := m[key]; !ok && len(m) >= maxMisses {
+		for k, missed := range m {
+			if now.Sub(missed) >= missTTL {
+				delete(m, k)
+			}
+		}
+		for k := range m {
+			if len(m) < maxMisses {
+				break
+			}
+			delete(m, k)
+		}
+	}
+	m[key] = now
+}
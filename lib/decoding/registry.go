@@ -0,0 +1,207 @@
+package decoding
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"lib/models"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Время, на которое запоминается отсутствие ABI или сигнатуры в хранилище,
+// чтобы не ходить в базу за каждым логом неизвестного контракта, и предельное
+// число таких записей
+const (
+	missTTL   = time.Minute
+	maxMisses = 100_000
+)
+
+var (
+	// ErrInvalidABI — ABI не удаётся разобрать
+	ErrInvalidABI = errors.New("invalid abi")
+	// ErrNotFound — ABI контракта нет в реестре
+	ErrNotFound = errors.New("abi not found")
+)
+
+// ABIStore — хранилище ABI контрактов, общее для экземпляров сервисов
+type ABIStore interface {
+	// LoadABI возвращает ABI контракта; ok == false — ABI не сохранён
+	LoadABI(ctx context.Context, address common.Address) (contract models.ContractABI, ok bool, err error)
+	SaveABI(ctx context.Context, contract models.ContractABI) error
+}
+
+// contractABI — разобранный ABI контракта
+type contractABI struct {
+	info models.ContractABI
+	abi  abi.ABI
+}
+
+// Registry — реестр ABI по адресам контрактов. ABI загружаются из файлов
+// (LoadDir) и из хранилища; найденные в хранилище ABI кешируются в памяти.
+type Registry struct {
+	store ABIStore // nil — только файлы и Add
+
+	mu     sync.RWMutex
+	abis   map[common.Address]*contractABI
+	misses missCache[common.Address]
+}
+
+func NewRegistry(store ABIStore) *Registry {
+	return &Registry{
+		store:  store,
+		abis:   make(map[common.Address]*contractABI),
+		misses: make(missCache[common.Address]),
+	}
+}
+
+// LoadDir загружает ABI из файлов <адрес>.json каталога dir. Файл — JSON-массив
+// ABI или артефакт сборки (Hardhat, Foundry) с полем abi.
+func (r *Registry) LoadDir(dir string) (int, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return 0, err
+	}
+	for _, path := range files {
+		address := strings.TrimSuffix(filepath.Base(path), ".json")
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			return 0, err
+		}
+		if _, err := r.Add(address, "", raw); err != nil {
+			return 0, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	return len(files), nil
+}
+
+// Add добавляет ABI в память реестра, не сохраняя его в хранилище
+func (r *Registry) Add(address, name string, raw []byte) (models.ContractABI, error) {
+	contract, err := newContractABI(address, name, raw)
+	if err != nil {
+		return models.ContractABI{}, err
+	}
+	r.put(contract)
+	return contract.info, nil
+}
+
+// Register проверяет ABI, сохраняет его в хранилище и добавляет в реестр
+func (r *Registry) Register(ctx context.Context, address, name string, raw []byte) (models.ContractABI, error) {
+	contract, err := newContractABI(address, name, raw)
+	if err != nil {
+		return models.ContractABI{}, err
+	}
+	if r.store != nil {
+		if err := r.store.SaveABI(ctx, contract.info); err != nil {
+			return models.ContractABI{}, err
+		}
+	}
+	r.put(contract)
+	return contract.info, nil
+}
+
+// Get возвращает ABI контракта
+func (r *Registry) Get(ctx context.Context, address string) (models.ContractABI, error) {
+	if !common.IsHexAddress(address) {
+		return models.ContractABI{}, fmt.Errorf("%w: invalid address %q", ErrInvalidABI, address)
+	}
+	contract, err := r.lookup(ctx, common.HexToAddress(address))
+	if err != nil {
+		return models.ContractABI{}, err
+	}
+	if contract == nil {
+		return models.ContractABI{}, fmt.Errorf("%s: %w", address, ErrNotFound)
+	}
+	return contract.info, nil
+}
+
+// lookup ищет ABI в памяти, затем в хранилище; nil — ABI неизвестен
+func (r *Registry) lookup(ctx context.Context, address common.Address) (*contractABI, error) {
+	r.mu.RLock()
+	contract, ok := r.abis[address]
+	missed := r.misses.fresh(address, time.Now())
+	r.mu.RUnlock()
+	if ok {
+		return contract, nil
+	}
+	if r.store == nil || missed {
+		return nil, nil
+	}
+
+	info, ok, err := r.store.LoadABI(ctx, address)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		r.mu.Lock()
+		r.misses.add(address, time.Now())
+		r.mu.Unlock()
+		return nil, nil
+	}
+
+	contract, err = newContractABI(info.Address, info.Name, []byte(info.ABI))
+	if err != nil {
+		return nil, fmt.Errorf("stored abi of %s: %w", address.Hex(), err)
+	}
+	r.put(contract)
+	return contract, nil
+}
+
+func (r *Registry) put(contract *contractABI) {
+	address := common.HexToAddress(contract.info.Address)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.abis[address] = contract
+	delete(r.misses, address)
+}
+
+// newContractABI разбирает ABI и приводит его к компактному JSON-массиву
+func newContractABI(address, name string, raw []byte) (*contractABI, error) {
+	if !common.IsHexAddress(address) {
+		return nil, fmt.Errorf("%w: invalid address %q", ErrInvalidABI, address)
+	}
+
+	raw = bytes.TrimSpace(raw)
+	if len(raw) > 0 && raw[0] == '{' {
+		var artifact struct {
+			ContractName string          `json:"contractName"`
+			ABI          json.RawMessage `json:"abi"`
+		}
+		if err := json.Unmarshal(raw, &artifact); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidABI, err)
+		}
+		if len(artifact.ABI) == 0 {
+			return nil, fmt.Errorf("%w: artifact has no abi field", ErrInvalidABI)
+		}
+		if name == "" {
+			name = artifact.ContractName
+		}
+		raw = artifact.ABI
+	}
+
+	parsed, err := abi.JSON(bytes.NewReader(raw))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidABI, err)
+	}
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, raw); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidABI, err)
+	}
+
+	return &contractABI{
+		info: models.ContractABI{
+			Address: common.HexToAddress(address).Hex(),
+			Name:    name,
+			ABI:     compact.String(),
+		},
+		abi: parsed,
+	}, nil
+}
@@ -0,0 +1,257 @@
+package decoding
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Виды сигнатур: функция (4-байтовый селектор) и событие (topic0)
+const (
+	KindFunction = "function"
+	KindEvent    = "event"
+)
+
+// ErrInvalidSignature — текстовую сигнатуру не удаётся разобрать
+var ErrInvalidSignature = errors.New("invalid signature")
+
+// Сигнатуры распространённых стандартов, известные без внешней базы
+var builtinSignatures = map[string][]string{
+	KindFunction: {
+		"transfer(address,uint256)",
+		"approve(address,uint256)",
+		"transferFrom(address,address,uint256)",
+		"safeTransferFrom(address,address,uint256)",
+		"safeTransferFrom(address,address,uint256,bytes)",
+		"safeTransferFrom(address,address,uint256,uint256,bytes)",
+		"safeBatchTransferFrom(address,address,uint256[],uint256[],bytes)",
+		"setApprovalForAll(address,bool)",
+		"deposit()",
+		"withdraw(uint256)",
+		"multicall(bytes[])",
+	},
+	KindEvent: {
+		"Transfer(address,address,uint256)",
+		"Approval(address,address,uint256)",
+		"ApprovalForAll(address,address,bool)",
+		"TransferSingle(address,address,address,uint256,uint256)",
+		"TransferBatch(address,address,address,uint256[],uint256[])",
+		"Deposit(address,uint256)",
+		"Withdrawal(address,uint256)",
+		"OwnershipTransferred(address,address)",
+		"Upgraded(address)",
+	},
+}
+
+// SignatureStore — внешняя база текстовых сигнатур по селектору или topic0
+type SignatureStore interface {
+	LookupSignatures(ctx context.Context, kind, hash string) ([]string, error)
+	SaveSignatures(ctx context.Context, kind string, signatures []string) error
+}
+
+// signature — разобранная текстовая сигнатура; имена аргументов неизвестны
+type signature struct {
+	text   string
+	name   string
+	hash   string
+	inputs abi.Arguments
+}
+
+// parseSignature разбирает сигнатуру вида name(type,...) и приводит её
+// к каноническому виду, по которому считается селектор или topic0
+func parseSignature(kind, text string) (signature, error) {
+	sel, err := abi.ParseSelector(strings.ReplaceAll(strings.TrimSpace(text), " ", ""))
+	if err != nil {
+		return signature{}, fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+	}
+
+	inputs := make(abi.Arguments, len(sel.Inputs))
+	types := make([]string, len(sel.Inputs))
+	for i, arg := range sel.Inputs {
+		typ, err := abi.NewType(arg.Type, "", arg.Components)
+		if err != nil {
+			return signature{}, fmt.Errorf("%w: %s: %v", ErrInvalidSignature, text, err)
+		}
+		inputs[i] = abi.Argument{Name: fmt.Sprintf("arg%d", i), Type: typ}
+		types[i] = typ.String()
+	}
+
+	canonical := sel.Name + "(" + strings.Join(types, ",") + ")"
+	hash := crypto.Keccak256([]byte(canonical))
+	switch kind {
+	case KindFunction:
+		hash = hash[:4]
+	case KindEvent:
+	default:
+		return signature{}, fmt.Errorf("%w: unknown kind %q", ErrInvalidSignature, kind)
+	}
+
+	return signature{text: canonical, name: sel.Name, hash: hexutil.Encode(hash), inputs: inputs}, nil
+}
+
+// Signatures — база сигнатур функций и событий для контрактов без ABI.
+// Встроенные сигнатуры и файлы (LoadFile) хранятся в памяти, остальные
+// ищутся во внешней базе и кешируются.
+type Signatures struct {
+	store SignatureStore // nil — только память
+
+	mu     sync.RWMutex
+	known  map[string][]signature // kind:hash
+	misses missCache[string]
+}
+
+func NewSignatures(store SignatureStore) *Signatures {
+	s := &Signatures{
+		store:  store,
+		known:  make(map[string][]signature),
+		misses: make(missCache[string]),
+	}
+	for kind, texts := range builtinSignatures {
+		for _, text := range texts {
+			if err := s.Add(kind, text); err != nil {
+				panic(err)
+			}
+		}
+	}
+	return s
+}
+
+func signatureKey(kind, hash string) string {
+	return kind + ":" + strings.ToLower(hash)
+}
+
+// Add добавляет сигнатуру в память
+func (s *Signatures) Add(kind, text string) error {
+	sig, err := parseSignature(kind, text)
+	if err != nil {
+		return err
+	}
+	s.put(kind, sig)
+	return nil
+}
+
+func (s *Signatures) put(kind string, sig signature) {
+	key := signatureKey(kind, sig.hash)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.misses, key)
+	if slices.ContainsFunc(s.known[key], func(known signature) bool { return known.text == sig.text }) {
+		return
+	}
+	s.known[key] = append(s.known[key], sig)
+}
+
+// LoadFile загружает сигнатуры из текстового файла: по одной на строку,
+// с префиксом "function " или "event "; строка без префикса добавляется
+// в оба вида. Пустые строки и строки с # пропускаются.
+func (s *Signatures) LoadFile(path string) (int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	count := 0
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		kinds := []string{KindFunction, KindEvent}
+		if kind, rest, ok := strings.Cut(text, " "); ok && (kind == KindFunction || kind == KindEvent) {
+			kinds, text = []string{kind}, rest
+		}
+		for _, kind := range kinds {
+			if err := s.Add(kind, text); err != nil {
+				return count, fmt.Errorf("%s:%d: %w", path, line, err)
+			}
+		}
+		count++
+	}
+	return count, scanner.Err()
+}
+
+// Register проверяет сигнатуры, сохраняет их во внешней базе в каноническом
+// виде и добавляет в память
+func (s *Signatures) Register(ctx context.Context, kind string, texts []string) ([]string, error) {
+	sigs := make([]signature, len(texts))
+	canonical := make([]string, len(texts))
+	for i, text := range texts {
+		sig, err := parseSignature(kind, text)
+		if err != nil {
+			return nil, err
+		}
+		sigs[i], canonical[i] = sig, sig.text
+	}
+
+	if s.store != nil {
+		if err := s.store.SaveSignatures(ctx, kind, canonical); err != nil {
+			return nil, err
+		}
+	}
+	for _, sig := range sigs {
+		s.put(kind, sig)
+	}
+	return canonical, nil
+}
+
+// Lookup возвращает текстовые сигнатуры с селектором или topic0 hash
+func (s *Signatures) Lookup(ctx context.Context, kind, hash string) ([]string, error) {
+	sigs, err := s.candidates(ctx, kind, hash)
+	if err != nil {
+		return nil, err
+	}
+	texts := make([]string, len(sigs))
+	for i, sig := range sigs {
+		texts[i] = sig.text
+	}
+	return texts, nil
+}
+
+// candidates ищет сигнатуры в памяти, затем во внешней базе
+func (s *Signatures) candidates(ctx context.Context, kind, hash string) ([]signature, error) {
+	key := signatureKey(kind, hash)
+	s.mu.RLock()
+	sigs, ok := s.known[key]
+	missed := s.misses.fresh(key, time.Now())
+	s.mu.RUnlock()
+	if ok {
+		return sigs, nil
+	}
+	if s.store == nil || missed {
+		return nil, nil
+	}
+
+	texts, err := s.store.LookupSignatures(ctx, kind, strings.ToLower(hash))
+	if err != nil {
+		return nil, err
+	}
+	for _, text := range texts {
+		sig, err := parseSignature(kind, text)
+		// Записи с другим хешем или неразборчивым текстом в базе пропускаем
+		if err != nil || sig.hash != strings.ToLower(hash) {
+			continue
+		}
+		s.put(kind, sig)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sigs, ok = s.known[key]
+	if !ok {
+		s.misses.add(key, time.Now())
+	}
+	return sigs, nil
+}
@@ -0,0 +1,61 @@
+package decoding
+
+import (
+	"math/big"
+	"reflect"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// jsonValue приводит значение, разобранное go-ethereum, к JSON-safe виду:
+// целые — десятичные строки, адреса и байты — hex, массивы — списки,
+// кортежи — объекты по именам полей ABI
+func jsonValue(typ abi.Type, value any) any {
+	switch typ.T {
+	case abi.IntTy, abi.UintTy:
+		if v, ok := value.(*big.Int); ok {
+			return v.String()
+		}
+		return intValue(reflect.ValueOf(value)).String()
+	case abi.AddressTy:
+		return value.(common.Address).Hex()
+	case abi.BoolTy, abi.StringTy:
+		return value
+	case abi.BytesTy:
+		return hexutil.Encode(value.([]byte))
+	case abi.FixedBytesTy, abi.FunctionTy, abi.HashTy, abi.FixedPointTy:
+		rv := reflect.ValueOf(value)
+		out := make([]byte, rv.Len())
+		reflect.Copy(reflect.ValueOf(out), rv)
+		return hexutil.Encode(out)
+	case abi.SliceTy, abi.ArrayTy:
+		rv := reflect.ValueOf(value)
+		list := make([]any, rv.Len())
+		for i := range list {
+			list[i] = jsonValue(*typ.Elem, rv.Index(i).Interface())
+		}
+		return list
+	case abi.TupleTy:
+		rv := reflect.ValueOf(value)
+		fields := make(map[string]any, len(typ.TupleElems))
+		for i, elem := range typ.TupleElems {
+			fields[typ.TupleRawNames[i]] = jsonValue(*elem, rv.Field(i).Interface())
+		}
+		return fields
+	default:
+		return value
+	}
+}
+
+// intValue приводит целые Go-типы (uint8 … int64), которыми go-ethereum
+// представляет небольшие целые ABI, к big.Int
+func intValue(rv reflect.Value) *big.Int {
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return big.NewInt(rv.Int())
+	default:
+		return new(big.Int).SetUint64(rv.Uint())
+	}
+}
@@ -0,0 +1,151 @@
+package dex
+
+import (
+	"fmt"
+	"lib/models"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// События пары Uniswap V2 (и форков: SushiSwap, PancakeSwap и др.)
+const pairV2ABI = `[
+{"type":"event","name":"Swap","inputs":[
+	{"name":"sender","type":"address","indexed":true},
+	{"name":"amount0In","type":"uint256"},{"name":"amount1In","type":"uint256"},
+	{"name":"amount0Out","type":"uint256"},{"name":"amount1Out","type":"uint256"},
+	{"name":"to","type":"address","indexed":true}]},
+{"type":"event","name":"Mint","inputs":[
+	{"name":"sender","type":"address","indexed":true},
+	{"name":"amount0","type":"uint256"},{"name":"amount1","type":"uint256"}]},
+{"type":"event","name":"Burn","inputs":[
+	{"name":"sender","type":"address","indexed":true},
+	{"name":"amount0","type":"uint256"},{"name":"amount1","type":"uint256"},
+	{"name":"to","type":"address","indexed":true}]},
+{"type":"event","name":"Sync","inputs":[
+	{"name":"reserve0","type":"uint112"},{"name":"reserve1","type":"uint112"}]}
+]`
+
+// События пула Uniswap V3 (и форков с тем же интерфейсом)
+const poolV3ABI = `[
+{"type":"event","name":"Swap","inputs":[
+	{"name":"sender","type":"address","indexed":true},
+	{"name":"recipient","type":"address","indexed":true},
+	{"name":"amount0","type":"int256"},{"name":"amount1","type":"int256"},
+	{"name":"sqrtPriceX96","type":"uint160"},{"name":"liquidity","type":"uint128"},
+	{"name":"tick","type":"int24"}]},
+{"type":"event","name":"Mint","inputs":[
+	{"name":"sender","type":"address"},
+	{"name":"owner","type":"address","indexed":true},
+	{"name":"tickLower","type":"int24","indexed":true},
+	{"name":"tickUpper","type":"int24","indexed":true},
+	{"name":"amount","type":"uint128"},
+	{"name":"amount0","type":"uint256"},{"name":"amount1","type":"uint256"}]},
+{"type":"event","name":"Burn","inputs":[
+	{"name":"owner","type":"address","indexed":true},
+	{"name":"tickLower","type":"int24","indexed":true},
+	{"name":"tickUpper","type":"int24","indexed":true},
+	{"name":"amount","type":"uint128"},
+	{"name":"amount0","type":"uint256"},{"name":"amount1","type":"uint256"}]}
+]`
+
+// poolEvent — событие пула, которое умеет разбирать пакет
+type poolEvent struct {
+	protocol string
+	kind     string
+	event    abi.Event
+}
+
+// events — известные события по topic0
+var events = func() map[common.Hash]poolEvent {
+	known := make(map[common.Hash]poolEvent)
+	for protocol, raw := range map[string]string{
+		models.DexProtocolUniswapV2: pairV2ABI,
+		models.DexProtocolUniswapV3: poolV3ABI,
+	} {
+		parsed, err := abi.JSON(strings.NewReader(raw))
+		if err != nil {
+			panic(fmt.Sprintf("dex: %s abi: %v", protocol, err))
+		}
+		for _, ev := range parsed.Events {
+			known[ev.ID] = poolEvent{protocol: protocol, kind: strings.ToLower(ev.Name), event: ev}
+		}
+	}
+	return known
+}()
+
+// Topics возвращает topic0 всех событий, которые разбирает пакет, —
+// например, для фильтра eth_getLogs
+func Topics() []common.Hash {
+	topics := make([]common.Hash, 0, len(events))
+	for topic := range events {
+		topics = append(topics, topic)
+	}
+	return topics
+}
+
+// unpack разбирает аргументы события из топиков и data
+func (e poolEvent) unpack(topics []common.Hash, data []byte) (map[string]any, error) {
+	values := make(map[string]any)
+	if err := e.event.Inputs.UnpackIntoMap(values, data); err != nil {
+		return nil, err
+	}
+	var indexed abi.Arguments
+	for _, input := range e.event.Inputs {
+		if input.Indexed {
+			indexed = append(indexed, input)
+		}
+	}
+	if err := abi.ParseTopicsIntoMap(values, indexed, topics); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// trade заполняет суммы и участников события в trade
+func (e poolEvent) trade(trade *models.DexTrade, values map[string]any) {
+	amount := func(name string) *big.Int { return values[name].(*big.Int) }
+	address := func(name string) string { return values[name].(common.Address).Hex() }
+	neg := func(v *big.Int) *big.Int { return new(big.Int).Neg(v) }
+
+	var amount0, amount1 *big.Int
+	switch e.protocol + "/" + e.kind {
+	case models.DexProtocolUniswapV2 + "/" + models.DexEventSwap:
+		amount0 = new(big.Int).Sub(amount("amount0In"), amount("amount0Out"))
+		amount1 = new(big.Int).Sub(amount("amount1In"), amount("amount1Out"))
+		trade.Sender, trade.Recipient = address("sender"), address("to")
+	case models.DexProtocolUniswapV2 + "/" + models.DexEventMint:
+		amount0, amount1 = amount("amount0"), amount("amount1")
+		trade.Sender = address("sender")
+	case models.DexProtocolUniswapV2 + "/" + models.DexEventBurn:
+		amount0, amount1 = neg(amount("amount0")), neg(amount("amount1"))
+		trade.Sender, trade.Recipient = address("sender"), address("to")
+	case models.DexProtocolUniswapV2 + "/" + models.DexEventSync:
+		amount0, amount1 = amount("reserve0"), amount("reserve1")
+	case models.DexProtocolUniswapV3 + "/" + models.DexEventSwap:
+		amount0, amount1 = amount("amount0"), amount("amount1")
+		trade.Sender, trade.Recipient = address("sender"), address("recipient")
+	case models.DexProtocolUniswapV3 + "/" + models.DexEventMint:
+		amount0, amount1 = amount("amount0"), amount("amount1")
+		trade.Sender, trade.Recipient = address("sender"), address("owner")
+	case models.DexProtocolUniswapV3 + "/" + models.DexEventBurn:
+		// Токены остаются в пуле до Collect, но принадлежат уже владельцу позиции
+		amount0, amount1 = neg(amount("amount0")), neg(amount("amount1"))
+		trade.Sender = address("owner")
+	}
+	trade.Amount0, trade.Amount1 = amount0.String(), amount1.String()
+
+	if e.kind != models.DexEventSwap {
+		return
+	}
+	// В swap одна сумма входит в пул, другая выходит
+	if amount0.Sign() > 0 {
+		trade.TokenIn, trade.AmountIn = trade.Token0, amount0.String()
+		trade.TokenOut, trade.AmountOut = trade.Token1, neg(amount1).String()
+	} else {
+		trade.TokenIn, trade.AmountIn = trade.Token1, amount1.String()
+		trade.TokenOut, trade.AmountOut = trade.Token0, neg(amount0).String()
+	}
+}
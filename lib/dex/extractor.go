@@ -0,0 +1,107 @@
+package dex
+
+import (
+	"context"
+	"fmt"
+	"lib/models"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// Extractor выделяет из логов события пулов Uniswap V2/V3 (Swap, Mint, Burn, Sync)
+// и приводит их к models.DexTrade. Логи с тем же topic0 от контрактов,
+// которые не являются пулами разрешённых фабрик, пропускаются.
+type Extractor struct {
+	pools *PoolResolver
+}
+
+func NewExtractor(pools *PoolResolver) *Extractor {
+	return &Extractor{pools: pools}
+}
+
+// Extract разбирает логи (например, всех квитанций блока) в события пулов
+func (e *Extractor) Extract(ctx context.Context, logs []models.Log) ([]models.DexTrade, error) {
+	type candidate struct {
+		log    models.Log
+		event  poolEvent
+		topics []common.Hash
+	}
+
+	var candidates []candidate
+	var addresses []common.Address
+	for _, log := range logs {
+		if len(log.Topics) == 0 || !common.IsHexAddress(log.Address) {
+			continue
+		}
+		topics := make([]common.Hash, len(log.Topics))
+		for i, t := range log.Topics {
+			topics[i] = common.HexToHash(t)
+		}
+		event, ok := events[topics[0]]
+		if !ok || len(topics)-1 != indexedCount(event) {
+			continue
+		}
+		candidates = append(candidates, candidate{log: log, event: event, topics: topics[1:]})
+		addresses = append(addresses, common.HexToAddress(log.Address))
+	}
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+
+	pools, err := e.pools.Resolve(ctx, addresses)
+	if err != nil {
+		return nil, err
+	}
+
+	var trades []models.DexTrade
+	for _, c := range candidates {
+		pool, ok := pools[common.HexToAddress(c.log.Address)]
+		// Событие V2 от пула фабрики V3 (и наоборот) не принимается
+		if !ok || pool.Protocol != c.event.protocol {
+			continue
+		}
+		data, err := hexutil.Decode(orEmpty(c.log.Data))
+		if err != nil {
+			return nil, fmt.Errorf("log %d of tx %s: data: %w", c.log.LogIndex, c.log.TransactionHash, err)
+		}
+		values, err := c.event.unpack(c.topics, data)
+		if err != nil {
+			// Контракт пула с нестандартным событием под тем же topic0
+			continue
+		}
+
+		trade := models.DexTrade{
+			Protocol:        c.event.protocol,
+			Event:           c.event.kind,
+			Pool:            pool.Address.Hex(),
+			Factory:         pool.Factory.Hex(),
+			Token0:          pool.Token0.Hex(),
+			Token1:          pool.Token1.Hex(),
+			BlockNumber:     c.log.BlockNumber,
+			TransactionHash: c.log.TransactionHash,
+			LogIndex:        c.log.LogIndex,
+			BlockTimestamp:  c.log.BlockTimestamp,
+		}
+		c.event.trade(&trade, values)
+		trades = append(trades, trade)
+	}
+	return trades, nil
+}
+
+func indexedCount(e poolEvent) int {
+	n := 0
+	for _, input := range e.event.Inputs {
+		if input.Indexed {
+			n++
+		}
+	}
+	return n
+}
+
+func orEmpty(data string) string {
+	if data == "" {
+		return "0x"
+	}
+	return data
+}
@@ -0,0 +1,312 @@
+package dex
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"lib/models"
+	"math/big"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Caller — провайдер, через которого читается состояние пулов (node.Provider)
+type Caller interface {
+	BatchCallContext(ctx context.Context, batch []rpc.BatchElem) error
+}
+
+// ErrInvalidFactory — адрес фабрики в списке разрешённых не разбирается
+var ErrInvalidFactory = errors.New("invalid factory")
+
+// poolChunk — пулов в одном батче (по четыре eth_call на пул)
+const poolChunk = 50
+
+// Factories — фабрики, пулы которых принимаются. token0(), token1() и factory()
+// может реализовать любой контракт, в том числе вернуть адрес чужой фабрики,
+// поэтому пул признаётся, только если фабрика из списка сама возвращает его
+// адрес: getPair(token0, token1) у V2 и getPool(token0, token1, fee) у V3.
+type Factories struct {
+	V2 []string // фабрики пар Uniswap V2 и форков
+	V3 []string // фабрики пулов Uniswap V3 и форков
+}
+
+// Pool — токены и фабрика пула; Protocol определяется по фабрике
+type Pool struct {
+	Address  common.Address
+	Factory  common.Address
+	Protocol string
+	Token0   common.Address
+	Token1   common.Address
+	Fee      uint32 // комиссия пула V3 в миллионных долях; у V2 — 0
+}
+
+// Методы пула: token0, token1 и factory общие для Uniswap V2 и V3,
+// fee есть только у V3
+var poolMethods = [...][]byte{
+	crypto.Keccak256([]byte("token0()"))[:4],
+	crypto.Keccak256([]byte("token1()"))[:4],
+	crypto.Keccak256([]byte("factory()"))[:4],
+	crypto.Keccak256([]byte("fee()"))[:4],
+}
+
+// Индекс fee() в poolMethods: без него контракт может быть парой V2
+const feeMethod = 3
+
+// Методы фабрик, возвращающие адрес созданного ими пула
+var (
+	getPairMethod = crypto.Keccak256([]byte("getPair(address,address)"))[:4]
+	getPoolMethod = crypto.Keccak256([]byte("getPool(address,address,uint24)"))[:4]
+)
+
+// PoolResolver определяет токены пулов через eth_call, сверяет пул с его
+// фабрикой и кеширует результат. token0, token1, fee и фабрика пула
+// неизменяемы, поэтому кеш не устаревает; контракты, которые не отвечают
+// на эти методы или не созданы разрешённой фабрикой, запоминаются как не пулы.
+type PoolResolver struct {
+	caller    Caller
+	factories map[common.Address]string // фабрика -> протокол
+
+	mu    sync.RWMutex
+	pools map[common.Address]*Pool // nil — контракт не является пулом
+}
+
+func NewPoolResolver(caller Caller, factories Factories) (*PoolResolver, error) {
+	r := &PoolResolver{
+		caller:    caller,
+		factories: make(map[common.Address]string, len(factories.V2)+len(factories.V3)),
+		pools:     make(map[common.Address]*Pool),
+	}
+	for protocol, addresses := range map[string][]string{models.DexProtocolUniswapV2: factories.V2, models.DexProtocolUniswapV3: factories.V3} {
+		for _, address := range addresses {
+			if !common.IsHexAddress(address) {
+				return nil, fmt.Errorf("%w: %s %q", ErrInvalidFactory, protocol, address)
+			}
+			r.factories[common.HexToAddress(address)] = protocol
+		}
+	}
+	return r, nil
+}
+
+// Add добавляет уже проверенный пул в кеш без запроса к провайдеру (например,
+// из базы); пул не разрешённой фабрики запоминается как не пул
+func (r *PoolResolver) Add(pool Pool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	protocol, ok := r.factories[pool.Factory]
+	if !ok {
+		r.pools[pool.Address] = nil
+		return
+	}
+	pool.Protocol = protocol
+	r.pools[pool.Address] = &pool
+}
+
+// Resolve возвращает пулы по адресам, запрашивая неизвестные батчами eth_call
+// по poolChunk пулов; адреса, которые не являются пулами, в результат не попадают
+func (r *PoolResolver) Resolve(ctx context.Context, addresses []common.Address) (map[common.Address]Pool, error) {
+	found := make(map[common.Address]Pool, len(addresses))
+	var unknown []common.Address
+
+	r.mu.RLock()
+	for _, address := range addresses {
+		pool, ok := r.pools[address]
+		switch {
+		case !ok:
+			unknown = append(unknown, address)
+		case pool != nil:
+			found[address] = *pool
+		}
+	}
+	r.mu.RUnlock()
+
+	unknown = dedup(unknown)
+	for start := 0; start < len(unknown); start += poolChunk {
+		if err := r.resolveChunk(ctx, unknown[start:min(start+poolChunk, len(unknown))], found); err != nil {
+			return nil, err
+		}
+	}
+	return found, nil
+}
+
+// resolveChunk запрашивает token0, token1, factory и fee пулов одним батчем,
+// вторым батчем сверяет кандидатов с их фабриками и добавляет пулы в found
+func (r *PoolResolver) resolveChunk(ctx context.Context, addresses []common.Address, found map[common.Address]Pool) error {
+	batch := make([]rpc.BatchElem, 0, len(addresses)*len(poolMethods))
+	for _, address := range addresses {
+		for _, method := range poolMethods {
+			batch = append(batch, callElem(address, method))
+		}
+	}
+	if err := r.caller.BatchCallContext(ctx, batch); err != nil {
+		return fmt.Errorf("resolve pools: %w", err)
+	}
+
+	// Кандидаты — контракты, которые называют своей разрешённую фабрику
+	var candidates []*Pool
+	for i, address := range addresses {
+		results := batch[i*len(poolMethods) : (i+1)*len(poolMethods)]
+		pool, hasFee, err := parsePool(address, results)
+		if err != nil {
+			return err
+		}
+		if pool == nil {
+			continue
+		}
+		protocol, ok := r.factories[pool.Factory]
+		// У пула V3 без fee() адрес у фабрики не спросить
+		if !ok || (protocol == models.DexProtocolUniswapV3 && !hasFee) {
+			continue
+		}
+		pool.Protocol = protocol
+		candidates = append(candidates, pool)
+	}
+
+	verified, err := r.verify(ctx, candidates)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, address := range addresses {
+		pool, ok := verified[address]
+		if !ok {
+			r.pools[address] = nil
+			continue
+		}
+		r.pools[address] = &pool
+		found[address] = pool
+	}
+	return nil
+}
+
+// verify спрашивает у фабрики каждого кандидата адрес пула с его токенами
+// (и fee у V3) и возвращает кандидатов, чей адрес совпал
+func (r *PoolResolver) verify(ctx context.Context, candidates []*Pool) (map[common.Address]Pool, error) {
+	verified := make(map[common.Address]Pool, len(candidates))
+	if len(candidates) == 0 {
+		return verified, nil
+	}
+
+	batch := make([]rpc.BatchElem, len(candidates))
+	for i, pool := range candidates {
+		data := append([]byte{}, getPairMethod...)
+		if pool.Protocol == models.DexProtocolUniswapV3 {
+			data = append([]byte{}, getPoolMethod...)
+		}
+		data = append(data, common.LeftPadBytes(pool.Token0.Bytes(), 32)...)
+		data = append(data, common.LeftPadBytes(pool.Token1.Bytes(), 32)...)
+		if pool.Protocol == models.DexProtocolUniswapV3 {
+			data = append(data, common.BigToHash(big.NewInt(int64(pool.Fee))).Bytes()...)
+		}
+		batch[i] = callElem(pool.Factory, data)
+	}
+	if err := r.caller.BatchCallContext(ctx, batch); err != nil {
+		return nil, fmt.Errorf("verify pools: %w", err)
+	}
+
+	for i, pool := range candidates {
+		registered, ok, err := addressResult(batch[i])
+		if err != nil {
+			return nil, fmt.Errorf("verify pool %s with factory %s: %w", pool.Address.Hex(), pool.Factory.Hex(), err)
+		}
+		if ok && registered == pool.Address {
+			verified[pool.Address] = *pool
+		}
+	}
+	return verified, nil
+}
+
+// callElem — eth_call метода с данными data контракта to на последнем блоке
+func callElem(to common.Address, data []byte) rpc.BatchElem {
+	return rpc.BatchElem{
+		Method: "eth_call",
+		Args: []any{
+			map[string]any{"to": to, "data": hexutil.Bytes(data)},
+			"latest",
+		},
+		Result: new(hexutil.Bytes),
+	}
+}
+
+// wordResult возвращает 32-байтовое слово из ответа eth_call; ok == false —
+// revert или ответ другой длины. Прочие ошибки провайдера возвращаются.
+func wordResult(elem rpc.BatchElem) ([]byte, bool, error) {
+	if elem.Error != nil {
+		if isRevert(elem.Error) {
+			return nil, false, nil
+		}
+		return nil, false, elem.Error
+	}
+	raw := *elem.Result.(*hexutil.Bytes)
+	if len(raw) != 32 {
+		return nil, false, nil
+	}
+	return raw, true, nil
+}
+
+// addressResult разбирает адрес — последние 20 байт слова
+func addressResult(elem rpc.BatchElem) (common.Address, bool, error) {
+	word, ok, err := wordResult(elem)
+	if !ok {
+		return common.Address{}, false, err
+	}
+	return common.BytesToAddress(word[12:]), true, nil
+}
+
+// parsePool разбирает ответы token0, token1, factory и fee; nil — не пул.
+// Revert token0, token1 или factory означает, что методов нет; прочие ошибки
+// провайдера возвращаются, чтобы не закешировать пул как неизвестный из-за сбоя.
+// hasFee == false — у контракта нет fee() (пара V2).
+func parsePool(address common.Address, results []rpc.BatchElem) (pool *Pool, hasFee bool, err error) {
+	var words [feeMethod]common.Address
+	for j := range words {
+		word, ok, err := addressResult(results[j])
+		if err != nil {
+			return nil, false, fmt.Errorf("resolve pool %s: %w", address.Hex(), err)
+		}
+		if !ok {
+			return nil, false, nil
+		}
+		words[j] = word
+	}
+	if words[0] == (common.Address{}) || words[1] == (common.Address{}) {
+		return nil, false, nil
+	}
+	pool = &Pool{Address: address, Token0: words[0], Token1: words[1], Factory: words[2]}
+
+	// fee — uint24 в 32-байтовом слове
+	word, ok, err := wordResult(results[feeMethod])
+	if err != nil {
+		return nil, false, fmt.Errorf("resolve pool %s: %w", address.Hex(), err)
+	}
+	if fee := new(big.Int).SetBytes(word); ok && fee.BitLen() <= 24 {
+		pool.Fee, hasFee = uint32(fee.Uint64()), true
+	}
+	return pool, hasFee, nil
+}
+
+// isRevert сообщает, что eth_call завершился revert (код 3 или сообщение с "revert")
+func isRevert(err error) bool {
+	if rpcErr, ok := err.(rpc.Error); ok && rpcErr.ErrorCode() == 3 {
+		return true
+	}
+	return strings.Contains(strings.ToLower(err.Error()), "revert")
+}
+
+func dedup(addresses []common.Address) []common.Address {
+	seen := make(map[common.Address]bool, len(addresses))
+	out := addresses[:0:0]
+	for _, address := range addresses {
+		if !seen[address] {
+			seen[address] = true
+			out = append(out, address)
+		}
+	}
+	return out
+}
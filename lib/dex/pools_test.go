@@ -0,0 +1,177 @@
+package dex
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"lib/models"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// revertError — ответ узла на eth_call, завершившийся revert
+type revertError struct{}
+
+func (revertError) Error() string  { return "execution reverted" }
+func (revertError) ErrorCode() int { return 3 }
+
+// fakeChain отвечает на eth_call методов пулов и фабрик. claims — что
+// контракты возвращают из token0/token1/factory/fee, created — пулы,
+// которые фабрика действительно создала.
+type fakeChain struct {
+	claims  map[common.Address]Pool
+	noFee   map[common.Address]bool
+	created map[common.Address][]Pool // фабрика -> пулы
+	err     error                     // ошибка провайдера на любой вызов
+	calls   int
+}
+
+func (c *fakeChain) BatchCallContext(_ context.Context, batch []rpc.BatchElem) error {
+	c.calls++
+	for i := range batch {
+		elem := &batch[i]
+		if c.err != nil {
+			elem.Error = c.err
+			continue
+		}
+		call := elem.Args[0].(map[string]any)
+		to, data := call["to"].(common.Address), []byte(call["data"].(hexutil.Bytes))
+		word, ok := c.call(to, data)
+		if !ok {
+			elem.Error = revertError{}
+			continue
+		}
+		*elem.Result.(*hexutil.Bytes) = word
+	}
+	return nil
+}
+
+func (c *fakeChain) call(to common.Address, data []byte) ([]byte, bool) {
+	if pool, ok := c.claims[to]; ok {
+		switch {
+		case bytes.Equal(data, poolMethods[0]):
+			return common.LeftPadBytes(pool.Token0.Bytes(), 32), true
+		case bytes.Equal(data, poolMethods[1]):
+			return common.LeftPadBytes(pool.Token1.Bytes(), 32), true
+		case bytes.Equal(data, poolMethods[2]):
+			return common.LeftPadBytes(pool.Factory.Bytes(), 32), true
+		case bytes.Equal(data, poolMethods[feeMethod]) && !c.noFee[to]:
+			return common.BigToHash(new(big.Int).SetUint64(uint64(pool.Fee))).Bytes(), true
+		}
+		return nil, false
+	}
+	pools, ok := c.created[to]
+	if !ok || len(data) < 4+64 {
+		return nil, false
+	}
+	token0, token1 := common.BytesToAddress(data[4:36]), common.BytesToAddress(data[36:68])
+	for _, pool := range pools {
+		v3 := bytes.Equal(data[:4], getPoolMethod) && len(data) == 4+96 &&
+			common.BytesToHash(data[68:]).Big().Uint64() == uint64(pool.Fee)
+		v2 := bytes.Equal(data[:4], getPairMethod) && len(data) == 4+64
+		if (v2 || v3) && pool.Token0 == token0 && pool.Token1 == token1 {
+			return common.LeftPadBytes(pool.Address.Bytes(), 32), true
+		}
+	}
+	// Пула нет — фабрика возвращает нулевой адрес
+	return make([]byte, 32), true
+}
+
+func TestResolveVerifiesPoolsWithFactory(t *testing.T) {
+	var (
+		v2Factory = common.HexToAddress("0x00000000000000000000000000000000000000f2")
+		v3Factory = common.HexToAddress("0x00000000000000000000000000000000000000f3")
+		unlisted  = common.HexToAddress("0x00000000000000000000000000000000000000ff")
+		token0    = common.HexToAddress("0x00000000000000000000000000000000000000b0")
+		token1    = common.HexToAddress("0x00000000000000000000000000000000000000b1")
+
+		pair       = common.HexToAddress("0x00000000000000000000000000000000000000a1")
+		fakePair   = common.HexToAddress("0x00000000000000000000000000000000000000a2")
+		pool       = common.HexToAddress("0x00000000000000000000000000000000000000a3")
+		fakePool   = common.HexToAddress("0x00000000000000000000000000000000000000a4")
+		noFeePool  = common.HexToAddress("0x00000000000000000000000000000000000000a5")
+		otherPair  = common.HexToAddress("0x00000000000000000000000000000000000000a6")
+		notPool    = common.HexToAddress("0x00000000000000000000000000000000000000c0")
+		realV2     = Pool{Address: pair, Factory: v2Factory, Token0: token0, Token1: token1}
+		realV3     = Pool{Address: pool, Factory: v3Factory, Token0: token0, Token1: token1, Fee: 3000}
+		otherV2    = Pool{Address: otherPair, Factory: unlisted, Token0: token0, Token1: token1}
+		candidates = []common.Address{pair, fakePair, pool, fakePool, noFeePool, otherPair, notPool}
+	)
+
+	chain := &fakeChain{
+		claims: map[common.Address]Pool{
+			pair: realV2,
+			// Контракт называет своей разрешённую фабрику, но создан не ею
+			fakePair: {Factory: v2Factory, Token0: token0, Token1: token1},
+			pool:     realV3,
+			// Чужой fee: фабрика знает пул этих токенов только с fee 3000
+			fakePool:  {Factory: v3Factory, Token0: token0, Token1: token1, Fee: 500},
+			noFeePool: {Factory: v3Factory, Token0: token0, Token1: token1},
+			otherPair: otherV2,
+		},
+		noFee: map[common.Address]bool{pair: true, fakePair: true, noFeePool: true, otherPair: true},
+		created: map[common.Address][]Pool{
+			v2Factory: {realV2},
+			v3Factory: {realV3},
+			unlisted:  {otherV2},
+		},
+	}
+	r, err := NewPoolResolver(chain, Factories{V2: []string{v2Factory.Hex()}, V3: []string{v3Factory.Hex()}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	found, err := r.Resolve(context.Background(), candidates)
+	if err != nil {
+		t.Fatalf("resolve: %v", err)
+	}
+	realV2.Protocol, realV3.Protocol = models.DexProtocolUniswapV2, models.DexProtocolUniswapV3
+	if len(found) != 2 || found[pair] != realV2 || found[pool] != realV3 {
+		t.Errorf("found %+v, want only %s and %s", found, pair.Hex(), pool.Hex())
+	}
+	// Пулы и фабрики — по батчу
+	if chain.calls != 2 {
+		t.Errorf("%d batch calls, want 2", chain.calls)
+	}
+
+	// Результат, в том числе отказ, закеширован
+	found, err = r.Resolve(context.Background(), candidates)
+	if err != nil || len(found) != 2 {
+		t.Fatalf("resolve from cache = %v, %v", found, err)
+	}
+	if chain.calls != 2 {
+		t.Errorf("%d batch calls after a cached resolve, want 2", chain.calls)
+	}
+}
+
+func TestResolveProviderErrorIsNotCached(t *testing.T) {
+	factory := common.HexToAddress("0x00000000000000000000000000000000000000f2")
+	pair := Pool{
+		Address: common.HexToAddress("0x00000000000000000000000000000000000000a1"),
+		Factory: factory,
+		Token0:  common.HexToAddress("0x00000000000000000000000000000000000000b0"),
+		Token1:  common.HexToAddress("0x00000000000000000000000000000000000000b1"),
+	}
+	chain := &fakeChain{
+		claims:  map[common.Address]Pool{pair.Address: pair},
+		created: map[common.Address][]Pool{factory: {pair}},
+		err:     errors.New("503 service unavailable"),
+	}
+	r, err := NewPoolResolver(chain, Factories{V2: []string{factory.Hex()}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := r.Resolve(context.Background(), []common.Address{pair.Address}); err == nil {
+		t.Fatal("resolve succeeded despite provider errors")
+	}
+	chain.err = nil
+	found, err := r.Resolve(context.Background(), []common.Address{pair.Address})
+	if err != nil || len(found) != 1 {
+		t.Errorf("resolve after recovery = %v, %v; want the pair", found, err)
+	}
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// AccountState — баланс и nonce адреса после блока BlockNumber.
+// Balance — в wei, десятичной строкой (UInt256 в ClickHouse).
+type AccountState struct {
+	Address        string    `json:"address" ch:"address"`
+	Balance        string    `json:"balance" ch:"balance"`
+	Nonce          uint      `json:"nonce" ch:"nonce"`
+	BlockNumber    uint      `json:"blockNumber" ch:"block_number"`
+	BlockHash      string    `json:"blockHash" ch:"block_hash"`
+	BlockTimestamp time.Time `json:"blockTimestamp" ch:"block_timestamp"`
+}
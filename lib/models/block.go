@@ -25,6 +25,47 @@ type Block struct {
 	MixHash          string    `json:"mixHash" ch:"mix_hash"`
 	Transactions     []string  `json:"transactions" ch:"transactions"`
 	Uncles           []string  `json:"uncles" ch:"uncles"`
+	// UncleHeaders — заголовки дядей в порядке Uncles; хранятся в отдельной таблице
+	UncleHeaders []Uncle `json:"uncleHeaders,omitempty" ch:"-"`
+	// Rewards — экономика блока; nil, если не загружались квитанции или заголовки дядей
+	Rewards *BlockReward `json:"rewards,omitempty" ch:"-"`
+}
+
+// BlockReward — экономика блока: что получил получатель комиссий и что сожжено.
+// Суммы — в wei, десятичными строками (UInt256 в ClickHouse).
+//
+// MinerReward = StaticReward + UncleInclusionReward + PriorityFees.
+// UnclesReward получают майнеры дядей, а не получатель комиссий блока.
+type BlockReward struct {
+	BlockNumber          uint      `json:"blockNumber" ch:"block_number"`
+	BlockHash            string    `json:"blockHash" ch:"block_hash"`
+	Miner                string    `json:"miner" ch:"miner"`
+	StaticReward         string    `json:"staticReward" ch:"static_reward"`
+	UncleInclusionReward string    `json:"uncleInclusionReward" ch:"uncle_inclusion_reward"`
+	UnclesReward         string    `json:"unclesReward" ch:"uncles_reward"`
+	PriorityFees         string    `json:"priorityFees" ch:"priority_fees"`
+	BaseFeeBurned        string    `json:"baseFeeBurned" ch:"base_fee_burned"`
+	BlobFeesBurned       string    `json:"blobFeesBurned" ch:"blob_fees_burned"`
+	MinerReward          string    `json:"minerReward" ch:"miner_reward"`
+	BlockTimestamp       time.Time `json:"blockTimestamp" ch:"block_timestamp"`
+}
+
+// Uncle — дядя (ommer): блок-сирота, включённый в блок BlockNumber до The Merge.
+// Reward — награда майнеру дяди в wei (десятичная строка).
+type Uncle struct {
+	Hash           string    `json:"hash" ch:"hash"`
+	Number         uint      `json:"number" ch:"number"`
+	ParentHash     string    `json:"parentHash" ch:"parent_hash"`
+	Miner          string    `json:"miner" ch:"miner"`
+	Difficulty     string    `json:"difficulty" ch:"difficulty"`
+	GasLimit       uint      `json:"gasLimit" ch:"gas_limit"`
+	GasUsed        uint      `json:"gasUsed" ch:"gas_used"`
+	Timestamp      time.Time `json:"timestamp" ch:"timestamp"`
+	BlockNumber    uint      `json:"blockNumber" ch:"block_number"`
+	BlockHash      string    `json:"blockHash" ch:"block_hash"`
+	UncleIndex     uint      `json:"uncleIndex" ch:"uncle_index"`
+	Reward         string    `json:"reward" ch:"reward"`
+	BlockTimestamp time.Time `json:"blockTimestamp" ch:"block_timestamp"`
 }
 
 // Tx — модель транзакции
@@ -64,6 +105,8 @@ type Receipt struct {
 	CumulativeGasUsed uint      `json:"cumulativeGasUsed" ch:"cumulative_gas_used"`
 	GasUsed           uint      `json:"gasUsed" ch:"gas_used"`
 	EffectiveGasPrice uint      `json:"effectiveGasPrice" ch:"effective_gas_price"`
+	BlobGasUsed       uint      `json:"blobGasUsed,omitempty" ch:"blob_gas_used"`
+	BlobGasPrice      uint      `json:"blobGasPrice,omitempty" ch:"blob_gas_price"`
 	Status            uint      `json:"status" ch:"status"`
 	LogsBloom         string    `json:"logsBloom" ch:"logs_bloom"`
 	BlockTimestamp    time.Time `json:"blockTimestamp" ch:"block_timestamp"`
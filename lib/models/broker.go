@@ -7,6 +7,13 @@ type MessageBroker struct {
 	Value   []byte
 	Headers map[string]string
 	Topic   string
+
+	// Заполняются консьюмером, если брокер их поддерживает; при отправке игнорируются
+	Partition int
+	Offset    int64
 }
 
 type MessageHandlerBroker func(ctx context.Context, msg MessageBroker) error
+
+// BatchMessageHandlerBroker обрабатывает пачку сообщений; пачка подтверждается целиком
+type BatchMessageHandlerBroker func(ctx context.Context, msgs []MessageBroker) error
@@ -0,0 +1,237 @@
+package models
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"lib/utils/logging"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/ilyakaznacheev/cleanenv"
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+// Константы, используемые для поиска конфига
+const (
+	flagConfigPathName = "configs"
+	envConfigPathName  = "CONFIG_PATH"
+	dotEnvFileName     = ".env"
+
+	// Суффикс переменной окружения с путём к файлу секрета
+	secretFileSuffix = "_FILE"
+)
+
+// Пути, по которым ищется конфиг, если он не задан флагом или CONFIG_PATH
+var defaultConfigPaths = []string{
+	"./configs/configs.yaml",     // из корня сервиса
+	"./configs/config.yaml",      // общий путь
+	"../configs/configs.yaml",    // если запускаем из cmd/
+	"../../configs/configs.yaml", // если запускаем из глубоких папок
+}
+
+// Порты по умолчанию для баз
+const (
+	defaultClickhousePort = 9000
+	defaultRedisPort      = 6379
+)
+
+// ConfigFlag регистрирует в fs флаг -configs с путём к конфигу.
+// Флаги разбирает сам сервис, LoadConfig получает уже готовое значение.
+func ConfigFlag(fs *flag.FlagSet) *string {
+	return fs.String(flagConfigPathName, "", "path to config file (e.g., ./configs/configs.yaml)")
+}
+
+// ConfigPath возвращает путь к конфигу: flagPath, затем CONFIG_PATH,
+// затем первый существующий из путей по умолчанию
+func ConfigPath(flagPath string) string {
+	if flagPath != "" {
+		return flagPath
+	}
+	if path, ok := os.LookupEnv(envConfigPathName); ok && path != "" {
+		return path
+	}
+	for _, path := range defaultConfigPaths {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return defaultConfigPaths[0]
+}
+
+// LoadConfig собирает конфиг сервиса service: YAML-файл (путь — см. ConfigPath),
+// переменные окружения и .env, секреты из файлов <ENV>_FILE, значения по умолчанию.
+// Затем конфиг проверяется; все найденные ошибки возвращаются вместе.
+// Если файла нет, конфиг целиком берётся из окружения.
+func LoadConfig(path string, service Service) (*Config, error) {
+	// Загружаем .env, но не падаем, если файла нет
+	_ = godotenv.Load(dotEnvFileName)
+
+	path = ConfigPath(path)
+	cfg := &Config{}
+	var errs []error
+
+	_, statErr := os.Stat(path)
+	switch {
+	case statErr == nil:
+		if err := checkUnknownFields(path); err != nil {
+			errs = append(errs, err)
+		}
+		if err := cleanenv.ReadConfig(path, cfg); err != nil {
+			return nil, fmt.Errorf("failed to read config %s: %w", path, err)
+		}
+	case errors.Is(statErr, os.ErrNotExist):
+		if err := cleanenv.ReadEnv(cfg); err != nil {
+			return nil, fmt.Errorf("failed to read config from environment: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("failed to stat config %s: %w", path, statErr)
+	}
+
+	if err := readSecretFiles(cfg); err != nil {
+		errs = append(errs, err)
+	}
+	cfg.setDefaults()
+
+	if err := cfg.Validate(service); err != nil {
+		errs = append(errs, err)
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return cfg, nil
+}
+
+// MustLoadConfig загружает конфиг через LoadConfig и настраивает логирование.
+// При ошибках пишет их все в лог и завершает процесс.
+func MustLoadConfig(path string, service Service, logger *logging.Logger) *Config {
+	cfg, err := LoadConfig(path, service)
+	if err != nil {
+		logger.Errorf("Invalid configuration of %s (%s):\n%v", service, ConfigPath(path), err)
+		os.Exit(1)
+	}
+
+	// Уровни, формат и сэмплирование логов берутся из секции logging
+	if err := logging.Configure(cfg.Logging); err != nil {
+		logger.Errorf("Invalid logging config: %v", err)
+		os.Exit(1)
+	}
+
+	// Секреты (ключи API, пароли) в выводе конфига маскируются
+	logger.WithField("config", logging.Redact(cfg)).Debug("config loaded successfully")
+	return cfg
+}
+
+// setDefaults заполняет значения по умолчанию, которые нельзя задать тегом
+// env-default: структура DB общая у ClickHouse и Redis, а порты у них разные
+func (c *Config) setDefaults() {
+	if c.Clickhouse.Port == 0 {
+		c.Clickhouse.Port = defaultClickhousePort
+	}
+	if c.Redis.Port == 0 {
+		c.Redis.Port = defaultRedisPort
+	}
+}
+
+// checkUnknownFields сообщает о ключах YAML, которых нет в Config:
+// cleanenv их молча пропускает, и опечатка в имени ключа оставляет значение по умолчанию
+func checkUnknownFields(path string) error {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+	default:
+		return nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	decoder := yaml.NewDecoder(f)
+	decoder.KnownFields(true)
+
+	var probe Config
+	if err := decoder.Decode(&probe); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// readSecretFiles подставляет в поля с тегом secret содержимое файлов из
+// переменных <ENV>_FILE (Docker/Kubernetes secrets). Для списков каждая
+// непустая строка файла — отдельный элемент.
+func readSecretFiles(cfg *Config) error {
+	var errs []error
+	walkEnv(reflect.ValueOf(cfg).Elem(), "", func(field reflect.Value, sf reflect.StructField, envs []string) {
+		if sf.Tag.Get("secret") != "true" {
+			return
+		}
+		for _, env := range envs {
+			path := os.Getenv(env + secretFileSuffix)
+			if path == "" {
+				continue
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s%s: %w", env, secretFileSuffix, err))
+				return
+			}
+
+			value := strings.TrimSpace(string(data))
+			switch field.Kind() {
+			case reflect.String:
+				field.SetString(value)
+			case reflect.Slice:
+				field.Set(reflect.ValueOf(strings.Fields(value)))
+			}
+			return
+		}
+	})
+	return errors.Join(errs...)
+}
+
+// walkEnv обходит поля конфига с тегом env, передавая fn имена переменных
+// окружения с учётом префиксов env-prefix вложенных секций
+func walkEnv(v reflect.Value, prefix string, fn func(field reflect.Value, sf reflect.StructField, envs []string)) {
+	t := v.Type()
+	for i := range t.NumField() {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+
+		field := v.Field(i)
+		if field.Kind() == reflect.Struct && field.Type() != reflect.TypeOf(time.Time{}) {
+			walkEnv(field, prefix+sf.Tag.Get("env-prefix"), fn)
+			continue
+		}
+
+		names := sf.Tag.Get("env")
+		if names == "" {
+			continue
+		}
+		envs := strings.Split(names, ",")
+		for i := range envs {
+			envs[i] = prefix + envs[i]
+		}
+		fn(field, sf, envs)
+	}
+}
+
+// EnvVars возвращает имена всех переменных окружения, которые читает конфиг
+func EnvVars() []string {
+	var names []string
+	walkEnv(reflect.ValueOf(&Config{}).Elem(), "", func(_ reflect.Value, sf reflect.StructField, envs []string) {
+		names = append(names, envs...)
+		if sf.Tag.Get("secret") == "true" {
+			names = append(names, envs[0]+secretFileSuffix)
+		}
+	})
+	return names
+}
@@ -0,0 +1,308 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Service — имя сервиса; определяет, какие секции конфига обязательны
+type Service string
+
+const (
+	ServiceRealtimeMiner   Service = "realtime-miner"
+	ServiceHistoricalMiner Service = "historical-miner"
+	ServiceClickhouse      Service = "clickhouse-service"
+	ServiceRedis           Service = "redis-service"
+	ServiceAPI             Service = "api"
+	// ServiceDLQReplay — утилита dlq-replay, ей нужен только брокер
+	ServiceDLQReplay Service = "dlq-replay"
+)
+
+// Services — все известные сервисы
+var Services = []Service{
+	ServiceRealtimeMiner,
+	ServiceHistoricalMiner,
+	ServiceClickhouse,
+	ServiceRedis,
+	ServiceAPI,
+	ServiceDLQReplay,
+}
+
+// Допустимые значения перечислимых параметров
+var (
+	brokerTypes         = []string{"kafka", "redis", "nats", "memory", "mock"}
+	payloadCodecs       = []string{"json", "protobuf", "binary"}
+	payloadCompressions = []string{"", "none", "zstd", "snappy"}
+	kafkaCompressions   = []string{"", "none", "gzip", "snappy", "lz4", "zstd"}
+	requiredAcks        = []string{"", "all", "one", "none"}
+	balancers           = []string{"", "hash", "network", "least_bytes", "round_robin"}
+	saslMechanisms      = []string{"", "plain", "scram-sha-256", "scram-sha-512"}
+	streamRetentions    = []string{"", "limits", "interest", "workqueue"}
+	streamStorages      = []string{"", "file", "memory"}
+	tracingExporters    = []string{"", "none", "stdout", "otlp"}
+	providerTypes       = []string{"alchemy"}
+	listenTypes         = []string{"http", "https"}
+	verifyModes         = []string{"", "off", "flag", "strict"}
+)
+
+// validator собирает ошибки проверки вместе с путём к полю в YAML
+type validator struct {
+	errs []error
+}
+
+func (v *validator) addf(field, format string, args ...any) {
+	v.errs = append(v.errs, fmt.Errorf("%s: %s", field, fmt.Sprintf(format, args...)))
+}
+
+func (v *validator) required(field, value string) {
+	if strings.TrimSpace(value) == "" {
+		v.addf(field, "is required")
+	}
+}
+
+func (v *validator) oneOf(field, value string, allowed []string) {
+	if !slices.Contains(allowed, strings.ToLower(value)) {
+		v.addf(field, "unknown value %q, expected one of %s", value, strings.Join(nonEmpty(allowed), " | "))
+	}
+}
+
+func (v *validator) nonNegative(field string, value int64) {
+	if value < 0 {
+		v.addf(field, "must not be negative, got %d", value)
+	}
+}
+
+func (v *validator) port(field string, value int) {
+	if value < 1 || value > 65535 {
+		v.addf(field, "must be a port number 1-65535, got %d", value)
+	}
+}
+
+func (v *validator) hexAddress(field, value string) {
+	if len(value) != 42 || !strings.HasPrefix(value, "0x") || strings.Trim(value[2:], "0123456789abcdefABCDEF") != "" {
+		v.addf(field, "must be a 0x-prefixed 20-byte hex address, got %q", value)
+	}
+}
+
+func (v *validator) addr(field, value string) {
+	if value == "" {
+		return
+	}
+	if _, _, err := net.SplitHostPort(value); err != nil {
+		v.addf(field, "must be host:port, got %q", value)
+	}
+}
+
+// Validate проверяет общие секции и секцию сервиса service.
+// Пустой service — только общие секции (например, для утилит).
+func (c *Config) Validate(service Service) error {
+	v := &validator{}
+
+	if service != "" && !slices.Contains(Services, service) {
+		v.addf("service", "unknown service %q", service)
+	}
+
+	c.validateBroker(v, service == ServiceRealtimeMiner || service == ServiceClickhouse || service == ServiceDLQReplay)
+	c.validateCommon(v)
+
+	switch service {
+	case ServiceRealtimeMiner:
+		c.RealtimeMiner.validate(v)
+	case ServiceHistoricalMiner:
+		c.HistoricalMiner.validate(v)
+	case ServiceClickhouse:
+		c.Clickhouse.DB.validate(v, "clickhouse")
+		c.ClickhouseService.validate(v)
+	case ServiceRedis:
+		c.Redis.DB.validate(v, "redis")
+	case ServiceAPI:
+		c.Clickhouse.DB.validate(v, "clickhouse")
+		c.API.validate(v)
+	}
+
+	return errors.Join(v.errs...)
+}
+
+// validateBroker проверяет секцию broker; адреса обязательны, только если
+// сервис работает с брокером (needed)
+func (c *Config) validateBroker(v *validator, needed bool) {
+	b := c.Broker
+
+	v.oneOf("broker.brocker_type", b.BrockerType, brokerTypes)
+	if needed && len(b.Brokers) == 0 && (b.BrockerType == "kafka" || b.BrockerType == "nats") {
+		v.addf("broker.brokers", "at least one address is required for %s", b.BrockerType)
+	}
+
+	v.oneOf("broker.payload_codec", b.PayloadCodec, payloadCodecs)
+	v.oneOf("broker.payload_compression", b.PayloadCompression, payloadCompressions)
+	v.oneOf("broker.compression", b.Compression, kafkaCompressions)
+	v.oneOf("broker.required_acks", b.RequiredAcks, requiredAcks)
+	v.oneOf("broker.balancer", b.Balancer, balancers)
+	v.oneOf("broker.stream_retention", b.StreamRetention, streamRetentions)
+	v.oneOf("broker.stream_storage", b.StreamStorage, streamStorages)
+
+	if b.StartOffset != 0 && b.StartOffset != -1 && b.StartOffset != -2 {
+		v.addf("broker.start_offset", "must be -1 (latest) or -2 (earliest), got %d", b.StartOffset)
+	}
+	v.nonNegative("broker.batch_size", int64(b.BatchSize))
+	v.nonNegative("broker.max_message_bytes", b.MaxMessageBytes)
+	v.nonNegative("broker.max_write_retries", int64(b.MaxWriteRetries))
+	v.nonNegative("broker.reader_min_bytes", int64(b.ReaderMinBytes))
+	v.nonNegative("broker.reader_max_bytes", int64(b.ReaderMaxBytes))
+	if b.ReaderMaxBytes > 0 && b.ReaderMinBytes > b.ReaderMaxBytes {
+		v.addf("broker.reader_min_bytes", "%d is greater than reader_max_bytes %d", b.ReaderMinBytes, b.ReaderMaxBytes)
+	}
+	v.nonNegative("broker.handler_max_attempts", int64(b.HandlerMaxAttempts))
+	v.nonNegative("broker.stream_max_len", b.StreamMaxLen)
+	v.nonNegative("broker.stream_read_count", int64(b.StreamReadCount))
+	v.nonNegative("broker.stream_block_timeout", int64(b.StreamBlockTimeout))
+	v.nonNegative("broker.max_deliveries", int64(b.MaxDeliveries))
+	v.nonNegative("broker.stream_max_bytes", b.StreamMaxBytes)
+
+	if (b.TLS.CertFile == "") != (b.TLS.KeyFile == "") {
+		v.addf("broker.tls", "cert_file and key_file must be set together")
+	}
+	v.oneOf("broker.sasl.mechanism", b.SASL.Mechanism, saslMechanisms)
+	if b.SASL.Mechanism != "" {
+		v.required("broker.sasl.username", b.SASL.Username)
+		v.required("broker.sasl.password", b.SASL.Password)
+	}
+}
+
+// validateCommon проверяет метрики, трассировку, health и логирование
+func (c *Config) validateCommon(v *validator) {
+	v.addr("metrics.addr", c.Metrics.Addr)
+
+	v.oneOf("tracing.exporter", c.Tracing.Exporter, tracingExporters)
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		v.addf("tracing.sample_ratio", "must be between 0 and 1, got %v", c.Tracing.SampleRatio)
+	}
+
+	v.nonNegative("health.interval", int64(c.Health.Interval))
+	v.nonNegative("health.timeout", int64(c.Health.Timeout))
+	v.nonNegative("health.max_head_age", int64(c.Health.MaxHeadAge))
+
+	if err := c.Logging.Validate(); err != nil {
+		v.addf("logging", "%v", err)
+	}
+}
+
+// validate проверяет провайдера секции section (например, realtime_miner.provider)
+func (p Provider) validate(v *validator, section string) {
+	v.oneOf(section+".provider_type", p.ProviderType, providerTypes)
+	v.required(section+".network_name", p.NetworkName)
+	v.required(section+".base_url", p.BaseURL)
+	v.required(section+".api_key", p.ApiKey)
+	v.nonNegative(section+".limiter", int64(p.Limiter))
+	if p.MaxRetries < 1 {
+		v.addf(section+".max_retries", "must be at least 1, got %d", p.MaxRetries)
+	}
+}
+
+func (r RealtimeMiner) validate(v *validator) {
+	p := r.Provider
+	p.validate(v, "realtime_miner.provider")
+
+	v.nonNegative("realtime_miner.outbox.segment_bytes", r.Outbox.SegmentBytes)
+	v.nonNegative("realtime_miner.outbox.max_bytes", r.Outbox.MaxBytes)
+
+	v.oneOf("realtime_miner.verify.mode", r.Verify.Mode, verifyModes)
+	if f := r.Verify.Fallback; f.BaseURL != "" {
+		v.oneOf("realtime_miner.verify.fallback.provider_type", f.ProviderType, providerTypes)
+		v.required("realtime_miner.verify.fallback.api_key", f.ApiKey)
+		if f.NetworkName != p.NetworkName {
+			v.addf("realtime_miner.verify.fallback.network_name", "must match provider network %q, got %q", p.NetworkName, f.NetworkName)
+		}
+	}
+}
+
+// validate проверяет провайдера, только если он задан: без него
+// clickhouse-service сохраняет одни блоки
+func (c ClickhouseService) validate(v *validator) {
+	if c.Provider.BaseURL != "" {
+		c.Provider.validate(v, "clickhouse_service.provider")
+	}
+	v.oneOf("clickhouse_service.verify_mode", c.VerifyMode, verifyModes)
+	if c.Contracts.Enabled && c.Provider.BaseURL == "" {
+		v.addf("clickhouse_service.contracts.enabled", "requires clickhouse_service.provider.base_url")
+	}
+	if a := c.Accounts; a.Enabled {
+		if c.Provider.BaseURL == "" {
+			v.addf("clickhouse_service.accounts.enabled", "requires clickhouse_service.provider.base_url")
+		}
+		if len(a.Watched) == 0 && !a.Touched {
+			v.addf("clickhouse_service.accounts", "watched addresses or touched is required")
+		}
+		for i, address := range a.Watched {
+			v.hexAddress(fmt.Sprintf("clickhouse_service.accounts.watched[%d]", i), address)
+		}
+	}
+
+	d := c.Dex
+	if !d.Enabled {
+		return
+	}
+	if c.Provider.BaseURL == "" {
+		v.addf("clickhouse_service.dex.enabled", "requires clickhouse_service.provider.base_url")
+	}
+	if len(d.V2Factories)+len(d.V3Factories) == 0 {
+		v.addf("clickhouse_service.dex", "at least one of v2_factories and v3_factories is required")
+	}
+	for i, factory := range d.V2Factories {
+		v.hexAddress(fmt.Sprintf("clickhouse_service.dex.v2_factories[%d]", i), factory)
+	}
+	for i, factory := range d.V3Factories {
+		v.hexAddress(fmt.Sprintf("clickhouse_service.dex.v3_factories[%d]", i), factory)
+	}
+}
+
+// validate проверяет только значения, заданные в секции: historical-miner
+// пока не подключается к провайдеру
+func (h HistoricalMiner) validate(v *validator) {
+	p := h.Provider
+	v.nonNegative("historical_miner.provider.compute_units_per_second", int64(p.ComputeUnitsPerSecond))
+	v.nonNegative("historical_miner.provider.compute_units_per_10seconds", int64(p.ComputeUnitsPer10Seconds))
+	v.nonNegative("historical_miner.provider.request_per_second", int64(p.RequestPerSecond))
+	v.nonNegative("historical_miner.provider.max_retries", int64(p.MaxRetries))
+	for i, key := range p.ApiKeys {
+		if strings.TrimSpace(key) == "" {
+			v.addf(fmt.Sprintf("historical_miner.provider.api_keys[%d]", i), "is empty")
+		}
+	}
+}
+
+func (a API) validate(v *validator) {
+	v.oneOf("api.listen.type", a.Listen.Type, listenTypes)
+	if ip := a.Listen.BindIP; ip != "" && net.ParseIP(ip) == nil {
+		v.addf("api.listen.bind_ip", "invalid IP address %q", ip)
+	}
+	port, err := strconv.Atoi(a.Listen.Port)
+	if err != nil {
+		v.addf("api.listen.port", "must be a number, got %q", a.Listen.Port)
+		return
+	}
+	v.port("api.listen.port", port)
+	if strings.EqualFold(a.Listen.Type, "https") {
+		v.required("api.listen.cert_file", a.Listen.CertFile)
+		v.required("api.listen.key_file", a.Listen.KeyFile)
+	}
+}
+
+func (d DB) validate(v *validator, section string) {
+	v.required(section+".host", d.Host)
+	v.port(section+".port", d.Port)
+}
+
+func nonEmpty(values []string) []string {
+	out := make([]string, 0, len(values))
+	for _, value := range values {
+		if value != "" {
+			out = append(out, value)
+		}
+	}
+	return out
+}
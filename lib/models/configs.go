@@ -1,134 +1,282 @@
 package models
 
 import (
-	"flag"
 	"lib/utils/logging"
-	"log/slog"
-	"os"
-	"sync"
 	"time"
-
-	"github.com/ilyakaznacheev/cleanenv"
-	"github.com/joho/godotenv"
 )
 
+// Config — конфигурация сервисов. Общие секции (брокер, базы, метрики,
+// трассировка, логи) используются всеми сервисами, секции realtime_miner,
+// historical_miner, clickhouse_service и api — только своим сервисом.
+//
+// Каждое поле можно переопределить переменной окружения из тега env;
+// для полей с тегом secret значение можно прочитать из файла, путь к которому
+// задаёт переменная <ENV>_FILE (например, REALTIME_API_KEY_FILE).
 type Config struct {
-	ProviderRealTime Provider   `yaml:"provider_realtime"`
-	Broker           Broker     `yaml:"broker"`
-	Clickhouse       Clickhouse `yaml:"clickhouse"`
-	Redis            Redis      `yaml:"redis"`
+	Broker     Broker         `yaml:"broker"`
+	Clickhouse Clickhouse     `yaml:"clickhouse"`
+	Redis      Redis          `yaml:"redis"`
+	Metrics    Metrics        `yaml:"metrics"`
+	Tracing    Tracing        `yaml:"tracing"`
+	Health     Health         `yaml:"health"`
+	Logging    logging.Config `yaml:"logging"`
+
+	RealtimeMiner     RealtimeMiner     `yaml:"realtime_miner"`
+	HistoricalMiner   HistoricalMiner   `yaml:"historical_miner"`
+	ClickhouseService ClickhouseService `yaml:"clickhouse_service"`
+	API               API               `yaml:"api"`
+}
+
+// RealtimeMiner — секция realtime-miner: провайдер с подпиской на новые блоки
+// и локальный журнал продюсера
+type RealtimeMiner struct {
+	Provider Provider `yaml:"provider" env-prefix:"REALTIME_"`
+	Outbox   Outbox   `yaml:"outbox"`
+	Verify   Verify   `yaml:"verify"`
+}
+
+// Verify — проверка полученных блоков по корням заголовка (транзакции,
+// квитанции, logs bloom, хеш). Mode — off | flag | strict.
+// Fallback — провайдер, с которого в режиме strict заново загружается блок
+// с расхождениями; пустой base_url — повторный запрос к основному провайдеру.
+type Verify struct {
+	Mode     string   `yaml:"mode" env:"REALTIME_VERIFY_MODE" env-default:"off"`
+	Fallback Provider `yaml:"fallback" env-prefix:"REALTIME_FALLBACK_"`
+}
+
+// HistoricalMiner — секция historical-miner
+type HistoricalMiner struct {
+	Provider HistoricalProvider `yaml:"provider"`
+}
+
+// ClickhouseService — секция clickhouse-service. В топике blocks приходят
+// только заголовки, поэтому транзакции и квитанции блоков для таблиц
+// transactions, receipts, logs и производных загружаются у Provider;
+// пустой base_url — сохраняются только блоки. VerifyMode — проверка
+// загруженных транзакций и квитанций по заголовку, как verify.mode realtime-miner.
+type ClickhouseService struct {
+	Provider   Provider  `yaml:"provider" env-prefix:"CLICKHOUSE_SERVICE_"`
+	VerifyMode string    `yaml:"verify_mode" env:"CLICKHOUSE_SERVICE_VERIFY_MODE" env-default:"off"`
+	Dex        Dex       `yaml:"dex"`
+	Contracts  Contracts `yaml:"contracts"`
+	Accounts   Accounts  `yaml:"accounts"`
+}
+
+// Dex — выделение событий пулов Uniswap V2/V3 (lib/dex) в dex_trades.
+// Пул принимается, только если фабрика из списка протокола возвращает его адрес
+// (getPair у V2, getPool у V3): factory() самого пула ничего не доказывает.
+type Dex struct {
+	Enabled     bool     `yaml:"enabled" env:"CLICKHOUSE_SERVICE_DEX_ENABLED"`
+	V2Factories []string `yaml:"v2_factories" env:"CLICKHOUSE_SERVICE_DEX_V2_FACTORIES"`
+	V3Factories []string `yaml:"v3_factories" env:"CLICKHOUSE_SERVICE_DEX_V3_FACTORIES"`
+}
+
+// Contracts — индекс созданных контрактов (lib/contracts) в contracts.
+// Без трейсов находятся только контракты, созданные транзакциями напрямую.
+type Contracts struct {
+	Enabled bool `yaml:"enabled" env:"CLICKHOUSE_SERVICE_CONTRACTS_ENABLED"`
+	// Traces — debug_traceBlockByNumber: контракты, созданные фабриками
+	Traces bool `yaml:"traces" env:"CLICKHOUSE_SERVICE_CONTRACTS_TRACES"`
+	// Code — eth_getCode: хеш байткода и интерфейсы токенов
+	Code bool `yaml:"code" env:"CLICKHOUSE_SERVICE_CONTRACTS_CODE"`
+}
+
+// Accounts — балансы и nonce адресов по блокам (lib/accounts) в account_states.
+// Watched записываются при изменении, с Touched — все адреса, затронутые блоком.
+type Accounts struct {
+	Enabled bool     `yaml:"enabled" env:"CLICKHOUSE_SERVICE_ACCOUNTS_ENABLED"`
+	Watched []string `yaml:"watched" env:"CLICKHOUSE_SERVICE_ACCOUNTS_WATCHED"`
+	Touched bool     `yaml:"touched" env:"CLICKHOUSE_SERVICE_ACCOUNTS_TOUCHED"`
+	// Prestate — изменения из трейсов блока (prestateTracer) вместо
+	// eth_getBalance и eth_getTransactionCount; видны внутренние переводы
+	Prestate bool `yaml:"prestate" env:"CLICKHOUSE_SERVICE_ACCOUNTS_PRESTATE"`
+}
+
+// API — секция api
+type API struct {
+	Listen Listen `yaml:"listen"`
+	ABI    ABI    `yaml:"abi"`
+}
+
+// ABI — источники для декодирования логов и input транзакций, дополняющие
+// реестр в ClickHouse. Dir — каталог файлов <адрес>.json с ABI контрактов,
+// SignaturesFile — текстовые сигнатуры функций и событий (по одной на строку).
+// AdminToken открывает запись ABI и сигнатур через API (заголовок
+// Authorization: Bearer <token>); пустой токен отключает запись.
+type ABI struct {
+	Dir            string `yaml:"dir" env:"API_ABI_DIR"`
+	SignaturesFile string `yaml:"signatures_file" env:"API_ABI_SIGNATURES_FILE"`
+	AdminToken     string `yaml:"admin_token" env:"API_ABI_ADMIN_TOKEN" secret:"true"`
 }
 
 type Provider struct {
-	ProviderType string `yaml:"provider_type"`
-	NetworkName  string `yaml:"network_name"`
-	BaseURL      string `yaml:"base_url"`
-	ApiKey       string `yaml:"api_key"`
+	ProviderType string `yaml:"provider_type" env:"PROVIDER_TYPE" env-default:"alchemy"`
+	NetworkName  string `yaml:"network_name" env:"NETWORK_NAME" env-default:"ethereum"`
+	BaseURL      string `yaml:"base_url" env:"BASE_URL"`
+	ApiKey       string `yaml:"api_key" env:"API_KEY" secret:"true"`
+
+	Limiter    int `yaml:"limiter" env:"LIMITER" env-default:"25"`
+	MaxRetries int `yaml:"max_retries" env:"MAX_RETRIES" env-default:"5"`
+}
+
+// HistoricalProvider — провайдер для загрузки истории: несколько ключей API
+// и лимиты в compute units провайдера
+type HistoricalProvider struct {
+	NetworkName              string   `yaml:"network_name" env:"HISTORICAL_NETWORK_NAME" env-default:"ethereum"`
+	BaseURL                  string   `yaml:"base_url" env:"HISTORICAL_BASE_URL"`
+	ApiKeys                  []string `yaml:"api_keys" env:"HISTORICAL_API_KEYS" secret:"true"`
+	ComputeUnitsPerSecond    int      `yaml:"compute_units_per_second" env:"HISTORICAL_COMPUTE_UNITS_PER_SECOND"`
+	ComputeUnitsPer10Seconds int      `yaml:"compute_units_per_10seconds" env:"HISTORICAL_COMPUTE_UNITS_PER_10SECONDS"`
+	RequestPerSecond         int      `yaml:"request_per_second" env:"HISTORICAL_REQUEST_PER_SECOND"`
+	MaxRetries               int      `yaml:"max_retries" env:"HISTORICAL_MAX_RETRIES" env-default:"5"`
+}
 
-	Limiter    int `yaml:"limiter"`
-	MaxRetries int `yaml:"max_retries"`
+// Listen — адрес, на котором сервис принимает запросы. Type — http | https;
+// для https нужны cert_file и key_file.
+type Listen struct {
+	Type     string `yaml:"type" env:"API_LISTEN_TYPE" env-default:"http"`
+	BindIP   string `yaml:"bind_ip" env:"API_LISTEN_BIND_IP" env-default:"0.0.0.0"`
+	Port     string `yaml:"port" env:"API_LISTEN_PORT" env-default:"8080"`
+	CertFile string `yaml:"cert_file" env:"API_LISTEN_CERT_FILE"`
+	KeyFile  string `yaml:"key_file" env:"API_LISTEN_KEY_FILE"`
 }
 
 type Broker struct {
-	BrockerType  string        `yaml:"brocker_type"`
-	Brokers      []string      `yaml:"brokers"`
-	GroupID      string        `yaml:"group_id"`
-	StartOffset  int64         `yaml:"start_offset"`
-	BatchSize    int           `yaml:"batch_size"`
-	BatchTimeout time.Duration `yaml:"batch_timeout"`
-	Async        bool          `yaml:"async"`
+	BrockerType  string        `yaml:"brocker_type" env:"BROKER_TYPE" env-default:"kafka"`
+	Brokers      []string      `yaml:"brokers" env:"BROKER_ADDRS,KAFKA_BROKERS"`
+	GroupID      string        `yaml:"group_id" env:"BROKER_GROUP_ID"`
+	StartOffset  int64         `yaml:"start_offset" env:"BROKER_START_OFFSET"`
+	BatchSize    int           `yaml:"batch_size" env:"BROKER_BATCH_SIZE"`
+	BatchTimeout time.Duration `yaml:"batch_timeout" env:"BROKER_BATCH_TIMEOUT"`
+	Async        bool          `yaml:"async" env:"BROKER_ASYNC"`
+
+	// Сериализация payload: PayloadCodec — json | protobuf | binary,
+	// PayloadCompression — none | zstd | snappy.
+	PayloadCodec       string `yaml:"payload_codec" env:"BROKER_PAYLOAD_CODEC" env-default:"json"`
+	PayloadCompression string `yaml:"payload_compression" env:"BROKER_PAYLOAD_COMPRESSION" env-default:"none"`
+
+	// Защищённое подключение к брокеру
+	TLS  TLS  `yaml:"tls" env-prefix:"BROKER_TLS_"`
+	SASL SASL `yaml:"sasl" env-prefix:"BROKER_SASL_"`
+
+	// Настройки продюсера Kafka.
+	// Compression — none | gzip | snappy | lz4 | zstd; RequiredAcks — all | one | none;
+	// Balancer — hash (по ключу) | network (по заголовку network, сохраняет порядок блоков сети) |
+	// least_bytes | round_robin. kafka-go не поддерживает идемпотентного продюсера,
+	// поэтому при повторах возможны дубликаты — консьюмеры дедуплицируют по ключу.
+	Compression     string        `yaml:"compression" env:"BROKER_COMPRESSION"`
+	RequiredAcks    string        `yaml:"required_acks" env:"BROKER_REQUIRED_ACKS"`
+	Balancer        string        `yaml:"balancer" env:"BROKER_BALANCER"`
+	MaxMessageBytes int64         `yaml:"max_message_bytes" env:"BROKER_MAX_MESSAGE_BYTES"`
+	WriteTimeout    time.Duration `yaml:"write_timeout" env:"BROKER_WRITE_TIMEOUT"`
+	MaxWriteRetries int           `yaml:"max_write_retries" env:"BROKER_MAX_WRITE_RETRIES"`
+
+	// Настройки консьюмера Kafka
+	ReaderMinBytes int           `yaml:"reader_min_bytes" env:"BROKER_READER_MIN_BYTES"`
+	ReaderMaxBytes int           `yaml:"reader_max_bytes" env:"BROKER_READER_MAX_BYTES"`
+	ReaderMaxWait  time.Duration `yaml:"reader_max_wait" env:"BROKER_READER_MAX_WAIT"`
+	CommitInterval time.Duration `yaml:"commit_interval" env:"BROKER_COMMIT_INTERVAL"`
+
+	// Повторная обработка сообщений консьюмером.
+	// HandlerMaxAttempts == 0 — повторять до успешной обработки. Если попытки
+	// исчерпаны, а DeadLetter выключен, консьюмер Kafka останавливается,
+	// не закоммитив сообщение.
+	HandlerMaxAttempts     int           `yaml:"handler_max_attempts" env:"BROKER_HANDLER_MAX_ATTEMPTS"`
+	HandlerRetryBackoff    time.Duration `yaml:"handler_retry_backoff" env:"BROKER_HANDLER_RETRY_BACKOFF"`
+	HandlerMaxRetryBackoff time.Duration `yaml:"handler_max_retry_backoff" env:"BROKER_HANDLER_MAX_RETRY_BACKOFF"`
+
+	// DeadLetter — отправлять необработанные сообщения в <topic>.dlq вместо бесконечных повторов.
+	// SpoolDir — каталог для сообщений, которые не удалось отправить продюсеру.
+	DeadLetter bool   `yaml:"dead_letter" env:"BROKER_DEAD_LETTER"`
+	SpoolDir   string `yaml:"spool_dir" env:"BROKER_SPOOL_DIR"`
+
+	// Параметры Redis Streams (brocker_type: redis) и NATS JetStream (brocker_type: nats).
+	// Username используется только NATS; Password без Username — токен NATS.
+	// ClaimMinIdle — через сколько сообщение упавшего консьюмера доставляется повторно.
+	// StreamReadCount — сообщений за одно чтение (XREADGROUP COUNT, размер pull-запроса NATS),
+	// StreamBlockTimeout — сколько XREADGROUP ждёт новых записей.
+	// MaxDeliveries — сколько раз сообщение доставляется, прежде чем уйти в DLQ (dead_letter)
+	// или остановить подписку; 0 — 5 с dead_letter, иначе без ограничения.
+	Username           string        `yaml:"username" env:"BROKER_USERNAME"`
+	Password           string        `yaml:"password" env:"BROKER_PASSWORD" secret:"true"`
+	StreamMaxLen       int64         `yaml:"stream_max_len" env:"BROKER_STREAM_MAX_LEN"`
+	ClaimMinIdle       time.Duration `yaml:"claim_min_idle" env:"BROKER_CLAIM_MIN_IDLE"`
+	StreamReadCount    int           `yaml:"stream_read_count" env:"BROKER_STREAM_READ_COUNT"`
+	StreamBlockTimeout time.Duration `yaml:"stream_block_timeout" env:"BROKER_STREAM_BLOCK_TIMEOUT"`
+	MaxDeliveries      int           `yaml:"max_deliveries" env:"BROKER_MAX_DELIVERIES"`
+
+	// Хранение стримов NATS JetStream
+	StreamMaxAge    time.Duration `yaml:"stream_max_age" env:"BROKER_STREAM_MAX_AGE"`
+	StreamMaxBytes  int64         `yaml:"stream_max_bytes" env:"BROKER_STREAM_MAX_BYTES"`
+	StreamRetention string        `yaml:"stream_retention" env:"BROKER_STREAM_RETENTION"` // limits | interest | workqueue
+	StreamStorage   string        `yaml:"stream_storage" env:"BROKER_STREAM_STORAGE"`     // file | memory
 }
 
-type DB struct {
-	Host     string
-	Port     int
-	Username string
-	Password string
-	Database string
-	SSLMode  string
+// Metrics — служебный HTTP-сервер: /metrics для Prometheus, /healthz и /readyz.
+// Пустой Addr отключает сервер.
+type Metrics struct {
+	Addr string `yaml:"addr" env:"METRICS_ADDR"`
 }
 
-type Clickhouse struct {
-	DB
+// Tracing — экспорт спанов OpenTelemetry. Exporter — none | stdout | otlp;
+// Endpoint — host:port OTLP/HTTP коллектора, SampleRatio — доля трасс (0 — все).
+type Tracing struct {
+	Exporter    string  `yaml:"exporter" env:"TRACING_EXPORTER" env-default:"none"`
+	Endpoint    string  `yaml:"endpoint" env:"TRACING_ENDPOINT"`
+	Insecure    bool    `yaml:"insecure" env:"TRACING_INSECURE"`
+	SampleRatio float64 `yaml:"sample_ratio" env:"TRACING_SAMPLE_RATIO"`
 }
 
-type Redis struct {
-	DB
+// Health — проверки состояния сервиса. Interval — период проверок, Timeout — таймаут
+// одной проверки, MaxHeadAge — сколько можно не получать новых блоков от провайдера.
+type Health struct {
+	Interval   time.Duration `yaml:"interval" env:"HEALTH_INTERVAL" env-default:"10s"`
+	Timeout    time.Duration `yaml:"timeout" env:"HEALTH_TIMEOUT" env-default:"3s"`
+	MaxHeadAge time.Duration `yaml:"max_head_age" env:"HEALTH_MAX_HEAD_AGE" env-default:"2m"`
 }
 
-// Константы, используемые для поиска конфига
-const (
-	flagConfigPathName = "configs"
-	envConfigPathName  = "CONFIG_PATH"
-	dotEnvFileName     = ".env"
-)
+// TLS — параметры TLS-подключения. Пустой CAFile — системные корневые сертификаты,
+// CertFile/KeyFile — клиентский сертификат для mTLS.
+type TLS struct {
+	Enabled            bool   `yaml:"enabled" env:"ENABLED"`
+	CAFile             string `yaml:"ca_file" env:"CA_FILE"`
+	CertFile           string `yaml:"cert_file" env:"CERT_FILE"`
+	KeyFile            string `yaml:"key_file" env:"KEY_FILE"`
+	ServerName         string `yaml:"server_name" env:"SERVER_NAME"`
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify" env:"INSECURE_SKIP_VERIFY"`
+}
 
-var (
-	instance *Config
-	once     sync.Once
-)
+// SASL — аутентификация в брокере. Mechanism — plain | scram-sha-256 | scram-sha-512;
+// пустой Mechanism отключает SASL.
+type SASL struct {
+	Mechanism string `yaml:"mechanism" env:"MECHANISM"`
+	Username  string `yaml:"username" env:"USERNAME"`
+	Password  string `yaml:"password" env:"PASSWORD" secret:"true"`
+}
+
+// Outbox — локальный журнал продюсера realtime-miner.
+// Пустой Dir отключает журнал; MaxBytes == 0 — без ограничения размера.
+type Outbox struct {
+	Dir          string `yaml:"dir" env:"OUTBOX_DIR"`
+	SegmentBytes int64  `yaml:"segment_bytes" env:"OUTBOX_SEGMENT_BYTES"`
+	MaxBytes     int64  `yaml:"max_bytes" env:"OUTBOX_MAX_BYTES"`
+}
+
+// DB — подключение к базе. Имена переменных окружения получают префикс
+// секции: CLICKHOUSE_HOST, REDIS_PASSWORD и т.д.
+type DB struct {
+	Host     string `yaml:"host" env:"HOST"`
+	Port     int    `yaml:"port" env:"PORT"`
+	Username string `yaml:"username" env:"USERNAME"`
+	Password string `yaml:"password" env:"PASSWORD" secret:"true"`
+	Database string `yaml:"database" env:"DATABASE"`
+	SSLMode  string `yaml:"ssl_mode" env:"SSL_MODE"`
+}
+
+type Clickhouse struct {
+	DB `yaml:",inline" env-prefix:"CLICKHOUSE_"`
+}
 
-func GetConfig(logger *logging.Logger) *Config {
-	logger.Debug("start get config")
-
-	once.Do(func() {
-		// Загружаем .env, но не падаем, если файла нет
-		_ = godotenv.Load(dotEnvFileName)
-
-		var configPath string
-		// 1. Чтение пути к конфигу из флагов командной строки
-		flag.StringVar(&configPath, flagConfigPathName, "", "path to config file (e.g., ./configs/config.yaml)")
-		flag.Parse()
-
-		// 2. Перезаписываем путь переменной окружения, если есть
-		if path, ok := os.LookupEnv(envConfigPathName); ok && path != "" {
-			configPath = path
-		}
-
-		// 3. Если путь не указан нигде, устанавливаем путь по умолчанию
-		if configPath == "" {
-			// Пробуем разные возможные пути
-			possiblePaths := []string{
-				"./configs/configs.yaml",     // для realtime-miner
-				"./configs/config.yaml",      // общий путь
-				"../configs/configs.yaml",    // если запускаем из cmd/
-				"../../configs/configs.yaml", // если запускаем из глубоких папок
-			}
-
-			for _, path := range possiblePaths {
-				if _, err := os.Stat(path); err == nil {
-					configPath = path
-					logger.Debugf("Found config at: %s", path)
-					break
-				}
-			}
-
-			// Если ни один путь не найден, используем первый по умолчанию
-			if configPath == "" {
-				configPath = "./configs/configs.yaml"
-			}
-		}
-
-		instance = &Config{}
-
-		// 4. Чтение и парсинг YAML-файла
-		if readErr := cleanenv.ReadConfig(configPath, instance); readErr != nil {
-			description, descrErr := cleanenv.GetDescription(instance, nil)
-			if descrErr != nil {
-				panic(descrErr)
-			}
-
-			slog.Error(
-				"Failed to read config. Ensure 'config.yaml' exists or all required env variables are set.",
-				slog.String("error", readErr.Error()),
-				slog.String("config_path", configPath),
-				slog.String("description", description),
-			)
-			os.Exit(1)
-		}
-
-		logger.Debug("config loaded successfully", slog.Any("config", instance))
-	})
-
-	return instance
+type Redis struct {
+	DB `yaml:",inline" env-prefix:"REDIS_"`
 }
@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+// Откуда известно о создании контракта
+const (
+	ContractSourceReceipt = "receipt" // contractAddress квитанции транзакции создания
+	ContractSourceTrace   = "trace"   // фрейм CREATE/CREATE2 трейса, в т.ч. внутренний
+)
+
+// Способ создания контракта
+const (
+	ContractCreate  = "create"
+	ContractCreate2 = "create2"
+)
+
+// Интерфейсы токенов, которые определяются по селекторам байткода
+const (
+	ContractStandardERC20   = "erc20"
+	ContractStandardERC721  = "erc721"
+	ContractStandardERC1155 = "erc1155"
+)
+
+// Contract — созданный контракт.
+//
+// Deployer — адрес, выполнивший CREATE: отправитель транзакции или фабрика,
+// если контракт создан внутренним вызовом; TxFrom — отправитель транзакции.
+// CodeHash, CodeSize и Standards заполняются, только если запрашивался
+// runtime-байткод; пустой CodeHash — код не запрашивался или пуст
+// (контракт самоуничтожился в той же транзакции).
+type Contract struct {
+	Address         string    `json:"address" ch:"address"`
+	Deployer        string    `json:"deployer" ch:"deployer"`
+	TxFrom          string    `json:"txFrom" ch:"tx_from"`
+	CreationType    string    `json:"creationType" ch:"creation_type"`
+	Source          string    `json:"source" ch:"source"`
+	CodeHash        string    `json:"codeHash,omitempty" ch:"code_hash"`
+	CodeSize        uint      `json:"codeSize" ch:"code_size"`
+	Standards       []string  `json:"standards,omitempty" ch:"standards"`
+	BlockNumber     uint      `json:"blockNumber" ch:"block_number"`
+	BlockHash       string    `json:"blockHash" ch:"block_hash"`
+	TransactionHash string    `json:"transactionHash" ch:"transaction_hash"`
+	BlockTimestamp  time.Time `json:"blockTimestamp" ch:"block_timestamp"`
+}
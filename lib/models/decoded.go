@@ -0,0 +1,52 @@
+package models
+
+// Источник, по которому декодированы лог или вызов
+const (
+	// DecodeSourceABI — ABI контракта из реестра
+	DecodeSourceABI = "abi"
+	// DecodeSourceSignature — текстовая сигнатура из базы селекторов и topic0;
+	// имена аргументов неизвестны, индексированные аргументы события угаданы по числу топиков
+	DecodeSourceSignature = "signature"
+)
+
+// DecodedArg — аргумент события или вызова. Value — JSON-safe значение:
+// целые числа — десятичные строки, адреса, хеши и байты — hex с 0x,
+// массивы — списки, кортежи — объекты по именам полей.
+// Индексированные аргументы динамических типов (string, bytes, массивы)
+// хранятся в топике как keccak256 и возвращаются хешем.
+type DecodedArg struct {
+	Name    string `json:"name"`
+	Type    string `json:"type"`
+	Value   any    `json:"value"`
+	Indexed bool   `json:"indexed,omitempty"`
+}
+
+// DecodedEvent — лог, разобранный по ABI или сигнатуре события
+type DecodedEvent struct {
+	Address         string       `json:"address"`
+	Name            string       `json:"name"`
+	Signature       string       `json:"signature"`
+	Topic0          string       `json:"topic0"`
+	Args            []DecodedArg `json:"args"`
+	Source          string       `json:"source"`
+	BlockNumber     uint         `json:"blockNumber,omitempty"`
+	TransactionHash string       `json:"transactionHash,omitempty"`
+	LogIndex        uint         `json:"logIndex,omitempty"`
+}
+
+// DecodedCall — input транзакции, разобранный по ABI или 4-байтовому селектору
+type DecodedCall struct {
+	To        string       `json:"to,omitempty"`
+	Name      string       `json:"name"`
+	Signature string       `json:"signature"`
+	Selector  string       `json:"selector"`
+	Args      []DecodedArg `json:"args"`
+	Source    string       `json:"source"`
+}
+
+// ContractABI — ABI контракта в реестре
+type ContractABI struct {
+	Address string `json:"address" ch:"address"`
+	Name    string `json:"name" ch:"name"`
+	ABI     string `json:"abi" ch:"abi"`
+}
@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+// Протоколы DEX, события которых разбирает lib/dex
+const (
+	DexProtocolUniswapV2 = "uniswap_v2"
+	DexProtocolUniswapV3 = "uniswap_v3"
+)
+
+// События пула
+const (
+	DexEventSwap = "swap"
+	DexEventMint = "mint"
+	DexEventBurn = "burn"
+	DexEventSync = "sync"
+)
+
+// DexTrade — событие пула Uniswap V2/V3 (и их форков) в едином виде.
+// Суммы — десятичные строки в минимальных единицах токенов.
+//
+// Amount0/Amount1 — изменение баланса пула: положительное — токен пришёл в пул,
+// отрицательное — ушёл из пула. Для sync (только V2) — резервы пула после события.
+// TokenIn/TokenOut и AmountIn/AmountOut заполняются только для swap.
+type DexTrade struct {
+	Protocol        string    `json:"protocol" ch:"protocol"`
+	Event           string    `json:"event" ch:"event"`
+	Pool            string    `json:"pool" ch:"pool"`
+	Factory         string    `json:"factory" ch:"factory"`
+	Token0          string    `json:"token0" ch:"token0"`
+	Token1          string    `json:"token1" ch:"token1"`
+	Amount0         string    `json:"amount0" ch:"amount0"`
+	Amount1         string    `json:"amount1" ch:"amount1"`
+	TokenIn         string    `json:"tokenIn,omitempty" ch:"token_in"`
+	TokenOut        string    `json:"tokenOut,omitempty" ch:"token_out"`
+	AmountIn        string    `json:"amountIn,omitempty" ch:"amount_in"`
+	AmountOut       string    `json:"amountOut,omitempty" ch:"amount_out"`
+	Sender          string    `json:"sender,omitempty" ch:"sender"`
+	Recipient       string    `json:"recipient,omitempty" ch:"recipient"`
+	BlockNumber     uint      `json:"blockNumber" ch:"block_number"`
+	TransactionHash string    `json:"transactionHash" ch:"transaction_hash"`
+	LogIndex        uint      `json:"logIndex" ch:"log_index"`
+	BlockTimestamp  time.Time `json:"blockTimestamp" ch:"block_timestamp"`
+}
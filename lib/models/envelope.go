@@ -0,0 +1,97 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Заголовки конверта сообщения брокера
+const (
+	HeaderSchemaVersion   = "schema-version"
+	HeaderContentType     = "content-type"
+	HeaderContentEncoding = "content-encoding"
+	HeaderMessageType     = "message-type"
+	HeaderNetwork         = "network"
+	HeaderProducer        = "producer"
+	HeaderProducedAt      = "produced-at"
+)
+
+// CurrentSchemaVersion — версия схемы, с которой пишут продюсеры.
+// Версия 0 — сообщения без конверта (голый json.Marshal), их читают как JSON.
+const CurrentSchemaVersion = 1
+
+// Типы содержимого payload
+const (
+	ContentTypeJSON     = "application/json"
+	ContentTypeProtobuf = "application/x-protobuf"
+	ContentTypeBinary   = "application/x-blockhub-binary"
+)
+
+// Типы сообщений
+const (
+	MessageTypeBlock = "block"
+)
+
+// Envelope — метаданные сообщения, передаваемые в MessageBroker.Headers
+type Envelope struct {
+	SchemaVersion   int
+	ContentType     string
+	ContentEncoding string // пусто — без сжатия
+	MessageType     string
+	Network         string
+	Producer        string
+	ProducedAt      time.Time
+}
+
+// SetHeaders записывает конверт в заголовки сообщения
+func (e Envelope) SetHeaders(headers map[string]string) {
+	headers[HeaderSchemaVersion] = strconv.Itoa(e.SchemaVersion)
+	headers[HeaderContentType] = e.ContentType
+	if e.ContentEncoding != "" {
+		headers[HeaderContentEncoding] = e.ContentEncoding
+	}
+	if e.MessageType != "" {
+		headers[HeaderMessageType] = e.MessageType
+	}
+	if e.Network != "" {
+		headers[HeaderNetwork] = e.Network
+	}
+	if e.Producer != "" {
+		headers[HeaderProducer] = e.Producer
+	}
+	if !e.ProducedAt.IsZero() {
+		headers[HeaderProducedAt] = e.ProducedAt.UTC().Format(time.RFC3339Nano)
+	}
+}
+
+// EnvelopeFromHeaders читает конверт из заголовков. Для сообщений без
+// конверта возвращает версию 0 и ContentTypeJSON.
+func EnvelopeFromHeaders(headers map[string]string) (Envelope, error) {
+	e := Envelope{
+		ContentType:     headers[HeaderContentType],
+		ContentEncoding: headers[HeaderContentEncoding],
+		MessageType:     headers[HeaderMessageType],
+		Network:         headers[HeaderNetwork],
+		Producer:        headers[HeaderProducer],
+	}
+
+	if v, ok := headers[HeaderSchemaVersion]; ok {
+		version, err := strconv.Atoi(v)
+		if err != nil {
+			return e, fmt.Errorf("invalid %s header %q: %w", HeaderSchemaVersion, v, err)
+		}
+		e.SchemaVersion = version
+	}
+	if e.ContentType == "" {
+		e.ContentType = ContentTypeJSON
+	}
+	if v, ok := headers[HeaderProducedAt]; ok {
+		producedAt, err := time.Parse(time.RFC3339Nano, v)
+		if err != nil {
+			return e, fmt.Errorf("invalid %s header %q: %w", HeaderProducedAt, v, err)
+		}
+		e.ProducedAt = producedAt
+	}
+	return e, nil
+}
@@ -0,0 +1,38 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Freshness проверяет, что событие (например, новый блок в голове цепи)
+// происходило не раньше чем maxAge назад. last возвращает время последнего
+// события, нулевое время — событий ещё не было.
+func Freshness(last func() time.Time, maxAge time.Duration) Check {
+	return func(context.Context) error {
+		at := last()
+		if at.IsZero() {
+			return errors.New("no events yet")
+		}
+		if age := time.Since(at); age > maxAge {
+			return fmt.Errorf("last event %s ago, limit %s", age.Round(time.Second), maxAge)
+		}
+		return nil
+	}
+}
+
+// MaxLag проверяет, что отставание, которое возвращает lag, не превышает limit
+func MaxLag(lag func(ctx context.Context) (int64, error), limit int64) Check {
+	return func(ctx context.Context) error {
+		n, err := lag(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to get lag: %w", err)
+		}
+		if n > limit {
+			return fmt.Errorf("lag %d exceeds limit %d", n, limit)
+		}
+		return nil
+	}
+}
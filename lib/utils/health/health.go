@@ -0,0 +1,226 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"lib/models"
+	"lib/utils/logging"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	defaultInterval = 10 * time.Second
+	defaultTimeout  = 3 * time.Second
+
+	// DefaultMaxHeadAge — допустимое время без новых блоков, если MaxHeadAge не задан
+	DefaultMaxHeadAge = 2 * time.Minute
+)
+
+// Значения поля status в ответе
+const (
+	StatusOK      = "ok"
+	StatusFail    = "fail"
+	StatusPending = "pending"
+)
+
+// Check — проверка компонента; nil — компонент исправен
+type Check func(ctx context.Context) error
+
+// Kind определяет, на какой эндпоинт влияет проверка
+type Kind string
+
+const (
+	// Liveness — процесс работоспособен; провал ведёт к перезапуску
+	Liveness Kind = "liveness"
+	// Readiness — сервис может обрабатывать данные; провал снимает его с нагрузки
+	Readiness Kind = "readiness"
+)
+
+// Result — последний результат проверки
+type Result struct {
+	Kind      Kind      `json:"kind"`
+	Status    string    `json:"status"`
+	Error     string    `json:"error,omitempty"`
+	Duration  string    `json:"duration,omitempty"`
+	CheckedAt time.Time `json:"checked_at,omitzero"`
+}
+
+// Report — тело ответа /healthz и /readyz
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]Result `json:"checks"`
+}
+
+type entry struct {
+	name   string
+	kind   Kind
+	check  Check
+	result Result
+}
+
+// Registry хранит проверки компонентов сервиса и периодически выполняет их
+// в фоне. Эндпоинты отдают закэшированные результаты, поэтому частые запросы
+// оркестратора не нагружают брокер и базы.
+type Registry struct {
+	interval time.Duration
+	timeout  time.Duration
+	logger   *logging.Logger
+
+	mu      sync.RWMutex
+	entries []*entry
+	checked bool // первый прогон проверок завершён
+}
+
+// New создаёт реестр; нулевые значения cfg заменяются значениями по умолчанию
+func New(cfg models.Health, logger *logging.Logger) *Registry {
+	r := &Registry{
+		interval: cfg.Interval,
+		timeout:  cfg.Timeout,
+		logger:   logger,
+	}
+	if r.interval <= 0 {
+		r.interval = defaultInterval
+	}
+	if r.timeout <= 0 {
+		r.timeout = defaultTimeout
+	}
+	return r
+}
+
+// Liveness регистрирует проверку, влияющую на /healthz и /readyz
+func (r *Registry) Liveness(name string, check Check) {
+	r.register(name, Liveness, check)
+}
+
+// Readiness регистрирует проверку, влияющую только на /readyz
+func (r *Registry) Readiness(name string, check Check) {
+	r.register(name, Readiness, check)
+}
+
+func (r *Registry) register(name string, kind Kind, check Check) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.entries = append(r.entries, &entry{
+		name:   name,
+		kind:   kind,
+		check:  check,
+		result: Result{Kind: kind, Status: StatusPending},
+	})
+}
+
+// Run выполняет проверки сразу и затем каждые interval до отмены ctx.
+// Проверки нужно зарегистрировать до вызова Run.
+func (r *Registry) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		r.runChecks(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// runChecks выполняет все проверки параллельно, каждую со своим таймаутом
+func (r *Registry) runChecks(ctx context.Context) {
+	r.mu.RLock()
+	entries := append([]*entry(nil), r.entries...)
+	r.mu.RUnlock()
+
+	var wg sync.WaitGroup
+	for _, e := range entries {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.runCheck(ctx, e)
+		}()
+	}
+	wg.Wait()
+
+	r.mu.Lock()
+	r.checked = true
+	r.mu.Unlock()
+}
+
+func (r *Registry) runCheck(ctx context.Context, e *entry) {
+	checkCtx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := e.check(checkCtx)
+	result := Result{
+		Kind:      e.kind,
+		Status:    StatusOK,
+		Duration:  time.Since(start).Round(time.Millisecond).String(),
+		CheckedAt: start.UTC(),
+	}
+	if err != nil {
+		result.Status = StatusFail
+		result.Error = err.Error()
+	}
+
+	r.mu.Lock()
+	prev := e.result
+	e.result = result
+	r.mu.Unlock()
+
+	// Пишем в лог только смену состояния, а не каждый прогон
+	switch {
+	case err != nil && prev.Status != StatusFail:
+		r.logger.Warnf("Health check %s failed: %v", e.name, err)
+	case err == nil && prev.Status == StatusFail:
+		r.logger.Infof("Health check %s recovered", e.name)
+	}
+}
+
+// Report собирает отчёт по проверкам kind; для Readiness учитываются все проверки.
+// До завершения первого прогона проверок статус отчёта — pending.
+func (r *Registry) Report(kind Kind) Report {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	report := Report{Status: StatusOK, Checks: make(map[string]Result)}
+	if !r.checked {
+		report.Status = StatusPending
+	}
+	for _, e := range r.entries {
+		if kind == Liveness && e.kind != Liveness {
+			continue
+		}
+		report.Checks[e.name] = e.result
+		if e.result.Status == StatusFail {
+			report.Status = StatusFail
+		}
+	}
+	return report
+}
+
+// Mount добавляет в mux эндпоинты /healthz и /readyz
+func (r *Registry) Mount(mux *http.ServeMux) {
+	mux.HandleFunc("/healthz", r.handler(Liveness))
+	mux.HandleFunc("/readyz", r.handler(Readiness))
+}
+
+func (r *Registry) handler(kind Kind) http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		report := r.Report(kind)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		if report.Status != StatusOK {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		} else {
+			w.WriteHeader(http.StatusOK)
+		}
+		if err := json.NewEncoder(w).Encode(report); err != nil {
+			r.logger.Debugf("Failed to write health report: %v", err)
+		}
+	}
+}
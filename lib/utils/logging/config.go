@@ -0,0 +1,94 @@
+package logging
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Форматы вывода
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// Config — настройки логирования из секции logging конфига.
+// Levels задаёт уровни для отдельных пакетов: ключ — путь пакета или его
+// префикс (например, "lib/clients/broker/kafka"), побеждает самый длинный.
+type Config struct {
+	Level    string            `yaml:"level" env:"LOG_LEVEL" env-default:"info"`
+	Format   string            `yaml:"format" env:"LOG_FORMAT" env-default:"text"` // text | json
+	Levels   map[string]string `yaml:"levels" env:"LOG_LEVELS"`                    // pkg:level,pkg:level
+	Sampling Sampling          `yaml:"sampling"`
+}
+
+// Sampling ограничивает поток debug/trace записей: из одного места вызова
+// за секунду пишутся первые Initial записей, затем каждая Thereafter-я.
+// Initial == 0 отключает сэмплирование.
+type Sampling struct {
+	Initial    int `yaml:"initial" env:"LOG_SAMPLING_INITIAL"`
+	Thereafter int `yaml:"thereafter" env:"LOG_SAMPLING_THEREAFTER"`
+}
+
+// Validate проверяет уровни и формат, не меняя настроек логгера
+func (cfg Config) Validate() error {
+	if cfg.Level != "" {
+		if _, err := logrus.ParseLevel(strings.ToLower(cfg.Level)); err != nil {
+			return fmt.Errorf("invalid log level %q", cfg.Level)
+		}
+	}
+	switch strings.ToLower(cfg.Format) {
+	case "", FormatText, FormatJSON:
+	default:
+		return fmt.Errorf("unknown log format %q (text|json)", cfg.Format)
+	}
+	for pkg, level := range cfg.Levels {
+		if _, err := logrus.ParseLevel(strings.ToLower(level)); err != nil {
+			return fmt.Errorf("invalid log level %q for %s", level, pkg)
+		}
+	}
+	if cfg.Sampling.Initial < 0 || cfg.Sampling.Thereafter < 0 {
+		return errors.New("sampling values must not be negative")
+	}
+	return nil
+}
+
+// Configure применяет cfg к общему логгеру. Логгеры, полученные раньше
+// через GetLogger, тоже начинают использовать новые настройки.
+func Configure(cfg Config) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	base := GetLogger()
+
+	level := base.Logger.GetLevel()
+	if cfg.Level != "" {
+		level, _ = logrus.ParseLevel(strings.ToLower(cfg.Level))
+	}
+
+	format := strings.ToLower(cfg.Format)
+	if format == "" {
+		format = FormatText
+	}
+
+	f := &filter{level: level, sampling: cfg.Sampling}
+	if f.sampling.Initial > 0 {
+		f.sampler = newSampler()
+	}
+
+	// Общий уровень logrus — самый подробный из настроенных,
+	// остальное отсекает фильтр хука по пакету места вызова
+	loggerLevel := level
+	for pkg, levelStr := range cfg.Levels {
+		parsed, _ := logrus.ParseLevel(strings.ToLower(levelStr))
+		f.packages = append(f.packages, packageLevel{prefix: strings.TrimSuffix(pkg, "/"), level: parsed})
+		loggerLevel = max(loggerLevel, parsed)
+	}
+	f.sortPackages()
+
+	hook.state.Store(&hookState{formatter: newFormatter(format), filter: f})
+	base.Logger.SetLevel(loggerLevel)
+	return nil
+}
@@ -0,0 +1,53 @@
+package logging
+
+import (
+	"context"
+	"maps"
+
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Поля корреляции
+const (
+	FieldBlock     = "block"
+	FieldNetwork   = "network"
+	FieldRequestID = "request_id"
+	FieldTraceID   = "trace_id"
+	FieldSpanID    = "span_id"
+)
+
+type fieldsKey struct{}
+
+// ContextWithFields возвращает контекст, к полям корреляции которого
+// добавлены fields; Ctx добавит их в каждую запись
+func ContextWithFields(ctx context.Context, fields logrus.Fields) context.Context {
+	merged := make(logrus.Fields, len(fields))
+	if parent, ok := ctx.Value(fieldsKey{}).(logrus.Fields); ok {
+		maps.Copy(merged, parent)
+	}
+	maps.Copy(merged, fields)
+	return context.WithValue(ctx, fieldsKey{}, merged)
+}
+
+// Ctx возвращает логгер с полями корреляции из ctx и идентификаторами
+// трассы и спана, если в ctx есть активный спан
+func (l *Logger) Ctx(ctx context.Context) *Logger {
+	fields := logrus.Fields{}
+	if stored, ok := ctx.Value(fieldsKey{}).(logrus.Fields); ok {
+		maps.Copy(fields, stored)
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		fields[FieldTraceID] = sc.TraceID().String()
+		fields[FieldSpanID] = sc.SpanID().String()
+	}
+	if len(fields) == 0 {
+		return l
+	}
+	return &Logger{l.WithFields(fields)}
+}
+
+// WithBlock возвращает логгер с номером блока в поле block
+func (l *Logger) WithBlock(number uint64) *Logger {
+	return &Logger{l.WithField(FieldBlock, number)}
+}
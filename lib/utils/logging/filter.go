@@ -0,0 +1,102 @@
+package logging
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const samplingTick = time.Second
+
+type packageLevel struct {
+	prefix string
+	level  logrus.Level
+}
+
+// filter решает, писать ли запись: сравнивает её уровень с уровнем
+// пакета места вызова и сэмплирует частые debug/trace записи
+type filter struct {
+	level    logrus.Level
+	packages []packageLevel // по убыванию длины префикса
+	sampling Sampling
+	sampler  *sampler
+}
+
+func (f *filter) sortPackages() {
+	sort.Slice(f.packages, func(i, j int) bool {
+		return len(f.packages[i].prefix) > len(f.packages[j].prefix)
+	})
+}
+
+func (f *filter) allow(entry *logrus.Entry) bool {
+	if entry.Level > f.levelFor(entry) {
+		return false
+	}
+	if f.sampler != nil && entry.Level >= logrus.DebugLevel && entry.HasCaller() {
+		return f.sampler.allow(entry.Caller.File, entry.Caller.Line, f.sampling)
+	}
+	return true
+}
+
+// levelFor возвращает уровень для пакета, из которого вызван логгер
+func (f *filter) levelFor(entry *logrus.Entry) logrus.Level {
+	if len(f.packages) == 0 || !entry.HasCaller() {
+		return f.level
+	}
+
+	pkg := packageOf(entry.Caller.Function)
+	for _, p := range f.packages {
+		if pkg == p.prefix || strings.HasPrefix(pkg, p.prefix+"/") {
+			return p.level
+		}
+	}
+	return f.level
+}
+
+// packageOf выделяет путь пакета из полного имени функции,
+// например "lib/clients/broker/kafka.(*KafkaBroker).Close" -> "lib/clients/broker/kafka"
+func packageOf(function string) string {
+	slash := strings.LastIndex(function, "/")
+	if dot := strings.Index(function[slash+1:], "."); dot >= 0 {
+		return function[:slash+1+dot]
+	}
+	return function
+}
+
+type callSite struct {
+	file string
+	line int
+}
+
+// sampler считает записи по месту вызова в пределах текущей секунды
+type sampler struct {
+	mu     sync.Mutex
+	window time.Time
+	counts map[callSite]int
+}
+
+func newSampler() *sampler {
+	return &sampler{counts: make(map[callSite]int)}
+}
+
+func (s *sampler) allow(file string, line int, cfg Sampling) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(s.window) >= samplingTick {
+		s.window = now
+		clear(s.counts)
+	}
+
+	site := callSite{file: file, line: line}
+	s.counts[site]++
+	n := s.counts[site]
+	if n <= cfg.Initial {
+		return true
+	}
+	return cfg.Thereafter > 0 && (n-cfg.Initial)%cfg.Thereafter == 0
+}
@@ -8,22 +8,37 @@ import (
 	"runtime"
 	"strings"
 	"sync"
+	"sync/atomic"
 
 	"github.com/sirupsen/logrus"
 )
 
+// hookState — текущие настройки вывода; заменяется целиком в Configure
+type hookState struct {
+	formatter logrus.Formatter
+	filter    *filter
+}
+
 type writerHook struct {
 	Writer    []io.Writer
 	Loglevels []logrus.Level
+
+	state atomic.Pointer[hookState]
 }
 
 func (hook *writerHook) Fire(entry *logrus.Entry) error {
-	line, err := entry.String()
+	state := hook.state.Load()
+	if !state.filter.allow(entry) {
+		return nil
+	}
+
+	redactEntry(entry)
+	line, err := state.formatter.Format(entry)
 	if err != nil {
 		return err
 	}
 	for _, w := range hook.Writer {
-		w.Write([]byte(line))
+		w.Write(line)
 	}
 	return nil
 }
@@ -34,6 +49,7 @@ func (hook *writerHook) Levels() []logrus.Level {
 
 var (
 	e    *logrus.Entry
+	hook *writerHook
 	once sync.Once
 )
 
@@ -46,25 +62,19 @@ func InitLogger(levelStr string) *Logger {
 		l := logrus.New()
 
 		l.SetReportCaller(true)
-		l.SetFormatter(&logrus.TextFormatter{
-			CallerPrettyfier: func(frame *runtime.Frame) (function string, file string) {
-				filename := path.Base(frame.File)
-				return fmt.Sprintf("%s()", frame.Function), fmt.Sprintf("%s:%d", filename, frame.Line)
-			},
-			DisableColors: false,
-			FullTimestamp: true,
-		})
 
 		// Не создаём директорию logs и не открываем файлы
 
-		// Отключаем стандартный вывод
+		// Отключаем стандартный вывод: строки пишет хук, он же фильтрует и маскирует
 		l.SetOutput(io.Discard)
+		l.SetFormatter(discardFormatter{})
 
 		// Хук только на вывод в Stdout
-		l.AddHook(&writerHook{
+		hook = &writerHook{
 			Writer:    []io.Writer{os.Stdout},
 			Loglevels: logrus.AllLevels,
-		})
+		}
+		l.AddHook(hook)
 
 		// Устанавливаем уровень логирования
 		level, err := logrus.ParseLevel(strings.ToLower(levelStr))
@@ -72,6 +82,10 @@ func InitLogger(levelStr string) *Logger {
 			level = logrus.InfoLevel
 		}
 		l.SetLevel(level)
+		hook.state.Store(&hookState{
+			formatter: newFormatter(FormatText),
+			filter:    &filter{level: level},
+		})
 
 		e = logrus.NewEntry(l)
 	})
@@ -88,3 +102,27 @@ func GetLogger() *Logger {
 func (l *Logger) GetLoggerWithField(k string, v interface{}) *Logger {
 	return &Logger{l.WithField(k, v)}
 }
+
+func newFormatter(format string) logrus.Formatter {
+	prettyfier := func(frame *runtime.Frame) (function string, file string) {
+		filename := path.Base(frame.File)
+		return fmt.Sprintf("%s()", frame.Function), fmt.Sprintf("%s:%d", filename, frame.Line)
+	}
+
+	if format == FormatJSON {
+		return &logrus.JSONFormatter{CallerPrettyfier: prettyfier}
+	}
+	return &logrus.TextFormatter{
+		CallerPrettyfier: prettyfier,
+		DisableColors:    false,
+		FullTimestamp:    true,
+	}
+}
+
+// discardFormatter — форматтер основного вывода logrus: вывод всё равно
+// отброшен, поэтому не тратим время на повторное форматирование записи
+type discardFormatter struct{}
+
+func (discardFormatter) Format(*logrus.Entry) ([]byte, error) {
+	return nil, nil
+}
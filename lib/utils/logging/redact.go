@@ -0,0 +1,219 @@
+package logging
+
+import (
+	"net/url"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Mask заменяет значение секрета в логах
+const Mask = "***"
+
+// Имена полей структур (без учёта регистра), значения которых маскируются
+var secretFields = map[string]bool{
+	"password": true,
+	"secret":   true,
+	"token":    true,
+	"apikey":   true,
+}
+
+// Параметры запроса в URL, значения которых маскируются
+var secretParams = map[string]bool{
+	"password":     true,
+	"secret":       true,
+	"token":        true,
+	"access_token": true,
+	"apikey":       true,
+	"api_key":      true,
+	"key":          true,
+}
+
+var (
+	urlPattern = regexp.MustCompile(`[a-zA-Z][a-zA-Z0-9+.-]*://[^\s"'<>]+`)
+	// Сегмент пути, похожий на ключ API (Alchemy, Infura и т.п. кладут ключ в путь)
+	keySegment = regexp.MustCompile(`^[A-Za-z0-9_-]{20,}$`)
+)
+
+// RedactURL маскирует в URL пароль, ключи API в пути и секретные параметры запроса.
+// Строка, которая не разбирается как URL, возвращается без изменений.
+func RedactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return raw
+	}
+
+	if u.User != nil {
+		if _, ok := u.User.Password(); ok {
+			u.User = url.UserPassword(u.User.Username(), Mask)
+		}
+	}
+
+	segments := strings.Split(u.Path, "/")
+	for i, s := range segments {
+		if keySegment.MatchString(s) {
+			segments[i] = Mask
+		}
+	}
+	u.Path = strings.Join(segments, "/")
+	u.RawPath = ""
+
+	if u.RawQuery != "" {
+		query := u.Query()
+		for name := range query {
+			if secretParams[strings.ToLower(name)] {
+				query.Set(name, Mask)
+			}
+		}
+		u.RawQuery = query.Encode()
+	}
+
+	// url.String экранирует '*' в пути и запросе, возвращаем маске читаемый вид
+	return strings.ReplaceAll(u.String(), url.PathEscape(Mask), Mask)
+}
+
+// RedactString маскирует секреты во всех URL внутри строки
+func RedactString(s string) string {
+	if !strings.Contains(s, "://") {
+		return s
+	}
+	return urlPattern.ReplaceAllStringFunc(s, func(match string) string {
+		// Знаки препинания после URL ("dial https://...: eof") не относятся к нему
+		trimmed := strings.TrimRight(match, ".,;:)]}")
+		return RedactURL(trimmed) + match[len(trimmed):]
+	})
+}
+
+// Redact возвращает копию v, в которой строковые поля с тегом secret:"true"
+// или с именем вроде Password/ApiKey/Token заменены на Mask, а строковые
+// поля с URL очищены через RedactURL. Используется перед выводом конфигов в лог.
+func Redact(v any) any {
+	if v == nil {
+		return nil
+	}
+	return redactValue(reflect.ValueOf(v)).Interface()
+}
+
+func redactValue(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return v
+		}
+		copied := reflect.New(v.Elem().Type())
+		copied.Elem().Set(redactValue(v.Elem()))
+		return copied
+
+	case reflect.Struct:
+		copied := reflect.New(v.Type()).Elem()
+		copied.Set(v)
+		for i := range v.NumField() {
+			field := v.Type().Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			if isSecretField(field) {
+				if masked, ok := maskValue(v.Field(i)); ok {
+					copied.Field(i).Set(masked)
+					continue
+				}
+			}
+			copied.Field(i).Set(redactValue(v.Field(i)))
+		}
+		return copied
+
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		copied := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := range v.Len() {
+			copied.Index(i).Set(redactValue(v.Index(i)))
+		}
+		return copied
+
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		copied := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			copied.SetMapIndex(iter.Key(), redactValue(iter.Value()))
+		}
+		return copied
+
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		copied := reflect.New(v.Type()).Elem()
+		copied.Set(redactValue(v.Elem()))
+		return copied
+
+	case reflect.String:
+		if s := v.String(); strings.Contains(s, "://") {
+			return reflect.ValueOf(RedactString(s)).Convert(v.Type())
+		}
+	}
+	return v
+}
+
+// maskValue заменяет на Mask непустую строку или каждый элемент списка строк
+func maskValue(v reflect.Value) (reflect.Value, bool) {
+	switch {
+	case v.Kind() == reflect.String:
+		if v.String() == "" {
+			return v, true
+		}
+		return reflect.ValueOf(Mask).Convert(v.Type()), true
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.String:
+		if v.IsNil() {
+			return v, true
+		}
+		masked := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := range v.Len() {
+			if v.Index(i).String() != "" {
+				masked.Index(i).Set(reflect.ValueOf(Mask).Convert(v.Type().Elem()))
+			}
+		}
+		return masked, true
+	}
+	return v, false
+}
+
+func isSecretField(field reflect.StructField) bool {
+	if field.Tag.Get("secret") == "true" {
+		return true
+	}
+	return secretFields[strings.ToLower(field.Name)]
+}
+
+// redactEntry маскирует URL в сообщении и полях записи, а структуры
+// в полях заменяет копиями без секретов
+func redactEntry(entry *logrus.Entry) {
+	entry.Message = RedactString(entry.Message)
+	if len(entry.Data) == 0 {
+		return
+	}
+
+	data := make(logrus.Fields, len(entry.Data))
+	for k, v := range entry.Data {
+		switch value := v.(type) {
+		case string:
+			data[k] = RedactString(value)
+		case error:
+			data[k] = RedactString(value.Error())
+		default:
+			switch reflect.Indirect(reflect.ValueOf(v)).Kind() {
+			case reflect.Struct, reflect.Map, reflect.Slice:
+				data[k] = Redact(v)
+			default:
+				data[k] = v
+			}
+		}
+	}
+	entry.Data = data
+}
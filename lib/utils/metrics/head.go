@@ -0,0 +1,60 @@
+package metrics
+
+import (
+	"sync"
+	"time"
+)
+
+// HeadTracker считает отставание от головы цепи: голову сообщает коллектор,
+// последний отправленный блок — воркер передачи в брокер
+type HeadTracker struct {
+	network string
+
+	mu      sync.Mutex
+	head    uint64
+	headAt  time.Time
+	emitted uint64
+}
+
+// NewHeadTracker создаёт трекер для сети network
+func NewHeadTracker(network string) *HeadTracker {
+	return &HeadTracker{network: network}
+}
+
+// Head фиксирует номер нового блока в голове цепи
+func (t *HeadTracker) Head(number uint64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.head = max(t.head, number)
+	t.headAt = time.Now()
+	ChainHead.WithLabelValues(t.network).Set(float64(t.head))
+	t.updateLag()
+}
+
+// LastHeadAt возвращает время последнего вызова Head; нулевое — блоков ещё не было
+func (t *HeadTracker) LastHeadAt() time.Time {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.headAt
+}
+
+// Emitted фиксирует номер блока, переданного в брокер
+func (t *HeadTracker) Emitted(number uint64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.emitted = max(t.emitted, number)
+	LastEmitted.WithLabelValues(t.network).Set(float64(t.emitted))
+	t.updateLag()
+}
+
+func (t *HeadTracker) updateLag() {
+	// Пока ни один блок не отправлен, отставание не определено
+	if t.emitted == 0 || t.head < t.emitted {
+		HeadLag.WithLabelValues(t.network).Set(0)
+		return
+	}
+	HeadLag.WithLabelValues(t.network).Set(float64(t.head - t.emitted))
+}
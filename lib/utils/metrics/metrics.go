@@ -0,0 +1,191 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "blockhub"
+
+// Значения метки status
+const (
+	StatusOK    = "ok"
+	StatusError = "error"
+)
+
+// Бакеты для сетевых вызовов: от миллисекунд до десятков секунд
+var latencyBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30}
+
+// Провайдеры блокчейна
+var (
+	ProviderRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "provider",
+		Name:      "requests_total",
+		Help:      "Provider RPC calls by method and status.",
+	}, []string{"provider", "method", "status"})
+
+	ProviderLatency = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "provider",
+		Name:      "request_duration_seconds",
+		Help:      "Provider RPC call latency.",
+		Buckets:   latencyBuckets,
+	}, []string{"provider", "method"})
+)
+
+// Сбор блоков
+var (
+	BlocksCollected = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "collector",
+		Name:      "blocks_total",
+		Help:      "Blocks collected from the provider by collection mode.",
+	}, []string{"mode"})
+
+	BlockCollectDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "collector",
+		Name:      "collect_duration_seconds",
+		Help:      "Time to collect a block with receipts (or a whole batch).",
+		Buckets:   latencyBuckets,
+	}, []string{"mode"})
+
+	BatchSize = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "collector",
+		Name:      "batch_size_blocks",
+		Help:      "Current adaptive batch size in blocks per provider request.",
+	})
+
+	BatchBlockFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "collector",
+		Name:      "batch_block_failures_total",
+		Help:      "Blocks that failed within a batch attempt by failure status.",
+	}, []string{"status"})
+
+	BlockVerifications = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "collector",
+		Name:      "block_verifications_total",
+		Help:      "Checks of provider data against block header roots by result.",
+	}, []string{"provider", "result"})
+
+	ChainHead = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "collector",
+		Name:      "chain_head_block",
+		Help:      "Latest block number announced by the chain.",
+	}, []string{"network"})
+
+	LastEmitted = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "collector",
+		Name:      "last_emitted_block",
+		Help:      "Latest block number handed over to the broker.",
+	}, []string{"network"})
+
+	HeadLag = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "collector",
+		Name:      "head_lag_blocks",
+		Help:      "Chain head minus the last emitted block.",
+	}, []string{"network"})
+)
+
+// Брокер сообщений
+var (
+	BrokerProduced = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "broker",
+		Name:      "produced_messages_total",
+		Help:      "Messages sent to the broker by status.",
+	}, []string{"broker", "topic", "status"})
+
+	BrokerProduceLatency = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "broker",
+		Name:      "produce_duration_seconds",
+		Help:      "Latency of a send call (one message or one batch).",
+		Buckets:   latencyBuckets,
+	}, []string{"broker", "topic"})
+
+	BrokerConsumed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "broker",
+		Name:      "consumed_messages_total",
+		Help:      "Messages passed to consumer handlers by status.",
+	}, []string{"broker", "topic", "status"})
+
+	BrokerConsumeLatency = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "broker",
+		Name:      "handle_duration_seconds",
+		Help:      "Consumer handler latency (one message or one batch).",
+		Buckets:   latencyBuckets,
+	}, []string{"broker", "topic"})
+
+	OutboxPending = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "outbox",
+		Name:      "pending_messages",
+		Help:      "Messages written to the local outbox and not yet acknowledged by the broker.",
+	})
+
+	OutboxBytes = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "outbox",
+		Name:      "bytes",
+		Help:      "Disk space used by outbox segments.",
+	})
+)
+
+// ClickHouse
+var (
+	ClickhouseBatchSize = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "clickhouse",
+		Name:      "batch_rows",
+		Help:      "Rows per insert batch.",
+		Buckets:   prometheus.ExponentialBuckets(1, 4, 10),
+	}, []string{"table"})
+
+	ClickhouseInsertLatency = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "clickhouse",
+		Name:      "insert_duration_seconds",
+		Help:      "Latency of sending an insert batch.",
+		Buckets:   latencyBuckets,
+	}, []string{"table"})
+
+	ClickhouseInserts = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "clickhouse",
+		Name:      "inserts_total",
+		Help:      "Insert batches by status.",
+	}, []string{"table", "status"})
+)
+
+// Кэш
+var CacheRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Subsystem: "cache",
+	Name:      "requests_total",
+	Help:      "Cache lookups by result (hit, miss, error).",
+}, []string{"cache", "result"})
+
+// Status возвращает значение метки status для ошибки
+func Status(err error) string {
+	if err != nil {
+		return StatusError
+	}
+	return StatusOK
+}
+
+// Since возвращает прошедшее с start время в секундах для Observe
+func Since(start time.Time) float64 {
+	return time.Since(start).Seconds()
+}
@@ -0,0 +1,49 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"lib/utils/logging"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const shutdownTimeout = 5 * time.Second
+
+// Serve поднимает HTTP-сервер с /metrics на addr и останавливает его при отмене ctx.
+// mounts добавляют на тот же сервер другие служебные эндпоинты (например, health.Registry.Mount).
+// Пустой addr отключает сервер. Ошибки запуска пишутся в лог: сервис без метрик продолжает работать.
+func Serve(ctx context.Context, addr string, logger *logging.Logger, mounts ...func(*http.ServeMux)) {
+	if addr == "" {
+		logger.Info("Metrics endpoint disabled")
+		return
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	for _, mount := range mounts {
+		mount(mux)
+	}
+
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+
+	go func() {
+		logger.Infof("Metrics endpoint listening on %s/metrics", addr)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Errorf("Metrics endpoint stopped: %v", err)
+		}
+	}()
+}
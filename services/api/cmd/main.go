@@ -1,8 +1,77 @@
 package main
 
-import "lib/utils/logging"
+import (
+	"api/internal/server"
+	"api/internal/store"
+	"context"
+	"flag"
+	clickhouseClient "lib/clients/db/clickhouse"
+	"lib/decoding"
+	"lib/models"
+	"lib/utils/health"
+	"lib/utils/logging"
+	"lib/utils/metrics"
+	"os/signal"
+	"syscall"
+)
 
 func main() {
 	logger := logging.GetLogger()
 	logger.Info("Logger initialized successfully")
+
+	configPath := models.ConfigFlag(flag.CommandLine)
+	flag.Parse()
+	cfg := models.MustLoadConfig(*configPath, models.ServiceAPI, logger)
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// Эндпоинты /metrics, /healthz и /readyz
+	checks := health.New(cfg.Health, logger)
+	metrics.Serve(ctx, cfg.Metrics.Addr, logger, checks.Mount)
+
+	// API читает данные, которые сохраняет clickhouse-service
+	client, err := clickhouseClient.NewClient(ctx, cfg.Clickhouse)
+	if err != nil {
+		logger.Fatalf("Failed to initialize ClickHouse client: %v", err)
+	}
+	defer client.Close()
+
+	// Готовность: ClickHouse отвечает на ping
+	checks.Readiness("clickhouse", client.Ping)
+	go checks.Run(ctx)
+
+	// Реестр ABI и база сигнатур: файлы из конфига плюс таблицы в ClickHouse
+	abiStore := decoding.NewClickhouseStore(client)
+	abis := decoding.NewRegistry(abiStore)
+	signatures := decoding.NewSignatures(abiStore)
+	if dir := cfg.API.ABI.Dir; dir != "" {
+		n, err := abis.LoadDir(dir)
+		if err != nil {
+			logger.Fatalf("Failed to load ABIs: %v", err)
+		}
+		logger.Infof("Loaded %d ABIs from %s", n, dir)
+	}
+	if path := cfg.API.ABI.SignaturesFile; path != "" {
+		n, err := signatures.LoadFile(path)
+		if err != nil {
+			logger.Fatalf("Failed to load signatures: %v", err)
+		}
+		logger.Infof("Loaded %d signatures from %s", n, path)
+	}
+
+	srv := server.New(cfg.API.Listen, server.Deps{
+		Store:      store.New(client),
+		ABIs:       abis,
+		Signatures: signatures,
+		Decoder:    decoding.NewDecoder(abis, signatures),
+		AdminToken: cfg.API.ABI.AdminToken,
+	}, logger)
+	if cfg.API.ABI.AdminToken == "" {
+		logger.Info("Admin token is not set, ABI and signature writes are disabled")
+	}
+	if err := srv.Run(ctx); err != nil {
+		logger.Fatalf("API server stopped: %v", err)
+	}
+	logger.Info("Shutdown signal received")
 }
@@ -0,0 +1,74 @@
+package server
+
+import (
+	"net/http"
+
+	"api/internal/store"
+	"lib/accounts"
+)
+
+// handleAccountState — GET /v1/accounts/{address}/balance?block=
+func (s *Server) handleAccountState(w http.ResponseWriter, r *http.Request) {
+	address, err := accountAddress(r)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+	block, err := parseUint("block", r.URL.Query().Get("block"), 0)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	state, err := s.Store.AccountState(r.Context(), address, block)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, state)
+}
+
+// handleAccountHistory — GET /v1/accounts/{address}/history?from=&to=&limit=
+func (s *Server) handleAccountHistory(w http.ResponseWriter, r *http.Request) {
+	address, err := accountAddress(r)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	q := r.URL.Query()
+	var f store.HistoryFilter
+	if f.From, err = parseUint("from", q.Get("from"), 0); err != nil {
+		s.writeError(w, err)
+		return
+	}
+	if f.To, err = parseUint("to", q.Get("to"), 0); err != nil {
+		s.writeError(w, err)
+		return
+	}
+	if f.To > 0 && f.To < f.From {
+		s.writeError(w, badRequest("to %d is less than from %d", f.To, f.From))
+		return
+	}
+	if f.Limit, err = parseLimit(q.Get("limit")); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	states, err := s.Store.AccountHistory(r.Context(), address, f)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, states)
+}
+
+// accountAddress читает адрес из пути и приводит его к checksum-виду,
+// чтобы выборка шла по первичному ключу account_states
+func accountAddress(r *http.Request) (string, error) {
+	address := r.PathValue("address")
+	if !isAddress(address) {
+		return "", badRequest("invalid address %q", address)
+	}
+	return accounts.Checksum(address), nil
+}
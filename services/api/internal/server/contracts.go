@@ -0,0 +1,52 @@
+package server
+
+import (
+	"net/http"
+
+	"api/internal/store"
+	"lib/models"
+)
+
+// handleContract — GET /v1/contracts/{address}
+func (s *Server) handleContract(w http.ResponseWriter, r *http.Request) {
+	address := r.PathValue("address")
+	if !isAddress(address) {
+		s.writeError(w, badRequest("invalid contract address %q", address))
+		return
+	}
+
+	contract, err := s.Store.Contract(r.Context(), address)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, contract)
+}
+
+// handleContracts — GET /v1/contracts?deployer=&standard=&limit=
+func (s *Server) handleContracts(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	var f store.ContractFilter
+	var err error
+	if f.Deployer = q.Get("deployer"); f.Deployer != "" && !isAddress(f.Deployer) {
+		s.writeError(w, badRequest("invalid deployer address %q", f.Deployer))
+		return
+	}
+	switch f.Standard = q.Get("standard"); f.Standard {
+	case "", models.ContractStandardERC20, models.ContractStandardERC721, models.ContractStandardERC1155:
+	default:
+		s.writeError(w, badRequest("unknown standard %q", f.Standard))
+		return
+	}
+	if f.Limit, err = parseLimit(q.Get("limit")); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	contracts, err := s.Store.Contracts(r.Context(), f)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, contracts)
+}
@@ -0,0 +1,131 @@
+package server
+
+import (
+	"io"
+	"net/http"
+
+	"lib/decoding"
+	"lib/models"
+)
+
+// handleGetABI — GET /v1/abis/{address}
+func (s *Server) handleGetABI(w http.ResponseWriter, r *http.Request) {
+	contract, err := s.ABIs.Get(r.Context(), r.PathValue("address"))
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, contract)
+}
+
+// handlePutABI — PUT /v1/abis/{address}?name=; тело — JSON ABI или артефакт сборки
+func (s *Server) handlePutABI(w http.ResponseWriter, r *http.Request) {
+	raw, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+	if err != nil {
+		s.writeError(w, badRequest("read request body: %v", err))
+		return
+	}
+
+	contract, err := s.ABIs.Register(r.Context(), r.PathValue("address"), r.URL.Query().Get("name"), raw)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, contract)
+}
+
+type signaturesResponse struct {
+	Kind       string   `json:"kind"`
+	Hash       string   `json:"hash,omitempty"`
+	Signatures []string `json:"signatures"`
+}
+
+// handleGetSignatures — GET /v1/signatures/{kind}/{hash}: сигнатуры
+// по 4-байтовому селектору (function) или topic0 (event)
+func (s *Server) handleGetSignatures(w http.ResponseWriter, r *http.Request) {
+	kind, hash := r.PathValue("kind"), r.PathValue("hash")
+	if err := checkKind(kind); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	sigs, err := s.Signatures.Lookup(r.Context(), kind, hash)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+	if len(sigs) == 0 {
+		s.writeError(w, decoding.ErrUnknown)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, signaturesResponse{Kind: kind, Hash: hash, Signatures: sigs})
+}
+
+// handlePostSignatures — POST /v1/signatures/{kind} {"signatures": ["transfer(address,uint256)"]}
+func (s *Server) handlePostSignatures(w http.ResponseWriter, r *http.Request) {
+	kind := r.PathValue("kind")
+	if err := checkKind(kind); err != nil {
+		s.writeError(w, err)
+		return
+	}
+	var req struct {
+		Signatures []string `json:"signatures"`
+	}
+	if err := readJSON(w, r, &req); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	sigs, err := s.Signatures.Register(r.Context(), kind, req.Signatures)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, signaturesResponse{Kind: kind, Signatures: sigs})
+}
+
+// handleDecodeLog — POST /v1/decode/log; тело — лог в формате models.Log
+func (s *Server) handleDecodeLog(w http.ResponseWriter, r *http.Request) {
+	var log models.Log
+	if err := readJSON(w, r, &log); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	event, err := s.Decoder.DecodeLog(r.Context(), log)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, event)
+}
+
+// handleDecodeInput — POST /v1/decode/input {"to": "0x…", "input": "0x…"}
+func (s *Server) handleDecodeInput(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		To    string `json:"to"`
+		Input string `json:"input"`
+	}
+	if err := readJSON(w, r, &req); err != nil {
+		s.writeError(w, err)
+		return
+	}
+	if req.To != "" && !isAddress(req.To) {
+		s.writeError(w, badRequest("invalid contract address %q", req.To))
+		return
+	}
+
+	call, err := s.Decoder.DecodeInput(r.Context(), req.To, req.Input)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, call)
+}
+
+func checkKind(kind string) error {
+	if kind != decoding.KindFunction && kind != decoding.KindEvent {
+		return badRequest("kind must be %s or %s, got %q", decoding.KindFunction, decoding.KindEvent, kind)
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package server
+
+import (
+	"net/http"
+
+	"api/internal/store"
+)
+
+// handleBlockReward — GET /v1/blocks/{number}/rewards
+func (s *Server) handleBlockReward(w http.ResponseWriter, r *http.Request) {
+	number, err := parseUint("block number", r.PathValue("number"), 0)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	reward, err := s.Store.BlockReward(r.Context(), number)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, reward)
+}
+
+// handleBlockRewards — GET /v1/rewards?from=&to=&miner=&limit=
+func (s *Server) handleBlockRewards(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	var f store.RewardFilter
+	var err error
+	if f.From, err = parseUint("from", q.Get("from"), 0); err != nil {
+		s.writeError(w, err)
+		return
+	}
+	if f.To, err = parseUint("to", q.Get("to"), 0); err != nil {
+		s.writeError(w, err)
+		return
+	}
+	if f.To > 0 && f.To < f.From {
+		s.writeError(w, badRequest("to %d is less than from %d", f.To, f.From))
+		return
+	}
+	if f.Miner = q.Get("miner"); f.Miner != "" && !isAddress(f.Miner) {
+		s.writeError(w, badRequest("invalid miner address %q", f.Miner))
+		return
+	}
+	if f.Limit, err = parseLimit(q.Get("limit")); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	rewards, err := s.Store.BlockRewards(r.Context(), f)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, rewards)
+}
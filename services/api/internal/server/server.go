@@ -0,0 +1,210 @@
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"api/internal/store"
+	"lib/decoding"
+	"lib/models"
+	"lib/utils/logging"
+)
+
+const (
+	shutdownTimeout = 5 * time.Second
+	defaultLimit    = 100
+	maxLimit        = 1000
+	maxBodyBytes    = 4 << 20
+)
+
+// Deps — зависимости обработчиков
+type Deps struct {
+	Store      *store.Store
+	ABIs       *decoding.Registry
+	Signatures *decoding.Signatures
+	Decoder    *decoding.Decoder
+
+	// AdminToken открывает запись ABI и сигнатур; пустой — запись отключена
+	AdminToken string
+}
+
+// Server — HTTP API BlockHub: чтение сохранённых данных из ClickHouse
+// и декодирование логов и input транзакций
+type Server struct {
+	Deps
+	listen models.Listen
+	logger *logging.Logger
+	mux    *http.ServeMux
+}
+
+func New(listen models.Listen, deps Deps, logger *logging.Logger) *Server {
+	s := &Server{
+		Deps:   deps,
+		listen: listen,
+		logger: logger,
+		mux:    http.NewServeMux(),
+	}
+	s.routes()
+	return s
+}
+
+func (s *Server) routes() {
+	s.mux.HandleFunc("GET /v1/blocks/{number}/rewards", s.handleBlockReward)
+	s.mux.HandleFunc("GET /v1/rewards", s.handleBlockRewards)
+
+	s.mux.HandleFunc("GET /v1/contracts/{address}", s.handleContract)
+	s.mux.HandleFunc("GET /v1/contracts", s.handleContracts)
+
+	s.mux.HandleFunc("GET /v1/accounts/{address}/balance", s.handleAccountState)
+	s.mux.HandleFunc("GET /v1/accounts/{address}/history", s.handleAccountHistory)
+
+	s.mux.HandleFunc("GET /v1/abis/{address}", s.handleGetABI)
+	s.mux.HandleFunc("PUT /v1/abis/{address}", s.admin(s.handlePutABI))
+	s.mux.HandleFunc("GET /v1/signatures/{kind}/{hash}", s.handleGetSignatures)
+	s.mux.HandleFunc("POST /v1/signatures/{kind}", s.admin(s.handlePostSignatures))
+	s.mux.HandleFunc("POST /v1/decode/log", s.handleDecodeLog)
+	s.mux.HandleFunc("POST /v1/decode/input", s.handleDecodeInput)
+}
+
+// Run принимает запросы до отмены ctx, затем останавливает сервер
+func (s *Server) Run(ctx context.Context) error {
+	server := &http.Server{
+		Addr:              net.JoinHostPort(s.listen.BindIP, s.listen.Port),
+		Handler:           s.mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+
+	s.logger.Infof("API listening on %s (%s)", server.Addr, s.listen.Type)
+	var err error
+	if strings.EqualFold(s.listen.Type, "https") {
+		err = server.ListenAndServeTLS(s.listen.CertFile, s.listen.KeyFile)
+	} else {
+		err = server.ListenAndServe()
+	}
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// admin пропускает запрос только с заголовком Authorization: Bearer <AdminToken>;
+// без настроенного токена эндпоинт закрыт
+func (s *Server) admin(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.AdminToken == "" {
+			s.writeJSON(w, http.StatusForbidden, errorResponse{Error: "writes are disabled: admin token is not configured"})
+			return
+		}
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.AdminToken)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			s.writeJSON(w, http.StatusUnauthorized, errorResponse{Error: "invalid admin token"})
+			return
+		}
+		next(w, r)
+	}
+}
+
+// writeJSON отправляет ответ в JSON
+func (s *Server) writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		s.logger.Errorf("Failed to write response: %v", err)
+	}
+}
+
+// writeError отправляет ошибку; ошибки базы пишутся в лог, клиенту уходит общее сообщение
+func (s *Server) writeError(w http.ResponseWriter, err error) {
+	var badRequest *badRequestError
+	switch {
+	case errors.As(err, &badRequest),
+		errors.Is(err, decoding.ErrInvalidABI),
+		errors.Is(err, decoding.ErrInvalidSignature),
+		errors.Is(err, decoding.ErrMalformed):
+		s.writeJSON(w, http.StatusBadRequest, errorResponse{Error: err.Error()})
+	case errors.Is(err, store.ErrNotFound),
+		errors.Is(err, decoding.ErrNotFound),
+		errors.Is(err, decoding.ErrUnknown):
+		s.writeJSON(w, http.StatusNotFound, errorResponse{Error: err.Error()})
+	default:
+		s.logger.Errorf("Request failed: %v", err)
+		s.writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "internal error"})
+	}
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// badRequestError — некорректные параметры запроса
+type badRequestError struct {
+	msg string
+}
+
+func (e *badRequestError) Error() string { return e.msg }
+
+func badRequest(format string, args ...any) error {
+	return &badRequestError{msg: fmt.Sprintf(format, args...)}
+}
+
+// readJSON разбирает тело запроса
+func readJSON(w http.ResponseWriter, r *http.Request, v any) error {
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+	if err := decoder.Decode(v); err != nil {
+		return badRequest("invalid request body: %v", err)
+	}
+	return nil
+}
+
+// parseUint разбирает числовой параметр; пустое значение — def
+func parseUint(name, value string, def uint64) (uint64, error) {
+	if value == "" {
+		return def, nil
+	}
+	n, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, badRequest("%s must be a non-negative integer, got %q", name, value)
+	}
+	return n, nil
+}
+
+// parseLimit разбирает параметр limit: по умолчанию defaultLimit, не больше maxLimit
+func parseLimit(value string) (int, error) {
+	limit, err := parseUint("limit", value, defaultLimit)
+	if err != nil {
+		return 0, err
+	}
+	if limit == 0 || limit > maxLimit {
+		return 0, badRequest("limit must be between 1 and %d, got %d", maxLimit, limit)
+	}
+	return int(limit), nil
+}
+
+// isAddress проверяет, что s — адрес вида 0x + 40 hex-символов
+func isAddress(s string) bool {
+	if len(s) != 42 || !strings.HasPrefix(s, "0x") {
+		return false
+	}
+	for _, c := range s[2:] {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') && (c < 'A' || c > 'F') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,174 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"api/internal/store"
+	clientsDB "lib/clients/db"
+	"lib/decoding"
+	"lib/models"
+	"lib/utils/logging"
+)
+
+const (
+	adminToken = "secret"
+	tokenAddr  = "0x00000000000000000000000000000000000000A0"
+	tokenABI   = `[{"type":"function","name":"transfer","stateMutability":"nonpayable",` +
+		`"inputs":[{"name":"to","type":"address"},{"name":"amount","type":"uint256"}],"outputs":[]}]`
+	// transfer(0x…b2, 1000)
+	transferInput = "0xa9059cbb00000000000000000000000000000000000000000000000000000000000000b2" +
+		"00000000000000000000000000000000000000000000000000000000000003e8"
+)
+
+// emptyClickhouse — ClickHouse без данных: любая выборка пуста или падает с err
+type emptyClickhouse struct {
+	clientsDB.ClickhouseClient
+	err     error
+	selects int
+}
+
+func (c *emptyClickhouse) Select(context.Context, any, string, ...any) error {
+	c.selects++
+	return c.err
+}
+
+func newTestServer(t *testing.T, token string, db *emptyClickhouse) *Server {
+	t.Helper()
+	abis := decoding.NewRegistry(nil)
+	sigs := decoding.NewSignatures(nil)
+	return New(models.Listen{}, Deps{
+		Store:      store.New(db),
+		ABIs:       abis,
+		Signatures: sigs,
+		Decoder:    decoding.NewDecoder(abis, sigs),
+		AdminToken: token,
+	}, logging.GetLogger())
+}
+
+type request struct {
+	method, path, body, auth string
+}
+
+func (s *Server) do(req request) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(req.method, req.path, strings.NewReader(req.body))
+	if req.auth != "" {
+		r.Header.Set("Authorization", req.auth)
+	}
+	w := httptest.NewRecorder()
+	s.mux.ServeHTTP(w, r)
+	return w
+}
+
+func TestAdminGate(t *testing.T) {
+	bearer := "Bearer " + adminToken
+	tests := []struct {
+		name   string
+		token  string
+		req    request
+		status int
+	}{
+		{"writes disabled without token", "", request{"PUT", "/v1/abis/" + tokenAddr, tokenABI, bearer}, http.StatusForbidden},
+		{"signatures disabled without token", "", request{"POST", "/v1/signatures/event", `{"signatures":["Foo(uint256)"]}`, bearer}, http.StatusForbidden},
+		{"no authorization", adminToken, request{"PUT", "/v1/abis/" + tokenAddr, tokenABI, ""}, http.StatusUnauthorized},
+		{"wrong token", adminToken, request{"PUT", "/v1/abis/" + tokenAddr, tokenABI, "Bearer other"}, http.StatusUnauthorized},
+		{"not a bearer token", adminToken, request{"PUT", "/v1/abis/" + tokenAddr, tokenABI, adminToken}, http.StatusUnauthorized},
+		{"token prefix", adminToken, request{"PUT", "/v1/abis/" + tokenAddr, tokenABI, "Bearer secre"}, http.StatusUnauthorized},
+		{"valid token", adminToken, request{"PUT", "/v1/abis/" + tokenAddr + "?name=Token", tokenABI, bearer}, http.StatusOK},
+		{"invalid abi", adminToken, request{"PUT", "/v1/abis/" + tokenAddr, `[{"type":`, bearer}, http.StatusBadRequest},
+		{"invalid address", adminToken, request{"PUT", "/v1/abis/token", tokenABI, bearer}, http.StatusBadRequest},
+		{"signatures", adminToken, request{"POST", "/v1/signatures/event", `{"signatures":["Foo(uint256)"]}`, bearer}, http.StatusOK},
+		{"invalid signature", adminToken, request{"POST", "/v1/signatures/event", `{"signatures":["Foo(uint7"]}`, bearer}, http.StatusBadRequest},
+		{"unknown kind", adminToken, request{"POST", "/v1/signatures/error", `{"signatures":["Foo()"]}`, bearer}, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		w := newTestServer(t, tt.token, &emptyClickhouse{}).do(tt.req)
+		if w.Code != tt.status {
+			t.Errorf("%s: status %d (%s), want %d", tt.name, w.Code, w.Body, tt.status)
+		}
+		if w.Code == http.StatusUnauthorized && w.Header().Get("WWW-Authenticate") != "Bearer" {
+			t.Errorf("%s: 401 without WWW-Authenticate", tt.name)
+		}
+	}
+}
+
+func TestRegisteredABIDecodes(t *testing.T) {
+	s := newTestServer(t, adminToken, &emptyClickhouse{})
+	if w := s.do(request{"PUT", "/v1/abis/" + tokenAddr + "?name=Token", tokenABI, "Bearer " + adminToken}); w.Code != http.StatusOK {
+		t.Fatalf("put abi: status %d (%s)", w.Code, w.Body)
+	}
+
+	w := s.do(request{method: "GET", path: "/v1/abis/" + strings.ToLower(tokenAddr)})
+	var contract models.ContractABI
+	if err := json.Unmarshal(w.Body.Bytes(), &contract); err != nil || w.Code != http.StatusOK || contract.Name != "Token" {
+		t.Errorf("get abi: status %d, %+v (%v)", w.Code, contract, err)
+	}
+
+	w = s.do(request{method: "POST", path: "/v1/decode/input", body: `{"to":"` + tokenAddr + `","input":"` + transferInput + `"}`})
+	var call models.DecodedCall
+	if err := json.Unmarshal(w.Body.Bytes(), &call); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("decode input: status %d (%s)", w.Code, w.Body)
+	}
+	if call.Source != models.DecodeSourceABI || len(call.Args) != 2 || call.Args[1].Name != "amount" || call.Args[1].Value != "1000" {
+		t.Errorf("decoded call = %+v, want transfer by abi", call)
+	}
+}
+
+func TestErrorStatuses(t *testing.T) {
+	tests := []struct {
+		name   string
+		req    request
+		status int
+	}{
+		{"unknown abi", request{method: "GET", path: "/v1/abis/" + tokenAddr}, http.StatusNotFound},
+		{"abi of invalid address", request{method: "GET", path: "/v1/abis/token"}, http.StatusBadRequest},
+		{"unknown signature", request{method: "GET", path: "/v1/signatures/function/0xdeadbeef"}, http.StatusNotFound},
+		{"builtin signature", request{method: "GET", path: "/v1/signatures/function/0xa9059cbb"}, http.StatusOK},
+		{"signature of unknown kind", request{method: "GET", path: "/v1/signatures/error/0xdeadbeef"}, http.StatusBadRequest},
+		{"decode body is not json", request{method: "POST", path: "/v1/decode/log", body: "{"}, http.StatusBadRequest},
+		{"decode malformed topic", request{method: "POST", path: "/v1/decode/log", body: `{"topics":["0x12"]}`}, http.StatusBadRequest},
+		{"decode unknown event", request{method: "POST", path: "/v1/decode/log", body: `{"topics":["0x` + strings.Repeat("11", 32) + `"]}`}, http.StatusNotFound},
+		{"decode input by signature", request{method: "POST", path: "/v1/decode/input", body: `{"input":"` + transferInput + `"}`}, http.StatusOK},
+		{"decode input with invalid to", request{method: "POST", path: "/v1/decode/input", body: `{"to":"0x12","input":"0x"}`}, http.StatusBadRequest},
+		{"decode unknown selector", request{method: "POST", path: "/v1/decode/input", body: `{"input":"0xdeadbeef"}`}, http.StatusNotFound},
+		{"reward of missing block", request{method: "GET", path: "/v1/blocks/5/rewards"}, http.StatusNotFound},
+		{"reward of invalid block", request{method: "GET", path: "/v1/blocks/-1/rewards"}, http.StatusBadRequest},
+		{"rewards with reversed range", request{method: "GET", path: "/v1/rewards?from=5&to=1"}, http.StatusBadRequest},
+		{"rewards with zero limit", request{method: "GET", path: "/v1/rewards?limit=0"}, http.StatusBadRequest},
+		{"rewards with invalid miner", request{method: "GET", path: "/v1/rewards?miner=miner"}, http.StatusBadRequest},
+		{"rewards", request{method: "GET", path: "/v1/rewards?from=1&to=5&limit=10"}, http.StatusOK},
+		{"missing contract", request{method: "GET", path: "/v1/contracts/" + tokenAddr}, http.StatusNotFound},
+		{"contract of invalid address", request{method: "GET", path: "/v1/contracts/0x12"}, http.StatusBadRequest},
+		{"balance of invalid address", request{method: "GET", path: "/v1/accounts/0x12/balance"}, http.StatusBadRequest},
+		{"balance at invalid block", request{method: "GET", path: "/v1/accounts/" + tokenAddr + "/balance?block=x"}, http.StatusBadRequest},
+		{"history above max limit", request{method: "GET", path: "/v1/accounts/" + tokenAddr + "/history?limit=1001"}, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		db := &emptyClickhouse{}
+		w := newTestServer(t, "", db).do(tt.req)
+		if w.Code != tt.status {
+			t.Errorf("%s: status %d (%s), want %d", tt.name, w.Code, w.Body, tt.status)
+		}
+		// Некорректный запрос не доходит до базы
+		if w.Code == http.StatusBadRequest && db.selects != 0 {
+			t.Errorf("%s: %d selects for a bad request", tt.name, db.selects)
+		}
+		var body errorResponse
+		if w.Code != http.StatusOK && (json.Unmarshal(w.Body.Bytes(), &body) != nil || body.Error == "") {
+			t.Errorf("%s: error body %q", tt.name, w.Body)
+		}
+	}
+}
+
+func TestStoreErrorIsHidden(t *testing.T) {
+	db := &emptyClickhouse{err: errors.New("dial tcp 10.0.0.1:9000: connection refused")}
+	w := newTestServer(t, "", db).do(request{method: "GET", path: "/v1/blocks/5/rewards"})
+	if w.Code != http.StatusInternalServerError || strings.Contains(w.Body.String(), "10.0.0.1") {
+		t.Errorf("status %d (%s), want 500 without database details", w.Code, w.Body)
+	}
+}
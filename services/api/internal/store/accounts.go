@@ -0,0 +1,91 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"lib/models"
+)
+
+const accountStatesTable = "account_states"
+
+// Баланс хранится как UInt256 и читается десятичной строкой
+const accountColumns = `address, toString(balance) AS balance, nonce,
+	block_number, block_hash, block_timestamp`
+
+// HistoryFilter — изменения состояния адреса в диапазоне блоков [From, To].
+// Нулевой To — без верхней границы.
+type HistoryFilter struct {
+	From  uint64
+	To    uint64
+	Limit int
+}
+
+type accountRow struct {
+	Address        string    `ch:"address"`
+	Balance        string    `ch:"balance"`
+	Nonce          uint64    `ch:"nonce"`
+	BlockNumber    uint64    `ch:"block_number"`
+	BlockHash      string    `ch:"block_hash"`
+	BlockTimestamp time.Time `ch:"block_timestamp"`
+}
+
+func (r accountRow) model() models.AccountState {
+	return models.AccountState{
+		Address:        r.Address,
+		Balance:        r.Balance,
+		Nonce:          uint(r.Nonce),
+		BlockNumber:    uint(r.BlockNumber),
+		BlockHash:      r.BlockHash,
+		BlockTimestamp: r.BlockTimestamp.UTC(),
+	}
+}
+
+// AccountState возвращает состояние адреса на блоке block — последнее
+// изменение не позже него; нулевой block — последнее известное состояние.
+// address — в checksum-виде, как в таблице.
+func (s *Store) AccountState(ctx context.Context, address string, block uint64) (models.AccountState, error) {
+	where := "address = ?"
+	args := []any{address}
+	if block > 0 {
+		where += " AND block_number <= ?"
+		args = append(args, block)
+	}
+
+	var rows []accountRow
+	query := "SELECT " + accountColumns + " FROM " + accountStatesTable + " FINAL WHERE " + where +
+		" ORDER BY block_number DESC LIMIT 1"
+	if err := s.client.Select(ctx, &rows, query, args...); err != nil {
+		return models.AccountState{}, fmt.Errorf("select account state: %w", err)
+	}
+	if len(rows) == 0 {
+		return models.AccountState{}, fmt.Errorf("state of %s: %w", address, ErrNotFound)
+	}
+	return rows[0].model(), nil
+}
+
+// AccountHistory возвращает изменения состояния адреса по фильтру в порядке блоков
+func (s *Store) AccountHistory(ctx context.Context, address string, f HistoryFilter) ([]models.AccountState, error) {
+	where := []string{"address = ?", "block_number >= ?"}
+	args := []any{address, f.From}
+	if f.To > 0 {
+		where = append(where, "block_number <= ?")
+		args = append(args, f.To)
+	}
+	args = append(args, f.Limit)
+
+	query := "SELECT " + accountColumns + " FROM " + accountStatesTable + " FINAL WHERE " +
+		strings.Join(where, " AND ") + " ORDER BY block_number LIMIT ?"
+
+	var rows []accountRow
+	if err := s.client.Select(ctx, &rows, query, args...); err != nil {
+		return nil, fmt.Errorf("select account history: %w", err)
+	}
+	states := make([]models.AccountState, len(rows))
+	for i, row := range rows {
+		states[i] = row.model()
+	}
+	return states, nil
+}
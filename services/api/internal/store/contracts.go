@@ -0,0 +1,101 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"lib/models"
+)
+
+const contractsTable = "contracts"
+
+const contractColumns = `address, deployer, tx_from, creation_type, source,
+	ifNull(code_hash, '') AS code_hash, code_size, standards,
+	block_number, block_hash, transaction_hash, block_timestamp`
+
+// ContractFilter — выборка контрактов по деплоеру и интерфейсу токена.
+// Пустые поля не ограничивают выборку.
+type ContractFilter struct {
+	Deployer string
+	Standard string
+	Limit    int
+}
+
+type contractRow struct {
+	Address         string    `ch:"address"`
+	Deployer        string    `ch:"deployer"`
+	TxFrom          string    `ch:"tx_from"`
+	CreationType    string    `ch:"creation_type"`
+	Source          string    `ch:"source"`
+	CodeHash        string    `ch:"code_hash"`
+	CodeSize        uint32    `ch:"code_size"`
+	Standards       []string  `ch:"standards"`
+	BlockNumber     uint64    `ch:"block_number"`
+	BlockHash       string    `ch:"block_hash"`
+	TransactionHash string    `ch:"transaction_hash"`
+	BlockTimestamp  time.Time `ch:"block_timestamp"`
+}
+
+func (r contractRow) model() models.Contract {
+	return models.Contract{
+		Address:         r.Address,
+		Deployer:        r.Deployer,
+		TxFrom:          r.TxFrom,
+		CreationType:    r.CreationType,
+		Source:          r.Source,
+		CodeHash:        r.CodeHash,
+		CodeSize:        uint(r.CodeSize),
+		Standards:       r.Standards,
+		BlockNumber:     uint(r.BlockNumber),
+		BlockHash:       r.BlockHash,
+		TransactionHash: r.TransactionHash,
+		BlockTimestamp:  r.BlockTimestamp.UTC(),
+	}
+}
+
+// Contract возвращает контракт по адресу
+func (s *Store) Contract(ctx context.Context, address string) (models.Contract, error) {
+	var rows []contractRow
+	query := "SELECT " + contractColumns + " FROM " + contractsTable + " FINAL WHERE lower(address) = lower(?) LIMIT 1"
+	if err := s.client.Select(ctx, &rows, query, address); err != nil {
+		return models.Contract{}, fmt.Errorf("select contract: %w", err)
+	}
+	if len(rows) == 0 {
+		return models.Contract{}, fmt.Errorf("contract %s: %w", address, ErrNotFound)
+	}
+	return rows[0].model(), nil
+}
+
+// Contracts возвращает контракты по фильтру в порядке создания
+func (s *Store) Contracts(ctx context.Context, f ContractFilter) ([]models.Contract, error) {
+	var where []string
+	var args []any
+	if f.Deployer != "" {
+		// Адреса хранятся в checksum-виде, фильтр не зависит от регистра
+		where = append(where, "lower(deployer) = lower(?)")
+		args = append(args, f.Deployer)
+	}
+	if f.Standard != "" {
+		where = append(where, "has(standards, ?)")
+		args = append(args, f.Standard)
+	}
+	args = append(args, f.Limit)
+
+	query := "SELECT " + contractColumns + " FROM " + contractsTable + " FINAL"
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY block_number, address LIMIT ?"
+
+	var rows []contractRow
+	if err := s.client.Select(ctx, &rows, query, args...); err != nil {
+		return nil, fmt.Errorf("select contracts: %w", err)
+	}
+	contracts := make([]models.Contract, len(rows))
+	for i, row := range rows {
+		contracts[i] = row.model()
+	}
+	return contracts, nil
+}
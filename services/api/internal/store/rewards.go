@@ -0,0 +1,104 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"lib/models"
+)
+
+const blockRewardsTable = "block_rewards"
+
+// Суммы хранятся как UInt256 и читаются десятичными строками
+const rewardColumns = `block_number, block_hash, miner,
+	toString(static_reward) AS static_reward,
+	toString(uncle_inclusion_reward) AS uncle_inclusion_reward,
+	toString(uncles_reward) AS uncles_reward,
+	toString(priority_fees) AS priority_fees,
+	toString(base_fee_burned) AS base_fee_burned,
+	toString(blob_fees_burned) AS blob_fees_burned,
+	toString(miner_reward) AS miner_reward,
+	block_timestamp`
+
+// RewardFilter — выборка наград по диапазону блоков [From, To] и получателю комиссий.
+// Нулевой To — без верхней границы, пустой Miner — все получатели.
+type RewardFilter struct {
+	From  uint64
+	To    uint64
+	Miner string
+	Limit int
+}
+
+type rewardRow struct {
+	BlockNumber          uint64    `ch:"block_number"`
+	BlockHash            string    `ch:"block_hash"`
+	Miner                string    `ch:"miner"`
+	StaticReward         string    `ch:"static_reward"`
+	UncleInclusionReward string    `ch:"uncle_inclusion_reward"`
+	UnclesReward         string    `ch:"uncles_reward"`
+	PriorityFees         string    `ch:"priority_fees"`
+	BaseFeeBurned        string    `ch:"base_fee_burned"`
+	BlobFeesBurned       string    `ch:"blob_fees_burned"`
+	MinerReward          string    `ch:"miner_reward"`
+	BlockTimestamp       time.Time `ch:"block_timestamp"`
+}
+
+func (r rewardRow) model() models.BlockReward {
+	return models.BlockReward{
+		BlockNumber:          uint(r.BlockNumber),
+		BlockHash:            r.BlockHash,
+		Miner:                r.Miner,
+		StaticReward:         r.StaticReward,
+		UncleInclusionReward: r.UncleInclusionReward,
+		UnclesReward:         r.UnclesReward,
+		PriorityFees:         r.PriorityFees,
+		BaseFeeBurned:        r.BaseFeeBurned,
+		BlobFeesBurned:       r.BlobFeesBurned,
+		MinerReward:          r.MinerReward,
+		BlockTimestamp:       r.BlockTimestamp.UTC(),
+	}
+}
+
+// BlockReward возвращает экономику блока number
+func (s *Store) BlockReward(ctx context.Context, number uint64) (models.BlockReward, error) {
+	var rows []rewardRow
+	query := "SELECT " + rewardColumns + " FROM " + blockRewardsTable + " FINAL WHERE block_number = ? LIMIT 1"
+	if err := s.client.Select(ctx, &rows, query, number); err != nil {
+		return models.BlockReward{}, fmt.Errorf("select block rewards: %w", err)
+	}
+	if len(rows) == 0 {
+		return models.BlockReward{}, fmt.Errorf("rewards of block %d: %w", number, ErrNotFound)
+	}
+	return rows[0].model(), nil
+}
+
+// BlockRewards возвращает экономику блоков по фильтру в порядке номеров
+func (s *Store) BlockRewards(ctx context.Context, f RewardFilter) ([]models.BlockReward, error) {
+	where := []string{"block_number >= ?"}
+	args := []any{f.From}
+	if f.To > 0 {
+		where = append(where, "block_number <= ?")
+		args = append(args, f.To)
+	}
+	if f.Miner != "" {
+		// Адреса хранятся в checksum-виде, фильтр не зависит от регистра
+		where = append(where, "lower(miner) = lower(?)")
+		args = append(args, f.Miner)
+	}
+	args = append(args, f.Limit)
+
+	query := "SELECT " + rewardColumns + " FROM " + blockRewardsTable + " FINAL WHERE " +
+		strings.Join(where, " AND ") + " ORDER BY block_number LIMIT ?"
+
+	var rows []rewardRow
+	if err := s.client.Select(ctx, &rows, query, args...); err != nil {
+		return nil, fmt.Errorf("select block rewards: %w", err)
+	}
+	rewards := make([]models.BlockReward, len(rows))
+	for i, row := range rows {
+		rewards[i] = row.model()
+	}
+	return rewards, nil
+}
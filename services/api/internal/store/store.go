@@ -0,0 +1,19 @@
+package store
+
+import (
+	"errors"
+
+	clientsDB "lib/clients/db"
+)
+
+// ErrNotFound — запрошенных данных нет в базе
+var ErrNotFound = errors.New("not found")
+
+// Store читает данные BlockHub из ClickHouse для API
+type Store struct {
+	client clientsDB.ClickhouseClient
+}
+
+func New(client clientsDB.ClickhouseClient) *Store {
+	return &Store{client: client}
+}
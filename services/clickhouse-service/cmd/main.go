@@ -2,14 +2,25 @@ package main
 
 import (
 	"context"
+	"flag"
 	"os"
 	"os/signal"
 	"syscall"
 
 	clickhouseRepo "clickhouse-service/internal/db/click_house"
+	"clickhouse-service/internal/ingest"
+	"lib/accounts"
+	collectorLib "lib/blocks/collector"
+	"lib/clients/broker"
 	clickhouseClient "lib/clients/db/clickhouse"
+	fabricClient "lib/clients/fabric_client"
+	"lib/contracts"
+	"lib/dex"
 	"lib/models"
+	"lib/utils/health"
 	"lib/utils/logging"
+	"lib/utils/metrics"
+	"lib/utils/tracing"
 )
 
 func main() {
@@ -18,13 +29,30 @@ func main() {
 	logger.Info("Starting ClickHouse service...")
 
 	// Получаем конфигурацию
-	config := models.GetConfig(logger)
+	configPath := models.ConfigFlag(flag.CommandLine)
+	flag.Parse()
+	config := models.MustLoadConfig(*configPath, models.ServiceClickhouse, logger)
 	logger.Info("Configuration loaded successfully")
 
 	// Создаем контекст
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	// Эндпоинты /metrics, /healthz и /readyz
+	checks := health.New(config.Health, logger)
+	metrics.Serve(ctx, config.Metrics.Addr, logger, checks.Mount)
+
+	// Трассировка OpenTelemetry: вставки продолжают трассы блоков из заголовков сообщений
+	shutdownTracing, err := tracing.Init(ctx, config.Tracing, "clickhouse-service", logger)
+	if err != nil {
+		logger.Fatalf("Failed to init tracing: %v", err)
+	}
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			logger.Errorf("Failed to flush traces: %v", err)
+		}
+	}()
+
 	// Инициализируем ClickHouse клиент
 	clickhouseClient, err := clickhouseClient.NewClient(ctx, config.Clickhouse)
 	if err != nil {
@@ -42,6 +70,72 @@ func main() {
 	}
 	logger.Info("ClickHouse connection verified")
 
+	// Брокер: блоки из топика blocks записываются в ClickHouse
+	brokerClient := fabricClient.NewBroker(config.Broker, logger)
+	if brokerClient == nil {
+		logger.Fatalf("Failed to create %s broker client", config.Broker.BrockerType)
+	}
+
+	// Транзакции, квитанции, логи и производные таблицы: в топике только
+	// заголовки блоков, остальное загружается у провайдера
+	var enrichment ingest.Enrichment
+	if providerCfg := config.ClickhouseService.Provider; providerCfg.BaseURL != "" {
+		providerClient, err := fabricClient.NewProvider(providerCfg, logger)
+		if err != nil {
+			logger.Fatalf("Failed to create provider client: %v", err)
+		}
+		defer providerClient.Close()
+
+		txs := collectorLib.NewBlockCollector(providerClient, logger)
+		txs.SetVerification(collectorLib.Verification{Mode: config.ClickhouseService.VerifyMode})
+		enrichment.Txs = txs
+
+		if dexCfg := config.ClickhouseService.Dex; dexCfg.Enabled {
+			pools, err := dex.NewPoolResolver(providerClient, dex.Factories{V2: dexCfg.V2Factories, V3: dexCfg.V3Factories})
+			if err != nil {
+				logger.Fatalf("Failed to create dex pool resolver: %v", err)
+			}
+			enrichment.Dex = dex.NewExtractor(pools)
+		}
+		if contractsCfg := config.ClickhouseService.Contracts; contractsCfg.Enabled {
+			enrichment.Contracts = contracts.NewIndexer(providerClient, contracts.Options{
+				Traces: contractsCfg.Traces,
+				Code:   contractsCfg.Code,
+			})
+		}
+		if accountsCfg := config.ClickhouseService.Accounts; accountsCfg.Enabled {
+			tracker, err := accounts.NewTracker(providerClient, accounts.Options{
+				Watched:  accountsCfg.Watched,
+				Touched:  accountsCfg.Touched,
+				Prestate: accountsCfg.Prestate,
+			})
+			if err != nil {
+				logger.Fatalf("Failed to create account tracker: %v", err)
+			}
+			enrichment.Accounts = tracker
+		}
+		logger.Infof("Loading transactions and receipts from %s", providerClient.Name())
+	}
+
+	handler := ingest.NewIngester(repo, enrichment, logger).HandleBlock
+	if config.Broker.DeadLetter && !broker.ManagesDeadLetters(config.Broker.BrockerType) {
+		handler = broker.NewDeadLetterQueue(brokerClient, broker.NewRetryPolicy(config.Broker), logger).Wrap(handler)
+	}
+
+	groupID := config.Broker.GroupID
+	if groupID == "" {
+		groupID = string(models.ServiceClickhouse)
+	}
+	if err := brokerClient.SubscribeWithGroup(ctx, ingest.BlocksTopic, groupID, handler); err != nil {
+		logger.Fatalf("Failed to subscribe to %s: %v", ingest.BlocksTopic, err)
+	}
+	logger.Infof("Consuming %s as group %s", ingest.BlocksTopic, groupID)
+
+	// Готовность: ClickHouse отвечает на ping, консьюмер брокера работает
+	checks.Readiness("clickhouse", clickhouseClient.Ping)
+	checks.Readiness("broker", brokerClient.HealthCheck)
+	go checks.Run(ctx)
+
 	// Настраиваем graceful shutdown
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
@@ -50,6 +144,12 @@ func main() {
 	<-sigChan
 	logger.Info("Received shutdown signal, closing connections...")
 
+	// Останавливаем консьюмер до закрытия ClickHouse
+	cancel()
+	if err := brokerClient.Close(); err != nil {
+		logger.Errorf("Failed to close broker client: %v", err)
+	}
+
 	// Закрываем соединения
 	if err := repo.Close(); err != nil {
 		logger.Errorf("Error closing repository: %v", err)
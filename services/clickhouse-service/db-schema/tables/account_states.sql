@@ -0,0 +1,19 @@
+-- Балансы и nonce адресов по блокам (lib/accounts): строка на каждое изменение.
+-- Состояние на блоке N — последняя строка адреса с block_number <= N.
+CREATE TABLE account_states
+(
+    `address` FixedString(42),
+    `balance` UInt256, -- wei
+    `nonce` UInt64,
+    `block_number` UInt64,
+    `block_hash` FixedString(66),
+    `block_timestamp` DateTime64(3, 'UTC'),
+    `date` Date MATERIALIZED toDate(block_timestamp)
+)
+ENGINE = ReplacingMergeTree
+PARTITION BY toYYYYMM(block_timestamp)
+ORDER BY (address, block_number);
+
+-- Индексы для таблицы account_states
+-- CREATE INDEX idx_account_states_block_number ON account_states (block_number) TYPE minmax GRANULARITY 1;
+-- CREATE INDEX idx_account_states_block_hash ON account_states (block_hash) TYPE bloom_filter GRANULARITY 1;
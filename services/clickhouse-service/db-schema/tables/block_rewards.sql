@@ -0,0 +1,22 @@
+-- Экономика блоков: награды получателю комиссий и сожжённые комиссии (суммы в wei)
+CREATE TABLE block_rewards
+(
+    `block_number` UInt64,
+    `block_hash` FixedString(66),
+    `miner` FixedString(42),
+    `static_reward` UInt256,
+    `uncle_inclusion_reward` UInt256,
+    `uncles_reward` UInt256,
+    `priority_fees` UInt256,
+    `base_fee_burned` UInt256,
+    `blob_fees_burned` UInt256,
+    `miner_reward` UInt256,
+    `block_timestamp` DateTime64(3, 'UTC'),
+    `date` Date MATERIALIZED toDate(block_timestamp)
+)
+ENGINE = ReplacingMergeTree
+PARTITION BY toYYYYMM(block_timestamp)
+ORDER BY (block_number);
+
+-- Индексы для таблицы block_rewards
+-- CREATE INDEX idx_block_rewards_miner ON block_rewards (miner) TYPE bloom_filter GRANULARITY 1;
@@ -0,0 +1,10 @@
+-- Реестр ABI контрактов для декодирования логов и input транзакций
+CREATE TABLE contract_abis
+(
+    `address` FixedString(42),
+    `name` String,
+    `abi` String, -- JSON-массив ABI
+    `updated_at` DateTime64(3, 'UTC')
+)
+ENGINE = ReplacingMergeTree(updated_at)
+ORDER BY (address);
@@ -0,0 +1,28 @@
+-- Индекс созданных контрактов (lib/contracts): из квитанций с contract_address
+-- и, если включены трейсы, из внутренних CREATE/CREATE2 фабрик.
+-- code_hash/code_size/standards заполняются, если запрашивался байткод (eth_getCode).
+CREATE TABLE contracts
+(
+    `address` FixedString(42),
+    `deployer` FixedString(42), -- отправитель транзакции или фабрика
+    `tx_from` FixedString(42),
+    `creation_type` LowCardinality(String), -- create | create2
+    `source` LowCardinality(String), -- receipt | trace
+    `code_hash` Nullable(FixedString(66)),
+    `code_size` UInt32,
+    `standards` Array(LowCardinality(String)), -- erc20 | erc721 | erc1155
+    `block_number` UInt64,
+    `block_hash` FixedString(66),
+    `transaction_hash` FixedString(66),
+    `block_timestamp` DateTime64(3, 'UTC'),
+    `date` Date MATERIALIZED toDate(block_timestamp)
+)
+ENGINE = ReplacingMergeTree
+PARTITION BY toYYYYMM(block_timestamp)
+ORDER BY address;
+
+-- Индексы для таблицы contracts
+-- CREATE INDEX idx_contracts_deployer ON contracts (deployer) TYPE bloom_filter GRANULARITY 1;
+-- CREATE INDEX idx_contracts_code_hash ON contracts (code_hash) TYPE bloom_filter GRANULARITY 1;
+-- CREATE INDEX idx_contracts_standards ON contracts (standards) TYPE bloom_filter GRANULARITY 1;
+-- CREATE INDEX idx_contracts_block_number ON contracts (block_number) TYPE minmax GRANULARITY 1;
@@ -0,0 +1,33 @@
+-- События пулов Uniswap V2/V3 и их форков (lib/dex): swap, mint, burn, sync.
+-- amount0/amount1 — изменение баланса пула (>0 — пришло в пул), для sync — резервы.
+CREATE TABLE dex_trades
+(
+    `protocol` LowCardinality(String), -- uniswap_v2 | uniswap_v3
+    `event` LowCardinality(String), -- swap | mint | burn | sync
+    `pool` FixedString(42),
+    `factory` FixedString(42),
+    `token0` FixedString(42),
+    `token1` FixedString(42),
+    `amount0` Int256,
+    `amount1` Int256,
+    `token_in` Nullable(FixedString(42)),
+    `token_out` Nullable(FixedString(42)),
+    `amount_in` Nullable(UInt256),
+    `amount_out` Nullable(UInt256),
+    `sender` Nullable(FixedString(42)),
+    `recipient` Nullable(FixedString(42)),
+    `block_number` UInt64,
+    `transaction_hash` FixedString(66),
+    `log_index` UInt32,
+    `block_timestamp` DateTime64(3, 'UTC'),
+    `date` Date MATERIALIZED toDate(block_timestamp)
+)
+ENGINE = ReplacingMergeTree
+PARTITION BY toYYYYMM(block_timestamp)
+ORDER BY (block_number, log_index);
+
+-- Индексы для таблицы dex_trades
+-- CREATE INDEX idx_dex_trades_pool ON dex_trades (pool) TYPE bloom_filter GRANULARITY 1;
+-- CREATE INDEX idx_dex_trades_token_in ON dex_trades (token_in) TYPE bloom_filter GRANULARITY 1;
+-- CREATE INDEX idx_dex_trades_token_out ON dex_trades (token_out) TYPE bloom_filter GRANULARITY 1;
+-- CREATE INDEX idx_dex_trades_tx_hash ON dex_trades (transaction_hash) TYPE bloom_filter GRANULARITY 1;
@@ -11,6 +11,8 @@ CREATE TABLE receipts
     `cumulative_gas_used` UInt64,
     `gas_used` UInt64,
     `effective_gas_price` UInt64,
+    `blob_gas_used` UInt64,
+    `blob_gas_price` UInt64,
     `status` UInt8, -- 1 (успех) или 0 (реверт)
     `logs_bloom` String,
     `block_timestamp` DateTime64(3, 'UTC'),
@@ -0,0 +1,10 @@
+-- База текстовых сигнатур для контрактов без ABI:
+-- 4-байтовые селекторы функций и topic0 событий
+CREATE TABLE signatures
+(
+    `kind` LowCardinality(String), -- function | event
+    `hash` String, -- 0x + 4 байта (function) или 32 байта (event), нижний регистр
+    `signature` String -- канонический вид: name(type,...)
+)
+ENGINE = ReplacingMergeTree
+ORDER BY (kind, hash, signature);
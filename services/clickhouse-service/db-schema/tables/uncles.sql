@@ -0,0 +1,25 @@
+-- Таблица дядей (ommers): блоки-сироты, включённые в блоки до The Merge
+CREATE TABLE uncles
+(
+    `hash` FixedString(66),
+    `number` UInt64,
+    `parent_hash` FixedString(66),
+    `miner` FixedString(42),
+    `difficulty` UInt256,
+    `gas_limit` UInt64,
+    `gas_used` UInt64,
+    `timestamp` DateTime64(3, 'UTC'),
+    `block_number` UInt64,
+    `block_hash` FixedString(66),
+    `uncle_index` UInt8,
+    `reward` UInt256,
+    `block_timestamp` DateTime64(3, 'UTC'),
+    `date` Date MATERIALIZED toDate(block_timestamp)
+)
+ENGINE = ReplacingMergeTree
+PARTITION BY toYYYYMM(block_timestamp)
+ORDER BY (block_number, uncle_index);
+
+-- Индексы для таблицы uncles
+-- CREATE INDEX idx_uncles_miner ON uncles (miner) TYPE bloom_filter GRANULARITY 1;
+-- CREATE INDEX idx_uncles_hash ON uncles (hash) TYPE bloom_filter GRANULARITY 1;
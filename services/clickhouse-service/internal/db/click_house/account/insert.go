@@ -0,0 +1,79 @@
+package account
+
+import (
+	"context"
+	"fmt"
+
+	"clickhouse-service/internal/db/click_house/rowtypes"
+	clientsDB "lib/clients/db"
+	"lib/models"
+	"lib/utils/logging"
+)
+
+type AccountRepository struct {
+	Client clientsDB.ClickhouseClient
+	Logger *logging.Logger
+}
+
+func NewAccountRepository(client clientsDB.ClickhouseClient, logger *logging.Logger) *AccountRepository {
+	return &AccountRepository{
+		Client: client,
+		Logger: logger,
+	}
+}
+
+// InsertAccountStates вставляет изменения балансов и nonce адресов в таблицу
+func (r *AccountRepository) InsertAccountStates(table string, states []models.AccountState) error {
+	if len(states) == 0 {
+		return nil
+	}
+
+	ctx := context.Background()
+
+	// Подготавливаем batch для вставки
+	batch, err := r.Client.PrepareBatch(ctx, "INSERT INTO "+table+" VALUES")
+	if err != nil {
+		r.Logger.Errorf("Failed to prepare batch for account states insert: %v", err)
+		return err
+	}
+
+	for _, state := range states {
+		row, err := convertAccountStateToClickHouseRow(state)
+		if err != nil {
+			r.Logger.Errorf("Failed to convert state of %s at block %d: %v", state.Address, state.BlockNumber, err)
+			return err
+		}
+		err = batch.Append(row...)
+		if err != nil {
+			r.Logger.Errorf("Failed to append state of %s at block %d to batch: %v", state.Address, state.BlockNumber, err)
+			return err
+		}
+	}
+
+	// Выполняем вставку
+	err = batch.Send()
+	if err != nil {
+		r.Logger.Errorf("Failed to send batch for account states insert: %v", err)
+		return err
+	}
+
+	r.Logger.Debugf("Successfully inserted %d account states", len(states))
+	return nil
+}
+
+// convertAccountStateToClickHouseRow конвертирует AccountState в строку для вставки в ClickHouse
+func convertAccountStateToClickHouseRow(state models.AccountState) ([]interface{}, error) {
+	balance, err := rowtypes.BigInt(state.Balance)
+	if err != nil {
+		return nil, fmt.Errorf("balance: %w", err)
+	}
+
+	return []interface{}{
+		state.Address,             // address
+		balance,                   // balance
+		uint64(state.Nonce),       // nonce
+		uint64(state.BlockNumber), // block_number
+		state.BlockHash,           // block_hash
+		state.BlockTimestamp,      // block_timestamp
+	}, nil
+}
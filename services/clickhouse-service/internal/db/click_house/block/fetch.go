@@ -3,6 +3,7 @@ package block
 import (
 	"context"
 	"strconv"
+	"strings"
 
 	"clickhouse-service/internal/db/click_house/rowtypes"
 	"lib/models"
@@ -14,7 +15,7 @@ func (r *BlockRepository) FetchBlock(table string, hashBlock string) (models.Blo
 
 	var result []rowtypes.BlockRow
 
-	query := "SELECT * FROM " + table + " WHERE hash = ? LIMIT 1"
+	query := "SELECT " + rowtypes.BlockColumns + " FROM " + table + " FINAL WHERE hash = ? LIMIT 1"
 	err := r.Client.Select(ctx, &result, query, hashBlock)
 	if err != nil {
 		r.Logger.Errorf("Failed to fetch block %s: %v", hashBlock, err)
@@ -25,40 +26,7 @@ func (r *BlockRepository) FetchBlock(table string, hashBlock string) (models.Blo
 		return models.Block{}, nil
 	}
 
-	// Конвертируем результат в модель Block
-	row := result[0]
-	block := models.Block{
-		Hash:             row.Hash,
-		Number:           row.Number,
-		ParentHash:       row.ParentHash,
-		Nonce:            parseHexToUint64Safe(row.Nonce),
-		Sha3Uncles:       row.Sha3Uncles,
-		LogsBloom:        row.LogsBloom,
-		TransactionsRoot: row.TransactionsRoot,
-		StateRoot:        row.StateRoot,
-		ReceiptsRoot:     row.ReceiptsRoot,
-		Miner:            row.Miner,
-		Difficulty:       "0x" + row.Difficulty,
-		Size:             row.Size,
-		ExtraData:        row.ExtraData,
-		GasLimit:         row.GasLimit,
-		GasUsed:          row.GasUsed,
-		Timestamp:        uint64(row.Timestamp.Unix()),
-		MixHash:          row.MixHash,
-		Uncles:           row.Uncles,
-	}
-
-	// Конвертируем baseFeePerGas если есть
-	if row.BaseFeePerGas != nil {
-		block.BaseFeePerGas = formatUint64ToHex(*row.BaseFeePerGas)
-	}
-
-	// Создаем транзакции (только хеши)
-	block.Transactions = make([]models.Tx, len(row.Transactions))
-	for i, txHash := range row.Transactions {
-		block.Transactions[i] = models.Tx{Hash: txHash}
-	}
-
+	block := blockFromRow(result[0])
 	r.Logger.Debugf("Successfully fetched block %s (number: %d)", block.Hash, block.Number)
 	return block, nil
 }
@@ -73,51 +41,14 @@ func (r *BlockRepository) FetchBlocks(table string, hashBlocks []string) ([]mode
 
 	var result []rowtypes.BlockRow
 
-	query := "SELECT * FROM " + table + " WHERE hash IN (?)"
+	query := "SELECT " + rowtypes.BlockColumns + " FROM " + table + " FINAL WHERE hash IN (?)"
 	err := r.Client.Select(ctx, &result, query, hashBlocks)
 	if err != nil {
 		r.Logger.Errorf("Failed to fetch blocks: %v", err)
 		return nil, err
 	}
 
-	// Конвертируем результаты в модели Block
-	blocks := make([]models.Block, len(result))
-	for i, row := range result {
-		block := models.Block{
-			Hash:             row.Hash,
-			Number:           row.Number,
-			ParentHash:       row.ParentHash,
-			Nonce:            parseHexToUint64Safe(row.Nonce),
-			Sha3Uncles:       row.Sha3Uncles,
-			LogsBloom:        row.LogsBloom,
-			TransactionsRoot: row.TransactionsRoot,
-			StateRoot:        row.StateRoot,
-			ReceiptsRoot:     row.ReceiptsRoot,
-			Miner:            row.Miner,
-			Difficulty:       "0x" + row.Difficulty,
-			Size:             row.Size,
-			ExtraData:        row.ExtraData,
-			GasLimit:         row.GasLimit,
-			GasUsed:          row.GasUsed,
-			Timestamp:        uint64(row.Timestamp.Unix()),
-			MixHash:          row.MixHash,
-			Uncles:           row.Uncles,
-		}
-
-		// Конвертируем baseFeePerGas если есть
-		if row.BaseFeePerGas != nil {
-			block.BaseFeePerGas = formatUint64ToHex(*row.BaseFeePerGas)
-		}
-
-		// Создаем транзакции (только хеши)
-		block.Transactions = make([]models.Tx, len(row.Transactions))
-		for j, txHash := range row.Transactions {
-			block.Transactions[j] = models.Tx{Hash: txHash}
-		}
-
-		blocks[i] = block
-	}
-
+	blocks := blocksFromRows(result)
 	r.Logger.Debugf("Successfully fetched %d blocks", len(blocks))
 	return blocks, nil
 }
@@ -128,7 +59,7 @@ func (r *BlockRepository) FetchBlockByNumber(table string, blockNumber uint64) (
 
 	var result []rowtypes.BlockRow
 
-	query := "SELECT * FROM " + table + " WHERE number = ? LIMIT 1"
+	query := "SELECT " + rowtypes.BlockColumns + " FROM " + table + " FINAL WHERE number = ? LIMIT 1"
 	err := r.Client.Select(ctx, &result, query, blockNumber)
 	if err != nil {
 		r.Logger.Errorf("Failed to fetch block by number %d: %v", blockNumber, err)
@@ -139,38 +70,7 @@ func (r *BlockRepository) FetchBlockByNumber(table string, blockNumber uint64) (
 		return models.Block{}, nil
 	}
 
-	// Конвертируем результат в модель Block (аналогично FetchBlock)
-	row := result[0]
-	block := models.Block{
-		Hash:             row.Hash,
-		Number:           row.Number,
-		ParentHash:       row.ParentHash,
-		Nonce:            parseHexToUint64Safe(row.Nonce),
-		Sha3Uncles:       row.Sha3Uncles,
-		LogsBloom:        row.LogsBloom,
-		TransactionsRoot: row.TransactionsRoot,
-		StateRoot:        row.StateRoot,
-		ReceiptsRoot:     row.ReceiptsRoot,
-		Miner:            row.Miner,
-		Difficulty:       "0x" + row.Difficulty,
-		Size:             row.Size,
-		ExtraData:        row.ExtraData,
-		GasLimit:         row.GasLimit,
-		GasUsed:          row.GasUsed,
-		Timestamp:        uint64(row.Timestamp.Unix()),
-		MixHash:          row.MixHash,
-		Uncles:           row.Uncles,
-	}
-
-	if row.BaseFeePerGas != nil {
-		block.BaseFeePerGas = formatUint64ToHex(*row.BaseFeePerGas)
-	}
-
-	block.Transactions = make([]models.Tx, len(row.Transactions))
-	for i, txHash := range row.Transactions {
-		block.Transactions[i] = models.Tx{Hash: txHash}
-	}
-
+	block := blockFromRow(result[0])
 	r.Logger.Debugf("Successfully fetched block by number %d (hash: %s)", blockNumber, block.Hash)
 	return block, nil
 }
@@ -181,63 +81,62 @@ func (r *BlockRepository) FetchBlocksByRange(table string, fromBlock, toBlock ui
 
 	var result []rowtypes.BlockRow
 
-	query := "SELECT * FROM " + table + " WHERE number >= ? AND number <= ? ORDER BY number"
+	query := "SELECT " + rowtypes.BlockColumns + " FROM " + table + " FINAL WHERE number >= ? AND number <= ? ORDER BY number"
 	err := r.Client.Select(ctx, &result, query, fromBlock, toBlock)
 	if err != nil {
 		r.Logger.Errorf("Failed to fetch blocks by range %d-%d: %v", fromBlock, toBlock, err)
 		return nil, err
 	}
 
-	// Конвертируем результаты в модели Block (аналогично FetchBlocks)
-	blocks := make([]models.Block, len(result))
-	for i, row := range result {
-		block := models.Block{
-			Hash:             row.Hash,
-			Number:           row.Number,
-			ParentHash:       row.ParentHash,
-			Nonce:            parseHexToUint64Safe(row.Nonce),
-			Sha3Uncles:       row.Sha3Uncles,
-			LogsBloom:        row.LogsBloom,
-			TransactionsRoot: row.TransactionsRoot,
-			StateRoot:        row.StateRoot,
-			ReceiptsRoot:     row.ReceiptsRoot,
-			Miner:            row.Miner,
-			Difficulty:       "0x" + row.Difficulty,
-			Size:             row.Size,
-			ExtraData:        row.ExtraData,
-			GasLimit:         row.GasLimit,
-			GasUsed:          row.GasUsed,
-			Timestamp:        uint64(row.Timestamp.Unix()),
-			MixHash:          row.MixHash,
-			Uncles:           row.Uncles,
-		}
-
-		if row.BaseFeePerGas != nil {
-			block.BaseFeePerGas = formatUint64ToHex(*row.BaseFeePerGas)
-		}
-
-		block.Transactions = make([]models.Tx, len(row.Transactions))
-		for j, txHash := range row.Transactions {
-			block.Transactions[j] = models.Tx{Hash: txHash}
-		}
-
-		blocks[i] = block
-	}
-
+	blocks := blocksFromRows(result)
 	r.Logger.Debugf("Successfully fetched %d blocks in range %d-%d", len(blocks), fromBlock, toBlock)
 	return blocks, nil
 }
 
-// parseHexToUint64Safe безопасно парсит hex строку в uint64
-func parseHexToUint64Safe(hexStr string) uint64 {
-	if hexStr == "" {
-		return 0
+func blocksFromRows(rows []rowtypes.BlockRow) []models.Block {
+	blocks := make([]models.Block, len(rows))
+	for i, row := range rows {
+		blocks[i] = blockFromRow(row)
 	}
-	// Убираем префикс 0x если есть
-	if len(hexStr) > 2 && hexStr[:2] == "0x" {
-		hexStr = hexStr[2:]
+	return blocks
+}
+
+// blockFromRow конвертирует строку ClickHouse в модель Block.
+// Заголовки дядей и награды хранятся в своих таблицах и здесь не заполняются.
+func blockFromRow(row rowtypes.BlockRow) models.Block {
+	block := models.Block{
+		Hash:             row.Hash,
+		Number:           uint(row.Number),
+		ParentHash:       row.ParentHash,
+		Nonce:            uint(parseHexToUint64Safe(row.Nonce)),
+		Sha3Uncles:       row.Sha3Uncles,
+		LogsBloom:        row.LogsBloom,
+		TransactionsRoot: row.TransactionsRoot,
+		StateRoot:        row.StateRoot,
+		ReceiptsRoot:     row.ReceiptsRoot,
+		Miner:            row.Miner,
+		Difficulty:       row.Difficulty,
+		TotalDifficulty:  row.TotalDifficulty,
+		Size:             uint(row.Size),
+		ExtraData:        row.ExtraData,
+		GasLimit:         uint(row.GasLimit),
+		GasUsed:          uint(row.GasUsed),
+		Timestamp:        row.Timestamp.UTC(),
+		MixHash:          row.MixHash,
+		Transactions:     row.Transactions,
+		Uncles:           row.Uncles,
+	}
+
+	if row.BaseFeePerGas != nil {
+		baseFee := uint(*row.BaseFeePerGas)
+		block.BaseFeePerGas = &baseFee
 	}
-	val, err := strconv.ParseUint(hexStr, 16, 64)
+	return block
+}
+
+// parseHexToUint64Safe безопасно парсит hex строку в uint64
+func parseHexToUint64Safe(hexStr string) uint64 {
+	val, err := strconv.ParseUint(strings.TrimPrefix(hexStr, "0x"), 16, 64)
 	if err != nil {
 		return 0
 	}
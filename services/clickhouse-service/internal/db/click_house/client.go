@@ -2,10 +2,15 @@ package clickhouseRepo
 
 import (
 	"clickhouse-service/internal/db"
+	"clickhouse-service/internal/db/click_house/account"
 	"clickhouse-service/internal/db/click_house/block"
+	"clickhouse-service/internal/db/click_house/contract"
+	"clickhouse-service/internal/db/click_house/dex"
+	"clickhouse-service/internal/db/click_house/reward"
 	"clickhouse-service/internal/db/click_house/tx"
 	"clickhouse-service/internal/db/click_house/tx/log"
 	"clickhouse-service/internal/db/click_house/tx/receipt"
+	"clickhouse-service/internal/db/click_house/uncle"
 	clientsDB "lib/clients/db"
 	"lib/models"
 	"lib/utils/logging"
@@ -16,11 +21,22 @@ type ClickhouseRepo struct {
 	Logger *logging.Logger
 
 	// Репозитории для каждой сущности
-	BlockRepo   *block.BlockRepository
-	TxRepo      *tx.TxRepository
-	ReceiptRepo *receipt.ReceiptRepository
-	LogRepo     *log.LogRepository
-}
+	BlockRepo    *block.BlockRepository
+	TxRepo       *tx.TxRepository
+	ReceiptRepo  *receipt.ReceiptRepository
+	LogRepo      *log.LogRepository
+	UncleRepo    *uncle.UncleRepository
+	RewardRepo   *reward.RewardRepository
+	DexRepo      *dex.DexRepository
+	ContractRepo *contract.ContractRepository
+	AccountRepo  *account.AccountRepository
+}
+
+// Таблицы, которые заполняются вместе с блоком
+const (
+	UnclesTable       = "uncles"
+	BlockRewardsTable = "block_rewards"
+)
 
 func NewClickhouseService(client clientsDB.ClickhouseClient, logger *logging.Logger) db.DB {
 	repo := &ClickhouseRepo{
@@ -33,6 +49,11 @@ func NewClickhouseService(client clientsDB.ClickhouseClient, logger *logging.Log
 	repo.TxRepo = tx.NewTxRepository(client, logger)
 	repo.ReceiptRepo = receipt.NewReceiptRepository(client, logger)
 	repo.LogRepo = log.NewLogRepository(client, logger)
+	repo.UncleRepo = uncle.NewUncleRepository(client, logger)
+	repo.RewardRepo = reward.NewRewardRepository(client, logger)
+	repo.DexRepo = dex.NewDexRepository(client, logger)
+	repo.ContractRepo = contract.NewContractRepository(client, logger)
+	repo.AccountRepo = account.NewAccountRepository(client, logger)
 
 	return repo
 }
@@ -43,12 +64,21 @@ func (c *ClickhouseRepo) Close() error {
 
 // Блоки
 
+// InsertBlock вставляет блок, заголовки его дядей (Block.UncleHeaders) и награды (Block.Rewards)
 func (c *ClickhouseRepo) InsertBlock(table string, block models.Block) error {
-	return c.BlockRepo.InsertBlock(table, block)
+	return c.InsertBlocks(table, []models.Block{block})
 }
 
+// InsertBlocks вставляет блоки, заголовки их дядей и награды. Таблицы — ReplacingMergeTree,
+// поэтому повторная вставка после частичной ошибки не создаёт дубликатов.
 func (c *ClickhouseRepo) InsertBlocks(table string, blocks []models.Block) error {
-	return c.BlockRepo.InsertBlocks(table, blocks)
+	if err := c.BlockRepo.InsertBlocks(table, blocks); err != nil {
+		return err
+	}
+	if err := c.UncleRepo.InsertBlockUncles(UnclesTable, blocks); err != nil {
+		return err
+	}
+	return c.RewardRepo.InsertBlockRewards(BlockRewardsTable, blocks)
 }
 
 func (c *ClickhouseRepo) FetchBlock(table string, hashBlock string) (models.Block, error) {
@@ -77,12 +107,12 @@ func (c *ClickhouseRepo) InsertTxs(table string, txs []models.Tx) error {
 	return c.TxRepo.InsertTxs(table, txs)
 }
 
-func (c *ClickhouseRepo) InsertTxWithBlockData(table string, tx models.Tx, blockHash string, blockNumber uint64, blockTimestamp uint64) error {
-	return c.TxRepo.InsertTxWithBlockData(table, tx, blockHash, blockNumber, blockTimestamp)
+func (c *ClickhouseRepo) InsertTxWithBlockData(table string, tx models.Tx) error {
+	return c.TxRepo.InsertTxWithBlockData(table, tx)
 }
 
-func (c *ClickhouseRepo) InsertTxsWithBlockData(table string, txs []models.Tx, blockHash string, blockNumber uint64, blockTimestamp uint64) error {
-	return c.TxRepo.InsertTxsWithBlockData(table, txs, blockHash, blockNumber, blockTimestamp)
+func (c *ClickhouseRepo) InsertTxsWithBlockData(table string, txs []models.Tx) error {
+	return c.TxRepo.InsertTxsWithBlockData(table, txs)
 }
 
 func (c *ClickhouseRepo) FetchTx(table string, txHash string) (models.Tx, error) {
@@ -107,16 +137,16 @@ func (c *ClickhouseRepo) FetchTxsByAddress(table string, address string, limit i
 
 // Квитанции
 
-func (c *ClickhouseRepo) InsertReceipt(table string, receipt models.Receipt, txHash string, txIndex uint32, blockHash string, blockNumber uint64, blockTimestamp uint64) error {
-	return c.ReceiptRepo.InsertReceipt(table, receipt, txHash, txIndex, blockHash, blockNumber, blockTimestamp)
+func (c *ClickhouseRepo) InsertReceipt(table string, receipt models.Receipt) error {
+	return c.ReceiptRepo.InsertReceipt(table, receipt)
 }
 
-func (c *ClickhouseRepo) InsertReceipts(table string, receipts []models.Receipt, txHashes []string, txIndexes []uint32, blockHash string, blockNumber uint64, blockTimestamp uint64) error {
-	return c.ReceiptRepo.InsertReceipts(table, receipts, txHashes, txIndexes, blockHash, blockNumber, blockTimestamp)
+func (c *ClickhouseRepo) InsertReceipts(table string, receipts []models.Receipt) error {
+	return c.ReceiptRepo.InsertReceipts(table, receipts)
 }
 
-func (c *ClickhouseRepo) InsertReceiptsFromBlock(table string, block models.Block) error {
-	return c.ReceiptRepo.InsertReceiptsFromBlock(table, block)
+func (c *ClickhouseRepo) InsertReceiptsFromTxs(table string, txs []models.Tx) error {
+	return c.ReceiptRepo.InsertReceiptsFromTxs(table, txs)
 }
 
 func (c *ClickhouseRepo) FetchReceipt(table string, txHash string) (models.Receipt, error) {
@@ -141,20 +171,20 @@ func (c *ClickhouseRepo) FetchReceiptsByAddress(table string, address string, li
 
 // Логи
 
-func (c *ClickhouseRepo) InsertLog(table string, log models.Log, blockHash string, blockNumber uint64, blockTimestamp uint64) error {
-	return c.LogRepo.InsertLog(table, log, blockHash, blockNumber, blockTimestamp)
+func (c *ClickhouseRepo) InsertLog(table string, log models.Log) error {
+	return c.LogRepo.InsertLog(table, log)
 }
 
-func (c *ClickhouseRepo) InsertLogs(table string, logs []models.Log, blockHash string, blockNumber uint64, blockTimestamp uint64) error {
-	return c.LogRepo.InsertLogs(table, logs, blockHash, blockNumber, blockTimestamp)
+func (c *ClickhouseRepo) InsertLogs(table string, logs []models.Log) error {
+	return c.LogRepo.InsertLogs(table, logs)
 }
 
-func (c *ClickhouseRepo) InsertLogsFromReceipt(table string, receipt models.Receipt, txHash string, txIndex uint32, blockHash string, blockNumber uint64, blockTimestamp uint64) error {
-	return c.LogRepo.InsertLogsFromReceipt(table, receipt, txHash, txIndex, blockHash, blockNumber, blockTimestamp)
+func (c *ClickhouseRepo) InsertLogsFromReceipt(table string, receipt models.Receipt) error {
+	return c.LogRepo.InsertLogsFromReceipt(table, receipt)
 }
 
-func (c *ClickhouseRepo) InsertLogsFromBlock(table string, block models.Block) error {
-	return c.LogRepo.InsertLogsFromBlock(table, block)
+func (c *ClickhouseRepo) InsertLogsFromTxs(table string, txs []models.Tx) error {
+	return c.LogRepo.InsertLogsFromTxs(table, txs)
 }
 
 func (c *ClickhouseRepo) FetchLogsByTransaction(table string, txHash string) ([]models.Log, error) {
@@ -184,3 +214,21 @@ func (c *ClickhouseRepo) FetchLogsByTopic0(table string, topic0 string, limit in
 func (c *ClickhouseRepo) FetchLogsByAddressAndTopic(table string, address string, topic string, limit int) ([]models.Log, error) {
 	return c.LogRepo.FetchLogsByAddressAndTopic(table, address, topic, limit)
 }
+
+// События пулов DEX
+
+func (c *ClickhouseRepo) InsertDexTrades(table string, trades []models.DexTrade) error {
+	return c.DexRepo.InsertDexTrades(table, trades)
+}
+
+// Созданные контракты
+
+func (c *ClickhouseRepo) InsertContracts(table string, contracts []models.Contract) error {
+	return c.ContractRepo.InsertContracts(table, contracts)
+}
+
+// Балансы и nonce адресов
+
+func (c *ClickhouseRepo) InsertAccountStates(table string, states []models.AccountState) error {
+	return c.AccountRepo.InsertAccountStates(table, states)
+}
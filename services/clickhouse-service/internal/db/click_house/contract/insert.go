@@ -0,0 +1,83 @@
+package contract
+
+import (
+	"context"
+
+	clientsDB "lib/clients/db"
+	"lib/models"
+	"lib/utils/logging"
+)
+
+type ContractRepository struct {
+	Client clientsDB.ClickhouseClient
+	Logger *logging.Logger
+}
+
+func NewContractRepository(client clientsDB.ClickhouseClient, logger *logging.Logger) *ContractRepository {
+	return &ContractRepository{
+		Client: client,
+		Logger: logger,
+	}
+}
+
+// InsertContracts вставляет созданные контракты в таблицу
+func (r *ContractRepository) InsertContracts(table string, contracts []models.Contract) error {
+	if len(contracts) == 0 {
+		return nil
+	}
+
+	ctx := context.Background()
+
+	// Подготавливаем batch для вставки
+	batch, err := r.Client.PrepareBatch(ctx, "INSERT INTO "+table+" VALUES")
+	if err != nil {
+		r.Logger.Errorf("Failed to prepare batch for contracts insert: %v", err)
+		return err
+	}
+
+	for _, contract := range contracts {
+		err = batch.Append(convertContractToClickHouseRow(contract)...)
+		if err != nil {
+			r.Logger.Errorf("Failed to append contract %s to batch: %v", contract.Address, err)
+			return err
+		}
+	}
+
+	// Выполняем вставку
+	err = batch.Send()
+	if err != nil {
+		r.Logger.Errorf("Failed to send batch for contracts insert: %v", err)
+		return err
+	}
+
+	r.Logger.Debugf("Successfully inserted %d contracts", len(contracts))
+	return nil
+}
+
+// convertContractToClickHouseRow конвертирует Contract в строку для вставки в ClickHouse
+func convertContractToClickHouseRow(contract models.Contract) []interface{} {
+	standards := contract.Standards
+	if standards == nil {
+		standards = []string{}
+	}
+
+	var codeHash *string
+	if contract.CodeHash != "" {
+		codeHash = &contract.CodeHash
+	}
+
+	return []interface{}{
+		contract.Address,             // address
+		contract.Deployer,            // deployer
+		contract.TxFrom,              // tx_from
+		contract.CreationType,        // creation_type
+		contract.Source,              // source
+		codeHash,                     // code_hash
+		uint32(contract.CodeSize),    // code_size
+		standards,                    // standards
+		uint64(contract.BlockNumber), // block_number
+		contract.BlockHash,           // block_hash
+		contract.TransactionHash,     // transaction_hash
+		contract.BlockTimestamp,      // block_timestamp
+	}
+}
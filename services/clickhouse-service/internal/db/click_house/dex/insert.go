@@ -0,0 +1,120 @@
+package dex
+
+import (
+	"context"
+	"fmt"
+
+	"clickhouse-service/internal/db/click_house/rowtypes"
+	clientsDB "lib/clients/db"
+	"lib/models"
+	"lib/utils/logging"
+)
+
+type DexRepository struct {
+	Client clientsDB.ClickhouseClient
+	Logger *logging.Logger
+}
+
+func NewDexRepository(client clientsDB.ClickhouseClient, logger *logging.Logger) *DexRepository {
+	return &DexRepository{
+		Client: client,
+		Logger: logger,
+	}
+}
+
+// InsertDexTrades вставляет события пулов DEX в таблицу
+func (r *DexRepository) InsertDexTrades(table string, trades []models.DexTrade) error {
+	if len(trades) == 0 {
+		return nil
+	}
+
+	ctx := context.Background()
+
+	// Подготавливаем batch для вставки
+	batch, err := r.Client.PrepareBatch(ctx, "INSERT INTO "+table+" VALUES")
+	if err != nil {
+		r.Logger.Errorf("Failed to prepare batch for dex trades insert: %v", err)
+		return err
+	}
+
+	for _, trade := range trades {
+		row, err := convertDexTradeToClickHouseRow(trade)
+		if err != nil {
+			r.Logger.Errorf("Failed to convert dex trade %s:%d: %v", trade.TransactionHash, trade.LogIndex, err)
+			return err
+		}
+		err = batch.Append(row...)
+		if err != nil {
+			r.Logger.Errorf("Failed to append dex trade %s:%d to batch: %v", trade.TransactionHash, trade.LogIndex, err)
+			return err
+		}
+	}
+
+	// Выполняем вставку
+	err = batch.Send()
+	if err != nil {
+		r.Logger.Errorf("Failed to send batch for dex trades insert: %v", err)
+		return err
+	}
+
+	r.Logger.Debugf("Successfully inserted %d dex trades", len(trades))
+	return nil
+}
+
+// convertDexTradeToClickHouseRow конвертирует DexTrade в строку для вставки в ClickHouse;
+// суммы — десятичные строки, в Int256/UInt256 передаются как *big.Int
+func convertDexTradeToClickHouseRow(trade models.DexTrade) ([]interface{}, error) {
+	amount0, err := rowtypes.BigInt(trade.Amount0)
+	if err != nil {
+		return nil, fmt.Errorf("amount0: %w", err)
+	}
+	amount1, err := rowtypes.BigInt(trade.Amount1)
+	if err != nil {
+		return nil, fmt.Errorf("amount1: %w", err)
+	}
+	amountIn, err := optionalBigInt(trade.AmountIn)
+	if err != nil {
+		return nil, fmt.Errorf("amount_in: %w", err)
+	}
+	amountOut, err := optionalBigInt(trade.AmountOut)
+	if err != nil {
+		return nil, fmt.Errorf("amount_out: %w", err)
+	}
+
+	return []interface{}{
+		trade.Protocol,            // protocol
+		trade.Event,               // event
+		trade.Pool,                // pool
+		trade.Factory,             // factory
+		trade.Token0,              // token0
+		trade.Token1,              // token1
+		amount0,                   // amount0
+		amount1,                   // amount1
+		optional(trade.TokenIn),   // token_in
+		optional(trade.TokenOut),  // token_out
+		amountIn,                  // amount_in
+		amountOut,                 // amount_out
+		optional(trade.Sender),    // sender
+		optional(trade.Recipient), // recipient
+		uint64(trade.BlockNumber), // block_number
+		trade.TransactionHash,     // transaction_hash
+		uint32(trade.LogIndex),    // log_index
+		trade.BlockTimestamp,      // block_timestamp
+	}, nil
+}
+
+// optional — пустая строка становится NULL
+func optional(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// optionalBigInt — пустая строка становится NULL
+func optionalBigInt(s string) (interface{}, error) {
+	if s == "" {
+		return nil, nil
+	}
+	return rowtypes.BigInt(s)
+}
@@ -0,0 +1,114 @@
+package reward
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"clickhouse-service/internal/db/click_house/rowtypes"
+	clientsDB "lib/clients/db"
+	"lib/models"
+	"lib/utils/logging"
+)
+
+type RewardRepository struct {
+	Client clientsDB.ClickhouseClient
+	Logger *logging.Logger
+}
+
+func NewRewardRepository(client clientsDB.ClickhouseClient, logger *logging.Logger) *RewardRepository {
+	return &RewardRepository{
+		Client: client,
+		Logger: logger,
+	}
+}
+
+// InsertRewards вставляет экономику блоков в таблицу
+func (r *RewardRepository) InsertRewards(table string, rewards []models.BlockReward) error {
+	if len(rewards) == 0 {
+		return nil
+	}
+
+	ctx := context.Background()
+
+	// Подготавливаем batch для вставки
+	batch, err := r.Client.PrepareBatch(ctx, "INSERT INTO "+table+" VALUES")
+	if err != nil {
+		r.Logger.Errorf("Failed to prepare batch for block rewards insert: %v", err)
+		return err
+	}
+
+	for _, reward := range rewards {
+		row, err := convertRewardToClickHouseRow(reward)
+		if err != nil {
+			r.Logger.Errorf("Failed to convert rewards of block %d: %v", reward.BlockNumber, err)
+			return err
+		}
+		err = batch.Append(row...)
+		if err != nil {
+			r.Logger.Errorf("Failed to append rewards of block %d to batch: %v", reward.BlockNumber, err)
+			return err
+		}
+	}
+
+	// Выполняем вставку
+	err = batch.Send()
+	if err != nil {
+		r.Logger.Errorf("Failed to send batch for block rewards insert: %v", err)
+		return err
+	}
+
+	r.Logger.Debugf("Successfully inserted rewards of %d blocks", len(rewards))
+	return nil
+}
+
+// InsertBlockRewards вставляет награды, пришедшие вместе с блоками;
+// блоки без наград (квитанции не загружались) пропускаются
+func (r *RewardRepository) InsertBlockRewards(table string, blocks []models.Block) error {
+	var rewards []models.BlockReward
+	for _, block := range blocks {
+		if block.Rewards != nil {
+			rewards = append(rewards, *block.Rewards)
+		}
+	}
+	return r.InsertRewards(table, rewards)
+}
+
+// convertRewardToClickHouseRow конвертирует BlockReward в строку для вставки в ClickHouse;
+// суммы в wei приходят десятичными строками и передаются в UInt256 как *big.Int
+func convertRewardToClickHouseRow(reward models.BlockReward) ([]interface{}, error) {
+	amounts := []struct {
+		column string
+		value  string
+	}{
+		{"static_reward", reward.StaticReward},
+		{"uncle_inclusion_reward", reward.UncleInclusionReward},
+		{"uncles_reward", reward.UnclesReward},
+		{"priority_fees", reward.PriorityFees},
+		{"base_fee_burned", reward.BaseFeeBurned},
+		{"blob_fees_burned", reward.BlobFeesBurned},
+		{"miner_reward", reward.MinerReward},
+	}
+	wei := make([]*big.Int, len(amounts))
+	for i, amount := range amounts {
+		v, err := rowtypes.BigInt(amount.value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", amount.column, err)
+		}
+		wei[i] = v
+	}
+
+	return []interface{}{
+		uint64(reward.BlockNumber), // block_number
+		reward.BlockHash,           // block_hash
+		reward.Miner,               // miner
+		wei[0],                     // static_reward
+		wei[1],                     // uncle_inclusion_reward
+		wei[2],                     // uncles_reward
+		wei[3],                     // priority_fees
+		wei[4],                     // base_fee_burned
+		wei[5],                     // blob_fees_burned
+		wei[6],                     // miner_reward
+		reward.BlockTimestamp,      // block_timestamp
+	}, nil
+}
@@ -0,0 +1,20 @@
+package rowtypes
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// BigInt разбирает сумму из модели (десятичная строка или 0x-hex) для колонок
+// UInt256/Int256: драйвер ClickHouse принимает их только как *big.Int.
+// Пустая строка — ноль.
+func BigInt(s string) (*big.Int, error) {
+	if s == "" {
+		return new(big.Int), nil
+	}
+	n, ok := new(big.Int).SetString(s, 0)
+	if !ok {
+		return nil, fmt.Errorf("invalid integer %q", s)
+	}
+	return n, nil
+}
@@ -2,6 +2,28 @@ package rowtypes
 
 import "time"
 
+// Колонки выборки. UInt256 читаются десятичными строками, материализованные
+// колонки в SELECT * не входят и перечисляются явно. from и to — ключевые слова
+// и экранируются.
+const (
+	BlockColumns = `hash, number, parent_hash, nonce, sha3_uncles, logs_bloom,
+	transactions_root, state_root, receipts_root, miner,
+	toString(difficulty) AS difficulty, toString(total_difficulty) AS total_difficulty,
+	size, extra_data, gas_limit, gas_used, base_fee_per_gas, timestamp, mix_hash,
+	transactions, uncles`
+
+	TxColumns = `hash, block_hash, block_number, transaction_index, "from", "to",
+	toString(value) AS value, gas, gas_price, input, nonce, type,
+	max_fee_per_gas, max_priority_fee_per_gas, chain_id, v, r, s, access_list, block_timestamp`
+
+	ReceiptColumns = `transaction_hash, transaction_index, block_hash, block_number,
+	"from", "to", contract_address, cumulative_gas_used, gas_used, effective_gas_price,
+	blob_gas_used, blob_gas_price, status, logs_bloom, block_timestamp`
+
+	LogColumns = `block_number, block_hash, transaction_hash, transaction_index, log_index,
+	address, data, topics, block_timestamp, topic0`
+)
+
 type BlockRow struct {
 	Hash             string    `ch:"hash"`
 	Number           uint64    `ch:"number"`
@@ -60,6 +82,8 @@ type ReceiptRow struct {
 	CumulativeGasUsed uint64    `ch:"cumulative_gas_used"`
 	GasUsed           uint64    `ch:"gas_used"`
 	EffectiveGasPrice uint64    `ch:"effective_gas_price"`
+	BlobGasUsed       uint64    `ch:"blob_gas_used"`
+	BlobGasPrice      uint64    `ch:"blob_gas_price"`
 	Status            uint8     `ch:"status"`
 	LogsBloom         string    `ch:"logs_bloom"`
 	BlockTimestamp    time.Time `ch:"block_timestamp"`
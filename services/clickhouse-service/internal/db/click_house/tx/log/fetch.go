@@ -2,7 +2,6 @@ package log
 
 import (
 	"context"
-	"time"
 
 	"clickhouse-service/internal/db/click_house/rowtypes"
 	"lib/models"
@@ -14,26 +13,14 @@ func (r *LogRepository) FetchLogsByTransaction(table string, txHash string) ([]m
 
 	var result []rowtypes.LogRow
 
-	query := "SELECT * FROM " + table + " WHERE transaction_hash = ? ORDER BY log_index"
+	query := "SELECT " + rowtypes.LogColumns + " FROM " + table + " FINAL WHERE transaction_hash = ? ORDER BY log_index"
 	err := r.Client.Select(ctx, &result, query, txHash)
 	if err != nil {
 		r.Logger.Errorf("Failed to fetch logs for transaction %s: %v", txHash, err)
 		return nil, err
 	}
 
-	// Конвертируем результаты в модели Log
-	logs := make([]models.Log, len(result))
-	for i, row := range result {
-		logs[i] = models.Log{
-			Address:         row.Address,
-			Topics:          row.Topics,
-			Data:            row.Data,
-			TransactionHash: row.TransactionHash,
-			LogIndex:        uint64(row.LogIndex),
-			Removed:         false, // По умолчанию false
-		}
-	}
-
+	logs := logsFromRows(result)
 	r.Logger.Debugf("Successfully fetched %d logs for transaction %s", len(logs), txHash)
 	return logs, nil
 }
@@ -44,26 +31,14 @@ func (r *LogRepository) FetchLogsByBlock(table string, blockHash string) ([]mode
 
 	var result []rowtypes.LogRow
 
-	query := "SELECT * FROM " + table + " WHERE block_hash = ? ORDER BY transaction_index, log_index"
+	query := "SELECT " + rowtypes.LogColumns + " FROM " + table + " FINAL WHERE block_hash = ? ORDER BY transaction_index, log_index"
 	err := r.Client.Select(ctx, &result, query, blockHash)
 	if err != nil {
 		r.Logger.Errorf("Failed to fetch logs for block %s: %v", blockHash, err)
 		return nil, err
 	}
 
-	// Конвертируем результаты в модели Log
-	logs := make([]models.Log, len(result))
-	for i, row := range result {
-		logs[i] = models.Log{
-			Address:         row.Address,
-			Topics:          row.Topics,
-			Data:            row.Data,
-			TransactionHash: row.TransactionHash,
-			LogIndex:        uint64(row.LogIndex),
-			Removed:         false, // По умолчанию false
-		}
-	}
-
+	logs := logsFromRows(result)
 	r.Logger.Debugf("Successfully fetched %d logs for block %s", len(logs), blockHash)
 	return logs, nil
 }
@@ -74,26 +49,14 @@ func (r *LogRepository) FetchLogsByBlockNumber(table string, blockNumber uint64)
 
 	var result []rowtypes.LogRow
 
-	query := "SELECT * FROM " + table + " WHERE block_number = ? ORDER BY transaction_index, log_index"
+	query := "SELECT " + rowtypes.LogColumns + " FROM " + table + " FINAL WHERE block_number = ? ORDER BY transaction_index, log_index"
 	err := r.Client.Select(ctx, &result, query, blockNumber)
 	if err != nil {
 		r.Logger.Errorf("Failed to fetch logs for block number %d: %v", blockNumber, err)
 		return nil, err
 	}
 
-	// Конвертируем результаты в модели Log
-	logs := make([]models.Log, len(result))
-	for i, row := range result {
-		logs[i] = models.Log{
-			Address:         row.Address,
-			Topics:          row.Topics,
-			Data:            row.Data,
-			TransactionHash: row.TransactionHash,
-			LogIndex:        uint64(row.LogIndex),
-			Removed:         false, // По умолчанию false
-		}
-	}
-
+	logs := logsFromRows(result)
 	r.Logger.Debugf("Successfully fetched %d logs for block number %d", len(logs), blockNumber)
 	return logs, nil
 }
@@ -104,26 +67,14 @@ func (r *LogRepository) FetchLogsByAddress(table string, address string, limit i
 
 	var result []rowtypes.LogRow
 
-	query := "SELECT * FROM " + table + " WHERE address = ? ORDER BY block_timestamp DESC LIMIT ?"
+	query := "SELECT " + rowtypes.LogColumns + " FROM " + table + " FINAL WHERE address = ? ORDER BY block_timestamp DESC LIMIT ?"
 	err := r.Client.Select(ctx, &result, query, address, limit)
 	if err != nil {
 		r.Logger.Errorf("Failed to fetch logs for address %s: %v", address, err)
 		return nil, err
 	}
 
-	// Конвертируем результаты в модели Log
-	logs := make([]models.Log, len(result))
-	for i, row := range result {
-		logs[i] = models.Log{
-			Address:         row.Address,
-			Topics:          row.Topics,
-			Data:            row.Data,
-			TransactionHash: row.TransactionHash,
-			LogIndex:        uint64(row.LogIndex),
-			Removed:         false, // По умолчанию false
-		}
-	}
-
+	logs := logsFromRows(result)
 	r.Logger.Debugf("Successfully fetched %d logs for address %s", len(logs), address)
 	return logs, nil
 }
@@ -134,26 +85,14 @@ func (r *LogRepository) FetchLogsByTopic(table string, topic string, limit int)
 
 	var result []rowtypes.LogRow
 
-	query := "SELECT * FROM " + table + " WHERE has(topics, ?) ORDER BY block_timestamp DESC LIMIT ?"
+	query := "SELECT " + rowtypes.LogColumns + " FROM " + table + " FINAL WHERE has(topics, ?) ORDER BY block_timestamp DESC LIMIT ?"
 	err := r.Client.Select(ctx, &result, query, topic, limit)
 	if err != nil {
 		r.Logger.Errorf("Failed to fetch logs for topic %s: %v", topic, err)
 		return nil, err
 	}
 
-	// Конвертируем результаты в модели Log
-	logs := make([]models.Log, len(result))
-	for i, row := range result {
-		logs[i] = models.Log{
-			Address:         row.Address,
-			Topics:          row.Topics,
-			Data:            row.Data,
-			TransactionHash: row.TransactionHash,
-			LogIndex:        uint64(row.LogIndex),
-			Removed:         false, // По умолчанию false
-		}
-	}
-
+	logs := logsFromRows(result)
 	r.Logger.Debugf("Successfully fetched %d logs for topic %s", len(logs), topic)
 	return logs, nil
 }
@@ -162,39 +101,16 @@ func (r *LogRepository) FetchLogsByTopic(table string, topic string, limit int)
 func (r *LogRepository) FetchLogsByTopic0(table string, topic0 string, limit int) ([]models.Log, error) {
 	ctx := context.Background()
 
-	var result []struct {
-		BlockNumber      uint64    `ch:"block_number"`
-		BlockHash        string    `ch:"block_hash"`
-		TransactionHash  string    `ch:"transaction_hash"`
-		TransactionIndex uint32    `ch:"transaction_index"`
-		LogIndex         uint32    `ch:"log_index"`
-		Address          string    `ch:"address"`
-		Data             string    `ch:"data"`
-		Topics           []string  `ch:"topics"`
-		BlockTimestamp   time.Time `ch:"block_timestamp"`
-		Topic0           string    `ch:"topic0"`
-	}
+	var result []rowtypes.LogRow
 
-	query := "SELECT * FROM " + table + " WHERE topic0 = ? ORDER BY block_timestamp DESC LIMIT ?"
+	query := "SELECT " + rowtypes.LogColumns + " FROM " + table + " FINAL WHERE topic0 = ? ORDER BY block_timestamp DESC LIMIT ?"
 	err := r.Client.Select(ctx, &result, query, topic0, limit)
 	if err != nil {
 		r.Logger.Errorf("Failed to fetch logs for topic0 %s: %v", topic0, err)
 		return nil, err
 	}
 
-	// Конвертируем результаты в модели Log
-	logs := make([]models.Log, len(result))
-	for i, row := range result {
-		logs[i] = models.Log{
-			Address:         row.Address,
-			Topics:          row.Topics,
-			Data:            row.Data,
-			TransactionHash: row.TransactionHash,
-			LogIndex:        uint64(row.LogIndex),
-			Removed:         false, // По умолчанию false
-		}
-	}
-
+	logs := logsFromRows(result)
 	r.Logger.Debugf("Successfully fetched %d logs for topic0 %s", len(logs), topic0)
 	return logs, nil
 }
@@ -203,39 +119,36 @@ func (r *LogRepository) FetchLogsByTopic0(table string, topic0 string, limit int
 func (r *LogRepository) FetchLogsByAddressAndTopic(table string, address string, topic string, limit int) ([]models.Log, error) {
 	ctx := context.Background()
 
-	var result []struct {
-		BlockNumber      uint64    `ch:"block_number"`
-		BlockHash        string    `ch:"block_hash"`
-		TransactionHash  string    `ch:"transaction_hash"`
-		TransactionIndex uint32    `ch:"transaction_index"`
-		LogIndex         uint32    `ch:"log_index"`
-		Address          string    `ch:"address"`
-		Data             string    `ch:"data"`
-		Topics           []string  `ch:"topics"`
-		BlockTimestamp   time.Time `ch:"block_timestamp"`
-		Topic0           string    `ch:"topic0"`
-	}
+	var result []rowtypes.LogRow
 
-	query := "SELECT * FROM " + table + " WHERE address = ? AND has(topics, ?) ORDER BY block_timestamp DESC LIMIT ?"
+	query := "SELECT " + rowtypes.LogColumns + " FROM " + table + " FINAL WHERE address = ? AND has(topics, ?) ORDER BY block_timestamp DESC LIMIT ?"
 	err := r.Client.Select(ctx, &result, query, address, topic, limit)
 	if err != nil {
 		r.Logger.Errorf("Failed to fetch logs for address %s and topic %s: %v", address, topic, err)
 		return nil, err
 	}
 
-	// Конвертируем результаты в модели Log
-	logs := make([]models.Log, len(result))
-	for i, row := range result {
+	logs := logsFromRows(result)
+	r.Logger.Debugf("Successfully fetched %d logs for address %s and topic %s", len(logs), address, topic)
+	return logs, nil
+}
+
+// logsFromRows конвертирует строки ClickHouse в модели Log
+func logsFromRows(rows []rowtypes.LogRow) []models.Log {
+	logs := make([]models.Log, len(rows))
+	for i, row := range rows {
 		logs[i] = models.Log{
-			Address:         row.Address,
-			Topics:          row.Topics,
-			Data:            row.Data,
-			TransactionHash: row.TransactionHash,
-			LogIndex:        uint64(row.LogIndex),
-			Removed:         false, // По умолчанию false
+			BlockNumber:      uint(row.BlockNumber),
+			BlockHash:        row.BlockHash,
+			TransactionHash:  row.TransactionHash,
+			TransactionIndex: uint(row.TransactionIndex),
+			LogIndex:         uint(row.LogIndex),
+			Address:          row.Address,
+			Data:             row.Data,
+			Topics:           row.Topics,
+			BlockTimestamp:   row.BlockTimestamp.UTC(),
+			Topic0:           row.Topic0,
 		}
 	}
-
-	r.Logger.Debugf("Successfully fetched %d logs for address %s and topic %s", len(logs), address, topic)
-	return logs, nil
+	return logs
 }
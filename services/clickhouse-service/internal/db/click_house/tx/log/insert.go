@@ -2,7 +2,6 @@ package log
 
 import (
 	"context"
-	"time"
 
 	clientsDB "lib/clients/db"
 	"lib/models"
@@ -22,35 +21,12 @@ func NewLogRepository(client clientsDB.ClickhouseClient, logger *logging.Logger)
 }
 
 // InsertLog вставляет один лог в таблицу
-func (r *LogRepository) InsertLog(table string, log models.Log, blockHash string, blockNumber uint64, blockTimestamp uint64) error {
-	ctx := context.Background()
-
-	row := convertLogToClickHouseRow(log, blockHash, blockNumber, blockTimestamp, 0)
-
-	batch, err := r.Client.PrepareBatch(ctx, "INSERT INTO "+table+" VALUES")
-	if err != nil {
-		r.Logger.Errorf("Failed to prepare batch for log insert: %v", err)
-		return err
-	}
-
-	err = batch.Append(row...)
-	if err != nil {
-		r.Logger.Errorf("Failed to append log to batch: %v", err)
-		return err
-	}
-
-	err = batch.Send()
-	if err != nil {
-		r.Logger.Errorf("Failed to send batch for log insert: %v", err)
-		return err
-	}
-
-	r.Logger.Debugf("Successfully inserted log for transaction %s", log.TransactionHash)
-	return nil
+func (r *LogRepository) InsertLog(table string, log models.Log) error {
+	return r.InsertLogs(table, []models.Log{log})
 }
 
 // InsertLogs вставляет массив логов в таблицу
-func (r *LogRepository) InsertLogs(table string, logs []models.Log, blockHash string, blockNumber uint64, blockTimestamp uint64) error {
+func (r *LogRepository) InsertLogs(table string, logs []models.Log) error {
 	if len(logs) == 0 {
 		return nil
 	}
@@ -63,11 +39,10 @@ func (r *LogRepository) InsertLogs(table string, logs []models.Log, blockHash st
 		return err
 	}
 
-	for i, log := range logs {
-		row := convertLogToClickHouseRow(log, blockHash, blockNumber, blockTimestamp, uint32(i))
-		err = batch.Append(row...)
+	for _, log := range logs {
+		err = batch.Append(convertLogToClickHouseRow(log)...)
 		if err != nil {
-			r.Logger.Errorf("Failed to append log %d to batch: %v", i, err)
+			r.Logger.Errorf("Failed to append log %s/%d to batch: %v", log.TransactionHash, log.LogIndex, err)
 			return err
 		}
 	}
@@ -83,87 +58,38 @@ func (r *LogRepository) InsertLogs(table string, logs []models.Log, blockHash st
 }
 
 // InsertLogsFromReceipt вставляет логи из квитанции
-func (r *LogRepository) InsertLogsFromReceipt(table string, receipt models.Receipt, txHash string, txIndex uint32, blockHash string, blockNumber uint64, blockTimestamp uint64) error {
-	if len(receipt.Logs) == 0 {
-		return nil
-	}
-
-	ctx := context.Background()
-
-	batch, err := r.Client.PrepareBatch(ctx, "INSERT INTO "+table+" VALUES")
-	if err != nil {
-		r.Logger.Errorf("Failed to prepare batch for logs from receipt insert: %v", err)
-		return err
-	}
-
-	for i, log := range receipt.Logs {
-		row := convertLogToClickHouseRow(log, blockHash, blockNumber, blockTimestamp, txIndex)
-		err = batch.Append(row...)
-		if err != nil {
-			r.Logger.Errorf("Failed to append log %d to batch: %v", i, err)
-			return err
-		}
-	}
-
-	err = batch.Send()
-	if err != nil {
-		r.Logger.Errorf("Failed to send batch for logs from receipt insert: %v", err)
-		return err
-	}
-
-	r.Logger.Debugf("Successfully inserted %d logs from receipt for transaction %s", len(receipt.Logs), txHash)
-	return nil
+func (r *LogRepository) InsertLogsFromReceipt(table string, receipt models.Receipt) error {
+	return r.InsertLogs(table, receipt.Logs)
 }
 
-// InsertLogsFromBlock вставляет логи из блока
-func (r *LogRepository) InsertLogsFromBlock(table string, block models.Block) error {
-	ctx := context.Background()
-
-	batch, err := r.Client.PrepareBatch(ctx, "INSERT INTO "+table+" VALUES")
-	if err != nil {
-		r.Logger.Errorf("Failed to prepare batch for logs from block insert: %v", err)
-		return err
-	}
-
-	logCount := 0
-	for i, tx := range block.Transactions {
+// InsertLogsFromTxs вставляет логи из квитанций транзакций
+func (r *LogRepository) InsertLogsFromTxs(table string, txs []models.Tx) error {
+	var logs []models.Log
+	for _, tx := range txs {
 		if tx.Receipt != nil {
-			for _, log := range tx.Receipt.Logs {
-				row := convertLogToClickHouseRow(log, block.Hash, block.Number, block.Timestamp, uint32(i))
-				err = batch.Append(row...)
-				if err != nil {
-					r.Logger.Errorf("Failed to append log to batch: %v", err)
-					return err
-				}
-				logCount++
-			}
+			logs = append(logs, tx.Receipt.Logs...)
 		}
 	}
-
-	err = batch.Send()
-	if err != nil {
-		r.Logger.Errorf("Failed to send batch for logs from block insert: %v", err)
-		return err
-	}
-
-	r.Logger.Debugf("Successfully inserted %d logs from block %s", logCount, block.Hash)
-	return nil
+	return r.InsertLogs(table, logs)
 }
 
 // convertLogToClickHouseRow конвертирует Log в строку для вставки в ClickHouse
-func convertLogToClickHouseRow(log models.Log, blockHash string, blockNumber uint64, blockTimestamp uint64, txIndex uint32) []interface{} {
-	timestamp := time.Unix(int64(blockTimestamp), 0)
+func convertLogToClickHouseRow(log models.Log) []interface{} {
+	topics := log.Topics
+	if topics == nil {
+		topics = []string{}
+	}
 
 	return []interface{}{
-		blockNumber,          // block_number UInt64
-		blockHash,            // block_hash FixedString(66)
-		log.TransactionHash,   // transaction_hash FixedString(66)
-		txIndex,              // transaction_index UInt32
-		uint32(log.LogIndex), // log_index UInt32
-		log.Address,          // address FixedString(42)
-		log.Data,             // data String
-		log.Topics,           // topics Array(FixedString(66))
-		timestamp,            // block_timestamp DateTime64(3, 'UTC')
-		// date и topic0 автоматически вычисляются
+		uint64(log.BlockNumber),      // block_number
+		log.BlockHash,                // block_hash
+		log.TransactionHash,          // transaction_hash
+		uint32(log.TransactionIndex), // transaction_index
+		uint32(log.LogIndex),         // log_index
+		log.Address,                  // address
+		log.Data,                     // data
+		topics,                       // topics
+		log.BlockTimestamp,           // block_timestamp
+		// date и topic0 вычисляются автоматически
 	}
 }
@@ -2,8 +2,6 @@ package receipt
 
 import (
 	"context"
-	"strconv"
-	"time"
 
 	"clickhouse-service/internal/db/click_house/rowtypes"
 	"lib/models"
@@ -15,7 +13,7 @@ func (r *ReceiptRepository) FetchReceipt(table string, txHash string) (models.Re
 
 	var result []rowtypes.ReceiptRow
 
-	query := "SELECT * FROM " + table + " WHERE transaction_hash = ? LIMIT 1"
+	query := "SELECT " + rowtypes.ReceiptColumns + " FROM " + table + " FINAL WHERE transaction_hash = ? LIMIT 1"
 	err := r.Client.Select(ctx, &result, query, txHash)
 	if err != nil {
 		r.Logger.Errorf("Failed to fetch receipt for transaction %s: %v", txHash, err)
@@ -26,28 +24,7 @@ func (r *ReceiptRepository) FetchReceipt(table string, txHash string) (models.Re
 		return models.Receipt{}, nil
 	}
 
-	// Конвертируем результат в модель Receipt
-	row := result[0]
-	receipt := models.Receipt{
-		From:              row.From,
-		CumulativeGasUsed: row.CumulativeGasUsed,
-		GasUsed:           row.GasUsed,
-		EffectiveGasPrice: formatUint64ToHex(row.EffectiveGasPrice),
-		LogsBloom:         row.LogsBloom,
-		Status:            uint64(row.Status),
-		Type:              row.Status, // Используем status как type
-	}
-
-	// Конвертируем to если есть
-	if row.To != nil {
-		receipt.To = *row.To
-	}
-
-	// Конвертируем contractAddress если есть
-	if row.ContractAddress != nil {
-		receipt.ContractAddress = *row.ContractAddress
-	}
-
+	receipt := receiptFromRow(result[0])
 	r.Logger.Debugf("Successfully fetched receipt for transaction %s", txHash)
 	return receipt, nil
 }
@@ -62,39 +39,14 @@ func (r *ReceiptRepository) FetchReceipts(table string, txHashes []string) ([]mo
 
 	var result []rowtypes.ReceiptRow
 
-	query := "SELECT * FROM " + table + " WHERE transaction_hash IN (?)"
+	query := "SELECT " + rowtypes.ReceiptColumns + " FROM " + table + " FINAL WHERE transaction_hash IN (?)"
 	err := r.Client.Select(ctx, &result, query, txHashes)
 	if err != nil {
 		r.Logger.Errorf("Failed to fetch receipts: %v", err)
 		return nil, err
 	}
 
-	// Конвертируем результаты в модели Receipt
-	receipts := make([]models.Receipt, len(result))
-	for i, row := range result {
-		receipt := models.Receipt{
-			From:              row.From,
-			CumulativeGasUsed: row.CumulativeGasUsed,
-			GasUsed:           row.GasUsed,
-			EffectiveGasPrice: formatUint64ToHex(row.EffectiveGasPrice),
-			LogsBloom:         row.LogsBloom,
-			Status:            uint64(row.Status),
-			Type:              row.Status, // Используем status как type
-		}
-
-		// Конвертируем to если есть
-		if row.To != nil {
-			receipt.To = *row.To
-		}
-
-		// Конвертируем contractAddress если есть
-		if row.ContractAddress != nil {
-			receipt.ContractAddress = *row.ContractAddress
-		}
-
-		receipts[i] = receipt
-	}
-
+	receipts := receiptsFromRows(result)
 	r.Logger.Debugf("Successfully fetched %d receipts", len(receipts))
 	return receipts, nil
 }
@@ -105,37 +57,14 @@ func (r *ReceiptRepository) FetchReceiptsByBlock(table string, blockHash string)
 
 	var result []rowtypes.ReceiptRow
 
-	query := "SELECT * FROM " + table + " WHERE block_hash = ? ORDER BY transaction_index"
+	query := "SELECT " + rowtypes.ReceiptColumns + " FROM " + table + " FINAL WHERE block_hash = ? ORDER BY transaction_index"
 	err := r.Client.Select(ctx, &result, query, blockHash)
 	if err != nil {
-		r.Logger.Errorf("Failed to fetch receipts by block %s: %v", blockHash, err)
+		r.Logger.Errorf("Failed to fetch receipts for block %s: %v", blockHash, err)
 		return nil, err
 	}
 
-	// Конвертируем результаты в модели Receipt (аналогично FetchReceipts)
-	receipts := make([]models.Receipt, len(result))
-	for i, row := range result {
-		receipt := models.Receipt{
-			From:              row.From,
-			CumulativeGasUsed: row.CumulativeGasUsed,
-			GasUsed:           row.GasUsed,
-			EffectiveGasPrice: formatUint64ToHex(row.EffectiveGasPrice),
-			LogsBloom:         row.LogsBloom,
-			Status:            uint64(row.Status),
-			Type:              row.Status, // Используем status как type
-		}
-
-		if row.To != nil {
-			receipt.To = *row.To
-		}
-
-		if row.ContractAddress != nil {
-			receipt.ContractAddress = *row.ContractAddress
-		}
-
-		receipts[i] = receipt
-	}
-
+	receipts := receiptsFromRows(result)
 	r.Logger.Debugf("Successfully fetched %d receipts for block %s", len(receipts), blockHash)
 	return receipts, nil
 }
@@ -146,37 +75,14 @@ func (r *ReceiptRepository) FetchReceiptsByBlockNumber(table string, blockNumber
 
 	var result []rowtypes.ReceiptRow
 
-	query := "SELECT * FROM " + table + " WHERE block_number = ? ORDER BY transaction_index"
+	query := "SELECT " + rowtypes.ReceiptColumns + " FROM " + table + " FINAL WHERE block_number = ? ORDER BY transaction_index"
 	err := r.Client.Select(ctx, &result, query, blockNumber)
 	if err != nil {
-		r.Logger.Errorf("Failed to fetch receipts by block number %d: %v", blockNumber, err)
+		r.Logger.Errorf("Failed to fetch receipts for block number %d: %v", blockNumber, err)
 		return nil, err
 	}
 
-	// Конвертируем результаты в модели Receipt (аналогично FetchReceipts)
-	receipts := make([]models.Receipt, len(result))
-	for i, row := range result {
-		receipt := models.Receipt{
-			From:              row.From,
-			CumulativeGasUsed: row.CumulativeGasUsed,
-			GasUsed:           row.GasUsed,
-			EffectiveGasPrice: formatUint64ToHex(row.EffectiveGasPrice),
-			LogsBloom:         row.LogsBloom,
-			Status:            uint64(row.Status),
-			Type:              row.Status, // Используем status как type
-		}
-
-		if row.To != nil {
-			receipt.To = *row.To
-		}
-
-		if row.ContractAddress != nil {
-			receipt.ContractAddress = *row.ContractAddress
-		}
-
-		receipts[i] = receipt
-	}
-
+	receipts := receiptsFromRows(result)
 	r.Logger.Debugf("Successfully fetched %d receipts for block number %d", len(receipts), blockNumber)
 	return receipts, nil
 }
@@ -185,58 +91,46 @@ func (r *ReceiptRepository) FetchReceiptsByBlockNumber(table string, blockNumber
 func (r *ReceiptRepository) FetchReceiptsByAddress(table string, address string, limit int) ([]models.Receipt, error) {
 	ctx := context.Background()
 
-	var result []struct {
-		TransactionHash   string    `ch:"transaction_hash"`
-		TransactionIndex  uint32    `ch:"transaction_index"`
-		BlockHash         string    `ch:"block_hash"`
-		BlockNumber       uint64    `ch:"block_number"`
-		From              string    `ch:"from"`
-		To                *string   `ch:"to"`
-		ContractAddress   *string   `ch:"contract_address"`
-		CumulativeGasUsed uint64    `ch:"cumulative_gas_used"`
-		GasUsed           uint64    `ch:"gas_used"`
-		EffectiveGasPrice uint64    `ch:"effective_gas_price"`
-		Status            uint8     `ch:"status"`
-		LogsBloom         string    `ch:"logs_bloom"`
-		BlockTimestamp    time.Time `ch:"block_timestamp"`
-	}
+	var result []rowtypes.ReceiptRow
 
-	query := "SELECT * FROM " + table + " WHERE from = ? OR to = ? OR contract_address = ? ORDER BY block_timestamp DESC LIMIT ?"
+	query := "SELECT " + rowtypes.ReceiptColumns + " FROM " + table +
+		` FINAL WHERE "from" = ? OR "to" = ? OR contract_address = ? ORDER BY block_timestamp DESC LIMIT ?`
 	err := r.Client.Select(ctx, &result, query, address, address, address, limit)
 	if err != nil {
 		r.Logger.Errorf("Failed to fetch receipts by address %s: %v", address, err)
 		return nil, err
 	}
 
-	// Конвертируем результаты в модели Receipt (аналогично FetchReceipts)
-	receipts := make([]models.Receipt, len(result))
-	for i, row := range result {
-		receipt := models.Receipt{
-			From:              row.From,
-			CumulativeGasUsed: row.CumulativeGasUsed,
-			GasUsed:           row.GasUsed,
-			EffectiveGasPrice: formatUint64ToHex(row.EffectiveGasPrice),
-			LogsBloom:         row.LogsBloom,
-			Status:            uint64(row.Status),
-			Type:              row.Status, // Используем status как type
-		}
-
-		if row.To != nil {
-			receipt.To = *row.To
-		}
-
-		if row.ContractAddress != nil {
-			receipt.ContractAddress = *row.ContractAddress
-		}
-
-		receipts[i] = receipt
-	}
-
+	receipts := receiptsFromRows(result)
 	r.Logger.Debugf("Successfully fetched %d receipts for address %s", len(receipts), address)
 	return receipts, nil
 }
 
-// formatUint64ToHex конвертирует uint64 в hex строку
-func formatUint64ToHex(n uint64) string {
-	return "0x" + strconv.FormatUint(n, 16)
+func receiptsFromRows(rows []rowtypes.ReceiptRow) []models.Receipt {
+	receipts := make([]models.Receipt, len(rows))
+	for i, row := range rows {
+		receipts[i] = receiptFromRow(row)
+	}
+	return receipts
+}
+
+// receiptFromRow конвертирует строку ClickHouse в модель Receipt (без логов)
+func receiptFromRow(row rowtypes.ReceiptRow) models.Receipt {
+	return models.Receipt{
+		TransactionHash:   row.TransactionHash,
+		TransactionIndex:  uint(row.TransactionIndex),
+		BlockHash:         row.BlockHash,
+		BlockNumber:       uint(row.BlockNumber),
+		From:              row.From,
+		To:                row.To,
+		ContractAddress:   row.ContractAddress,
+		CumulativeGasUsed: uint(row.CumulativeGasUsed),
+		GasUsed:           uint(row.GasUsed),
+		EffectiveGasPrice: uint(row.EffectiveGasPrice),
+		BlobGasUsed:       uint(row.BlobGasUsed),
+		BlobGasPrice:      uint(row.BlobGasPrice),
+		Status:            uint(row.Status),
+		LogsBloom:         row.LogsBloom,
+		BlockTimestamp:    row.BlockTimestamp.UTC(),
+	}
 }
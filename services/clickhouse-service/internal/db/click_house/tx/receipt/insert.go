@@ -2,8 +2,6 @@ package receipt
 
 import (
 	"context"
-	"strconv"
-	"time"
 
 	clientsDB "lib/clients/db"
 	"lib/models"
@@ -23,35 +21,12 @@ func NewReceiptRepository(client clientsDB.ClickhouseClient, logger *logging.Log
 }
 
 // InsertReceipt вставляет одну квитанцию в таблицу
-func (r *ReceiptRepository) InsertReceipt(table string, receipt models.Receipt, txHash string, txIndex uint32, blockHash string, blockNumber uint64, blockTimestamp uint64) error {
-	ctx := context.Background()
-
-	row := convertReceiptToClickHouseRow(receipt, txHash, txIndex, blockHash, blockNumber, blockTimestamp)
-
-	batch, err := r.Client.PrepareBatch(ctx, "INSERT INTO "+table+" VALUES")
-	if err != nil {
-		r.Logger.Errorf("Failed to prepare batch for receipt insert: %v", err)
-		return err
-	}
-
-	err = batch.Append(row...)
-	if err != nil {
-		r.Logger.Errorf("Failed to append receipt to batch: %v", err)
-		return err
-	}
-
-	err = batch.Send()
-	if err != nil {
-		r.Logger.Errorf("Failed to send batch for receipt insert: %v", err)
-		return err
-	}
-
-	r.Logger.Debugf("Successfully inserted receipt for transaction %s", txHash)
-	return nil
+func (r *ReceiptRepository) InsertReceipt(table string, receipt models.Receipt) error {
+	return r.InsertReceipts(table, []models.Receipt{receipt})
 }
 
 // InsertReceipts вставляет массив квитанций в таблицу
-func (r *ReceiptRepository) InsertReceipts(table string, receipts []models.Receipt, txHashes []string, txIndexes []uint32, blockHash string, blockNumber uint64, blockTimestamp uint64) error {
+func (r *ReceiptRepository) InsertReceipts(table string, receipts []models.Receipt) error {
 	if len(receipts) == 0 {
 		return nil
 	}
@@ -64,11 +39,10 @@ func (r *ReceiptRepository) InsertReceipts(table string, receipts []models.Recei
 		return err
 	}
 
-	for i, receipt := range receipts {
-		row := convertReceiptToClickHouseRow(receipt, txHashes[i], txIndexes[i], blockHash, blockNumber, blockTimestamp)
-		err = batch.Append(row...)
+	for _, receipt := range receipts {
+		err = batch.Append(convertReceiptToClickHouseRow(receipt)...)
 		if err != nil {
-			r.Logger.Errorf("Failed to append receipt %d to batch: %v", i, err)
+			r.Logger.Errorf("Failed to append receipt for transaction %s to batch: %v", receipt.TransactionHash, err)
 			return err
 		}
 	}
@@ -83,86 +57,35 @@ func (r *ReceiptRepository) InsertReceipts(table string, receipts []models.Recei
 	return nil
 }
 
-// InsertReceiptsFromBlock вставляет квитанции из блока
-func (r *ReceiptRepository) InsertReceiptsFromBlock(table string, block models.Block) error {
-	if len(block.Transactions) == 0 {
-		return nil
-	}
-
-	ctx := context.Background()
-
-	batch, err := r.Client.PrepareBatch(ctx, "INSERT INTO "+table+" VALUES")
-	if err != nil {
-		r.Logger.Errorf("Failed to prepare batch for receipts from block insert: %v", err)
-		return err
-	}
-
-	for i, tx := range block.Transactions {
+// InsertReceiptsFromTxs вставляет квитанции транзакций; транзакции без квитанции пропускаются
+func (r *ReceiptRepository) InsertReceiptsFromTxs(table string, txs []models.Tx) error {
+	receipts := make([]models.Receipt, 0, len(txs))
+	for _, tx := range txs {
 		if tx.Receipt != nil {
-			row := convertReceiptToClickHouseRow(*tx.Receipt, tx.Hash, uint32(i), block.Hash, block.Number, block.Timestamp)
-			err = batch.Append(row...)
-			if err != nil {
-				r.Logger.Errorf("Failed to append receipt for transaction %s to batch: %v", tx.Hash, err)
-				return err
-			}
+			receipts = append(receipts, *tx.Receipt)
 		}
 	}
-
-	err = batch.Send()
-	if err != nil {
-		r.Logger.Errorf("Failed to send batch for receipts from block insert: %v", err)
-		return err
-	}
-
-	r.Logger.Debugf("Successfully inserted receipts from block %s", block.Hash)
-	return nil
+	return r.InsertReceipts(table, receipts)
 }
 
 // convertReceiptToClickHouseRow конвертирует Receipt в строку для вставки в ClickHouse
-func convertReceiptToClickHouseRow(receipt models.Receipt, txHash string, txIndex uint32, blockHash string, blockNumber uint64, blockTimestamp uint64) []interface{} {
-	timestamp := time.Unix(int64(blockTimestamp), 0)
-
-	// Конвертируем to в указатель
-	var to *string
-	if receipt.To != "" {
-		to = &receipt.To
-	}
-
-	// Конвертируем contractAddress в указатель
-	var contractAddress *string
-	if receipt.ContractAddress != "" {
-		contractAddress = &receipt.ContractAddress
-	}
-
-	// Конвертируем effectiveGasPrice
-	effectiveGasPrice, _ := parseHexToUint64(receipt.EffectiveGasPrice)
-
+func convertReceiptToClickHouseRow(receipt models.Receipt) []interface{} {
 	return []interface{}{
-		txHash,                    // transaction_hash FixedString(66)
-		txIndex,                   // transaction_index UInt32
-		blockHash,                 // block_hash FixedString(66)
-		blockNumber,               // block_number UInt64
-		receipt.From,              // from FixedString(42)
-		to,                        // to Nullable(FixedString(42))
-		contractAddress,           // contract_address Nullable(FixedString(42))
-		receipt.CumulativeGasUsed, // cumulative_gas_used UInt64
-		receipt.GasUsed,           // gas_used UInt64
-		effectiveGasPrice,         // effective_gas_price UInt64
-		uint8(receipt.Status),     // status UInt8
-		receipt.LogsBloom,         // logs_bloom String
-		timestamp,                 // block_timestamp DateTime64(3, 'UTC')
-		// date автоматически вычисляется из block_timestamp
-	}
-}
-
-// Вспомогательные функции для парсинга
-func parseHexToUint64(hexStr string) (uint64, error) {
-	if hexStr == "" {
-		return 0, nil
-	}
-	// Убираем префикс 0x если есть
-	if len(hexStr) > 2 && hexStr[:2] == "0x" {
-		hexStr = hexStr[2:]
+		receipt.TransactionHash,           // transaction_hash
+		uint32(receipt.TransactionIndex),  // transaction_index
+		receipt.BlockHash,                 // block_hash
+		uint64(receipt.BlockNumber),       // block_number
+		receipt.From,                      // from
+		receipt.To,                        // to
+		receipt.ContractAddress,           // contract_address
+		uint64(receipt.CumulativeGasUsed), // cumulative_gas_used
+		uint64(receipt.GasUsed),           // gas_used
+		uint64(receipt.EffectiveGasPrice), // effective_gas_price
+		uint64(receipt.BlobGasUsed),       // blob_gas_used
+		uint64(receipt.BlobGasPrice),      // blob_gas_price
+		uint8(receipt.Status),             // status
+		receipt.LogsBloom,                 // logs_bloom
+		receipt.BlockTimestamp,            // block_timestamp
+		// date вычисляется из block_timestamp
 	}
-	return strconv.ParseUint(hexStr, 16, 64)
 }
@@ -0,0 +1,99 @@
+package uncle
+
+import (
+	"context"
+	"fmt"
+
+	"clickhouse-service/internal/db/click_house/rowtypes"
+	clientsDB "lib/clients/db"
+	"lib/models"
+	"lib/utils/logging"
+)
+
+type UncleRepository struct {
+	Client clientsDB.ClickhouseClient
+	Logger *logging.Logger
+}
+
+func NewUncleRepository(client clientsDB.ClickhouseClient, logger *logging.Logger) *UncleRepository {
+	return &UncleRepository{
+		Client: client,
+		Logger: logger,
+	}
+}
+
+// InsertUncles вставляет заголовки дядей в таблицу
+func (r *UncleRepository) InsertUncles(table string, uncles []models.Uncle) error {
+	if len(uncles) == 0 {
+		return nil
+	}
+
+	ctx := context.Background()
+
+	// Подготавливаем batch для вставки
+	batch, err := r.Client.PrepareBatch(ctx, "INSERT INTO "+table+" VALUES")
+	if err != nil {
+		r.Logger.Errorf("Failed to prepare batch for uncles insert: %v", err)
+		return err
+	}
+
+	for _, uncle := range uncles {
+		row, err := convertUncleToClickHouseRow(uncle)
+		if err != nil {
+			r.Logger.Errorf("Failed to convert uncle %s: %v", uncle.Hash, err)
+			return err
+		}
+		err = batch.Append(row...)
+		if err != nil {
+			r.Logger.Errorf("Failed to append uncle %s to batch: %v", uncle.Hash, err)
+			return err
+		}
+	}
+
+	// Выполняем вставку
+	err = batch.Send()
+	if err != nil {
+		r.Logger.Errorf("Failed to send batch for uncles insert: %v", err)
+		return err
+	}
+
+	r.Logger.Debugf("Successfully inserted %d uncles", len(uncles))
+	return nil
+}
+
+// InsertBlockUncles вставляет дядей, пришедших вместе с блоками
+func (r *UncleRepository) InsertBlockUncles(table string, blocks []models.Block) error {
+	var uncles []models.Uncle
+	for _, block := range blocks {
+		uncles = append(uncles, block.UncleHeaders...)
+	}
+	return r.InsertUncles(table, uncles)
+}
+
+// convertUncleToClickHouseRow конвертирует Uncle в строку для вставки в ClickHouse
+func convertUncleToClickHouseRow(uncle models.Uncle) ([]interface{}, error) {
+	difficulty, err := rowtypes.BigInt(uncle.Difficulty)
+	if err != nil {
+		return nil, fmt.Errorf("difficulty: %w", err)
+	}
+	reward, err := rowtypes.BigInt(uncle.Reward)
+	if err != nil {
+		return nil, fmt.Errorf("reward: %w", err)
+	}
+
+	return []interface{}{
+		uncle.Hash,                // hash
+		uint64(uncle.Number),      // number
+		uncle.ParentHash,          // parent_hash
+		uncle.Miner,               // miner
+		difficulty,                // difficulty
+		uint64(uncle.GasLimit),    // gas_limit
+		uint64(uncle.GasUsed),     // gas_used
+		uncle.Timestamp,           // timestamp
+		uint64(uncle.BlockNumber), // block_number
+		uncle.BlockHash,           // block_hash
+		uint8(uncle.UncleIndex),   // uncle_index
+		reward,                    // reward
+		uncle.BlockTimestamp,      // block_timestamp
+	}, nil
+}
@@ -5,7 +5,7 @@ import "lib/models"
 type DB interface {
 	Close() error
 
-	// Блоки
+	// Блоки; вместе с блоком вставляются заголовки его дядей и награды
 	InsertBlock(table string, block models.Block) error
 	InsertBlocks(table string, blocks []models.Block) error
 	FetchBlock(table string, hashBlock string) (models.Block, error)
@@ -27,7 +27,7 @@ type DB interface {
 	// Квитанции
 	InsertReceipt(table string, receipt models.Receipt) error
 	InsertReceipts(table string, receipts []models.Receipt) error
-	InsertReceiptsFromBlock(table string, block models.Block) error
+	InsertReceiptsFromTxs(table string, txs []models.Tx) error
 	FetchReceipt(table string, txHash string) (models.Receipt, error)
 	FetchReceipts(table string, txHashes []string) ([]models.Receipt, error)
 	FetchReceiptsByBlock(table string, blockHash string) ([]models.Receipt, error)
@@ -38,7 +38,7 @@ type DB interface {
 	InsertLog(table string, log models.Log) error
 	InsertLogs(table string, logs []models.Log) error
 	InsertLogsFromReceipt(table string, receipt models.Receipt) error
-	InsertLogsFromBlock(table string, block models.Block) error
+	InsertLogsFromTxs(table string, txs []models.Tx) error
 	FetchLogsByTransaction(table string, txHash string) ([]models.Log, error)
 	FetchLogsByBlock(table string, blockHash string) ([]models.Log, error)
 	FetchLogsByBlockNumber(table string, blockNumber uint64) ([]models.Log, error)
@@ -46,4 +46,13 @@ type DB interface {
 	FetchLogsByTopic(table string, topic string, limit int) ([]models.Log, error)
 	FetchLogsByTopic0(table string, topic0 string, limit int) ([]models.Log, error)
 	FetchLogsByAddressAndTopic(table string, address string, topic string, limit int) ([]models.Log, error)
+
+	// События пулов DEX
+	InsertDexTrades(table string, trades []models.DexTrade) error
+
+	// Созданные контракты
+	InsertContracts(table string, contracts []models.Contract) error
+
+	// Балансы и nonce адресов
+	InsertAccountStates(table string, states []models.AccountState) error
 }
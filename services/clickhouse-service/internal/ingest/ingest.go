@@ -0,0 +1,152 @@
+package ingest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"clickhouse-service/internal/db"
+	"lib/accounts"
+	"lib/clients/broker"
+	"lib/codec"
+	"lib/contracts"
+	"lib/dex"
+	"lib/models"
+	"lib/utils/logging"
+	"lib/utils/tracing"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Топик и таблицы, которые заполняет Ingester
+const (
+	BlocksTopic        = "blocks"
+	BlocksTable        = "blocks"
+	TransactionsTable  = "transactions"
+	ReceiptsTable      = "receipts"
+	LogsTable          = "logs"
+	DexTradesTable     = "dex_trades"
+	ContractsTable     = "contracts"
+	AccountStatesTable = "account_states"
+)
+
+// Enrichment — таблицы, которые строятся по транзакциям и квитанциям блока.
+// Txs == nil — сохраняются только блоки.
+type Enrichment struct {
+	Txs       TxSource
+	Dex       *dex.Extractor     // nil — события пулов не выделяются
+	Contracts *contracts.Indexer // nil — контракты не индексируются
+	Accounts  *accounts.Tracker  // nil — балансы адресов не записываются
+}
+
+// Ingester записывает блоки из брокера в ClickHouse
+type Ingester struct {
+	repo       db.DB
+	enrichment Enrichment
+	logger     *logging.Logger
+}
+
+func NewIngester(repo db.DB, enrichment Enrichment, logger *logging.Logger) *Ingester {
+	return &Ingester{
+		repo:       repo,
+		enrichment: enrichment,
+		logger:     logger,
+	}
+}
+
+// HandleBlock — обработчик сообщений топика blocks. Ошибка вставки возвращается
+// брокеру для повтора; нераспознаваемое сообщение помечается как Permanent.
+// Таблицы — ReplacingMergeTree, поэтому повтор после частичной вставки безопасен.
+func (i *Ingester) HandleBlock(ctx context.Context, msg models.MessageBroker) (err error) {
+	ctx = tracing.Extract(ctx, msg.Headers)
+
+	var block models.Block
+	if _, err := codec.Decode(msg, &block); err != nil {
+		return broker.Permanent(fmt.Errorf("decode block %s/%d@%d: %w", msg.Topic, msg.Partition, msg.Offset, err))
+	}
+
+	ctx, span := tracing.Start(ctx, "ingest.Block", trace.WithAttributes(tracing.BlockNumber(uint64(block.Number))))
+	defer func() { tracing.End(span, err) }()
+
+	if err := i.repo.InsertBlock(BlocksTable, block); err != nil {
+		return fmt.Errorf("insert block %d (%s): %w", block.Number, block.Hash, err)
+	}
+
+	if i.enrichment.Txs != nil {
+		if err := i.enrich(ctx, block); err != nil {
+			return err
+		}
+	}
+
+	i.logger.Debugf("Block %d (%s) stored", block.Number, block.Hash)
+	return nil
+}
+
+// enrich загружает транзакции блока с квитанциями и записывает их вместе
+// с производными таблицами
+func (i *Ingester) enrich(ctx context.Context, block models.Block) error {
+	txs, err := fetchTxs(ctx, i.enrichment.Txs, block)
+	if err != nil {
+		return err
+	}
+
+	if err := i.repo.InsertTxs(TransactionsTable, txs); err != nil {
+		return fmt.Errorf("insert txs of block %d: %w", block.Number, err)
+	}
+	if err := i.repo.InsertReceiptsFromTxs(ReceiptsTable, txs); err != nil {
+		return fmt.Errorf("insert receipts of block %d: %w", block.Number, err)
+	}
+	if err := i.repo.InsertLogsFromTxs(LogsTable, txs); err != nil {
+		return fmt.Errorf("insert logs of block %d: %w", block.Number, err)
+	}
+
+	if i.enrichment.Dex != nil {
+		trades, err := i.enrichment.Dex.Extract(ctx, blockLogs(txs))
+		if err != nil {
+			return fmt.Errorf("extract dex trades of block %d: %w", block.Number, err)
+		}
+		if err := i.repo.InsertDexTrades(DexTradesTable, trades); err != nil {
+			return fmt.Errorf("insert dex trades of block %d: %w", block.Number, err)
+		}
+	}
+
+	if i.enrichment.Contracts != nil {
+		created, err := i.enrichment.Contracts.Index(ctx, block, txs)
+		if err != nil {
+			return fmt.Errorf("index contracts of block %d: %w", block.Number, err)
+		}
+		if err := i.repo.InsertContracts(ContractsTable, created); err != nil {
+			return fmt.Errorf("insert contracts of block %d: %w", block.Number, err)
+		}
+	}
+
+	if i.enrichment.Accounts != nil {
+		states, err := i.enrichment.Accounts.Track(ctx, block, txs)
+		if errors.Is(err, accounts.ErrBlockOrder) {
+			// Повтор уже записанного или запоздавший блок: изменения отслеживаемых
+			// адресов считаются от более нового состояния, повтор не поможет
+			return broker.Permanent(fmt.Errorf("track accounts of block %d: %w", block.Number, err))
+		}
+		if err != nil {
+			return fmt.Errorf("track accounts of block %d: %w", block.Number, err)
+		}
+		if err := i.repo.InsertAccountStates(AccountStatesTable, states); err != nil {
+			return fmt.Errorf("insert account states of block %d: %w", block.Number, err)
+		}
+		if err := i.enrichment.Accounts.Commit(block, states); err != nil {
+			return fmt.Errorf("commit account states of block %d: %w", block.Number, err)
+		}
+	}
+	return nil
+}
+
+// blockLogs — логи всех квитанций блока в порядке транзакций
+func blockLogs(txs []models.Tx) []models.Log {
+	var logs []models.Log
+	for _, tx := range txs {
+		if tx.Receipt != nil {
+			logs = append(logs, tx.Receipt.Logs...)
+		}
+	}
+	return logs
+}
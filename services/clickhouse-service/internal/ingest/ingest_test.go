@@ -0,0 +1,578 @@
+package ingest
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	clickhouseRepo "clickhouse-service/internal/db/click_house"
+	"lib/accounts"
+	"lib/blocks/collector"
+	"lib/blocks/metrics"
+	"lib/clients/broker"
+	clientsDB "lib/clients/db"
+	"lib/clients/node"
+	"lib/codec"
+	"lib/contracts"
+	"lib/dex"
+	"lib/models"
+	"lib/utils/logging"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/column"
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// fakeClickhouse принимает вставки в колонки драйвера, построенные по схеме
+// из db-schema/tables: число значений и их типы проверяются так же, как
+// при отправке batch в ClickHouse. failOnce — таблицы, первая отправка
+// в которые завершится ошибкой.
+type fakeClickhouse struct {
+	clientsDB.ClickhouseClient
+
+	mu       sync.Mutex
+	rows     map[string][][]any
+	failOnce map[string]bool
+}
+
+func newFakeClickhouse() *fakeClickhouse {
+	return &fakeClickhouse{rows: make(map[string][][]any), failOnce: make(map[string]bool)}
+}
+
+var insertQuery = regexp.MustCompile(`^INSERT INTO (\w+) VALUES$`)
+
+func (f *fakeClickhouse) PrepareBatch(_ context.Context, query string, _ ...driver.PrepareBatchOption) (driver.Batch, error) {
+	m := insertQuery.FindStringSubmatch(query)
+	if m == nil {
+		return nil, fmt.Errorf("unexpected query %q", query)
+	}
+	columns, err := schemaColumns(m[1])
+	if err != nil {
+		return nil, err
+	}
+	return &fakeBatch{client: f, table: m[1], columns: columns}, nil
+}
+
+func (f *fakeClickhouse) table(name string) [][]any {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.rows[name]
+}
+
+type fakeBatch struct {
+	driver.Batch
+
+	client  *fakeClickhouse
+	table   string
+	columns []column.Interface
+	rows    [][]any
+	sent    bool
+}
+
+func (b *fakeBatch) Append(v ...any) error {
+	if len(v) != len(b.columns) {
+		return fmt.Errorf("%s: %d values for %d columns", b.table, len(v), len(b.columns))
+	}
+	for i, col := range b.columns {
+		if err := col.AppendRow(v[i]); err != nil {
+			return fmt.Errorf("%s.%s: %w", b.table, col.Name(), err)
+		}
+	}
+	b.rows = append(b.rows, v)
+	return nil
+}
+
+func (b *fakeBatch) Send() error {
+	if b.sent {
+		return fmt.Errorf("%s: batch already sent", b.table)
+	}
+	b.sent = true
+	b.client.mu.Lock()
+	defer b.client.mu.Unlock()
+	if b.client.failOnce[b.table] {
+		delete(b.client.failOnce, b.table)
+		return fmt.Errorf("%s: connection reset", b.table)
+	}
+	b.client.rows[b.table] = append(b.client.rows[b.table], b.rows...)
+	return nil
+}
+
+var columnDef = regexp.MustCompile("^`(\\w+)`\\s+(.+)$")
+
+// schemaColumns читает вставляемые колонки таблицы из db-schema/tables/<table>.sql;
+// MATERIALIZED колонки в INSERT ... VALUES не передаются
+func schemaColumns(table string) ([]column.Interface, error) {
+	file, err := os.Open(filepath.Join("..", "..", "db-schema", "tables", table+".sql"))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	sc := &column.ServerContext{Timezone: time.UTC}
+	var columns []column.Interface
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "--")
+		m := columnDef.FindStringSubmatch(strings.TrimSpace(line))
+		if m == nil || strings.Contains(m[2], "MATERIALIZED") {
+			continue
+		}
+		col, err := column.Type(strings.TrimSuffix(strings.TrimSpace(m[2]), ",")).Column(m[1], sc)
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s: %w", table, m[1], err)
+		}
+		columns = append(columns, col)
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("no columns in schema of %s", table)
+	}
+	return columns, scanner.Err()
+}
+
+// goldenFixtures — golden-блоки lib/blocks/metrics/testdata: сырые ответы провайдера и модели
+func goldenFixtures(t *testing.T) map[string]metrics.Fixture {
+	t.Helper()
+	files, err := filepath.Glob(filepath.Join("..", "..", "..", "..", "lib", "blocks", "metrics", "testdata", "*.json"))
+	if err != nil || len(files) == 0 {
+		t.Fatalf("no golden blocks: %v", err)
+	}
+	fixtures := make(map[string]metrics.Fixture, len(files))
+	for _, path := range files {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var fx metrics.Fixture
+		if err := json.Unmarshal(data, &fx); err != nil {
+			t.Fatal(err)
+		}
+		fixtures[strings.TrimSuffix(filepath.Base(path), ".json")] = fx
+	}
+	return fixtures
+}
+
+// goldenBlocks — модели golden-блоков
+func goldenBlocks(t *testing.T) map[string]metrics.Converted {
+	t.Helper()
+	blocks := make(map[string]metrics.Converted)
+	for name, fx := range goldenFixtures(t) {
+		blocks[name] = *fx.Expected
+	}
+	return blocks
+}
+
+// fakeNode отвечает на батчи как провайдер: блок и квитанции из golden-файла,
+// token0/token1/factory — для известных пулов, getPair — для их фабрик (адрес
+// пула, только если он в created), revert — для остальных контрактов, code — байткод, balance и nonce — состояние любого адреса.
+// noBlockReceipts — узел не знает eth_getBlockReceipts.
+type fakeNode struct {
+	node.Provider
+
+	block           json.RawMessage
+	receipts        json.RawMessage
+	noBlockReceipts bool
+	pools           map[common.Address]dex.Pool
+	created         map[common.Address]bool
+	code            hexutil.Bytes
+	balance         *big.Int
+	nonce           uint64
+}
+
+type revertError struct{}
+
+func (revertError) Error() string  { return "execution reverted" }
+func (revertError) ErrorCode() int { return 3 }
+
+func (n *fakeNode) Name() string { return "fake" }
+
+func (n *fakeNode) BatchCallContext(_ context.Context, batch []rpc.BatchElem) error {
+	for i := range batch {
+		elem := &batch[i]
+		switch elem.Method {
+		case "eth_getBlockByHash":
+			*elem.Result.(*json.RawMessage) = n.block
+		case "eth_getBlockReceipts":
+			if n.noBlockReceipts {
+				elem.Error = fmt.Errorf("the method %s does not exist/is not available", elem.Method)
+				continue
+			}
+			*elem.Result.(*json.RawMessage) = n.receipts
+		case "eth_getTransactionReceipt":
+			var receipts []json.RawMessage
+			if err := json.Unmarshal(n.receipts, &receipts); err != nil {
+				return err
+			}
+			for _, receipt := range receipts {
+				var ids struct {
+					TxHash common.Hash `json:"transactionHash"`
+				}
+				if err := json.Unmarshal(receipt, &ids); err == nil && ids.TxHash == elem.Args[0].(common.Hash) {
+					*elem.Result.(*json.RawMessage) = receipt
+				}
+			}
+		case "eth_getCode":
+			*elem.Result.(*hexutil.Bytes) = n.code
+		case "eth_getBalance":
+			*elem.Result.(*hexutil.Big) = hexutil.Big(*n.balance)
+		case "eth_getTransactionCount":
+			*elem.Result.(*hexutil.Uint64) = hexutil.Uint64(n.nonce)
+		case "eth_call":
+			call := elem.Args[0].(map[string]any)
+			to, data := call["to"].(common.Address), call["data"].(hexutil.Bytes)
+			if pair, ok := n.getPair(to, data); ok {
+				*elem.Result.(*hexutil.Bytes) = common.LeftPadBytes(pair.Bytes(), 32)
+				continue
+			}
+			pool, ok := n.pools[to]
+			if !ok {
+				elem.Error = revertError{}
+				continue
+			}
+			word := map[string]common.Address{
+				"token0()":  pool.Token0,
+				"token1()":  pool.Token1,
+				"factory()": pool.Factory,
+			}
+			for signature, address := range word {
+				if hexutil.Encode(crypto.Keccak256([]byte(signature))[:4]) == call["data"].(hexutil.Bytes).String() {
+					*elem.Result.(*hexutil.Bytes) = common.LeftPadBytes(address.Bytes(), 32)
+				}
+			}
+		default:
+			elem.Error = fmt.Errorf("unexpected method %s", elem.Method)
+		}
+	}
+	return nil
+}
+
+// getPair отвечает за фабрику на getPair(token0, token1): адрес созданной ею
+// пары или нулевой адрес; ok == false — to не фабрика или вызван другой метод
+func (n *fakeNode) getPair(factory common.Address, data []byte) (common.Address, bool) {
+	isFactory := false
+	for _, pool := range n.pools {
+		isFactory = isFactory || pool.Factory == factory
+	}
+	selector := crypto.Keccak256([]byte("getPair(address,address)"))[:4]
+	if !isFactory || len(data) != 4+64 || !bytes.Equal(data[:4], selector) {
+		return common.Address{}, false
+	}
+	token0, token1 := common.BytesToAddress(data[4:36]), common.BytesToAddress(data[36:])
+	for address, pool := range n.pools {
+		if n.created[address] && pool.Factory == factory && pool.Token0 == token0 && pool.Token1 == token1 {
+			return address, true
+		}
+	}
+	return common.Address{}, true
+}
+
+func blockMessage(t *testing.T, block models.Block) models.MessageBroker {
+	t.Helper()
+	encoder, err := codec.NewEncoder("json", "none", "ethereum", string(models.ServiceRealtimeMiner))
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg, err := encoder.Encode(BlocksTopic, []byte(block.Hash), models.MessageTypeBlock, block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return msg
+}
+
+func TestHandleBlockStoresBlockAndUncles(t *testing.T) {
+	logger := logging.GetLogger()
+	for name, golden := range goldenBlocks(t) {
+		t.Run(name, func(t *testing.T) {
+			client := newFakeClickhouse()
+			ingester := NewIngester(clickhouseRepo.NewClickhouseService(client, logger), Enrichment{}, logger)
+
+			if err := ingester.HandleBlock(context.Background(), blockMessage(t, golden.Block)); err != nil {
+				t.Fatalf("handle block: %v", err)
+			}
+
+			blocks := client.table(BlocksTable)
+			if len(blocks) != 1 || blocks[0][0] != golden.Block.Hash {
+				t.Fatalf("blocks table = %v, want block %s", blocks, golden.Block.Hash)
+			}
+			if got, want := len(client.table(clickhouseRepo.UnclesTable)), len(golden.Block.UncleHeaders); got != want {
+				t.Errorf("%d uncles stored, want %d", got, want)
+			}
+			if rewards := client.table(clickhouseRepo.BlockRewardsTable); len(rewards) != 1 || rewards[0][0] != uint64(golden.Block.Number) {
+				t.Errorf("block_rewards table = %v, want rewards of block %d", rewards, golden.Block.Number)
+			}
+		})
+	}
+}
+
+// Квитанции golden-блоков, включая blob-транзакции, укладываются в схему receipts
+func TestReceiptsMatchSchema(t *testing.T) {
+	logger := logging.GetLogger()
+	for name, golden := range goldenBlocks(t) {
+		t.Run(name, func(t *testing.T) {
+			client := newFakeClickhouse()
+			repo := clickhouseRepo.NewClickhouseService(client, logger)
+
+			if err := repo.InsertReceiptsFromTxs("receipts", golden.Txs); err != nil {
+				t.Fatalf("insert receipts: %v", err)
+			}
+
+			rows := client.table("receipts")
+			if len(rows) != len(golden.Txs) {
+				t.Fatalf("%d receipts stored, want %d", len(rows), len(golden.Txs))
+			}
+			for i, tx := range golden.Txs {
+				// blob_gas_used и blob_gas_price идут сразу после effective_gas_price
+				if rows[i][10] != uint64(tx.Receipt.BlobGasUsed) || rows[i][11] != uint64(tx.Receipt.BlobGasPrice) {
+					t.Errorf("receipt %s blob gas = %v/%v, want %d/%d", tx.Hash, rows[i][10], rows[i][11], tx.Receipt.BlobGasUsed, tx.Receipt.BlobGasPrice)
+				}
+			}
+		})
+	}
+}
+
+// v2Swap — лог Swap пары Uniswap V2: amount0In в пул, amount1Out из пула
+func v2Swap(pool common.Address, tx models.Tx, logIndex uint, amount0In, amount1Out int64) map[string]any {
+	word := func(v int64) []byte { return common.LeftPadBytes(big.NewInt(v).Bytes(), 32) }
+	data := append(append(append(word(amount0In), word(0)...), word(0)...), word(amount1Out)...)
+	return map[string]any{
+		"address": pool,
+		"topics": []common.Hash{
+			crypto.Keccak256Hash([]byte("Swap(address,uint256,uint256,uint256,uint256,address)")),
+			common.BytesToHash(common.HexToAddress(tx.From).Bytes()),
+			common.BytesToHash(common.HexToAddress(tx.From).Bytes()),
+		},
+		"data":             hexutil.Bytes(data),
+		"blockNumber":      hexutil.Uint64(tx.BlockNumber),
+		"blockHash":        tx.BlockHash,
+		"transactionHash":  tx.Hash,
+		"transactionIndex": hexutil.Uint64(tx.TransactionIndex),
+		"logIndex":         hexutil.Uint64(logIndex),
+		"removed":          false,
+	}
+}
+
+func TestHandleBlockStoresTxsAndDerivedTables(t *testing.T) {
+	logger := logging.GetLogger()
+	fx := goldenFixtures(t)["legacy"]
+	golden := *fx.Expected
+	if len(golden.Txs) == 0 {
+		t.Fatal("golden block has no transactions")
+	}
+
+	var (
+		uniswapV2 = common.HexToAddress("0x5C69bEe701ef814a2B6a3EDD4B1652CB9cc5aA6f")
+		pair      = common.HexToAddress("0x00000000000000000000000000000000000000a1")
+		fakePair  = common.HexToAddress("0x00000000000000000000000000000000000000a2")
+		impostor  = common.HexToAddress("0x00000000000000000000000000000000000000a3")
+		token0    = common.HexToAddress("0x00000000000000000000000000000000000000b0")
+		token1    = common.HexToAddress("0x00000000000000000000000000000000000000b1")
+	)
+
+	// Swap пары разрешённой фабрики и такие же Swap контракта с чужой фабрикой
+	// и контракта, который называет своей разрешённую фабрику, но создан не ею
+	var receipts []map[string]any
+	if err := json.Unmarshal(fx.Receipts, &receipts); err != nil {
+		t.Fatal(err)
+	}
+	tx := golden.Txs[0]
+	logs, _ := receipts[0]["logs"].([]any)
+	receipts[0]["logs"] = append(logs, v2Swap(pair, tx, 100, 1000, 500), v2Swap(fakePair, tx, 101, 1000, 500),
+		v2Swap(impostor, tx, 102, 1000, 500))
+	rawReceipts, err := json.Marshal(receipts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	node := &fakeNode{
+		block:    fx.Block,
+		receipts: rawReceipts,
+		pools: map[common.Address]dex.Pool{
+			pair:     {Factory: uniswapV2, Token0: token0, Token1: token1},
+			fakePair: {Factory: common.HexToAddress("0x00000000000000000000000000000000000000ff"), Token0: token0, Token1: token1},
+			impostor: {Factory: uniswapV2, Token0: token0, Token1: token1},
+		},
+		created: map[common.Address]bool{pair: true, fakePair: true},
+		code:    hexutil.MustDecode("0x6080604052"),
+		balance: new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil),
+		nonce:   7,
+	}
+	pools, err := dex.NewPoolResolver(node, dex.Factories{V2: []string{uniswapV2.Hex()}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tracker, err := accounts.NewTracker(node, accounts.Options{Touched: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := newFakeClickhouse()
+	ingester := NewIngester(clickhouseRepo.NewClickhouseService(client, logger), Enrichment{
+		Txs:       collector.NewBlockCollector(node, logger),
+		Dex:       dex.NewExtractor(pools),
+		Contracts: contracts.NewIndexer(node, contracts.Options{Code: true}),
+		Accounts:  tracker,
+	}, logger)
+	if err := ingester.HandleBlock(context.Background(), blockMessage(t, golden.Block)); err != nil {
+		t.Fatalf("handle block: %v", err)
+	}
+
+	var wantLogs int
+	for _, tx := range golden.Txs {
+		wantLogs += len(tx.Receipt.Logs)
+	}
+	for table, want := range map[string]int{
+		BlocksTable:       1,
+		TransactionsTable: len(golden.Txs),
+		ReceiptsTable:     len(golden.Txs),
+		LogsTable:         wantLogs + 3,
+	} {
+		if got := len(client.table(table)); got != want {
+			t.Errorf("%d rows in %s, want %d", got, table, want)
+		}
+	}
+
+	trades := client.table(DexTradesTable)
+	if len(trades) != 1 {
+		t.Fatalf("%d dex trades stored, want 1 (pools not created by a listed factory must be skipped)", len(trades))
+	}
+	trade := trades[0]
+	// pool, amount0 (в пул), amount1 (из пула), amount_in, amount_out
+	if trade[2] != pair.Hex() || trade[6].(*big.Int).Int64() != 1000 || trade[7].(*big.Int).Int64() != -500 ||
+		trade[10].(*big.Int).Int64() != 1000 || trade[11].(*big.Int).Int64() != 500 {
+		t.Errorf("dex trade = %v, want swap of 1000 token0 for 500 token1 in %s", trade, pair.Hex())
+	}
+
+	// Контракт из успешной квитанции: адреса в checksum-виде, байткод загружен
+	var created []models.Tx
+	for _, tx := range golden.Txs {
+		if tx.Receipt.ContractAddress != nil && tx.Receipt.Status == 1 {
+			created = append(created, tx)
+		}
+	}
+	stored := client.table(ContractsTable)
+	if len(stored) != len(created) || len(created) == 0 {
+		t.Fatalf("%d contracts stored, want %d", len(stored), len(created))
+	}
+	from := common.HexToAddress(created[0].From).Hex()
+	if stored[0][1] != from || stored[0][2] != from || stored[0][5] == (*string)(nil) {
+		t.Errorf("contract = %v, want deployer and tx_from %s and a code hash", stored[0], from)
+	}
+
+	// Состояния затронутых адресов: баланс — UInt256, получатель комиссий среди них
+	states := client.table(AccountStatesTable)
+	miner := common.HexToAddress(golden.Block.Miner).Hex()
+	var minerStored bool
+	for _, state := range states {
+		if balance, ok := state[1].(*big.Int); !ok || balance.Cmp(node.balance) != 0 || state[2] != node.nonce {
+			t.Errorf("account state = %v, want balance %s and nonce %d", state, node.balance, node.nonce)
+		}
+		minerStored = minerStored || state[0] == miner
+	}
+	if len(states) == 0 || !minerStored {
+		t.Errorf("%d account states stored, want touched addresses including miner %s", len(states), miner)
+	}
+}
+
+func TestHandleBlockCommitsAccountStatesAfterInsert(t *testing.T) {
+	logger := logging.GetLogger()
+	golden := *goldenFixtures(t)["legacy"].Expected
+	fx := goldenFixtures(t)["legacy"]
+	miner := common.HexToAddress(golden.Block.Miner).Hex()
+
+	node := &fakeNode{block: fx.Block, receipts: fx.Receipts, balance: big.NewInt(5), nonce: 1}
+	tracker, err := accounts.NewTracker(node, accounts.Options{Watched: []string{miner}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := newFakeClickhouse()
+	client.failOnce[AccountStatesTable] = true
+	ingester := NewIngester(clickhouseRepo.NewClickhouseService(client, logger), Enrichment{
+		Txs:      collector.NewBlockCollector(node, logger),
+		Accounts: tracker,
+	}, logger)
+	msg := blockMessage(t, golden.Block)
+
+	// Вставка не удалась: брокер повторит блок
+	if err := ingester.HandleBlock(context.Background(), msg); err == nil || broker.IsPermanent(err) {
+		t.Fatalf("handle block with failed insert = %v, want retryable error", err)
+	}
+	// Повтор записывает состояние отслеживаемого адреса, хотя оно не менялось
+	if err := ingester.HandleBlock(context.Background(), msg); err != nil {
+		t.Fatalf("retry block: %v", err)
+	}
+	if states := client.table(AccountStatesTable); len(states) != 1 || states[0][0] != miner {
+		t.Fatalf("account states after retry = %v, want state of %s", states, miner)
+	}
+
+	// Повторная доставка записанного блока отклоняется без повторов
+	err = ingester.HandleBlock(context.Background(), msg)
+	if !broker.IsPermanent(err) || !errors.Is(err, accounts.ErrBlockOrder) {
+		t.Fatalf("redelivered block = %v, want permanent ErrBlockOrder", err)
+	}
+	if states := client.table(AccountStatesTable); len(states) != 1 {
+		t.Errorf("%d account states after redelivery, want 1", len(states))
+	}
+}
+
+func TestHandleBlockRejectsUndecodableMessage(t *testing.T) {
+	logger := logging.GetLogger()
+	client := newFakeClickhouse()
+	ingester := NewIngester(clickhouseRepo.NewClickhouseService(client, logger), Enrichment{}, logger)
+
+	msg := models.MessageBroker{Topic: BlocksTopic, Value: []byte("not a block")}
+	err := ingester.HandleBlock(context.Background(), msg)
+	if !broker.IsPermanent(err) {
+		t.Fatalf("handle undecodable message = %v, want permanent error", err)
+	}
+	if rows := client.table(BlocksTable); len(rows) != 0 {
+		t.Errorf("%d blocks stored from undecodable message", len(rows))
+	}
+}
+
+func TestHandleBlockFallsBackToTransactionReceipts(t *testing.T) {
+	logger := logging.GetLogger()
+	for name, fx := range goldenFixtures(t) {
+		t.Run(name, func(t *testing.T) {
+			golden := *fx.Expected
+
+			// Узел без eth_getBlockReceipts: квитанции загружаются по транзакциям
+			// и сверяются с корнями заголовка
+			txs := collector.NewBlockCollector(&fakeNode{block: fx.Block, receipts: fx.Receipts, noBlockReceipts: true}, logger)
+			txs.SetVerification(collector.Verification{Mode: collector.VerifyStrict})
+
+			client := newFakeClickhouse()
+			ingester := NewIngester(clickhouseRepo.NewClickhouseService(client, logger), Enrichment{Txs: txs}, logger)
+			if err := ingester.HandleBlock(context.Background(), blockMessage(t, golden.Block)); err != nil {
+				t.Fatalf("handle block: %v", err)
+			}
+
+			var wantLogs int
+			for _, tx := range golden.Txs {
+				wantLogs += len(tx.Receipt.Logs)
+			}
+			for table, want := range map[string]int{
+				TransactionsTable: len(golden.Txs),
+				ReceiptsTable:     len(golden.Txs),
+				LogsTable:         wantLogs,
+			} {
+				if got := len(client.table(table)); got != want {
+					t.Errorf("%d rows in %s, want %d", got, table, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,25 @@
+package ingest
+
+import (
+	"context"
+	"fmt"
+
+	"lib/models"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// TxSource загружает транзакции блока с квитанциями (collector.BlockCollector)
+type TxSource interface {
+	CollectTxs(ctx context.Context, hash common.Hash) ([]models.Tx, error)
+}
+
+// fetchTxs загружает транзакции блока с квитанциями. Блок запрашивается по хешу,
+// чтобы после реорга не получить транзакции другого блока с тем же номером.
+func fetchTxs(ctx context.Context, source TxSource, block models.Block) ([]models.Tx, error) {
+	txs, err := source.CollectTxs(ctx, common.HexToHash(block.Hash))
+	if err != nil {
+		return nil, fmt.Errorf("fetch txs of block %d (%s): %w", block.Number, block.Hash, err)
+	}
+	return txs, nil
+}
@@ -1,8 +1,33 @@
 package main
 
-import "lib/utils/logging"
+import (
+	"context"
+	"flag"
+	"lib/models"
+	"lib/utils/health"
+	"lib/utils/logging"
+	"lib/utils/metrics"
+	"os/signal"
+	"syscall"
+)
 
 func main() {
 	logger := logging.GetLogger()
 	logger.Info("Logger initialized successfully")
+
+	configPath := models.ConfigFlag(flag.CommandLine)
+	flag.Parse()
+	cfg := models.MustLoadConfig(*configPath, models.ServiceHistoricalMiner, logger)
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// Эндпоинты /metrics, /healthz и /readyz. Своих зависимостей у сервиса пока нет,
+	// поэтому проверки не регистрируются: эндпоинты отвечают, пока процесс жив.
+	checks := health.New(cfg.Health, logger)
+	metrics.Serve(ctx, cfg.Metrics.Addr, logger, checks.Mount)
+	go checks.Run(ctx)
+
+	<-ctx.Done()
+	logger.Info("Shutdown signal received")
 }
@@ -3,12 +3,18 @@ package main
 import (
 	"blockhub/services/realtime-miner/internal/node/collector"
 	"blockhub/services/realtime-miner/internal/node/worker"
+	"blockhub/services/realtime-miner/internal/outbox"
 	"context"
+	"flag"
 	"fmt"
 	collectorLib "lib/blocks/collector"
 	fabricClient "lib/clients/fabric_client"
+	"lib/codec"
 	"lib/models"
+	"lib/utils/health"
 	"lib/utils/logging"
+	"lib/utils/metrics"
+	"lib/utils/tracing"
 	"os/signal"
 	"syscall"
 )
@@ -18,14 +24,11 @@ func main() {
 	logger := logging.GetLogger()
 	logger.Info("Logger initialized successfully")
 
-	// Загрузка конфигурации
-	cfg := models.GetConfig(logger)
-	if cfg.ProviderRealTime.ApiKey == "" {
-		logger.Errorf("REALTIME_API_KEY is empty")
-		return
-	} else {
-		logger.Infof("REALTIME_API_KEY found")
-	}
+	// Загрузка конфигурации: ключ провайдера и остальные обязательные поля проверяются при загрузке
+	configPath := models.ConfigFlag(flag.CommandLine)
+	flag.Parse()
+	cfg := models.MustLoadConfig(*configPath, models.ServiceRealtimeMiner, logger)
+	providerCfg := cfg.RealtimeMiner.Provider
 
 	fmt.Println(cfg.Broker.BrockerType)
 
@@ -33,16 +36,25 @@ func main() {
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
 
-	// Инициализация Alchemy клиента
-	ProviderConfig := models.Provider{
-		ProviderType: cfg.ProviderRealTime.ProviderType,
-		BaseURL:      cfg.ProviderRealTime.BaseURL,
-		NetworkName:  cfg.ProviderRealTime.NetworkName,
-		ApiKey:       cfg.ProviderRealTime.ApiKey,
+	// Трассировка OpenTelemetry
+	shutdownTracing, err := tracing.Init(ctx, cfg.Tracing, "realtime-miner", logger)
+	if err != nil {
+		logger.Fatalf("Failed to init tracing: %v", err)
 	}
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			logger.Errorf("Failed to flush traces: %v", err)
+		}
+	}()
 
-	maxRetries := cfg.ProviderRealTime.MaxRetries
-	providerClient, err := fabricClient.NewProvider(ProviderConfig, logger)
+	// Эндпоинты /metrics, /healthz и /readyz; проверки регистрируются ниже по мере создания компонентов
+	checks := health.New(cfg.Health, logger)
+	metrics.Serve(ctx, cfg.Metrics.Addr, logger, checks.Mount)
+	heads := metrics.NewHeadTracker(providerCfg.NetworkName)
+
+	// Инициализация Alchemy клиента
+	maxRetries := providerCfg.MaxRetries
+	providerClient, err := fabricClient.NewProvider(providerCfg, logger)
 	if err != nil {
 		logger.Errorf("Failed to create provider Client: %v", err)
 	}
@@ -59,8 +71,21 @@ func main() {
 	// Инициализация BlockCollector
 	blockCollector := collectorLib.NewBlockCollector(providerClient, logger)
 
+	// Проверка блоков по корням заголовка; в режиме strict блоки
+	// с расхождениями загружаются заново с резервного провайдера
+	verification := collectorLib.Verification{Mode: cfg.RealtimeMiner.Verify.Mode}
+	if fallbackCfg := cfg.RealtimeMiner.Verify.Fallback; fallbackCfg.BaseURL != "" {
+		fallbackClient, err := fabricClient.NewProvider(fallbackCfg, logger)
+		if err != nil {
+			logger.Fatalf("Failed to create fallback provider client: %v", err)
+		}
+		defer fallbackClient.Close()
+		verification.Fallback = fallbackClient
+	}
+	blockCollector.SetVerification(verification)
+
 	// Инициализация RealtimeCollector
-	realtimeCollector := collector.NewRealtimeCollector(blockCollector)
+	realtimeCollector := collector.NewRealtimeCollector(blockCollector, heads)
 
 	// Подписка на новые блоки
 	blocksChan, err := realtimeCollector.SubscribeNewBlocks(ctx, maxRetries)
@@ -69,7 +94,49 @@ func main() {
 	}
 	logger.Info("Subscribed to new blocks, waiting for incoming data...")
 
-	blockTransfer := worker.NewBlockTransfer(logger, brockerClient).(*worker.BlockTransfer)
+	// Журнал продюсера: блоки сохраняются на диск до подтверждения брокером,
+	// неотправленные с прошлого запуска отправляются первыми
+	var blockOutbox *outbox.Outbox
+	var relay *outbox.Relay
+	if cfg.RealtimeMiner.Outbox.Dir != "" {
+		blockOutbox, err = outbox.Open(cfg.RealtimeMiner.Outbox, logger)
+		if err != nil {
+			logger.Fatalf("Failed to open outbox: %v", err)
+		}
+		defer func() {
+			if err := blockOutbox.Close(); err != nil {
+				logger.Errorf("Failed to close outbox: %v", err)
+			}
+		}()
+
+		relay = outbox.NewRelay(blockOutbox, brockerClient, logger)
+		go func() {
+			if err := relay.Run(ctx); err != nil {
+				logger.Error(err)
+			}
+		}()
+	}
+
+	encoder, err := codec.NewEncoder(cfg.Broker.PayloadCodec, cfg.Broker.PayloadCompression, providerCfg.NetworkName, string(models.ServiceRealtimeMiner))
+	if err != nil {
+		logger.Fatalf("Failed to create block encoder: %v", err)
+	}
+
+	// Готовность: провайдер присылает новые блоки, брокер доступен, журнал не переполнен
+	// и записи из него отправляются
+	maxHeadAge := cfg.Health.MaxHeadAge
+	if maxHeadAge <= 0 {
+		maxHeadAge = health.DefaultMaxHeadAge
+	}
+	checks.Readiness("provider_head", health.Freshness(heads.LastHeadAt, maxHeadAge))
+	checks.Readiness("broker", brockerClient.HealthCheck)
+	if blockOutbox != nil {
+		checks.Readiness("outbox", blockOutbox.HealthCheck)
+		checks.Readiness("outbox_relay", relay.HealthCheck)
+	}
+	go checks.Run(ctx)
+
+	blockTransfer := worker.NewBlockTransfer(logger, brockerClient, encoder, heads, blockOutbox).(*worker.BlockTransfer)
 
 	go blockTransfer.TransferBlocks(ctx, blocksChan)
 	// Ждем завершения по сигналу
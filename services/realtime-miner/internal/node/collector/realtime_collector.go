@@ -3,34 +3,40 @@ package collector
 import (
 	"blockhub/services/realtime-miner/internal/node"
 	"context"
+	"errors"
 	collectorLib "lib/blocks/collector"
 	"lib/models"
 	"lib/utils/logging"
+	"lib/utils/metrics"
+	"lib/utils/tracing"
 	"math"
 	"strings"
 	"time"
 
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/core/types"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type realtimeCollector struct {
 	bc     *collectorLib.BlockCollector
+	heads  *metrics.HeadTracker
 	logger *logging.Logger
 }
 
-func NewRealtimeCollector(bc *collectorLib.BlockCollector) node.RtCollector {
+func NewRealtimeCollector(bc *collectorLib.BlockCollector, heads *metrics.HeadTracker) node.RtCollector {
 	return &realtimeCollector{
 		bc:     bc,
+		heads:  heads,
 		logger: bc.Logger(),
 	}
 }
 
-func (rc *realtimeCollector) SubscribeNewBlocks(ctx context.Context, maxRetries int) (<-chan *models.Block, error) {
+func (rc *realtimeCollector) SubscribeNewBlocks(ctx context.Context, maxRetries int) (<-chan node.CollectedBlock, error) {
 
 	rc.logger.Info("Starting new block subscription setup.")
 
-	out := make(chan *models.Block, 100)
+	out := make(chan node.CollectedBlock, 100)
 
 	go func() {
 		defer close(out)
@@ -84,7 +90,7 @@ func (rc *realtimeCollector) processSubscription(
 	ctx context.Context,
 	sub ethereum.Subscription,
 	headers <-chan *types.Header,
-	out chan<- *models.Block,
+	out chan<- node.CollectedBlock,
 	maxBlockRetries int,
 ) {
 	defer sub.Unsubscribe()
@@ -103,71 +109,92 @@ func (rc *realtimeCollector) processSubscription(
 		case header := <-headers:
 			blockNumber := header.Number.Uint64()
 			rc.logger.Debugf("New block header received: #%d", blockNumber)
+			rc.heads.Head(blockNumber)
 
-			initialDelay := 500 * time.Millisecond
-			rc.logger.Debugf("Waiting %v for block %d to be available...", initialDelay, blockNumber)
-
-			select {
-			case <-time.After(initialDelay):
-			case <-ctx.Done():
-				rc.logger.Debug("Context cancelled during initial delay")
-				return
-			}
-
-			var block *models.Block
-			var blockErr error
-			for attempt := 1; attempt <= maxBlockRetries; attempt++ {
-				block, blockErr = rc.bc.CollectBlockByNumber(ctx, blockNumber)
-				if blockErr == nil {
-					break
-				}
-
-				if strings.Contains(blockErr.Error(), "not found") ||
-					strings.Contains(blockErr.Error(), "not available") {
-					rc.logger.Debugf("Block %d not available yet (attempt %d/%d), waiting...",
-						blockNumber, attempt, maxBlockRetries)
-				} else {
-					rc.logger.Warnf("Attempt %d/%d failed for block %d: %v",
-						attempt, maxBlockRetries, blockNumber, blockErr)
-				}
-
-				if attempt < maxBlockRetries {
-					retryDelay := time.Duration(attempt) * 500 * time.Millisecond
-					rc.logger.Debugf("Waiting %v before retry %d for block %d",
-						retryDelay, attempt+1, blockNumber)
-
-					select {
-					case <-time.After(retryDelay):
-						// продолжаем retry
-					case <-ctx.Done():
-						rc.logger.Debug("Context cancelled during retry delay")
-						return
-					}
-				}
-			}
-
-			if blockErr != nil {
-				if strings.Contains(blockErr.Error(), "not found") {
-					rc.logger.Warnf("Block %d still not available after %d attempts",
-						blockNumber, maxBlockRetries)
-				} else {
-					rc.logger.Errorf("All %d attempts failed for block %d: %v",
-						maxBlockRetries, blockNumber, blockErr)
+			// Корневой спан трассы блока: от заголовка до вставки в ClickHouse
+			blockCtx, span := tracing.Start(ctx, "realtime.block", trace.WithAttributes(tracing.BlockNumber(blockNumber)))
+			block, err := rc.collect(blockCtx, blockNumber, maxBlockRetries)
+			if err != nil {
+				tracing.End(span, err)
+				if ctx.Err() != nil {
+					return
 				}
 				continue
 			}
 
-			rc.logger.Infof("Successfully processed block #%d", blockNumber)
+			rc.logger.Ctx(blockCtx).WithBlock(blockNumber).Infof("Successfully processed block #%d", blockNumber)
 
 			select {
-			case out <- block:
+			case out <- node.CollectedBlock{Block: block, Trace: span.SpanContext()}:
 				// Block sent successfully
+				span.End()
 			case <-ctx.Done():
 				rc.logger.Debug("Context cancelled while sending block")
+				tracing.End(span, ctx.Err())
 				return
 			default:
 				rc.logger.Warn("Block channel is full, dropping block")
+				tracing.End(span, errors.New("block channel is full"))
 			}
 		}
 	}
 }
+
+// collect получает блок с повторами, пока он не станет доступен у провайдера
+func (rc *realtimeCollector) collect(ctx context.Context, blockNumber uint64, maxBlockRetries int) (*models.Block, error) {
+	logger := rc.logger.Ctx(ctx).WithBlock(blockNumber)
+	initialDelay := 500 * time.Millisecond
+	logger.Debugf("Waiting %v for block %d to be available...", initialDelay, blockNumber)
+
+	select {
+	case <-time.After(initialDelay):
+	case <-ctx.Done():
+		logger.Debug("Context cancelled during initial delay")
+		return nil, ctx.Err()
+	}
+
+	var block *models.Block
+	var blockErr error
+	for attempt := 1; attempt <= maxBlockRetries; attempt++ {
+		block, blockErr = rc.bc.CollectBlockByNumber(ctx, blockNumber)
+		if blockErr == nil {
+			break
+		}
+
+		if strings.Contains(blockErr.Error(), "not found") ||
+			strings.Contains(blockErr.Error(), "not available") {
+			logger.Debugf("Block %d not available yet (attempt %d/%d), waiting...",
+				blockNumber, attempt, maxBlockRetries)
+		} else {
+			logger.Warnf("Attempt %d/%d failed for block %d: %v",
+				attempt, maxBlockRetries, blockNumber, blockErr)
+		}
+
+		if attempt < maxBlockRetries {
+			retryDelay := time.Duration(attempt) * 500 * time.Millisecond
+			logger.Debugf("Waiting %v before retry %d for block %d",
+				retryDelay, attempt+1, blockNumber)
+
+			select {
+			case <-time.After(retryDelay):
+				// продолжаем retry
+			case <-ctx.Done():
+				logger.Debug("Context cancelled during retry delay")
+				return nil, ctx.Err()
+			}
+		}
+	}
+
+	if blockErr != nil {
+		if strings.Contains(blockErr.Error(), "not found") {
+			logger.Warnf("Block %d still not available after %d attempts",
+				blockNumber, maxBlockRetries)
+		} else {
+			logger.Errorf("All %d attempts failed for block %d: %v",
+				maxBlockRetries, blockNumber, blockErr)
+		}
+		return nil, blockErr
+	}
+
+	return block, nil
+}
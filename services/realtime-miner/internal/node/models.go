@@ -3,12 +3,21 @@ package node
 import (
 	"context"
 	"lib/models"
+
+	"go.opentelemetry.io/otel/trace"
 )
 
+// CollectedBlock — собранный блок вместе с контекстом трассы, в которой он
+// был получен, чтобы отправка в брокер продолжала ту же трассу
+type CollectedBlock struct {
+	Block *models.Block
+	Trace trace.SpanContext
+}
+
 type Worker interface {
-	TransferBlocks(ctx context.Context, in <-chan *models.Block) error
+	TransferBlocks(ctx context.Context, in <-chan CollectedBlock) error
 }
 
 type RtCollector interface {
-	SubscribeNewBlocks(ctx context.Context, maxRetries int) (<-chan *models.Block, error)
+	SubscribeNewBlocks(ctx context.Context, maxRetries int) (<-chan CollectedBlock, error)
 }
@@ -2,104 +2,166 @@ package worker
 
 import (
 	"blockhub/services/realtime-miner/internal/node"
+	"blockhub/services/realtime-miner/internal/outbox"
 	"context"
-	"encoding/json"
+	"errors"
+	"lib/codec"
 	"lib/models"
-	"os"
+	"lib/utils/metrics"
+	"lib/utils/tracing"
 	"time"
 
 	"lib/clients/broker"
 
 	"lib/utils/logging"
+
+	"go.opentelemetry.io/otel/trace"
 )
 
-const maxKafkaRetries = 3                      // Максимальное количество попыток отправки
-const kafkaRetryDelay = 500 * time.Millisecond // Задержка между попытками
+// Повторы при недоступном журнале или брокере: блок не отбрасывается,
+// задержка растёт до maxRetryDelay, а коллектор ждёт освобождения канала
+const (
+	retryDelay    = 500 * time.Millisecond
+	maxRetryDelay = 30 * time.Second
+)
 
 const topicKafka = "blocks"
 
 type BlockTransfer struct {
 	Logger      *logging.Logger
 	KafkaClient broker.BrokerClient
+	Encoder     *codec.Encoder
+	Heads       *metrics.HeadTracker
+	Outbox      *outbox.Outbox // может быть nil — тогда блок отправляется напрямую, повторяясь до успеха
 }
 
 // NewBlockTransfer создаёт новый worker для отправки блоков в Kafka
-func NewBlockTransfer(logger *logging.Logger, kafkaClient broker.BrokerClient) node.Worker {
+func NewBlockTransfer(logger *logging.Logger, kafkaClient broker.BrokerClient, encoder *codec.Encoder, heads *metrics.HeadTracker, ob *outbox.Outbox) node.Worker {
 	return &BlockTransfer{
 		Logger:      logger,
 		KafkaClient: kafkaClient,
+		Encoder:     encoder,
+		Heads:       heads,
+		Outbox:      ob,
 	}
 }
 
 // TransferBlocks слушает канал передачи блоков и отправляет их в Kafka
-func (bt *BlockTransfer) TransferBlocks(ctx context.Context, in <-chan *models.Block) error {
+func (bt *BlockTransfer) TransferBlocks(ctx context.Context, in <-chan node.CollectedBlock) error {
 	for {
 		select {
 		case <-ctx.Done():
 			bt.Logger.Infof("block transfer stopped")
 			return nil
 
-		case block, ok := <-in:
+		case collected, ok := <-in:
 			if !ok {
 				bt.Logger.Warnf("block channel closed")
 				return nil
 			}
 
-			// Сериализация блока в JSON
-			data, err := json.Marshal(block)
-			if err != nil {
-				bt.Logger.Errorf("failed to serialize block %s: %v", block.Hash, err)
-				continue
-			}
+			bt.transfer(ctx, collected)
+		}
+	}
+}
 
-			tst := os.WriteFile("Block_new.json", data, 0644)
-			if tst != nil {
-				bt.Logger.Errorf("failed to write block %s: %v", block.Hash, err)
-				continue
-			}
+// transfer сериализует блок и передаёт его в журнал или напрямую в брокер.
+// Спан продолжает трассу коллектора, её контекст уходит в заголовки сообщения.
+func (bt *BlockTransfer) transfer(ctx context.Context, collected node.CollectedBlock) {
+	block := collected.Block
+	if block.Number == 0 {
+		bt.Logger.Debug("Skipping block with number 0")
+		return
+	}
 
-			if block.Number == 0 {
-				bt.Logger.Debug("Skipping block with number 0")
-				continue
-			}
+	ctx, span := tracing.Start(trace.ContextWithSpanContext(ctx, collected.Trace), "realtime.TransferBlock",
+		trace.WithAttributes(tracing.BlockNumber(uint64(block.Number))))
+	defer span.End()
+	logger := bt.Logger.Ctx(ctx).WithBlock(uint64(block.Number))
+
+	// Сериализация блока с конвертом (версия схемы, кодек, сеть, продюсер)
+	m, err := bt.Encoder.Encode(topicKafka, []byte(block.Hash), models.MessageTypeBlock, block)
+	if err != nil {
+		logger.Errorf("failed to serialize block %s: %v", block.Hash, err)
+		span.RecordError(err)
+		return
+	}
+	tracing.Inject(ctx, m.Headers)
+
+	// Блок сначала попадает в журнал, в Kafka его отправляет outbox.Relay
+	if bt.Outbox != nil {
+		if seq, ok := bt.appendWithRetry(ctx, m); ok {
+			logger.Debugf("Block %s written to outbox (seq %d)", block.Hash, seq)
+			bt.Heads.Emitted(uint64(block.Number))
+		}
+		return
+	}
 
-			// Создаем сообщение для брокера
-			m := models.MessageBroker{
-				Key:   []byte(block.Hash),
-				Value: data,       // Используем сериализованные данные
-				Topic: topicKafka, // Указываем топик
+	// Без журнала блок отправляется в Kafka напрямую
+	if bt.sendWithRetry(ctx, m) {
+		bt.Heads.Emitted(uint64(block.Number))
+	}
+}
+
+// appendWithRetry записывает сообщение в журнал, повторяя попытки, пока журнал
+// переполнен или недоступен. Пока запись не принята, новые блоки не читаются.
+// Возвращает false только при остановке сервиса.
+func (bt *BlockTransfer) appendWithRetry(ctx context.Context, m models.MessageBroker) (uint64, bool) {
+	logger := bt.Logger.Ctx(ctx)
+	delay := retryDelay
+
+	for attempt := 1; ; attempt++ {
+		seq, err := bt.Outbox.Append(m)
+		if err == nil {
+			if attempt > 1 {
+				logger.Infof("Block %s written to outbox after %d attempts", string(m.Key), attempt)
 			}
+			return seq, true
+		}
 
-			// Отправка в Kafka с повторными попытками
-			bt.sendWithRetry(ctx, m)
+		if errors.Is(err, outbox.ErrFull) {
+			logger.Warnf("Outbox is full, waiting before writing block %s (attempt %d)", string(m.Key), attempt)
+		} else {
+			logger.Errorf("Failed to write block %s to outbox (attempt %d): %v", string(m.Key), attempt, err)
 		}
+
+		if !sleep(ctx, delay) {
+			logger.Warnf("Stopped before block %s was written to outbox", string(m.Key))
+			return 0, false
+		}
+		delay = min(2*delay, maxRetryDelay)
 	}
 }
 
-// sendWithRetry повторные попытки отправки
-func (bt *BlockTransfer) sendWithRetry(ctx context.Context, m models.MessageBroker) {
-	var err error
-
-	for attempt := 1; attempt <= maxKafkaRetries; attempt++ {
-		// Отправка в Kafka
-		err = bt.KafkaClient.SendMessage(ctx, m)
+// sendWithRetry отправляет сообщение в Kafka, повторяя попытки до успеха.
+// Возвращает false только при остановке сервиса.
+func (bt *BlockTransfer) sendWithRetry(ctx context.Context, m models.MessageBroker) bool {
+	logger := bt.Logger.Ctx(ctx)
+	delay := retryDelay
 
+	for attempt := 1; ; attempt++ {
+		err := bt.KafkaClient.SendMessage(ctx, m)
 		if err == nil {
-			bt.Logger.Infof("Block %s sent to Kafka successfully (attempt %d)", string(m.Key), attempt)
-			return
+			logger.Infof("Block %s sent to Kafka successfully (attempt %d)", string(m.Key), attempt)
+			return true
 		}
 
-		bt.Logger.Warnf("Failed to send block %s to Kafka (attempt %d/%d): %v", string(m.Key), attempt, maxKafkaRetries, err)
+		logger.Warnf("Failed to send block %s to Kafka (attempt %d): %v", string(m.Key), attempt, err)
 
-		if attempt < maxKafkaRetries {
-			select {
-			case <-ctx.Done():
-				bt.Logger.Warnf("Context cancelled during Kafka retry for block %s", string(m.Key))
-				return
-			case <-time.After(kafkaRetryDelay):
-				// Ждем перед следующей попыткой
-			}
+		if !sleep(ctx, delay) {
+			logger.Warnf("Context cancelled during Kafka retry for block %s", string(m.Key))
+			return false
 		}
+		delay = min(2*delay, maxRetryDelay)
+	}
+}
+
+// sleep ждёт delay; false — контекст отменён
+func sleep(ctx context.Context, delay time.Duration) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(delay):
+		return true
 	}
-	bt.Logger.Errorf("FATAL: Failed to send block %s to Kafka after %d attempts. DROPPING MESSAGE.", string(m.Key), maxKafkaRetries)
 }
@@ -0,0 +1,192 @@
+package worker
+
+import (
+	"blockhub/services/realtime-miner/internal/node"
+	"blockhub/services/realtime-miner/internal/outbox"
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	memoryBroker "lib/clients/broker/memory"
+	fabricClient "lib/clients/fabric_client"
+	"lib/codec"
+	"lib/models"
+	"lib/utils/logging"
+	"lib/utils/metrics"
+)
+
+// TestBlocksThroughMemoryBroker проходит путь блока внутри одного процесса:
+// BlockTransfer → outbox → Relay → memory-брокер → consumer group
+func TestBlocksThroughMemoryBroker(t *testing.T) {
+	logger := logging.GetLogger()
+	cfg := models.Broker{BrockerType: "memory", PayloadCodec: "json", PayloadCompression: "none"}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Продюсер и консьюмер — разные клиенты общего in-process брокера
+	producer := fabricClient.NewBroker(cfg, logger)
+	consumer := fabricClient.NewBroker(cfg, logger)
+	if producer == nil || consumer == nil {
+		t.Fatal("memory broker is not created by the factory")
+	}
+	defer producer.Close()
+	defer consumer.Close()
+
+	ob, err := outbox.Open(models.Outbox{Dir: t.TempDir()}, logger)
+	if err != nil {
+		t.Fatalf("open outbox: %v", err)
+	}
+	defer ob.Close()
+	go outbox.NewRelay(ob, producer, logger).Run(ctx)
+
+	var (
+		mu       sync.Mutex
+		received []models.Block
+		done     = make(chan struct{})
+	)
+	const blocks = 3
+	err = consumer.SubscribeWithGroup(ctx, topicKafka, "clickhouse-service", func(_ context.Context, msg models.MessageBroker) error {
+		var block models.Block
+		env, err := codec.Decode(msg, &block)
+		if err != nil {
+			t.Errorf("decode: %v", err)
+			return nil
+		}
+		if env.Network != "ethereum" || string(msg.Key) != block.Hash {
+			t.Errorf("envelope network %q key %q for block %s", env.Network, msg.Key, block.Hash)
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		received = append(received, block)
+		if len(received) == blocks {
+			close(done)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+
+	encoder, err := codec.NewEncoder(cfg.PayloadCodec, cfg.PayloadCompression, "ethereum", string(models.ServiceRealtimeMiner))
+	if err != nil {
+		t.Fatalf("encoder: %v", err)
+	}
+	transfer := NewBlockTransfer(logger, producer, encoder, metrics.NewHeadTracker("ethereum"), ob)
+
+	in := make(chan node.CollectedBlock)
+	go transfer.TransferBlocks(ctx, in)
+	for number := uint(1); number <= blocks; number++ {
+		in <- node.CollectedBlock{Block: &models.Block{
+			Number:       number,
+			Hash:         blockHash(number),
+			Transactions: []string{blockHash(number + 100)},
+			Timestamp:    time.Unix(int64(1700000000+number), 0).UTC(),
+		}}
+	}
+
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("timed out waiting for blocks")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	for i, block := range received {
+		number := uint(i + 1)
+		if block.Number != number || block.Hash != blockHash(number) || len(block.Transactions) != 1 {
+			t.Errorf("block %d = %+v", i, block)
+		}
+	}
+}
+
+// TestFullOutboxAppliesBackpressure: пока журнал переполнен, блок ждёт
+// освобождения места и не отбрасывается
+func TestFullOutboxAppliesBackpressure(t *testing.T) {
+	logger := logging.GetLogger()
+	cfg := models.Broker{BrockerType: "memory", PayloadCodec: "json", PayloadCompression: "none"}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	encoder, err := codec.NewEncoder(cfg.PayloadCodec, cfg.PayloadCompression, "ethereum", string(models.ServiceRealtimeMiner))
+	if err != nil {
+		t.Fatalf("encoder: %v", err)
+	}
+	block := func(number uint) *models.Block {
+		return &models.Block{Number: number, Hash: blockHash(number), Timestamp: time.Unix(1700000000, 0).UTC()}
+	}
+
+	// Размер одной записи: в журнал помещаются две, каждая в своём сегменте
+	scratch, err := outbox.Open(models.Outbox{Dir: t.TempDir()}, logger)
+	if err != nil {
+		t.Fatalf("open outbox: %v", err)
+	}
+	m, err := encoder.Encode(topicKafka, []byte(blockHash(1)), models.MessageTypeBlock, block(1))
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	if _, err := scratch.Append(m); err != nil {
+		t.Fatalf("append: %v", err)
+	}
+	record := scratch.Stats().Bytes
+	scratch.Close()
+
+	ob, err := outbox.Open(models.Outbox{Dir: t.TempDir(), SegmentBytes: record, MaxBytes: 2*record + record/2}, logger)
+	if err != nil {
+		t.Fatalf("open outbox: %v", err)
+	}
+	defer ob.Close()
+
+	producer := memoryBroker.NewClient(memoryBroker.NewBroker(0), cfg, logger)
+	defer producer.Close()
+
+	transfer := NewBlockTransfer(logger, producer, encoder, metrics.NewHeadTracker("ethereum"), ob)
+	in := make(chan node.CollectedBlock)
+	go transfer.TransferBlocks(ctx, in)
+
+	for number := uint(1); number <= 3; number++ {
+		in <- node.CollectedBlock{Block: block(number)}
+	}
+
+	// Третий блок не помещается: BlockTransfer ждёт и не принимает следующий
+	select {
+	case in <- node.CollectedBlock{Block: block(4)}:
+		t.Fatal("block accepted while outbox is full")
+	case <-time.After(300 * time.Millisecond):
+	}
+	if pending := ob.Stats().Pending; pending != 2 {
+		t.Fatalf("outbox holds %d records, want 2", pending)
+	}
+
+	// Relay освобождает журнал, ожидавший блок записывается
+	go outbox.NewRelay(ob, producer, logger).Run(ctx)
+
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		_, next, err := producer.Broker().Offsets(topicKafka, 0)
+		if err != nil {
+			t.Fatalf("offsets: %v", err)
+		}
+		if next == 3 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d blocks delivered, want 3", next)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func blockHash(n uint) string {
+	const hex = "0123456789abcdef"
+	b := []byte("0x0000000000000000000000000000000000000000000000000000000000000000")
+	for i := len(b) - 1; n > 0; i-- {
+		b[i] = hex[n%16]
+		n /= 16
+	}
+	return string(b)
+}
@@ -0,0 +1,418 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"lib/models"
+	"lib/utils/logging"
+	"lib/utils/metrics"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	ackFileName = "ack"
+
+	defaultSegmentBytes = 64 << 20
+	// Доля MaxBytes, после которой в лог пишется предупреждение
+	warnFillRatio = 0.8
+)
+
+// ErrFull — журнал достиг MaxBytes, новая запись не принята
+var ErrFull = errors.New("outbox is full")
+
+// Entry — запись журнала, ожидающая подтверждения брокера
+type Entry struct {
+	Seq     uint64
+	Message models.MessageBroker
+}
+
+// Stats — текущее состояние журнала
+type Stats struct {
+	Pending  uint64 // записано, но не подтверждено брокером
+	Bytes    int64  // размер сегментов на диске
+	Segments int
+}
+
+// Outbox — журнал упреждающей записи для продюсера. Сообщение сначала
+// дописывается в текущий сегмент на диске (с fsync), затем отправляется
+// в брокер; номер последней подтверждённой записи хранится в файле ack.
+// Сегменты, все записи которых подтверждены, удаляются. После рестарта
+// неподтверждённые записи отдаются через Next повторно.
+type Outbox struct {
+	dir          string
+	segmentBytes int64
+	maxBytes     int64
+	logger       *logging.Logger
+
+	mu       sync.Mutex
+	segments []*segment // по возрастанию base, последний — активный
+	active   *os.File
+	size     int64
+	nextSeq  uint64
+	acked    uint64
+	closed   bool
+
+	// Позиция следующей записи для Next
+	readSeg *segment
+	readOff int64
+
+	// Флаги, чтобы не спамить предупреждениями на каждый блок
+	warned bool
+	full   bool
+
+	notify chan struct{}
+}
+
+// Open открывает журнал в каталоге cfg.Dir, проверяет сегменты и
+// обрезает запись, оборванную при падении процесса
+func Open(cfg models.Outbox, logger *logging.Logger) (*Outbox, error) {
+	if cfg.Dir == "" {
+		return nil, errors.New("outbox dir is empty")
+	}
+	if err := os.MkdirAll(cfg.Dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create outbox dir %s: %w", cfg.Dir, err)
+	}
+
+	o := &Outbox{
+		dir:          cfg.Dir,
+		segmentBytes: cfg.SegmentBytes,
+		maxBytes:     cfg.MaxBytes,
+		logger:       logger,
+		nextSeq:      1,
+		notify:       make(chan struct{}, 1),
+	}
+	if o.segmentBytes <= 0 {
+		o.segmentBytes = defaultSegmentBytes
+	}
+	// Активный сегмент не удаляется, поэтому он должен быть заметно меньше лимита
+	if o.maxBytes > 0 {
+		o.segmentBytes = min(o.segmentBytes, max(o.maxBytes/4, 1))
+	}
+
+	acked, hasAck, err := o.readAck()
+	if err != nil {
+		return nil, err
+	}
+
+	segments, err := listSegments(o.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list outbox segments: %w", err)
+	}
+	if !hasAck && len(segments) > 0 {
+		acked = segments[0].base - 1
+	}
+	o.acked = acked
+
+	for _, seg := range segments {
+		truncated, err := seg.recover(func(seq uint64, off int64) {
+			if o.readSeg == nil && seq > acked {
+				o.readSeg, o.readOff = seg, off
+			}
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to recover outbox segment %s: %w", seg.path, err)
+		}
+		if truncated {
+			logger.Warnf("Outbox segment %s had a torn record, truncated to %d bytes", seg.path, seg.size)
+		}
+		o.size += seg.size
+		o.nextSeq = max(o.nextSeq, seg.last+1)
+	}
+	o.segments = segments
+	// Номера записей не должны повторяться, даже если все сегменты уже удалены
+	o.nextSeq = max(o.nextSeq, o.acked+1)
+
+	if err := o.openActive(); err != nil {
+		return nil, err
+	}
+	if o.readSeg == nil {
+		o.readSeg, o.readOff = o.segments[len(o.segments)-1], o.segments[len(o.segments)-1].size
+	}
+
+	if pending := o.nextSeq - 1 - o.acked; pending > 0 {
+		logger.Infof("Outbox %s: %d pending messages will be replayed", o.dir, pending)
+	}
+	o.reportLocked()
+	return o, nil
+}
+
+// Append записывает сообщение в журнал и возвращает его номер.
+// Запись считается принятой только после fsync.
+func (o *Outbox) Append(msg models.MessageBroker) (uint64, error) {
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return 0, fmt.Errorf("failed to serialize outbox message: %w", err)
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.closed {
+		return 0, errors.New("outbox is closed")
+	}
+
+	record := encodeRecord(o.nextSeq, payload)
+	recordSize := int64(len(record))
+
+	if o.maxBytes > 0 && o.size+recordSize > o.maxBytes {
+		if !o.full {
+			o.full = true
+			o.logger.Errorf("ALERT: outbox %s is full (%d/%d bytes, %d pending messages), new messages are not persisted",
+				o.dir, o.size, o.maxBytes, o.nextSeq-1-o.acked)
+		}
+		return 0, ErrFull
+	}
+
+	active := o.segments[len(o.segments)-1]
+	if active.size > 0 && active.size+recordSize > o.segmentBytes {
+		if err := o.rotate(); err != nil {
+			return 0, err
+		}
+		active = o.segments[len(o.segments)-1]
+	}
+
+	if _, err := o.active.Write(record); err != nil {
+		// Откатываем частично записанную запись
+		_ = o.active.Truncate(active.size)
+		_, _ = o.active.Seek(active.size, 0)
+		return 0, fmt.Errorf("failed to write outbox record: %w", err)
+	}
+	if err := o.active.Sync(); err != nil {
+		_ = o.active.Truncate(active.size)
+		_, _ = o.active.Seek(active.size, 0)
+		return 0, fmt.Errorf("failed to sync outbox segment: %w", err)
+	}
+
+	seq := o.nextSeq
+	o.nextSeq++
+	active.last = seq
+	active.size += recordSize
+	o.size += recordSize
+
+	if o.maxBytes > 0 && !o.warned && float64(o.size) >= float64(o.maxBytes)*warnFillRatio {
+		o.warned = true
+		o.logger.Warnf("Outbox %s is %.0f%% full (%d/%d bytes)", o.dir, 100*float64(o.size)/float64(o.maxBytes), o.size, o.maxBytes)
+	}
+
+	o.reportLocked()
+
+	select {
+	case o.notify <- struct{}{}:
+	default:
+	}
+	return seq, nil
+}
+
+// Next возвращает следующую неотправленную запись, ожидая её появления.
+// Записи отдаются строго по порядку; повторно та же запись не отдаётся
+// до рестарта, поэтому вызывающий должен отправлять её до успеха и затем вызвать Ack.
+func (o *Outbox) Next(ctx context.Context) (Entry, error) {
+	for {
+		entry, ok, err := o.read()
+		if err != nil || ok {
+			return entry, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return Entry{}, ctx.Err()
+		case <-o.notify:
+		}
+	}
+}
+
+func (o *Outbox) read() (Entry, bool, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.closed {
+		return Entry{}, false, errors.New("outbox is closed")
+	}
+
+	for {
+		for o.readOff >= o.readSeg.size {
+			next := o.segmentAfter(o.readSeg)
+			if next == nil {
+				return Entry{}, false, nil
+			}
+			o.readSeg, o.readOff = next, 0
+		}
+
+		seq, payload, err := o.readAt(o.readSeg, o.readOff)
+		if err != nil {
+			return Entry{}, false, fmt.Errorf("failed to read outbox record at %s:%d: %w", o.readSeg.path, o.readOff, err)
+		}
+		o.readOff += recordHeaderSize + int64(len(payload))
+
+		var msg models.MessageBroker
+		if err := json.Unmarshal(payload, &msg); err != nil {
+			// Битую запись не отправить никогда — пропускаем, она подтвердится вместе со следующей
+			o.logger.Errorf("Outbox record %d is corrupted and skipped: %v", seq, err)
+			continue
+		}
+		return Entry{Seq: seq, Message: msg}, true, nil
+	}
+}
+
+func (o *Outbox) readAt(seg *segment, off int64) (uint64, []byte, error) {
+	f, err := os.Open(seg.path)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer f.Close()
+
+	return readRecord(f, off)
+}
+
+// Ack отмечает записи до seq включительно как подтверждённые брокером
+// и удаляет полностью подтверждённые сегменты
+func (o *Outbox) Ack(seq uint64) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if seq <= o.acked {
+		return nil
+	}
+	o.acked = seq
+	if err := o.writeAck(); err != nil {
+		return err
+	}
+
+	// Активный сегмент не удаляем, он освободится после ротации
+	for len(o.segments) > 1 && o.segments[0].last <= o.acked {
+		seg := o.segments[0]
+		if err := os.Remove(seg.path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove outbox segment %s: %w", seg.path, err)
+		}
+		o.size -= seg.size
+		o.segments = o.segments[1:]
+	}
+
+	if o.maxBytes > 0 {
+		if o.full && o.size < o.maxBytes {
+			o.full = false
+			o.logger.Infof("Outbox %s has free space again (%d/%d bytes)", o.dir, o.size, o.maxBytes)
+		}
+		if o.warned && float64(o.size) < float64(o.maxBytes)*warnFillRatio {
+			o.warned = false
+		}
+	}
+	o.reportLocked()
+	return nil
+}
+
+// Stats возвращает число неподтверждённых записей и занятое место
+func (o *Outbox) Stats() Stats {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	return Stats{
+		Pending:  o.nextSeq - 1 - o.acked,
+		Bytes:    o.size,
+		Segments: len(o.segments),
+	}
+}
+
+// HealthCheck возвращает ErrFull, пока журнал заполнен и не принимает записи
+func (o *Outbox) HealthCheck(ctx context.Context) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.closed {
+		return errors.New("outbox is closed")
+	}
+	if o.full {
+		return ErrFull
+	}
+	return nil
+}
+
+// reportLocked обновляет метрики журнала; вызывается под o.mu
+func (o *Outbox) reportLocked() {
+	metrics.OutboxPending.Set(float64(o.nextSeq - 1 - o.acked))
+	metrics.OutboxBytes.Set(float64(o.size))
+}
+
+// Close закрывает активный сегмент
+func (o *Outbox) Close() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.closed {
+		return nil
+	}
+	o.closed = true
+	return o.active.Close()
+}
+
+// openActive открывает последний сегмент на дозапись или создаёт первый
+func (o *Outbox) openActive() error {
+	if len(o.segments) == 0 {
+		o.segments = append(o.segments, &segment{base: o.nextSeq, last: o.nextSeq - 1, path: segmentPath(o.dir, o.nextSeq)})
+	}
+
+	seg := o.segments[len(o.segments)-1]
+	f, err := os.OpenFile(seg.path, os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open outbox segment %s: %w", seg.path, err)
+	}
+	if _, err := f.Seek(seg.size, 0); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to seek outbox segment %s: %w", seg.path, err)
+	}
+	o.active = f
+	return nil
+}
+
+// rotate закрывает активный сегмент и начинает новый с номера nextSeq
+func (o *Outbox) rotate() error {
+	if err := o.active.Close(); err != nil {
+		return fmt.Errorf("failed to close outbox segment: %w", err)
+	}
+	o.segments = append(o.segments, &segment{base: o.nextSeq, last: o.nextSeq - 1, path: segmentPath(o.dir, o.nextSeq)})
+	return o.openActive()
+}
+
+func (o *Outbox) segmentAfter(seg *segment) *segment {
+	for _, s := range o.segments {
+		if s.base > seg.base {
+			return s
+		}
+	}
+	return nil
+}
+
+func (o *Outbox) readAck() (uint64, bool, error) {
+	data, err := os.ReadFile(filepath.Join(o.dir, ackFileName))
+	if os.IsNotExist(err) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to read outbox ack: %w", err)
+	}
+	acked, err := strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to parse outbox ack: %w", err)
+	}
+	return acked, true, nil
+}
+
+// writeAck сохраняет номер подтверждённой записи. Потеря ack при падении
+// приводит лишь к повторной отправке, поэтому fsync здесь не нужен.
+func (o *Outbox) writeAck() error {
+	path := filepath.Join(o.dir, ackFileName)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(strconv.FormatUint(o.acked, 10)), 0o644); err != nil {
+		return fmt.Errorf("failed to write outbox ack: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to commit outbox ack: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,317 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"lib/clients/broker"
+	"lib/models"
+	"lib/utils/logging"
+)
+
+func message(i int) models.MessageBroker {
+	return models.MessageBroker{Topic: "blocks", Key: []byte(fmt.Sprintf("0x%04d", i)), Value: []byte(fmt.Sprintf("block-%04d", i))}
+}
+
+// recordSize — размер записи журнала для message(i)
+func recordSize(t *testing.T) int64 {
+	t.Helper()
+	payload, err := json.Marshal(message(0))
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	return int64(len(encodeRecord(1, payload)))
+}
+
+func open(t *testing.T, cfg models.Outbox) *Outbox {
+	t.Helper()
+	o, err := Open(cfg, logging.GetLogger())
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	t.Cleanup(func() { _ = o.Close() })
+	return o
+}
+
+func appendN(t *testing.T, o *Outbox, from, n int) {
+	t.Helper()
+	for i := from; i < from+n; i++ {
+		if _, err := o.Append(message(i)); err != nil {
+			t.Fatalf("append %d: %v", i, err)
+		}
+	}
+}
+
+// next читает запись, не дожидаясь новых
+func next(t *testing.T, o *Outbox) Entry {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	entry, err := o.Next(ctx)
+	if err != nil {
+		t.Fatalf("next: %v", err)
+	}
+	return entry
+}
+
+func noNext(t *testing.T, o *Outbox) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if entry, err := o.Next(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("next = seq %d, %v, want no more records", entry.Seq, err)
+	}
+}
+
+func segmentFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	files, err := filepath.Glob(filepath.Join(dir, "*"+segmentExt))
+	if err != nil {
+		t.Fatalf("glob: %v", err)
+	}
+	return files
+}
+
+func TestOpenTruncatesTornRecord(t *testing.T) {
+	dir := t.TempDir()
+	o := open(t, models.Outbox{Dir: dir})
+	appendN(t, o, 1, 3)
+	o.Close()
+
+	path := segmentFiles(t, dir)[0]
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("stat: %v", err)
+	}
+
+	// Падение посреди записи: на диске только начало четвёртой записи
+	payload, _ := json.Marshal(message(4))
+	torn := encodeRecord(4, payload)[:recordHeaderSize+5]
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatalf("open segment: %v", err)
+	}
+	f.Write(torn)
+	f.Close()
+
+	o = open(t, models.Outbox{Dir: dir})
+	if got, err := os.Stat(path); err != nil || got.Size() != info.Size() {
+		t.Fatalf("segment size after recovery = %d (%v), want %d", got.Size(), err, info.Size())
+	}
+	if stats := o.Stats(); stats.Pending != 3 || stats.Bytes != info.Size() {
+		t.Errorf("stats = %+v, want 3 pending in %d bytes", stats, info.Size())
+	}
+
+	// Новая запись продолжает нумерацию после последней целой
+	if seq, err := o.Append(message(5)); err != nil || seq != 4 {
+		t.Fatalf("append after recovery = %d, %v, want seq 4", seq, err)
+	}
+	for want := uint64(1); want <= 4; want++ {
+		if entry := next(t, o); entry.Seq != want {
+			t.Fatalf("next = seq %d, want %d", entry.Seq, want)
+		}
+	}
+	noNext(t, o)
+}
+
+func TestAckSurvivesReopen(t *testing.T) {
+	dir := t.TempDir()
+	o := open(t, models.Outbox{Dir: dir})
+	appendN(t, o, 1, 5)
+
+	for want := uint64(1); want <= 3; want++ {
+		if entry := next(t, o); entry.Seq != want || string(entry.Message.Value) != string(message(int(want)).Value) {
+			t.Fatalf("next = seq %d %s, want %d", entry.Seq, entry.Message.Value, want)
+		}
+	}
+	if err := o.Ack(3); err != nil {
+		t.Fatalf("ack: %v", err)
+	}
+	o.Close()
+
+	// После рестарта отдаются только неподтверждённые записи, включая прочитанные, но не подтверждённые
+	o = open(t, models.Outbox{Dir: dir})
+	if stats := o.Stats(); stats.Pending != 2 {
+		t.Errorf("pending after reopen = %d, want 2", stats.Pending)
+	}
+	for want := uint64(4); want <= 5; want++ {
+		if entry := next(t, o); entry.Seq != want {
+			t.Fatalf("replayed seq %d, want %d", entry.Seq, want)
+		}
+	}
+	noNext(t, o)
+
+	// Повторный ack старого номера ничего не меняет
+	if err := o.Ack(2); err != nil || o.Stats().Pending != 2 {
+		t.Errorf("stale ack = %v, pending %d", err, o.Stats().Pending)
+	}
+}
+
+func TestSegmentsRotateAndAreDeletedAfterAck(t *testing.T) {
+	dir := t.TempDir()
+	size := recordSize(t)
+	// Две записи на сегмент
+	o := open(t, models.Outbox{Dir: dir, SegmentBytes: 2 * size})
+	appendN(t, o, 1, 7)
+
+	if stats := o.Stats(); stats.Segments != 4 || stats.Bytes != 7*size {
+		t.Fatalf("stats = %+v, want 4 segments of %d bytes", stats, 7*size)
+	}
+	if files := segmentFiles(t, dir); len(files) != 4 {
+		t.Fatalf("%d segment files, want 4", len(files))
+	}
+
+	// Подтверждение середины сегмента не удаляет его
+	if err := o.Ack(3); err != nil {
+		t.Fatalf("ack: %v", err)
+	}
+	if stats := o.Stats(); stats.Segments != 3 || stats.Bytes != 5*size {
+		t.Errorf("after ack 3 stats = %+v, want 3 segments", stats)
+	}
+
+	// Активный сегмент остаётся, даже когда подтверждено всё
+	if err := o.Ack(7); err != nil {
+		t.Fatalf("ack: %v", err)
+	}
+	files := segmentFiles(t, dir)
+	if len(files) != 1 || filepath.Base(files[0]) != filepath.Base(segmentPath(dir, 7)) {
+		t.Errorf("segment files after full ack = %v, want only the active one", files)
+	}
+	if stats := o.Stats(); stats.Pending != 0 || stats.Bytes != size {
+		t.Errorf("stats after full ack = %+v", stats)
+	}
+
+	// Нумерация продолжается после рестарта, даже если подтверждено всё
+	o.Close()
+	o = open(t, models.Outbox{Dir: dir, SegmentBytes: 2 * size})
+	if seq, err := o.Append(message(8)); err != nil || seq != 8 {
+		t.Errorf("append after reopen = %d, %v, want seq 8", seq, err)
+	}
+}
+
+func TestAppendReturnsErrFullUntilAck(t *testing.T) {
+	size := recordSize(t)
+	o := open(t, models.Outbox{Dir: t.TempDir(), MaxBytes: 8 * size})
+	appendN(t, o, 1, 8)
+
+	if _, err := o.Append(message(9)); !errors.Is(err, ErrFull) {
+		t.Fatalf("append over max_bytes = %v, want ErrFull", err)
+	}
+	if err := o.HealthCheck(context.Background()); !errors.Is(err, ErrFull) {
+		t.Errorf("health check of a full outbox = %v, want ErrFull", err)
+	}
+	if stats := o.Stats(); stats.Pending != 8 {
+		t.Errorf("pending = %d, want the rejected record not persisted", stats.Pending)
+	}
+
+	if err := o.Ack(6); err != nil {
+		t.Fatalf("ack: %v", err)
+	}
+	if err := o.HealthCheck(context.Background()); err != nil {
+		t.Errorf("health check after ack = %v", err)
+	}
+	if seq, err := o.Append(message(9)); err != nil || seq != 9 {
+		t.Errorf("append after ack = %d, %v, want seq 9", seq, err)
+	}
+}
+
+func TestNextSkipsCorruptRecord(t *testing.T) {
+	dir := t.TempDir()
+	o := open(t, models.Outbox{Dir: dir})
+	appendN(t, o, 1, 1)
+	o.Close()
+
+	// Контрольная сумма сходится, но payload не разбирается
+	f, err := os.OpenFile(segmentFiles(t, dir)[0], os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatalf("open segment: %v", err)
+	}
+	f.Write(encodeRecord(2, []byte("{not json")))
+	f.Close()
+
+	o = open(t, models.Outbox{Dir: dir})
+	appendN(t, o, 3, 1)
+
+	if entry := next(t, o); entry.Seq != 1 {
+		t.Fatalf("next = seq %d, want 1", entry.Seq)
+	}
+	entry := next(t, o)
+	if entry.Seq != 3 || string(entry.Message.Value) != string(message(3).Value) {
+		t.Fatalf("next after corrupt record = seq %d %s, want 3", entry.Seq, entry.Message.Value)
+	}
+
+	// Битая запись подтверждается вместе со следующей
+	if err := o.Ack(entry.Seq); err != nil || o.Stats().Pending != 0 {
+		t.Errorf("ack = %v, pending %d", err, o.Stats().Pending)
+	}
+}
+
+// producer отправляет или отклоняет сообщения
+type producer struct {
+	broker.MockBrokerClient
+	err  error
+	sent []models.MessageBroker
+}
+
+func (p *producer) SendMessage(_ context.Context, msg models.MessageBroker) error {
+	if p.err != nil {
+		return p.err
+	}
+	p.sent = append(p.sent, msg)
+	return nil
+}
+
+func TestRelaySendsAndAcks(t *testing.T) {
+	o := open(t, models.Outbox{Dir: t.TempDir()})
+	appendN(t, o, 1, 3)
+
+	p := &producer{}
+	relay := NewRelay(o, p, logging.GetLogger())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- relay.Run(ctx) }()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for o.Stats().Pending != 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("pending = %d, want all records acked", o.Stats().Pending)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("run after cancel = %v, want nil", err)
+	}
+	if err := relay.HealthCheck(context.Background()); err != nil {
+		t.Errorf("health check after shutdown = %v", err)
+	}
+	if len(p.sent) != 3 || string(p.sent[2].Key) != string(message(3).Key) {
+		t.Errorf("sent %d messages, want 3 in order", len(p.sent))
+	}
+}
+
+func TestRelayStopReportedByHealthCheck(t *testing.T) {
+	o := open(t, models.Outbox{Dir: t.TempDir()})
+	appendN(t, o, 1, 2)
+
+	// Неустранимая ошибка прекращает повторы
+	p := &producer{err: broker.Permanent(errors.New("message too large"))}
+	relay := NewRelay(o, p, logging.GetLogger())
+
+	if err := relay.Run(context.Background()); err == nil {
+		t.Fatal("relay stopped on a send failure without an error")
+	}
+	if err := relay.HealthCheck(context.Background()); err == nil {
+		t.Error("health check passes after the relay stopped")
+	}
+	if stats := o.Stats(); stats.Pending != 2 {
+		t.Errorf("pending = %d, want the unsent records kept", stats.Pending)
+	}
+}
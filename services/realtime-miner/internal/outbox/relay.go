@@ -0,0 +1,91 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"lib/clients/broker"
+	"lib/utils/logging"
+	"lib/utils/tracing"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Relay отправляет записи журнала в брокер по порядку и подтверждает их
+type Relay struct {
+	outbox   *Outbox
+	producer broker.BrokerClient
+	retry    broker.RetryPolicy
+	logger   *logging.Logger
+
+	// stopped — причина, по которой Run завершился до отмены ctx; HealthCheck возвращает её
+	stopped atomic.Pointer[error]
+}
+
+// NewRelay создаёт отправителя записей журнала. Отправка повторяется
+// до успеха с экспоненциальной задержкой, поэтому при недоступном брокере
+// записи копятся на диске, а не теряются.
+func NewRelay(outbox *Outbox, producer broker.BrokerClient, logger *logging.Logger) *Relay {
+	return &Relay{
+		outbox:   outbox,
+		producer: producer,
+		retry:    broker.RetryPolicy{InitialBackoff: 500 * time.Millisecond, MaxBackoff: 30 * time.Second},
+		logger:   logger,
+	}
+}
+
+// Run отправляет записи, пока не будет отменён ctx. Если отправка или чтение
+// журнала прекращены из-за ошибки, возвращает её, и HealthCheck сообщает об остановке.
+func (r *Relay) Run(ctx context.Context) error {
+	for {
+		entry, err := r.outbox.Next(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return r.stop(err)
+		}
+
+		// Продолжаем трассу блока, сохранённую в заголовках записи
+		sendCtx, span := tracing.Start(tracing.Extract(ctx, entry.Message.Headers), "outbox.relay",
+			trace.WithAttributes(attribute.Int64("outbox.seq", int64(entry.Seq))))
+
+		attempts, err := r.retry.Run(sendCtx, func(attempt int) error {
+			err := r.producer.SendMessage(sendCtx, entry.Message)
+			if err != nil {
+				r.logger.Warnf("Failed to send outbox message %d (key %s, attempt %d): %v", entry.Seq, string(entry.Message.Key), attempt, err)
+			}
+			return err
+		})
+		span.SetAttributes(attribute.Int("outbox.attempts", attempts))
+		tracing.End(span, err)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			// Запись осталась неподтверждённой и будет отправлена после рестарта
+			return r.stop(fmt.Errorf("outbox message %d was not sent after %d attempts: %w", entry.Seq, attempts, err))
+		}
+		r.logger.Infof("Message %s sent to %s from outbox (seq %d, attempt %d)", string(entry.Message.Key), entry.Message.Topic, entry.Seq, attempts)
+
+		if err := r.outbox.Ack(entry.Seq); err != nil {
+			r.logger.Errorf("Failed to ack outbox message %d: %v", entry.Seq, err)
+		}
+	}
+}
+
+// HealthCheck возвращает ошибку, если Run остановился и записи больше не отправляются
+func (r *Relay) HealthCheck(ctx context.Context) error {
+	if stopped := r.stopped.Load(); stopped != nil {
+		return *stopped
+	}
+	return nil
+}
+
+func (r *Relay) stop(err error) error {
+	err = fmt.Errorf("outbox relay stopped: %w", err)
+	r.stopped.Store(&err)
+	return err
+}
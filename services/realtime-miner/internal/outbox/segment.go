@@ -0,0 +1,137 @@
+package outbox
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	segmentExt = ".seg"
+
+	// Заголовок записи: длина payload (uint32), crc32 payload (uint32), номер записи (uint64)
+	recordHeaderSize = 16
+	// Защита от чтения мусора как гигантской записи
+	maxRecordSize = 256 << 20
+)
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+// errTornRecord — запись обрезана или повреждена (например, падение посреди записи)
+var errTornRecord = errors.New("torn outbox record")
+
+// segment — один файл журнала. Имя файла — номер первой записи в нём.
+type segment struct {
+	base uint64 // номер первой записи
+	last uint64 // номер последней записи, base-1 если сегмент пуст
+	size int64
+	path string
+}
+
+func segmentPath(dir string, base uint64) string {
+	return filepath.Join(dir, fmt.Sprintf("%020d%s", base, segmentExt))
+}
+
+// listSegments возвращает сегменты каталога в порядке номеров
+func listSegments(dir string) ([]*segment, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var segments []*segment
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, segmentExt) {
+			continue
+		}
+		base, err := strconv.ParseUint(strings.TrimSuffix(name, segmentExt), 10, 64)
+		if err != nil {
+			continue
+		}
+		segments = append(segments, &segment{base: base, last: base - 1, path: filepath.Join(dir, name)})
+	}
+
+	sort.Slice(segments, func(i, j int) bool { return segments[i].base < segments[j].base })
+	return segments, nil
+}
+
+// encodeRecord собирает запись журнала
+func encodeRecord(seq uint64, payload []byte) []byte {
+	buf := make([]byte, recordHeaderSize+len(payload))
+	binary.BigEndian.PutUint32(buf[0:4], uint32(len(payload)))
+	binary.BigEndian.PutUint32(buf[4:8], crc32.Checksum(payload, crcTable))
+	binary.BigEndian.PutUint64(buf[8:16], seq)
+	copy(buf[recordHeaderSize:], payload)
+	return buf
+}
+
+// readRecord читает запись по смещению off. Возвращает io.EOF в конце файла
+// и errTornRecord, если запись обрезана или не сходится контрольная сумма.
+func readRecord(r io.ReaderAt, off int64) (seq uint64, payload []byte, err error) {
+	var header [recordHeaderSize]byte
+	n, err := r.ReadAt(header[:], off)
+	if n == 0 && errors.Is(err, io.EOF) {
+		return 0, nil, io.EOF
+	}
+	if n < recordHeaderSize {
+		return 0, nil, errTornRecord
+	}
+
+	length := binary.BigEndian.Uint32(header[0:4])
+	if length > maxRecordSize {
+		return 0, nil, errTornRecord
+	}
+
+	payload = make([]byte, length)
+	if n, _ := r.ReadAt(payload, off+recordHeaderSize); n < int(length) {
+		return 0, nil, errTornRecord
+	}
+	if crc32.Checksum(payload, crcTable) != binary.BigEndian.Uint32(header[4:8]) {
+		return 0, nil, errTornRecord
+	}
+
+	return binary.BigEndian.Uint64(header[8:16]), payload, nil
+}
+
+// recover проверяет записи сегмента и обрезает повреждённый хвост.
+// onRecord вызывается для каждой целой записи со смещением её начала.
+func (s *segment) recover(onRecord func(seq uint64, off int64)) (truncated bool, err error) {
+	f, err := os.OpenFile(s.path, os.O_RDWR, 0o644)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	var off int64
+	for {
+		seq, payload, err := readRecord(f, off)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if errors.Is(err, errTornRecord) {
+			if err := f.Truncate(off); err != nil {
+				return false, fmt.Errorf("failed to truncate %s: %w", s.path, err)
+			}
+			truncated = true
+			break
+		}
+		if err != nil {
+			return false, err
+		}
+
+		onRecord(seq, off)
+		s.last = seq
+		off += recordHeaderSize + int64(len(payload))
+	}
+
+	s.size = off
+	return truncated, nil
+}
@@ -1,8 +1,48 @@
 package main
 
-import "lib/utils/logging"
+import (
+	"context"
+	"flag"
+	redisClient "lib/clients/db/redis"
+	"lib/models"
+	"lib/utils/health"
+	"lib/utils/logging"
+	"lib/utils/metrics"
+	"os/signal"
+	"syscall"
+)
 
 func main() {
 	logger := logging.GetLogger()
 	logger.Info("Logger initialized successfully")
+
+	configPath := models.ConfigFlag(flag.CommandLine)
+	flag.Parse()
+	cfg := models.MustLoadConfig(*configPath, models.ServiceRedis, logger)
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// Эндпоинты /metrics, /healthz и /readyz
+	checks := health.New(cfg.Health, logger)
+	metrics.Serve(ctx, cfg.Metrics.Addr, logger, checks.Mount)
+
+	cache, err := redisClient.NewClient(ctx, cfg.Redis)
+	if err != nil {
+		logger.Fatalf("Failed to initialize Redis client: %v", err)
+	}
+	defer func() {
+		if err := cache.Close(); err != nil {
+			logger.Errorf("Failed to close Redis client: %v", err)
+		}
+	}()
+
+	// Готовность: Redis отвечает на ping
+	checks.Readiness("redis", func(ctx context.Context) error {
+		return cache.Ping(ctx).Err()
+	})
+	go checks.Run(ctx)
+
+	<-ctx.Done()
+	logger.Info("Shutdown signal received")
 }